@@ -0,0 +1,235 @@
+package ethauth
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// AdminScope is the scope required of the admin proofs of AdminServer, unless
+// AdminOptions.Scope is set.
+const AdminScope = "ethauth:admin"
+
+// AdminOptions configures an AdminServer.
+type AdminOptions struct {
+	// Admins are the accounts whose proofs are accepted by the admin endpoints, which is
+	// required. Scopes are chosen by the signer of a proof, so the accounts are what grants
+	// admin access, while the scope keeps the everyday proofs of an admin account from being
+	// usable as admin proofs.
+	Admins []common.Address
+
+	// Scope is the scope required of admin proofs, AdminScope by default
+	Scope string
+
+	// Verifier verifies the admin proofs, ie. with the audience of the admin endpoints. By
+	// default, admin proofs are verified by the ETHAuth instance managed by the server.
+	Verifier *ETHAuth
+}
+
+// AdminServer is an http.Handler of endpoints to manage an ETHAuth instance at runtime, ie.
+// so on-call can respond to incidents without a deploy:
+//
+//   - POST /revoke-token, revoking the proof passed as the `token` form value, or the proof of
+//     the `jti` form value until the unix time of the `exp` form value
+//   - POST /revoke-address, revoking the proofs of the `address` form value, or only those
+//     issued before the unix time of the `before` form value
+//   - GET /sessions?address=, listing the sessions of an account
+//   - POST /flush-cache, purging the verification cache
+//
+// Requests must carry an admin proof, of an account of AdminOptions.Admins with the admin
+// scope, as an `Authorization: Bearer <proof>` header, or are rejected with a 401 Unauthorized
+// status, or a 403 Forbidden status for the proofs of other accounts or scopes. Endpoints of
+// a store or cache the ETHAuth instance isn't configured with respond with a 501 Not
+// Implemented status. Admin actions are logged at the warn level, see ETHAuth.ConfigLogger.
+type AdminServer struct {
+	ethAuth *ETHAuth
+	options AdminOptions
+	mux     *http.ServeMux
+}
+
+// NewAdminServer returns an AdminServer managing the revocation store, session registry and
+// verification cache of ethAuth.
+func NewAdminServer(ethAuth *ETHAuth, options AdminOptions) (*AdminServer, error) {
+	if ethAuth == nil {
+		return nil, fmt.Errorf("ethauth: admin server requires an ETHAuth instance")
+	}
+	if len(options.Admins) == 0 {
+		return nil, fmt.Errorf("ethauth: admin server requires admin accounts")
+	}
+	if options.Scope == "" {
+		options.Scope = AdminScope
+	}
+	if options.Verifier == nil {
+		options.Verifier = ethAuth
+	}
+
+	s := &AdminServer{ethAuth: ethAuth, options: options, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/revoke-token", s.admin(http.MethodPost, s.handleRevokeToken))
+	s.mux.HandleFunc("/revoke-address", s.admin(http.MethodPost, s.handleRevokeAddress))
+	s.mux.HandleFunc("/sessions", s.admin(http.MethodGet, s.handleSessions))
+	s.mux.HandleFunc("/flush-cache", s.admin(http.MethodPost, s.handleFlushCache))
+	return s, nil
+}
+
+func (s *AdminServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// admin authenticates the admin proof of the requests of the method, passing the admin
+// account to the handler.
+func (s *AdminServer) admin(method string, handler func(w http.ResponseWriter, r *http.Request, admin string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+			return
+		}
+		proof, err := Authenticate(s.options.Verifier, NewAuthRequest(r), MiddlewareOptions{})
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ethauth"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		address, err := proof.AddressBytes()
+		if err != nil || !slices.Contains(s.options.Admins, address) || !proof.Claims.Scope.Has(s.options.Scope) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return
+		}
+		handler(w, r, address.Hex())
+	}
+}
+
+func (s *AdminServer) handleRevokeToken(w http.ResponseWriter, r *http.Request, admin string) {
+	if s.ethAuth.revocationStore == nil {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "revocation store is not configured"})
+		return
+	}
+
+	id := r.PostFormValue("jti")
+	exp, err := formUnixTime(r, "exp")
+	if token := r.PostFormValue("token"); token != "" {
+		// the revoked proof isn't verified, so expired or otherwise invalid proofs can be revoked
+		proof, perr := Parse(token)
+		if perr != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": perr.Error()})
+			return
+		}
+		id, exp, err = proof.Claims.ID, time.Time{}, nil
+		if proof.Claims.ExpiresAt != 0 {
+			exp = time.Unix(proof.Claims.ExpiresAt, 0)
+		}
+	}
+	if id == "" || err != nil || exp.IsZero() {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "revoke-token requires a token with a jti claim, or a jti and exp"})
+		return
+	}
+
+	if err := s.ethAuth.revocationStore.RevokeID(r.Context(), id, exp); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	s.ethAuth.log().Warn(r.Context(), "ethauth: admin revoked proof", "admin", admin, "jti", id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": true})
+}
+
+func (s *AdminServer) handleRevokeAddress(w http.ResponseWriter, r *http.Request, admin string) {
+	if s.ethAuth.revocationStore == nil {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "revocation store is not configured"})
+		return
+	}
+
+	address := r.PostFormValue("address")
+	before, err := formUnixTime(r, "before")
+	if !common.IsHexAddress(address) || err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "revoke-address requires an address"})
+		return
+	}
+
+	if before.IsZero() {
+		err = s.ethAuth.revocationStore.RevokeAddress(r.Context(), address)
+	} else {
+		err = s.ethAuth.revocationStore.RevokeIssuedBefore(r.Context(), address, before)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	s.ethAuth.log().Warn(r.Context(), "ethauth: admin revoked account", "admin", admin, "address", address, "before", before)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"revoked": true})
+}
+
+// adminSession is the JSON representation of a SessionInfo of the sessions endpoint.
+type adminSession struct {
+	ID        string `json:"id"`
+	Address   string `json:"address"`
+	App       string `json:"app,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	LastSeen  int64  `json:"last_seen"`
+}
+
+func (s *AdminServer) handleSessions(w http.ResponseWriter, r *http.Request, admin string) {
+	if s.ethAuth.sessionRegistry == nil {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "session registry is not configured"})
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "sessions requires an address"})
+		return
+	}
+	sessions, err := s.ethAuth.sessionRegistry.Sessions(r.Context(), address)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	out := make([]adminSession, 0, len(sessions))
+	for _, session := range sessions {
+		out = append(out, adminSession{
+			ID:        session.ID,
+			Address:   session.Address,
+			App:       session.App,
+			IssuedAt:  unixOrZero(session.IssuedAt),
+			ExpiresAt: unixOrZero(session.ExpiresAt),
+			LastSeen:  session.LastSeen.Unix(),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": out})
+}
+
+func (s *AdminServer) handleFlushCache(w http.ResponseWriter, r *http.Request, admin string) {
+	if s.ethAuth.cache == nil {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "verification cache is not configured"})
+		return
+	}
+
+	purged := s.ethAuth.cache.Stats().Len
+	s.ethAuth.cache.Purge()
+	s.ethAuth.log().Warn(r.Context(), "ethauth: admin flushed the verification cache", "admin", admin, "purged", purged)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"purged": purged})
+}
+
+// formUnixTime returns the unix time of the form value, or the zero time if it is empty.
+func formUnixTime(r *http.Request, key string) (time.Time, error) {
+	value := r.PostFormValue(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || t <= 0 {
+		return time.Time{}, fmt.Errorf("ethauth: invalid %s", key)
+	}
+	return time.Unix(t, 0), nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
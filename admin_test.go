@@ -0,0 +1,123 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminServer(t *testing.T) {
+	admin, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	user, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	_, err = NewAdminServer(ethAuth, AdminOptions{})
+	require.Error(t, err)
+
+	server, err := NewAdminServer(ethAuth, AdminOptions{Admins: []common.Address{admin.Address()}})
+	require.NoError(t, err)
+
+	adminProof, err := Issue(NewWalletSigner(admin), WithApp("ETHAuthAdmin"), WithScope(AdminScope))
+	require.NoError(t, err)
+
+	do := func(method, path, proofString string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if proofString != "" {
+			req.Header.Set("Authorization", "Bearer "+proofString)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// admin proofs are required, of an admin account with the admin scope
+	rec := do("POST", "/flush-cache", "", nil)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+
+	proofString, err := Issue(NewWalletSigner(admin), WithApp("ETHAuthAdmin"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, do("POST", "/flush-cache", proofString, nil).Code)
+
+	proofString, err = Issue(NewWalletSigner(user), WithApp("ETHAuthAdmin"), WithScope(AdminScope))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, do("POST", "/flush-cache", proofString, nil).Code)
+
+	require.Equal(t, http.StatusMethodNotAllowed, do("GET", "/flush-cache", adminProof, nil).Code)
+
+	// endpoints of unconfigured stores
+	require.Equal(t, http.StatusNotImplemented, do("POST", "/flush-cache", adminProof, nil).Code)
+	require.Equal(t, http.StatusNotImplemented, do("POST", "/revoke-token", adminProof, url.Values{"jti": {"1"}}).Code)
+	require.Equal(t, http.StatusNotImplemented, do("GET", "/sessions?address="+user.Address().Hex(), adminProof, nil).Code)
+
+	ethAuth.ConfigRevocationStore(NewMemoryRevocationStore())
+	ethAuth.ConfigSessionRegistry(NewSessionRegistry())
+	cache := NewVerificationCache(16, time.Minute)
+	ethAuth.ConfigCache(cache)
+
+	userProof, err := Issue(NewWalletSigner(user), WithApp("ETHAuthTest"), WithID("user-proof"))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(userProof)
+	require.NoError(t, err)
+
+	// sessions
+	rec = do("GET", "/sessions?address="+user.Address().Hex(), adminProof, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var sessions struct {
+		Sessions []adminSession `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sessions))
+	require.Len(t, sessions.Sessions, 1)
+	require.Equal(t, "ETHAuthTest", sessions.Sessions[0].App)
+	require.Equal(t, http.StatusBadRequest, do("GET", "/sessions", adminProof, nil).Code)
+
+	// flush-cache
+	require.Positive(t, cache.Stats().Len)
+	rec = do("POST", "/flush-cache", adminProof, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Zero(t, cache.Stats().Len)
+
+	// revoke-token
+	require.Equal(t, http.StatusBadRequest, do("POST", "/revoke-token", adminProof, url.Values{"jti": {"user-proof"}}).Code)
+	proofString, err = Issue(NewWalletSigner(user), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, do("POST", "/revoke-token", adminProof, url.Values{"token": {proofString}}).Code)
+
+	require.Equal(t, http.StatusOK, do("POST", "/revoke-token", adminProof, url.Values{"token": {userProof}}).Code)
+	_, _, err = ethAuth.DecodeProof(userProof)
+	require.ErrorIs(t, err, ErrProofRevoked)
+
+	exp := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	require.Equal(t, http.StatusOK, do("POST", "/revoke-token", adminProof, url.Values{"jti": {"other-proof"}, "exp": {exp}}).Code)
+	proofString, err = Issue(NewWalletSigner(user), WithApp("ETHAuthTest"), WithID("other-proof"))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrProofRevoked)
+
+	// revoke-address
+	require.Equal(t, http.StatusBadRequest, do("POST", "/revoke-address", adminProof, url.Values{"address": {"0x1"}}).Code)
+	proofString, err = Issue(NewWalletSigner(user), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, do("POST", "/revoke-address", adminProof, url.Values{"address": {user.Address().Hex()}}).Code)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrProofRevoked)
+
+	// the admin account itself is unaffected
+	require.Equal(t, http.StatusOK, do("POST", "/flush-cache", adminProof, nil).Code)
+}
@@ -0,0 +1,94 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+)
+
+// Audit decisions of AuditEvent.
+const (
+	AuditDecisionAllow = "allow"
+	AuditDecisionDeny  = "deny"
+)
+
+// AuditEvent records the decision of a proof verification.
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+
+	// Digest is the digest of the message signed by the proof, which identifies the proof
+	// without recording the bearer proof string itself
+	Digest string `json:"digest,omitempty"`
+
+	Address string  `json:"address,omitempty"`
+	App     string  `json:"app,omitempty"`
+	Claims  *Claims `json:"claims,omitempty"`
+
+	// Decision is AuditDecisionAllow or AuditDecisionDeny
+	Decision string `json:"decision"`
+
+	// Reason is the FailureReason of denied proofs, and Error the verification error
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Duration is how long the verification took
+	Duration time.Duration `json:"durationNs"`
+}
+
+// AuditLogger records the decision of each proof verification of an ETHAuth instance,
+// see ETHAuth.ConfigAuditLogger. LogVerification is called synchronously, so it must not block.
+type AuditLogger interface {
+	LogVerification(ctx context.Context, event *AuditEvent)
+}
+
+// ConfigAuditLogger sets the audit logger of proof verifications, or disables audit
+// logging if nil.
+func (w *ETHAuth) ConfigAuditLogger(logger AuditLogger) {
+	w.auditLogger = logger
+}
+
+func (w *ETHAuth) logVerification(ctx context.Context, start time.Time, proof *Proof, err error) {
+	event := &AuditEvent{
+		Time:     start.UTC(),
+		Decision: AuditDecisionAllow,
+		Duration: time.Since(start),
+	}
+	if proof != nil {
+		if digest, err := proof.MessageDigest(); err == nil {
+			event.Digest = ethcoder.HexEncode(digest)
+		}
+		claims := proof.Claims
+		event.Address = proof.Address
+		event.App = proof.Claims.App
+		event.Claims = &claims
+	}
+	if err != nil {
+		event.Decision = AuditDecisionDeny
+		event.Reason = FailureReason(err)
+		event.Error = err.Error()
+	}
+	w.auditLogger.LogVerification(ctx, event)
+}
+
+// JSONAuditLogger is an AuditLogger writing each event as a line of JSON.
+type JSONAuditLogger struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+var _ AuditLogger = &JSONAuditLogger{}
+
+// NewJSONAuditLogger returns a JSONAuditLogger writing to w, ie. os.Stdout or a log file.
+func NewJSONAuditLogger(w io.Writer) *JSONAuditLogger {
+	return &JSONAuditLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *JSONAuditLogger) LogVerification(ctx context.Context, event *AuditEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(event)
+}
@@ -0,0 +1,62 @@
+package ethauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONAuditLogger(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigAuditLogger(NewJSONAuditLogger(&buf))
+	require.NoError(t, ethAuth.ConfigExpectedAudience("https://api.example.com"))
+
+	claims := Claims{App: "ETHAuthTest", Audience: "https://api.example.com", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proof := signTestProof(t, wallet, claims)
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	claims.Audience = "https://other.example.com"
+	proofString, err = signTestProof(t, wallet, claims).Encode()
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var allowed, denied AuditEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &allowed))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &denied))
+
+	digest, err := proof.MessageDigest()
+	require.NoError(t, err)
+	require.Equal(t, AuditDecisionAllow, allowed.Decision)
+	require.Equal(t, ethcoder.HexEncode(digest), allowed.Digest)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), allowed.Address)
+	require.Equal(t, "ETHAuthTest", allowed.App)
+	require.Equal(t, "https://api.example.com", allowed.Claims.Audience)
+	require.Empty(t, allowed.Reason)
+
+	require.Equal(t, AuditDecisionDeny, denied.Decision)
+	require.Equal(t, "invalid_audience", denied.Reason)
+	require.NotEmpty(t, denied.Error)
+
+	// the proof string itself is not logged
+	require.NotContains(t, buf.String(), proofString[len(proofString)-20:])
+}
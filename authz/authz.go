@@ -0,0 +1,117 @@
+// Package authz implements the Envoy external authorization gRPC API with ETHAuth, so proofs
+// can be verified at the edge of a service mesh instead of by each upstream service.
+//
+// Requests are authorized by the proof of their `Authorization: Bearer <proof>` header, and
+// forwarded upstream with the x-ewt-address (EIP-55 checksummed) and x-ewt-app identity
+// headers of the verified proof. Requests without a valid proof are denied with a 401
+// Unauthorized status. Proofs bound to a client key must be presented with their
+// proof-of-possession, see ethauth.ETHAuth.VerifyPoP, but proofs bound to a client TLS
+// certificate are denied, as the certificate isn't passed to the authorization server.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/go-ethauth"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Identity headers set on authorized requests.
+const (
+	HeaderAddress = "x-ewt-address"
+	HeaderApp     = "x-ewt-app"
+)
+
+// Server is an Envoy ext_authz AuthorizationServer verifying ETHAuth proofs.
+type Server struct {
+	ethAuth *ethauth.ETHAuth
+}
+
+var _ authv3.AuthorizationServer = &Server{}
+
+// NewServer returns a Server verifying proofs with ethAuth.
+func NewServer(ethAuth *ethauth.ETHAuth) *Server {
+	return &Server{ethAuth: ethAuth}
+}
+
+// Register registers the server with a gRPC server.
+func (s *Server) Register(grpcServer *grpc.Server) {
+	authv3.RegisterAuthorizationServer(grpcServer, s)
+}
+
+func (s *Server) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	// envoy passes the request headers with lowercase keys
+	httpReq := req.GetAttributes().GetRequest().GetHttp()
+	headers := httpReq.GetHeaders()
+	path, _, _ := strings.Cut(httpReq.GetPath(), "?")
+
+	proof, err := ethauth.Authenticate(s.ethAuth, ethauth.AuthRequest{
+		Context:       ctx,
+		Authorization: headers["authorization"],
+		Method:        httpReq.GetMethod(),
+		Host:          httpReq.GetHost(),
+		Path:          path,
+		PoP:           headers[strings.ToLower(ethauth.HeaderPoP)],
+		RemoteAddr:    req.GetAttributes().GetSource().GetAddress().GetSocketAddress().GetAddress(),
+		UserAgent:     headers["user-agent"],
+		Body: func() ([]byte, error) {
+			// the body is passed when the filter is configured with_request_body
+			if headers["x-envoy-auth-partial-body"] == "true" {
+				return nil, fmt.Errorf("request body is truncated")
+			}
+			if raw := httpReq.GetRawBody(); len(raw) > 0 {
+				return raw, nil
+			}
+			return []byte(httpReq.GetBody()), nil
+		},
+	}, ethauth.MiddlewareOptions{})
+	if err != nil {
+		return denied(err.Error()), nil
+	}
+
+	address, err := proof.AddressBytes()
+	if err != nil {
+		return denied(err.Error()), nil
+	}
+
+	return &authv3.CheckResponse{
+		Status: &rpcstatus.Status{Code: int32(codes.OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{
+			OkResponse: &authv3.OkHttpResponse{
+				Headers: []*corev3.HeaderValueOption{
+					header(HeaderAddress, address.Hex()),
+					header(HeaderApp, proof.Claims.App),
+				},
+			},
+		},
+	}, nil
+}
+
+func denied(message string) *authv3.CheckResponse {
+	return &authv3.CheckResponse{
+		Status: &rpcstatus.Status{Code: int32(codes.Unauthenticated), Message: message},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv3.DeniedHttpResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_Unauthorized},
+				Headers: []*corev3.HeaderValueOption{header("www-authenticate", `Bearer realm="ethauth"`)},
+				Body:    "Unauthorized",
+			},
+		},
+	}
+}
+
+// header returns a header overwriting any header of the same name of the request, so clients
+// can't spoof the identity headers.
+func header(key, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header:       &corev3.HeaderValue{Key: key, Value: value},
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+	}
+}
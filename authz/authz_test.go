@@ -0,0 +1,90 @@
+package authz
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-ethauth"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func checkRequest(authorization string) *authv3.CheckRequest {
+	headers := map[string]string{"x-ewt-address": "0xspoofed"}
+	if authorization != "" {
+		headers["authorization"] = authorization
+	}
+	return &authv3.CheckRequest{
+		Attributes: &authv3.AttributeContext{
+			Request: &authv3.AttributeContext_Request{
+				Http: &authv3.AttributeContext_HttpRequest{Method: "GET", Path: "/", Headers: headers},
+			},
+		},
+	}
+}
+
+func TestServerCheck(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	NewServer(ethAuth).Register(grpcServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := authv3.NewAuthorizationClient(conn)
+	ctx := context.Background()
+
+	proof := ethauth.NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(time.Hour)
+	require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+
+	// valid proofs are allowed, with the identity headers overwriting the request headers
+	resp, err := client.Check(ctx, checkRequest("Bearer "+proofString))
+	require.NoError(t, err)
+	require.Equal(t, int32(codes.OK), resp.Status.Code)
+	headers := map[string]string{}
+	for _, h := range resp.GetOkResponse().Headers {
+		headers[h.Header.Key] = h.Header.Value
+	}
+	require.Equal(t, map[string]string{
+		HeaderAddress: wallet.Address().Hex(),
+		HeaderApp:     "ETHAuthTest",
+	}, headers)
+
+	// missing or invalid proofs are denied
+	for _, authorization := range []string{"", "Basic abc", "Bearer eth.invalid"} {
+		resp, err := client.Check(ctx, checkRequest(authorization))
+		require.NoError(t, err)
+		require.Equal(t, int32(codes.Unauthenticated), resp.Status.Code, authorization)
+		require.Equal(t, typev3.StatusCode_Unauthorized, resp.GetDeniedResponse().Status.Code)
+	}
+
+	// expired proofs are denied
+	proof.Claims.IssuedAt = time.Now().Add(-2 * time.Hour).Unix()
+	proof.Claims.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+	proofString, err = proof.Encode()
+	require.NoError(t, err)
+	resp, err = client.Check(ctx, checkRequest("Bearer "+proofString))
+	require.NoError(t, err)
+	require.Equal(t, int32(codes.Unauthenticated), resp.Status.Code)
+}
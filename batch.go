@@ -0,0 +1,76 @@
+package ethauth
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Result is the outcome of verifying one of the proof strings passed to VerifyBatch.
+type Result struct {
+	Proof *Proof
+	Valid bool
+	Err   error
+}
+
+// VerifyBatch decodes and validates the proof strings concurrently, returning a Result for
+// each of them in order. Identical proof strings are only verified once. When a nonce store
+// is configured, the repeated occurrences of a proof in the batch are rejected as replays.
+//
+// Configure a BatchRemoteValidator to check the EIP-1271 signatures of the batch in as few
+// multicalls as possible.
+func (w *ETHAuth) VerifyBatch(ctx context.Context, proofStrings []string) []Result {
+	results := make([]Result, len(proofStrings))
+
+	// dedupe identical proof strings, keeping the index of their first occurrence
+	first := make(map[string]int, len(proofStrings))
+	var unique []int
+	for i, s := range proofStrings {
+		if _, ok := first[s]; !ok {
+			first[s] = i
+			unique = append(unique, i)
+		}
+	}
+
+	// oversubscribe the CPUs, as remote validators block on RPC round trips, which a
+	// BatchRemoteValidator coalesces into multicalls across the workers
+	workers := 16 * runtime.GOMAXPROCS(0)
+	if workers > len(unique) {
+		workers = len(unique)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i] = Result{Err: err}
+					continue
+				}
+				valid, proof, err := w.decodeProof(ctx, proofStrings[i], nil)
+				results[i] = Result{Proof: proof, Valid: valid, Err: err}
+			}
+		}()
+	}
+	for _, i := range unique {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, s := range proofStrings {
+		j := first[s]
+		if i == j {
+			continue
+		}
+		if w.nonceStore != nil && results[j].Valid {
+			results[i] = Result{Proof: results[j].Proof, Err: ErrNonceUsed}
+		} else {
+			results[i] = results[j]
+		}
+	}
+	return results
+}
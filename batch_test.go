@@ -0,0 +1,45 @@
+package ethauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	var proofStrings []string
+	for i := 0; i < 10; i++ {
+		claims := Claims{App: "ETHAuthTest", Nonce: uint64(i + 1), ETHAuthVersion: ETHAuthVersion}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(5 * time.Minute)
+		proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+		require.NoError(t, err)
+		proofStrings = append(proofStrings, proofString)
+	}
+	proofStrings = append(proofStrings, proofStrings[0], "invalid")
+
+	results := ethAuth.VerifyBatch(context.Background(), proofStrings)
+	require.Len(t, results, len(proofStrings))
+	for i := 0; i < 11; i++ {
+		require.NoError(t, results[i].Err)
+		require.True(t, results[i].Valid)
+		require.Equal(t, uint64(i%10+1), results[i].Proof.Claims.Nonce)
+	}
+	require.Error(t, results[11].Err)
+	require.False(t, results[11].Valid)
+
+	// repeated proofs are rejected as replays when nonces are consumed
+	ethAuth.ConfigNonceStore(NewMemoryNonceStore())
+	results = ethAuth.VerifyBatch(context.Background(), proofStrings)
+	require.True(t, results[0].Valid)
+	require.ErrorIs(t, results[10].Err, ErrNonceUsed)
+}
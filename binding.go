@@ -0,0 +1,146 @@
+package ethauth
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// BindingMode is how strictly the `ip` and `ua` claims binding a proof to its client are
+// enforced, see ETHAuth.ConfigClientBinding.
+type BindingMode int
+
+const (
+	// BindingOff doesn't enforce the claim.
+	BindingOff BindingMode = iota
+
+	// BindingSubnet requires the client IP to be in the network of the `ip` claim.
+	BindingSubnet
+
+	// BindingExact requires the client IP to be the address of the `ip` claim, or the client
+	// user agent to be the user agent of the `ua` claim.
+	BindingExact
+)
+
+// IPBinding returns the `ip` claim binding a proof to the network prefix, ie. the /32 of a
+// client IPv4 address, or its /24 subnet. The claim is the prefix length and the keccak256 hash
+// of the masked prefix, so the client IP isn't disclosed in the proof. Invalid prefixes are
+// rejected at issuance.
+func IPBinding(prefix netip.Prefix) string {
+	if !prefix.IsValid() {
+		return "invalid"
+	}
+	prefix = prefix.Masked()
+	return strconv.Itoa(prefix.Bits()) + ":" + ethcoder.HexEncode(crypto.Keccak256([]byte(prefix.String())))
+}
+
+// UserAgentBinding returns the `ua` claim binding a proof to the client user agent, the
+// keccak256 hash of the User-Agent header.
+func UserAgentBinding(userAgent string) string {
+	return ethcoder.HexEncode(crypto.Keccak256([]byte(userAgent)))
+}
+
+// WithClientIP sets the `ip` claim binding the proof to the network prefix, see IPBinding.
+func WithClientIP(prefix netip.Prefix) IssueOption {
+	return func(claims *Claims) {
+		claims.ClientIP = IPBinding(prefix)
+	}
+}
+
+// WithUserAgent sets the `ua` claim binding the proof to the user agent, see UserAgentBinding.
+func WithUserAgent(userAgent string) IssueOption {
+	return func(claims *Claims) {
+		claims.UserAgent = UserAgentBinding(userAgent)
+	}
+}
+
+// parseIPBinding returns the prefix length and hash of an `ip` claim.
+func parseIPBinding(claim string) (int, string, bool) {
+	bitsString, hash, ok := strings.Cut(claim, ":")
+	if !ok {
+		return 0, "", false
+	}
+	bits, err := strconv.Atoi(bitsString)
+	if err != nil || bits < 0 || bits > 128 || len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return 0, "", false
+	}
+	return bits, hash, true
+}
+
+func (c Claims) validClientBinding() error {
+	if c.ClientIP != "" {
+		if _, _, ok := parseIPBinding(c.ClientIP); !ok {
+			return fmt.Errorf("claims: ip is not a network prefix binding")
+		}
+	}
+	if c.UserAgent != "" && (len(c.UserAgent) != 66 || !strings.HasPrefix(c.UserAgent, "0x")) {
+		return fmt.Errorf("claims: ua is not a user agent hash")
+	}
+	return nil
+}
+
+// ConfigClientBinding enforces the `ip` and `ua` claims binding proofs to their client, so ie.
+// the proofs of an admin panel are unusable from another network. Once enforced, the claim is
+// required. The user agent may only be bound exactly. The client IP is taken from the remote
+// address of requests, so servers behind a proxy must set it from the forwarded headers the
+// proxy sets.
+func (w *ETHAuth) ConfigClientBinding(ip, userAgent BindingMode) error {
+	if ip < BindingOff || ip > BindingExact {
+		return fmt.Errorf("ethauth: invalid ip binding mode")
+	}
+	if userAgent != BindingOff && userAgent != BindingExact {
+		return fmt.Errorf("ethauth: invalid user agent binding mode")
+	}
+	w.ipBinding = ip
+	w.userAgentBinding = userAgent
+	return nil
+}
+
+// VerifyClientBinding verifies the `ip` and `ua` claims of the proof against the client of the
+// request, as configured by ConfigClientBinding.
+func (w *ETHAuth) VerifyClientBinding(proof *Proof, req AuthRequest) error {
+	if w.ipBinding != BindingOff {
+		bits, _, ok := parseIPBinding(proof.Claims.ClientIP)
+		if !ok {
+			return fmt.Errorf("%w, proof is not bound to a client ip", ErrInvalidClientBinding)
+		}
+		addr, err := remoteIP(req.RemoteAddr)
+		if err != nil {
+			return fmt.Errorf("%w, %v", ErrInvalidClientBinding, err)
+		}
+		if w.ipBinding == BindingExact && bits != addr.BitLen() {
+			return fmt.Errorf("%w, proof is bound to a subnet", ErrInvalidClientBinding)
+		}
+		prefix, err := addr.Prefix(bits)
+		if err != nil || !strings.EqualFold(IPBinding(prefix), proof.Claims.ClientIP) {
+			return fmt.Errorf("%w, client ip does not match", ErrInvalidClientBinding)
+		}
+	}
+	if w.userAgentBinding != BindingOff {
+		if proof.Claims.UserAgent == "" {
+			return fmt.Errorf("%w, proof is not bound to a user agent", ErrInvalidClientBinding)
+		}
+		if !strings.EqualFold(UserAgentBinding(req.UserAgent), proof.Claims.UserAgent) {
+			return fmt.Errorf("%w, user agent does not match", ErrInvalidClientBinding)
+		}
+	}
+	return nil
+}
+
+// remoteIP returns the IP of a remote address, with or without its port.
+func remoteIP(remoteAddr string) (netip.Addr, error) {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid client ip %q", remoteAddr)
+	}
+	return addr.Unmap(), nil
+}
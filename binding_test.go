@@ -0,0 +1,57 @@
+package ethauth
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientBinding(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	signer := NewWalletSigner(wallet)
+
+	clientIP := netip.MustParseAddr("203.0.113.7")
+	exact, err := Issue(signer, WithApp("ETHAuthTest"), WithClientIP(netip.PrefixFrom(clientIP, 32)), WithUserAgent("Mozilla/5.0"))
+	require.NoError(t, err)
+	subnet, err := Issue(signer, WithApp("ETHAuthTest"), WithClientIP(netip.PrefixFrom(clientIP, 24)))
+	require.NoError(t, err)
+	unbound, err := Issue(signer, WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+
+	// the client ip isn't disclosed by the proof
+	proof, err := Parse(exact)
+	require.NoError(t, err)
+	require.NotContains(t, proof.Claims.ClientIP, "203.0.113")
+
+	authenticate := func(ethAuth *ETHAuth, proofString, remoteAddr, userAgent string) error {
+		_, err := Authenticate(ethAuth, AuthRequest{Authorization: "Bearer " + proofString, RemoteAddr: remoteAddr, UserAgent: userAgent}, MiddlewareOptions{})
+		return err
+	}
+
+	// bindings aren't enforced by default
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.NoError(t, authenticate(ethAuth, exact, "198.51.100.1:443", "curl"))
+
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingSubnet, BindingOff))
+	require.NoError(t, authenticate(ethAuth, exact, "203.0.113.7:443", ""))
+	require.NoError(t, authenticate(ethAuth, subnet, "203.0.113.200:443", ""))
+	require.NoError(t, authenticate(ethAuth, subnet, "[::ffff:203.0.113.200]:443", ""))
+	require.ErrorIs(t, authenticate(ethAuth, exact, "203.0.113.8:443", ""), ErrInvalidClientBinding)
+	require.ErrorIs(t, authenticate(ethAuth, subnet, "203.0.114.1:443", ""), ErrInvalidClientBinding)
+	require.ErrorIs(t, authenticate(ethAuth, unbound, "203.0.113.7:443", ""), ErrInvalidClientBinding)
+
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingExact, BindingExact))
+	require.NoError(t, authenticate(ethAuth, exact, "203.0.113.7", "Mozilla/5.0"))
+	require.ErrorIs(t, authenticate(ethAuth, exact, "203.0.113.7", "curl"), ErrInvalidClientBinding)
+	require.ErrorIs(t, authenticate(ethAuth, subnet, "203.0.113.7", "Mozilla/5.0"), ErrInvalidClientBinding)
+
+	require.Error(t, ethAuth.ConfigClientBinding(BindingOff, BindingSubnet))
+
+	// invalid bindings are rejected at issuance
+	_, err = Issue(signer, WithApp("ETHAuthTest"), WithClientIP(netip.Prefix{}))
+	require.Error(t, err)
+}
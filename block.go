@@ -0,0 +1,171 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// SignatureBlock selects the chain state the EIP-1271 signatures of contract accounts are
+// verified against, see ETHAuth.ConfigSignatureBlock. Contract wallets can rotate their owners,
+// so a proof signed by a key at `iat` may be signed by a key which has since been removed.
+type SignatureBlock int
+
+const (
+	// SignatureBlockLatest verifies signatures against the latest block, so proofs signed by a
+	// key removed from the wallet are rejected as soon as the key is removed. This is the
+	// default, for deployments which rotate wallet owners to revoke compromised keys.
+	SignatureBlockLatest SignatureBlock = iota
+
+	// SignatureBlockIssuedAt verifies signatures against the last block mined at or before the
+	// minute of the `iat` claim, which requires an archive node, so proofs stay valid until
+	// they expire when the wallet rotates its owners. Note, the `iat` claim is chosen by the signer, so a key
+	// removed from the wallet can still sign proofs issued before its removal: pair it with
+	// RequireIat and a short MaxAge in the ValidatorConfig to bound how far back proofs reach.
+	SignatureBlockIssuedAt
+)
+
+// maxSignatureBlocks bounds the cache of the blocks of `iat` claims.
+const maxSignatureBlocks = 4096
+
+// signatureBlockBucket is the granularity of the `iat` claims whose blocks are searched, so
+// the proofs issued within the same minute share the RPC calls of a single search, rather than
+// each `iat` chosen by a signer costing a search of its own.
+const signatureBlockBucket = int64(60)
+
+var signatureBlockCtxKey = &contextKey{"signatureBlock"}
+
+// signatureBlocks finds and caches the blocks of the `iat` claims of proofs, which ETHAuth
+// passes to the contract account validators in the context.
+type signatureBlocks struct {
+	blocks map[int64]*big.Int
+
+	// times are the timestamps of the blocks fetched by the searches, which searches of other
+	// `iat` claims mostly share
+	times map[int64]int64
+	mu    sync.Mutex
+}
+
+// ConfigSignatureBlock sets the chain state the EIP-1271 signatures of contract accounts are
+// verified against by ValidateContractAccountProof and ValidateERC6492Proof, which is the
+// latest block by default. See SignatureBlockIssuedAt for the threat model of each option.
+// The wallet contract must still be deployed at the latest block either way. Batched
+// validators, ie. BatchRemoteValidator, always verify signatures at the latest block.
+func (w *ETHAuth) ConfigSignatureBlock(block SignatureBlock) error {
+	switch block {
+	case SignatureBlockLatest:
+		w.signatureBlocks = nil
+	case SignatureBlockIssuedAt:
+		w.signatureBlocks = &signatureBlocks{blocks: map[int64]*big.Int{}, times: map[int64]int64{}}
+	default:
+		return fmt.Errorf("ethauth: invalid signature block %d", block)
+	}
+	return nil
+}
+
+// signatureBlockSearch is the context value of the signature blocks of a verification, with
+// the time and MaxAge of the instance bounding the `iat` claims searched.
+type signatureBlockSearch struct {
+	blocks *signatureBlocks
+	now    time.Time
+	maxAge time.Duration
+}
+
+// signatureBlockNumber returns the block number the signature of the proof is verified at, or
+// nil for the latest block.
+func signatureBlockNumber(ctx context.Context, provider *ethrpc.Provider, proof *Proof) (*big.Int, error) {
+	search, ok := ctx.Value(signatureBlockCtxKey).(*signatureBlockSearch)
+	if !ok || proof.Claims.IssuedAt == 0 {
+		return nil, nil
+	}
+
+	// the `iat` claim is chosen by the signer, so it is clamped to the lifetime of accepted
+	// proofs, and rounded down to its minute, before any block is fetched
+	t, now := proof.Claims.IssuedAt, search.now.Unix()
+	if t > now {
+		t = now
+	}
+	if search.maxAge > 0 && t < now-int64(search.maxAge.Seconds()) {
+		t = now - int64(search.maxAge.Seconds())
+	}
+	t -= t % signatureBlockBucket
+	return search.blocks.blockAt(ctx, provider, t)
+}
+
+// blockAt returns the number of the last block mined at or before the unix time t, or nil if
+// it is the latest block.
+func (b *signatureBlocks) blockAt(ctx context.Context, provider *ethrpc.Provider, t int64) (*big.Int, error) {
+	b.mu.Lock()
+	number, ok := b.blocks[t]
+	b.mu.Unlock()
+	if ok {
+		return number, nil
+	}
+
+	rpcCtx, call := startRPCCall(ctx, "eth_getBlockByNumber")
+	latest, err := provider.HeaderByNumber(rpcCtx, nil)
+	call.end(err)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the latest block - %w", err)
+	}
+	if int64(latest.Time) <= t {
+		// blocks mined at or before t may still follow the latest block, so it isn't cached
+		return nil, nil
+	}
+
+	// binary search the last block whose timestamp is at most t
+	lo, hi := int64(-1), latest.Number.Int64()-1
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		blockTime, err := b.blockTime(ctx, provider, mid)
+		if err != nil {
+			return nil, err
+		}
+		if blockTime <= t {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo < 0 {
+		return nil, fmt.Errorf("proof was issued before the genesis block")
+	}
+	number = big.NewInt(lo)
+
+	b.mu.Lock()
+	if len(b.blocks) >= maxSignatureBlocks {
+		clear(b.blocks)
+	}
+	b.blocks[t] = number
+	b.mu.Unlock()
+	return number, nil
+}
+
+// blockTime returns the timestamp of the block.
+func (b *signatureBlocks) blockTime(ctx context.Context, provider *ethrpc.Provider, number int64) (int64, error) {
+	b.mu.Lock()
+	t, ok := b.times[number]
+	b.mu.Unlock()
+	if ok {
+		return t, nil
+	}
+
+	rpcCtx, call := startRPCCall(ctx, "eth_getBlockByNumber")
+	header, err := provider.HeaderByNumber(rpcCtx, big.NewInt(number))
+	call.end(err)
+	if err != nil {
+		return 0, fmt.Errorf("unable to fetch block %d - %w", number, err)
+	}
+
+	b.mu.Lock()
+	if len(b.times) >= maxSignatureBlocks {
+		clear(b.times)
+	}
+	b.times[number] = int64(header.Time)
+	b.mu.Unlock()
+	return int64(header.Time), nil
+}
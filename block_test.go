@@ -0,0 +1,145 @@
+package ethauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryTestServer returns a JSON-RPC server of a chain of blocks mined every 12 seconds
+// from genesis at unix time 1000, whose contract wallet accepts signatures until block
+// rotatedAt, when its owner is rotated. The requests of each method are counted.
+func newHistoryTestServer(t *testing.T, latest, rotatedAt int64, calls map[string]int, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		type request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		var reqs []request
+		batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+		if batch {
+			require.NoError(t, json.Unmarshal(body, &reqs))
+		} else {
+			reqs = make([]request, 1)
+			require.NoError(t, json.Unmarshal(body, &reqs[0]))
+		}
+
+		blockParam := func(param json.RawMessage) int64 {
+			var s string
+			require.NoError(t, json.Unmarshal(param, &s))
+			if s == "latest" {
+				return latest
+			}
+			n, err := hexutil.DecodeUint64(s)
+			require.NoError(t, err)
+			return int64(n)
+		}
+
+		var resps []map[string]interface{}
+		for _, req := range reqs {
+			mu.Lock()
+			calls[req.Method]++
+			mu.Unlock()
+
+			var result interface{}
+			switch req.Method {
+			case "eth_getBlockByNumber":
+				n := blockParam(req.Params[0])
+				result = &types.Header{Number: big.NewInt(n), Time: uint64(1000 + 12*n), Difficulty: big.NewInt(0)}
+			case "eth_getCode":
+				result = "0x6000"
+			case "eth_call":
+				if blockParam(req.Params[1]) < rotatedAt {
+					result = ethcoder.HexEncode(common.RightPadBytes(ethcoder.MustHexDecode(IsValidSignatureBytes32MagicValue), 32))
+				} else {
+					result = ethcoder.HexEncode(make([]byte, 32))
+				}
+			default:
+				t.Fatalf("unexpected method %s", req.Method)
+			}
+			resps = append(resps, map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		}
+		if batch {
+			json.NewEncoder(w).Encode(resps)
+		} else {
+			json.NewEncoder(w).Encode(resps[0])
+		}
+	}))
+}
+
+func TestConfigSignatureBlock(t *testing.T) {
+	calls, mu := map[string]int{}, &sync.Mutex{}
+	server := newHistoryTestServer(t, 1000, 500, calls, mu)
+	defer server.Close()
+
+	newProof := func(blockTime int64) *Proof {
+		proof := NewProof()
+		proof.Address = "0x1111111111111111111111111111111111111111"
+		proof.Claims.App = "ETHAuthTest"
+		proof.Claims.IssuedAt = blockTime
+		proof.Claims.ExpiresAt = 1000 + 12*1000 + 3600
+		proof.Signature = "0x01"
+		return proof
+	}
+
+	ethAuth, err := New(ValidateContractAccountProof)
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(server.URL, 1))
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return time.Unix(1000+12*1000, 0) }))
+
+	// the owner signing at block 400 has since been rotated out of the wallet
+	proof := newProof(1000 + 12*400 + 5)
+	_, err = ethAuth.ValidateProof(proof)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	require.Zero(t, calls["eth_getBlockByNumber"])
+
+	// verified at the block of its `iat` claim, the signature is valid
+	require.NoError(t, ethAuth.ConfigSignatureBlock(SignatureBlockIssuedAt))
+	ok, err := ethAuth.ValidateProof(proof)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotZero(t, calls["eth_getBlockByNumber"])
+	number, err := ethAuth.signatureBlocks.blockAt(context.Background(), ethAuth.provider, proof.Claims.IssuedAt)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), number.Int64())
+
+	// the blocks of `iat` claims are cached by the minute, so signers can't choose an `iat`
+	// costing a search of its own on every proof
+	lookups := calls["eth_getBlockByNumber"]
+	_, err = ethAuth.ValidateProof(proof)
+	require.NoError(t, err)
+	_, err = ethAuth.ValidateProof(newProof(proof.Claims.IssuedAt + 1))
+	require.NoError(t, err)
+	require.Equal(t, lookups, calls["eth_getBlockByNumber"])
+
+	// proofs issued a minute after the rotation are verified at the block of the rotation
+	_, err = ethAuth.ValidateProof(newProof(1000 + 12*500 + 60))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// proofs issued after the latest block are verified at the latest block
+	_, err = ethAuth.ValidateProof(newProof(1000 + 12*1000 + 1))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = ethAuth.VerifyProofSignature(context.Background(), newProof(999))
+	require.ErrorContains(t, err, "genesis")
+
+	require.Error(t, ethAuth.ConfigSignatureBlock(SignatureBlock(7)))
+	require.NoError(t, ethAuth.ConfigSignatureBlock(SignatureBlockLatest))
+	require.Nil(t, ethAuth.signatureBlocks)
+}
@@ -0,0 +1,199 @@
+package ethauth
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// MaxAppLength is the maximum length of the `app` claim accepted by ClaimsBuilder.
+const MaxAppLength = 128
+
+// ClaimsBuilder builds proof claims, validating each claim as it is set, so invalid claims are
+// caught at issuance instead of at their first failed verification. The first invalid claim
+// is returned by Build, which refuses to build the claims.
+//
+//	claims, err := ethauth.NewClaimsBuilder().
+//		App("myapp").
+//		Origin("https://app.example.com").
+//		ExpiresIn(15 * time.Minute).
+//		Build()
+type ClaimsBuilder struct {
+	claims Claims
+	err    error
+}
+
+// NewClaimsBuilder returns a ClaimsBuilder of claims issued now, of the current ETHAuthVersion.
+func NewClaimsBuilder() *ClaimsBuilder {
+	b := &ClaimsBuilder{claims: Claims{ETHAuthVersion: ETHAuthVersion}}
+	b.claims.SetIssuedAtNow()
+	return b
+}
+
+func (b *ClaimsBuilder) fail(err error) *ClaimsBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// App sets the `app` claim, which must not be empty or longer than MaxAppLength.
+func (b *ClaimsBuilder) App(app string) *ClaimsBuilder {
+	if app == "" {
+		return b.fail(ErrMissingApp)
+	}
+	if utf8.RuneCountInString(app) > MaxAppLength {
+		return b.fail(fmt.Errorf("claims: app is longer than %d characters", MaxAppLength))
+	}
+	b.claims.App = app
+	return b
+}
+
+// IssuedAt sets the `iat` claim, instead of the time the builder was created.
+func (b *ClaimsBuilder) IssuedAt(t time.Time) *ClaimsBuilder {
+	if t.Unix() <= 0 {
+		return b.fail(fmt.Errorf("claims: iat is invalid"))
+	}
+	b.claims.IssuedAt = t.Unix()
+	return b
+}
+
+// ExpiresAt sets the `exp` claim, which must be after the `iat` claim.
+func (b *ClaimsBuilder) ExpiresAt(t time.Time) *ClaimsBuilder {
+	if t.Unix() <= b.claims.IssuedAt {
+		return b.fail(ErrInvalidExpiry)
+	}
+	b.claims.ExpiresAt = t.Unix()
+	return b
+}
+
+// ExpiresIn sets the `exp` claim to the duration after the `iat` claim.
+func (b *ClaimsBuilder) ExpiresIn(ttl time.Duration) *ClaimsBuilder {
+	return b.ExpiresAt(time.Unix(b.claims.IssuedAt, 0).Add(ttl))
+}
+
+// Nonce sets the `n` claim.
+func (b *ClaimsBuilder) Nonce(nonce uint64) *ClaimsBuilder {
+	b.claims.Nonce = nonce
+	return b
+}
+
+// Type sets the `typ` claim.
+func (b *ClaimsBuilder) Type(typ string) *ClaimsBuilder {
+	b.claims.Type = typ
+	return b
+}
+
+// Origin sets the `ogn` claim, which must be the origin of a web page, ie. an absolute
+// "https://app.example.com" url without a path, query or fragment.
+func (b *ClaimsBuilder) Origin(origin string) *ClaimsBuilder {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return b.fail(fmt.Errorf("claims: ogn %q is not an origin url", origin))
+	}
+	b.claims.Origin = origin
+	return b
+}
+
+// ChainID sets the `cid` claim.
+func (b *ClaimsBuilder) ChainID(chainID uint64) *ClaimsBuilder {
+	if chainID == 0 {
+		return b.fail(fmt.Errorf("claims: cid is invalid"))
+	}
+	b.claims.ChainID = chainID
+	return b
+}
+
+// Audience sets the `aud` claim.
+func (b *ClaimsBuilder) Audience(audience string) *ClaimsBuilder {
+	b.claims.Audience = audience
+	return b
+}
+
+// Subject sets the `sub` claim.
+func (b *ClaimsBuilder) Subject(subject string) *ClaimsBuilder {
+	b.claims.Subject = subject
+	return b
+}
+
+// ID sets the `jti` claim.
+func (b *ClaimsBuilder) ID(id string) *ClaimsBuilder {
+	b.claims.ID = id
+	return b
+}
+
+// Scope adds the scopes to the `scope` claim, which must not be empty.
+func (b *ClaimsBuilder) Scope(scopes ...string) *ClaimsBuilder {
+	for _, scope := range scopes {
+		if scope == "" {
+			return b.fail(fmt.Errorf("claims: scope is empty"))
+		}
+	}
+	b.claims.Scope = append(b.claims.Scope, scopes...)
+	return b
+}
+
+// Confirmation sets the `cnf` claim, which must be a KeyConfirmation or TLSConfirmation.
+func (b *ClaimsBuilder) Confirmation(cnf string) *ClaimsBuilder {
+	if !validConfirmation(cnf) {
+		return b.fail(ErrInvalidConfirmation)
+	}
+	b.claims.Confirmation = cnf
+	return b
+}
+
+// Statement sets the `statement` claim shown by the wallet when signing, see WithStatement.
+func (b *ClaimsBuilder) Statement(statement string) *ClaimsBuilder {
+	if err := (Claims{Statement: statement}).validStatement(); err != nil {
+		return b.fail(err)
+	}
+	b.claims.Statement = statement
+	return b
+}
+
+// Host sets the `dom` claim binding the proof to the hosts it may be used on, see WithHost.
+func (b *ClaimsBuilder) Host(host string) *ClaimsBuilder {
+	if err := (Claims{Host: host}).validHost(); err != nil {
+		return b.fail(err)
+	}
+	b.claims.Host = host
+	return b
+}
+
+// MerkleRoot sets the `mrk` claim of the root of the merkle tree the account is a member of,
+// see WithMerkleRoot.
+func (b *ClaimsBuilder) MerkleRoot(root common.Hash) *ClaimsBuilder {
+	b.claims.MerkleRoot = root.Hex()
+	return b
+}
+
+// Custom sets the custom application claims, which must be valid.
+func (b *ClaimsBuilder) Custom(custom ClaimsProvider) *ClaimsBuilder {
+	if err := custom.Valid(); err != nil {
+		return b.fail(fmt.Errorf("claims: custom claims are invalid - %w", err))
+	}
+	b.claims.Custom = custom
+	return b
+}
+
+// Build returns the claims in canonical form, or the first error of the claims set. The
+// `app` and `exp` claims are required.
+func (b *ClaimsBuilder) Build() (Claims, error) {
+	if b.err != nil {
+		return Claims{}, b.err
+	}
+	if b.claims.App == "" {
+		return Claims{}, ErrMissingApp
+	}
+	if b.claims.ExpiresAt == 0 {
+		return Claims{}, ErrInvalidExpiry
+	}
+	claims := b.claims.Canonicalize()
+	if err := claims.Valid(); err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,56 @@
+package ethauth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimsBuilder(t *testing.T) {
+	iat := time.Now().Truncate(time.Second)
+
+	claims, err := NewClaimsBuilder().
+		App("ETHAuthTest").
+		IssuedAt(iat).
+		ExpiresIn(15*time.Minute).
+		Origin("HTTPS://App.Example.com/").
+		Scope("write:orders", "read:orders").
+		Nonce(7).
+		Build()
+	require.NoError(t, err)
+	require.Equal(t, Claims{
+		App:            "ETHAuthTest",
+		IssuedAt:       iat.Unix(),
+		ExpiresAt:      iat.Add(15 * time.Minute).Unix(),
+		Nonce:          7,
+		Origin:         "https://app.example.com",
+		Scope:          Scopes{"read:orders", "write:orders"},
+		ETHAuthVersion: ETHAuthVersion,
+	}, claims)
+
+	// the first invalid claim is returned by Build
+	for name, b := range map[string]*ClaimsBuilder{
+		"missing app":     NewClaimsBuilder().ExpiresIn(time.Hour),
+		"empty app":       NewClaimsBuilder().App("").ExpiresIn(time.Hour),
+		"long app":        NewClaimsBuilder().App(strings.Repeat("a", MaxAppLength+1)).ExpiresIn(time.Hour),
+		"missing exp":     NewClaimsBuilder().App("ETHAuthTest"),
+		"exp before iat":  NewClaimsBuilder().App("ETHAuthTest").ExpiresIn(-time.Minute),
+		"exp equals iat":  NewClaimsBuilder().App("ETHAuthTest").IssuedAt(iat).ExpiresAt(iat),
+		"origin path":     NewClaimsBuilder().App("ETHAuthTest").ExpiresIn(time.Hour).Origin("https://app.example.com/login"),
+		"origin relative": NewClaimsBuilder().App("ETHAuthTest").ExpiresIn(time.Hour).Origin("app.example.com"),
+		"origin query":    NewClaimsBuilder().App("ETHAuthTest").ExpiresIn(time.Hour).Origin("https://app.example.com?a=b"),
+		"empty scope":     NewClaimsBuilder().App("ETHAuthTest").ExpiresIn(time.Hour).Scope(""),
+		"expired":         NewClaimsBuilder().App("ETHAuthTest").IssuedAt(iat.Add(-2 * time.Hour)).ExpiresIn(time.Hour),
+		"custom":          NewClaimsBuilder().App("ETHAuthTest").ExpiresIn(time.Hour).Custom(&testCustomClaims{}),
+	} {
+		_, err := b.Build()
+		require.Error(t, err, name)
+	}
+
+	_, err = NewClaimsBuilder().App("ETHAuthTest").Build()
+	require.ErrorIs(t, err, ErrInvalidExpiry)
+	_, err = NewClaimsBuilder().ExpiresIn(time.Hour).Build()
+	require.ErrorIs(t, err, ErrMissingApp)
+}
@@ -0,0 +1,241 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	// CACAOHeaderEIP4361 is the header type of CACAOs of Sign-In with Ethereum messages.
+	CACAOHeaderEIP4361 = "eip4361"
+
+	// CACAOSignatureEIP191 is the signature type of CACAOs signed by an EOA with personal_sign.
+	CACAOSignatureEIP191 = "eip191"
+
+	// CACAOSignatureEIP1271 is the signature type of CACAOs signed by a contract account.
+	CACAOSignatureEIP1271 = "eip1271"
+
+	didPKHPrefix = "did:pkh:"
+)
+
+// CACAO is a chain agnostic capability object (CAIP-74), the Sign-In with Ethereum message of a
+// SIWE proof carried as its payload, for interop with Ceramic, ComposeDB and other CAIP-74
+// consumers. See Proof.CACAO and CACAO.Proof to convert between proofs and CACAOs.
+type CACAO struct {
+	Header    CACAOHeader    `cbor:"h"`
+	Payload   CACAOPayload   `cbor:"p"`
+	Signature CACAOSignature `cbor:"s"`
+}
+
+// CACAOHeader is the header of a CACAO.
+type CACAOHeader struct {
+	Type string `cbor:"t"`
+}
+
+// CACAOPayload is the payload of an EIP-4361 CACAO, the fields of its SIWE message. The issuer
+// is the did:pkh of the CAIP-10 account id of the signer, and the audience the SIWE URI.
+type CACAOPayload struct {
+	Domain         string   `cbor:"domain"`
+	Issuer         string   `cbor:"iss"`
+	Audience       string   `cbor:"aud"`
+	Version        string   `cbor:"version"`
+	Nonce          string   `cbor:"nonce"`
+	IssuedAt       string   `cbor:"iat"`
+	NotBefore      string   `cbor:"nbf,omitempty"`
+	ExpirationTime string   `cbor:"exp,omitempty"`
+	Statement      string   `cbor:"statement,omitempty"`
+	RequestID      string   `cbor:"requestId,omitempty"`
+	Resources      []string `cbor:"resources,omitempty"`
+}
+
+// CACAOSignature is the signature of a CACAO.
+type CACAOSignature struct {
+	Type      string `cbor:"t"`
+	Signature []byte `cbor:"s"`
+}
+
+// cacaoEncMode encodes CACAOs as DAG-CBOR, whose map keys are sorted length first.
+var cacaoEncMode = func() cbor.EncMode {
+	mode, err := cbor.CanonicalEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// CACAO returns the CACAO of a SIWE proof, whose signature is the personal_sign of the SIWE
+// message of its claims, see ProofTypeSIWE. The signature type is eip191 for 65 byte EOA
+// signatures, and eip1271 otherwise. Note, CACAO does not validate the proof signature or
+// claims, see ETHAuth.EncodeCACAO for that.
+func (t *Proof) CACAO() (*CACAO, error) {
+	if t.Claims.Type != ProofTypeSIWE {
+		return nil, fmt.Errorf("ethauth: only %s proofs can be converted to a CACAO", ProofTypeSIWE)
+	}
+	if err := t.validateEncoding(); err != nil {
+		return nil, err
+	}
+	m, err := SIWEMessageFromClaims(t.Address, t.Claims)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := ethcoder.HexDecode(t.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid signature encoding - %w", err)
+	}
+
+	c := &CACAO{
+		Header: CACAOHeader{Type: CACAOHeaderEIP4361},
+		Payload: CACAOPayload{
+			Domain:    m.Domain,
+			Issuer:    didPKHPrefix + CAIP10AccountID(m.ChainID, common.HexToAddress(m.Address)),
+			Audience:  m.URI,
+			Version:   m.Version,
+			Nonce:     m.Nonce,
+			IssuedAt:  m.IssuedAt.UTC().Format(time.RFC3339),
+			Statement: m.Statement,
+			RequestID: m.RequestID,
+			Resources: m.Resources,
+		},
+		Signature: CACAOSignature{Type: CACAOSignatureEIP191, Signature: signature},
+	}
+	if !m.ExpirationTime.IsZero() {
+		c.Payload.ExpirationTime = m.ExpirationTime.UTC().Format(time.RFC3339)
+	}
+	if len(signature) != 65 {
+		c.Signature.Type = CACAOSignatureEIP1271
+	}
+	return c, nil
+}
+
+// SIWEMessage returns the SIWE message of the CACAO payload, which is signed by the CACAO.
+func (c *CACAO) SIWEMessage() (*SIWEMessage, error) {
+	if c.Header.Type != CACAOHeaderEIP4361 {
+		return nil, fmt.Errorf("ethauth: unsupported CACAO header type %q", c.Header.Type)
+	}
+	p := c.Payload
+	chainID, address, err := ParseCAIP10AccountID(strings.TrimPrefix(p.Issuer, didPKHPrefix))
+	if err != nil || !strings.HasPrefix(p.Issuer, didPKHPrefix) {
+		return nil, fmt.Errorf("ethauth: invalid CACAO issuer %q, expecting a did:pkh of an eip155 account", p.Issuer)
+	}
+
+	m := &SIWEMessage{
+		Domain:    p.Domain,
+		Address:   address.Hex(),
+		Statement: p.Statement,
+		URI:       p.Audience,
+		Version:   p.Version,
+		ChainID:   chainID,
+		Nonce:     p.Nonce,
+		RequestID: p.RequestID,
+		Resources: p.Resources,
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+		t     *time.Time
+	}{
+		{"iat", p.IssuedAt, &m.IssuedAt},
+		{"nbf", p.NotBefore, &m.NotBefore},
+		{"exp", p.ExpirationTime, &m.ExpirationTime},
+	} {
+		if field.value == "" {
+			continue
+		}
+		*field.t, err = time.Parse(time.RFC3339, field.value)
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: invalid CACAO %s - %w", field.name, err)
+		}
+	}
+	if m.Domain == "" || m.URI == "" || m.Version == "" || m.Nonce == "" || m.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("ethauth: invalid CACAO, missing required fields")
+	}
+	return m, nil
+}
+
+// Proof returns the SIWE proof of the CACAO, see Proof.CACAO. CACAOs whose SIWE message is
+// not the message of its claims, ie. those not issued by ethauth, can't be converted to a
+// proof, as the proof signature would not be valid. Note, Proof does not validate the proof
+// signature or claims, see ETHAuth.DecodeCACAO for that.
+func (c *CACAO) Proof() (*Proof, error) {
+	if c.Signature.Type != CACAOSignatureEIP191 && c.Signature.Type != CACAOSignatureEIP1271 {
+		return nil, fmt.Errorf("ethauth: unsupported CACAO signature type %q", c.Signature.Type)
+	}
+	m, err := c.SIWEMessage()
+	if err != nil {
+		return nil, err
+	}
+	claims, err := m.Claims()
+	if err != nil {
+		return nil, err
+	}
+
+	proof := NewProof()
+	proof.Address = m.Address
+	proof.Claims = claims
+	proof.Signature = ethcoder.HexEncode(c.Signature.Signature)
+
+	expected, err := SIWEMessageFromClaims(proof.Address, claims)
+	if err != nil {
+		return nil, err
+	}
+	if expected.String() != m.String() {
+		return nil, fmt.Errorf("ethauth: CACAO message is not the siwe message of its claims")
+	}
+	return proof, nil
+}
+
+// EncodeCBOR serializes the CACAO into its DAG-CBOR form.
+func (c *CACAO) EncodeCBOR() ([]byte, error) {
+	return cacaoEncMode.Marshal(c)
+}
+
+// ParseCACAO decodes the DAG-CBOR form of a CACAO, see CACAO.EncodeCBOR.
+func ParseCACAO(data []byte) (*CACAO, error) {
+	var c CACAO
+	if err := cborDecMode.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("ethauth: invalid CACAO - %w", err)
+	}
+	return &c, nil
+}
+
+// EncodeCACAO validates the SIWE proof, and returns the DAG-CBOR form of its CACAO.
+func (w *ETHAuth) EncodeCACAO(proof *Proof) ([]byte, error) {
+	if _, err := w.EncodeProof(proof); err != nil {
+		return nil, err
+	}
+	c, err := proof.CACAO()
+	if err != nil {
+		return nil, err
+	}
+	return c.EncodeCBOR()
+}
+
+// DecodeCACAO will decode the DAG-CBOR form of a CACAO, validate it, and return its Proof.
+func (w *ETHAuth) DecodeCACAO(data []byte) (bool, *Proof, error) {
+	return w.DecodeCACAOContext(context.Background(), data)
+}
+
+// DecodeCACAOContext is DecodeCACAO with the context of the request of the proof.
+func (w *ETHAuth) DecodeCACAOContext(ctx context.Context, data []byte) (bool, *Proof, error) {
+	ctx, start := w.startVerification(ctx)
+	_, span := startSpan(ctx, SpanParse)
+	c, err := ParseCACAO(data)
+	var proof *Proof
+	if err == nil {
+		proof, err = c.Proof()
+	}
+	span.End(err)
+	if err != nil {
+		w.observeVerification(ctx, start, nil, err)
+		return false, nil, err
+	}
+	ok, proof, err := w.verifyParsedProof(ctx, proof, nil)
+	w.observeVerification(ctx, start, proof, err)
+	return ok, proof, err
+}
@@ -0,0 +1,88 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCACAO(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	proof := NewProof()
+	proof.Claims = Claims{
+		App:            "ETHAuthTest",
+		Type:           ProofTypeSIWE,
+		Origin:         "https://app.example.com",
+		Nonce:          42,
+		ChainID:        137,
+		ID:             "proof-1",
+		Scope:          Scopes{"read"},
+		ETHAuthVersion: ETHAuthVersion,
+	}
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+	require.NoError(t, SignProof(proof, wallet.PrivateKey()))
+
+	data, err := ethAuth.EncodeCACAO(proof)
+	require.NoError(t, err)
+
+	c, err := ParseCACAO(data)
+	require.NoError(t, err)
+	require.Equal(t, CACAOHeaderEIP4361, c.Header.Type)
+	require.Equal(t, "did:pkh:eip155:137:0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", c.Payload.Issuer)
+	require.Equal(t, "app.example.com", c.Payload.Domain)
+	require.Equal(t, "https://app.example.com", c.Payload.Audience)
+	require.Equal(t, CACAOSignatureEIP191, c.Signature.Type)
+
+	// the CACAO payload is the signed siwe message
+	message, err := proof.Message()
+	require.NoError(t, err)
+	siweMessage, err := c.SIWEMessage()
+	require.NoError(t, err)
+	require.Equal(t, string(message), siweMessage.String())
+
+	ok, decoded, err := ethAuth.DecodeCACAO(data)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, proof.Claims, decoded.Claims)
+	require.Equal(t, proof.Signature, decoded.Signature)
+
+	// CACAOs of other messages are rejected
+	c.Payload.Resources = append(c.Payload.Resources, "https://example.com/terms")
+	_, err = c.Proof()
+	require.Error(t, err)
+	c.Payload.Resources = c.Payload.Resources[:len(c.Payload.Resources)-1]
+	for _, tamper := range []func(){
+		func() { c.Payload.Nonce = "00000043" },
+		func() { c.Payload.Statement = "I accept the terms of service" },
+	} {
+		tamper()
+		tampered, err := c.EncodeCBOR()
+		require.NoError(t, err)
+		_, _, err = ethAuth.DecodeCACAO(tampered)
+		require.Error(t, err)
+	}
+
+	for _, c := range []CACAO{
+		{Header: CACAOHeader{Type: "caip122"}, Payload: c.Payload, Signature: c.Signature},
+		{Header: c.Header, Payload: CACAOPayload{Issuer: "did:key:z6Mk"}, Signature: c.Signature},
+		{Header: c.Header, Payload: c.Payload, Signature: CACAOSignature{Type: "solana:ed25519"}},
+	} {
+		_, err = c.Proof()
+		require.Error(t, err)
+	}
+	_, err = ParseCACAO([]byte{0xff})
+	require.Error(t, err)
+
+	// only siwe proofs have a CACAO
+	eip712Proof := signTestProof(t, wallet, Claims{App: "ETHAuthTest", IssuedAt: time.Now().Unix(), ETHAuthVersion: ETHAuthVersion})
+	_, err = eip712Proof.CACAO()
+	require.Error(t, err)
+}
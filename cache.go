@@ -0,0 +1,193 @@
+package ethauth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VerificationCache is an LRU cache of successful proof signature verifications, so the
+// signature recovery or EIP-1271 RPC call of a proof only happens once until the proof
+// expires or the cache TTL elapses, whichever is first.
+type VerificationCache struct {
+	size int
+	ttl  time.Duration
+
+	entries map[[32]byte]*list.Element
+	lru     *list.List
+	mu      sync.Mutex
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type verificationCacheEntry struct {
+	key       [32]byte
+	validator int
+	expiresAt time.Time
+}
+
+// CacheStats reports the usage of a VerificationCache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Len    int
+}
+
+// HitRate returns the ratio of cache hits to lookups, or 0 if there have been no lookups.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewVerificationCache returns a cache holding up to size verifications, each for at most
+// ttl. A ttl of 0 caches verifications until the proof expires, and doesn't cache proofs
+// without an `exp` claim.
+func NewVerificationCache(size int, ttl time.Duration) *VerificationCache {
+	return &VerificationCache{
+		size:    size,
+		ttl:     ttl,
+		entries: map[[32]byte]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// Stats returns the hit and miss counts of the cache.
+func (c *VerificationCache) Stats() CacheStats {
+	c.mu.Lock()
+	n := c.lru.Len()
+	c.mu.Unlock()
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Len: n}
+}
+
+// Purge removes all verifications from the cache.
+func (c *VerificationCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[[32]byte]*list.Element{}
+	c.lru.Init()
+}
+
+func (c *VerificationCache) get(key [32]byte, now time.Time) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses.Add(1)
+		return -1, false
+	}
+	entry := el.Value.(*verificationCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		c.misses.Add(1)
+		return -1, false
+	}
+	c.lru.MoveToFront(el)
+	c.hits.Add(1)
+	return entry.validator, true
+}
+
+func (c *VerificationCache) add(key [32]byte, validator int, now time.Time, exp time.Time) {
+	if exp.IsZero() || c.ttl > 0 && now.Add(c.ttl).Before(exp) {
+		exp = now.Add(c.ttl)
+	}
+	if !now.Before(exp) || c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value = &verificationCacheEntry{key: key, validator: validator, expiresAt: exp}
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&verificationCacheEntry{key: key, validator: validator, expiresAt: exp})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*verificationCacheEntry).key)
+	}
+}
+
+// verificationCacheKey hashes everything the proof signature is verified over.
+func verificationCacheKey(proof *Proof) ([32]byte, error) {
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	h := sha256.New()
+	h.Write([]byte(proof.addressKey()))
+	h.Write(digest)
+	h.Write([]byte(signatureKey(proof.Signature)))
+	h.Write([]byte(strings.ToLower(proof.Extra)))
+	var key [32]byte
+	h.Sum(key[:0])
+	return key, nil
+}
+
+// ttlCache caches values for its ttl, ie. the results of RPC calls. Its values are sharded by
+// key, and purged as they expire by the timing wheel of their shard.
+type ttlCache[V any] struct {
+	ttl    time.Duration
+	shards [memoryShards]ttlCacheShard[V]
+}
+
+type ttlCacheShard[V any] struct {
+	values map[string]ttlCacheValue[V]
+	wheel  expiryWheel[string]
+	mu     sync.Mutex
+}
+
+type ttlCacheValue[V any] struct {
+	value V
+	exp   time.Time
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	c := &ttlCache[V]{ttl: ttl}
+	for i := range c.shards {
+		c.shards[i].values = map[string]ttlCacheValue[V]{}
+	}
+	return c
+}
+
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	shard := &c.shards[shardIndex(key)]
+	now := time.Now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	v, ok := shard.values[key]
+	if !ok || now.After(v.exp) {
+		var zero V
+		return zero, false
+	}
+	return v.value, true
+}
+
+func (c *ttlCache[V]) add(key string, value V) {
+	shard := &c.shards[shardIndex(key)]
+	now := time.Now()
+	exp := now.Add(c.ttl)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.wheel.advance(now, func(key string, exp int64) {
+		if v, ok := shard.values[key]; ok && v.exp.Unix() == exp {
+			delete(shard.values, key)
+		}
+	})
+	shard.values[key] = ttlCacheValue[V]{value: value, exp: exp}
+	shard.wheel.add(key, exp)
+}
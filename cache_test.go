@@ -0,0 +1,79 @@
+package ethauth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationCache(t *testing.T) {
+	var calls int
+	ethAuth, err := New(func(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+		calls++
+		return ValidateEOAProof(ctx, provider, chainID, proof)
+	})
+	require.NoError(t, err)
+
+	cache := NewVerificationCache(1, time.Minute)
+	ethAuth.ConfigCache(cache)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	newProof := func(nonce uint64) *Proof {
+		claims := Claims{App: "ETHAuthTest", Nonce: nonce, ETHAuthVersion: ETHAuthVersion}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(5 * time.Minute)
+		return signTestProof(t, wallet, claims)
+	}
+	proof1, proof2 := newProof(1), newProof(2)
+
+	for i := 0; i < 3; i++ {
+		_, err = ethAuth.VerifyProofSignature(context.Background(), proof1)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, calls)
+	stats := cache.Stats()
+	require.Equal(t, uint64(2), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.InDelta(t, 2.0/3.0, stats.HitRate(), 0.001)
+
+	// failed verifications aren't cached
+	badProof := newProof(3)
+	badProof.Signature = proof1.Signature
+	_, err = ethAuth.VerifyProofSignature(context.Background(), badProof)
+	require.Error(t, err)
+	_, err = ethAuth.VerifyProofSignature(context.Background(), badProof)
+	require.Error(t, err)
+	require.Equal(t, 3, calls)
+
+	// least recently used verifications are evicted
+	_, err = ethAuth.VerifyProofSignature(context.Background(), proof2)
+	require.NoError(t, err)
+	_, err = ethAuth.VerifyProofSignature(context.Background(), proof1)
+	require.NoError(t, err)
+	require.Equal(t, 5, calls)
+
+	// verifications expire after the cache ttl
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return time.Now().Add(2 * time.Minute) }))
+	_, err = ethAuth.VerifyProofSignature(context.Background(), proof1)
+	require.NoError(t, err)
+	require.Equal(t, 6, calls)
+}
+
+func TestTTLCache(t *testing.T) {
+	cache := newTTLCache[int](time.Millisecond)
+	cache.add("key", 1)
+	value, ok := cache.get("key")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	time.Sleep(5 * time.Millisecond)
+	_, ok = cache.get("key")
+	require.False(t, ok)
+}
@@ -0,0 +1,64 @@
+package ethauth
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// caip10NamespaceEIP155 is the CAIP-2 namespace of EVM chains.
+const caip10NamespaceEIP155 = "eip155"
+
+// CAIP10AccountID returns the CAIP-10 account id of the address on the EVM chain, of the form
+// `eip155:<chain id>:<address>`.
+func CAIP10AccountID(chainID uint64, address common.Address) string {
+	return caip10NamespaceEIP155 + ":" + strconv.FormatUint(chainID, 10) + ":" + address.Hex()
+}
+
+// ParseCAIP10AccountID parses the CAIP-10 account id of an address on an EVM chain, see
+// CAIP10AccountID.
+func ParseCAIP10AccountID(accountID string) (uint64, common.Address, error) {
+	parts := strings.Split(accountID, ":")
+	if len(parts) != 3 || parts[0] != caip10NamespaceEIP155 {
+		return 0, common.Address{}, fmt.Errorf("ethauth: invalid CAIP-10 account id %q, expecting eip155:<chain id>:<address>", accountID)
+	}
+	chainID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || chainID == 0 || parts[1] != strconv.FormatUint(chainID, 10) {
+		return 0, common.Address{}, fmt.Errorf("ethauth: invalid CAIP-10 account id %q, bad chain id", accountID)
+	}
+	if !common.IsHexAddress(parts[2]) || !strings.HasPrefix(parts[2], "0x") {
+		return 0, common.Address{}, fmt.Errorf("ethauth: invalid CAIP-10 account id %q, bad address", accountID)
+	}
+	return chainID, common.HexToAddress(parts[2]), nil
+}
+
+// AccountID returns the CAIP-10 account id of the proof address on the chain of the account,
+// or of the `cid` claim if the proof address isn't an account id, or "" if neither is set.
+func (t *Proof) AccountID() string {
+	chainID := t.AccountChainID
+	if chainID == 0 {
+		chainID = t.Claims.ChainID
+	}
+	address, err := t.AddressBytes()
+	if chainID == 0 || err != nil {
+		return ""
+	}
+	return CAIP10AccountID(chainID, address)
+}
+
+// parseAccountID splits a proof address given as a CAIP-10 account id into the hex address
+// and the chain of the account.
+func (t *Proof) parseAccountID() error {
+	if !strings.HasPrefix(t.Address, caip10NamespaceEIP155+":") {
+		return nil
+	}
+	chainID, _, err := ParseCAIP10AccountID(t.Address)
+	if err != nil {
+		return fmt.Errorf("%w - %w", ErrInvalidAddress, err)
+	}
+	t.Address = t.Address[strings.LastIndexByte(t.Address, ':')+1:]
+	t.AccountChainID = chainID
+	return nil
+}
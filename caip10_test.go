@@ -0,0 +1,97 @@
+package ethauth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCAIP10AccountID(t *testing.T) {
+	address := common.HexToAddress("0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0")
+	accountID := CAIP10AccountID(1, address)
+	require.Equal(t, "eip155:1:0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", accountID)
+
+	chainID, parsed, err := ParseCAIP10AccountID(accountID)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), chainID)
+	require.Equal(t, address, parsed)
+
+	for _, s := range []string{
+		"", "eip155:1", "cosmos:cosmoshub-3:cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0",
+		"eip155:0:0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", "eip155:01:0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0",
+		"eip155:1:e0C9828dee3411A28CcB4bb82a18d0aAd24489E0", "eip155:1:0x1234",
+	} {
+		_, _, err := ParseCAIP10AccountID(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestCAIP10ProofAddress(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+
+	newProof := func(accountChainID, chainID uint64) *Proof {
+		proof := signTestProof(t, wallet, Claims{App: "ETHAuthTest", IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Hour).Unix(), ChainID: chainID, ETHAuthVersion: ETHAuthVersion})
+		proof.Address = CAIP10AccountID(accountChainID, wallet.Address())
+		return proof
+	}
+
+	// the address of the proof string is the account id
+	proofString, err := ethAuth.EncodeProof(newProof(137, 137))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(proofString, "eth.eip155:137:0xe0c9828dee3411a28ccb4bb82a18d0aad24489e0."))
+
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "0xe0c9828dee3411a28ccb4bb82a18d0aad24489e0", proof.Address)
+	require.Equal(t, uint64(137), proof.AccountChainID)
+	require.Equal(t, "eip155:137:0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", proof.AccountID())
+
+	streamed, err := ParseReader(strings.NewReader(proofString))
+	require.NoError(t, err)
+	require.Equal(t, proof.AccountChainID, streamed.AccountChainID)
+
+	// the chain of the account must be the chain of the claims
+	for _, chainID := range []uint64{0, 1} {
+		_, err = ethAuth.EncodeProof(newProof(137, chainID))
+		require.ErrorIs(t, err, ErrInvalidChainID)
+	}
+
+	_, err = Parse(strings.Replace(proofString, "eip155:137", "eip155:x", 1))
+	require.ErrorIs(t, err, ErrInvalidAddress)
+	_, err = Parse(strings.Replace(proofString, "eip155:137", "eip155:1", 1))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(strings.Replace(proofString, "eip155:137", "eip155:1", 1))
+	require.ErrorIs(t, err, ErrInvalidChainID)
+
+	// proofs with a hex address have the account id of their cid claim
+	require.Equal(t, "eip155:10:0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", signTestProof(t, wallet, Claims{App: "ETHAuthTest", ChainID: 10}).AccountID())
+	require.Equal(t, "", signTestProof(t, wallet, Claims{App: "ETHAuthTest"}).AccountID())
+}
+
+func TestConfigAllowedChains(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.Error(t, ethAuth.ConfigAllowedChains(1, 0))
+	require.NoError(t, ethAuth.ConfigAllowedChains(1, 137, 42161))
+
+	for chainID, valid := range map[uint64]bool{1: true, 137: true, 42161: true, 10: false, 0: false} {
+		proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithChainID(chainID))
+		require.NoError(t, err)
+		_, _, err = ethAuth.DecodeProof(proofString)
+		if valid {
+			require.NoError(t, err, chainID)
+		} else {
+			require.ErrorIs(t, err, ErrInvalidChainID, chainID)
+		}
+	}
+}
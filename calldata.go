@@ -0,0 +1,73 @@
+package ethauth
+
+import (
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/go-ethauth/ewtverifier"
+)
+
+// VerifierClaims returns the claims of the proof as the Claims struct of the EWTVerifier
+// contract. Only the EIP712 signed version 1 claims, without custom claims, can be verified
+// on-chain.
+func (c Claims) VerifierClaims() (ewtverifier.EWTVerifierClaims, error) {
+	if c.Type == ProofTypeSIWE || c.Type == ProofTypeEIP191 {
+		return ewtverifier.EWTVerifierClaims{}, fmt.Errorf("ethauth: %s proofs can't be verified on-chain", c.Type)
+	}
+	if !c.isV1() {
+		return ewtverifier.EWTVerifierClaims{}, fmt.Errorf("ethauth: only version %s claims without custom claims can be verified on-chain", ETHAuthVersion)
+	}
+	if c.Statement != "" || c.Host != "" || c.MerkleRoot != "" {
+		return ewtverifier.EWTVerifierClaims{}, fmt.Errorf("ethauth: claims with a statement, dom or mrk claim can't be verified on-chain")
+	}
+	if *c.domainConfig() != DefaultDomainConfig {
+		return ewtverifier.EWTVerifierClaims{}, fmt.Errorf("ethauth: only claims of the default domain can be verified on-chain")
+	}
+	return ewtverifier.EWTVerifierClaims{
+		App:   c.App,
+		Iat:   c.IssuedAt,
+		Exp:   c.ExpiresAt,
+		N:     c.Nonce,
+		Typ:   c.Type,
+		Ogn:   c.Origin,
+		Cid:   c.ChainID,
+		Aud:   c.Audience,
+		Sub:   c.Subject,
+		Jti:   c.ID,
+		Scope: c.Scope.String(),
+		V:     c.ETHAuthVersion,
+		Cnf:   c.Confirmation,
+		Htm:   c.RequestMethod,
+		Htp:   c.RequestPath,
+		Bdh:   c.RequestBodyHash,
+		Ip:    c.ClientIP,
+		Ua:    c.UserAgent,
+	}, nil
+}
+
+// ToCalldata returns the calldata of the isValidProof call of the EWTVerifier contract for the
+// proof, so contracts can accept the proof as on-chain authorization of its account. Proofs
+// with extra data, ie. of counterfactual smart wallets, can't be verified on-chain.
+func (t *Proof) ToCalldata() ([]byte, error) {
+	address, err := t.AddressBytes()
+	if err != nil {
+		return nil, err
+	}
+	if t.Extra != "" {
+		return nil, fmt.Errorf("ethauth: proofs with extra data can't be verified on-chain")
+	}
+	signature, err := ethcoder.HexDecode(t.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid signature - %w", err)
+	}
+	claims, err := t.Claims.VerifierClaims()
+	if err != nil {
+		return nil, err
+	}
+
+	verifierABI, err := ewtverifier.EWTVerifierMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return verifierABI.Pack("isValidProof", address, claims, signature)
+}
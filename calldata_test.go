@@ -0,0 +1,75 @@
+package ethauth
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-ethauth/ewtverifier"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofToCalldata(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithChainID(137), WithScope("read", "write"))
+	require.NoError(t, err)
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+
+	calldata, err := proof.ToCalldata()
+	require.NoError(t, err)
+	selector := crypto.Keccak256([]byte("isValidProof(address,(string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string),bytes)"))[:4]
+	require.Equal(t, selector, calldata[:4])
+
+	verifierABI, err := ewtverifier.EWTVerifierMetaData.GetAbi()
+	require.NoError(t, err)
+	args, err := verifierABI.Methods["isValidProof"].Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address(), args[0].(common.Address))
+	require.Equal(t, ethcoder.HexEncode(args[2].([]byte)), proof.Signature)
+
+	claims := args[1].(struct {
+		App   string `json:"app"`
+		Iat   int64  `json:"iat"`
+		Exp   int64  `json:"exp"`
+		N     uint64 `json:"n"`
+		Typ   string `json:"typ"`
+		Ogn   string `json:"ogn"`
+		Cid   uint64 `json:"cid"`
+		Aud   string `json:"aud"`
+		Sub   string `json:"sub"`
+		Jti   string `json:"jti"`
+		Scope string `json:"scope"`
+		V     string `json:"v"`
+		Cnf   string `json:"cnf"`
+		Htm   string `json:"htm"`
+		Htp   string `json:"htp"`
+		Bdh   string `json:"bdh"`
+		Ip    string `json:"ip"`
+		Ua    string `json:"ua"`
+	})
+	require.Equal(t, "ETHAuthTest", claims.App)
+	require.Equal(t, uint64(137), claims.Cid)
+	require.Equal(t, "read write", claims.Scope)
+	require.Equal(t, proof.Claims.ExpiresAt, claims.Exp)
+	require.Equal(t, ETHAuthVersion, claims.V)
+
+	// proofs which can't be verified on-chain
+	withExtra := *proof
+	withExtra.Extra = "0x1234"
+	_, err = withExtra.ToCalldata()
+	require.Error(t, err)
+
+	siwe := *proof
+	siwe.Claims.Type = ProofTypeSIWE
+	_, err = siwe.ToCalldata()
+	require.ErrorContains(t, err, "on-chain")
+
+	custom := *proof
+	custom.Claims.Custom = &testCustomClaims{Role: "admin"}
+	_, err = custom.ToCalldata()
+	require.ErrorContains(t, err, "custom claims")
+}
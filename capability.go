@@ -0,0 +1,89 @@
+package ethauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Capability grants the ability Can on the resource With, as a UCAN capability, ie.
+// `{with: "https://api.example.com/orders/*", can: "orders/read"}`. Resources ending in `*`
+// cover every resource they prefix, abilities ending in `/*` cover every ability of their
+// namespace, and the `*` ability covers every ability.
+type Capability struct {
+	With string `json:"with"`
+	Can  string `json:"can"`
+}
+
+// Capabilities are the capabilities a delegation attenuates its delegate to, see
+// Delegation.Capabilities. Empty capabilities don't restrict the delegate.
+type Capabilities []Capability
+
+// String returns the capability as `<can> <with>`, as signed by delegations.
+func (c Capability) String() string {
+	return c.Can + " " + c.With
+}
+
+// Valid returns an error if the resource or ability of the capability is empty or has spaces.
+func (c Capability) Valid() error {
+	if c.With == "" || strings.ContainsAny(c.With, " \t\n") {
+		return fmt.Errorf("ethauth: invalid capability resource %q", c.With)
+	}
+	if c.Can == "" || strings.ContainsAny(c.Can, " \t\n") {
+		return fmt.Errorf("ethauth: invalid capability ability %q", c.Can)
+	}
+	return nil
+}
+
+// Covers reports whether the capability grants every ability of the capability other.
+func (c Capability) Covers(other Capability) bool {
+	return matchCapabilityPattern(c.With, other.With, "*") && matchCapabilityPattern(c.Can, other.Can, "/*")
+}
+
+// Allows reports whether the capabilities grant the ability on the resource. Empty
+// capabilities allow any ability on any resource.
+func (c Capabilities) Allows(resource, ability string) bool {
+	if len(c) == 0 {
+		return true
+	}
+	return c.covers(Capability{With: resource, Can: ability})
+}
+
+// IsAttenuationOf reports whether the capabilities are narrower than the parent capabilities,
+// ie. each of them is covered by a parent capability. Empty capabilities are unrestricted, so
+// they are only an attenuation of empty parent capabilities.
+func (c Capabilities) IsAttenuationOf(parent Capabilities) bool {
+	if len(parent) == 0 {
+		return true
+	}
+	if len(c) == 0 {
+		return false
+	}
+	for _, capability := range c {
+		if !parent.covers(capability) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Capabilities) covers(capability Capability) bool {
+	for _, granted := range c {
+		if granted.Covers(capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCapabilityPattern reports whether the pattern covers the value, where patterns ending
+// in the wildcard suffix cover the values they prefix, and `*` covers every value.
+func matchCapabilityPattern(pattern, value, wildcard string) bool {
+	if pattern == "*" || pattern == value {
+		return true
+	}
+	if strings.HasSuffix(pattern, wildcard) {
+		prefix := strings.TrimSuffix(pattern, "*")
+		return len(value) > len(prefix) && strings.HasPrefix(value, prefix)
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilityCovers(t *testing.T) {
+	for _, tc := range []struct {
+		parent, child Capability
+		covers        bool
+	}{
+		{Capability{"https://api.example.com/orders", "orders/read"}, Capability{"https://api.example.com/orders", "orders/read"}, true},
+		{Capability{"https://api.example.com/*", "orders/read"}, Capability{"https://api.example.com/orders", "orders/read"}, true},
+		{Capability{"https://api.example.com/*", "orders/read"}, Capability{"https://api.example.com/orders/*", "orders/read"}, true},
+		{Capability{"https://api.example.com/orders", "orders/*"}, Capability{"https://api.example.com/orders", "orders/write"}, true},
+		{Capability{"https://api.example.com/orders", "*"}, Capability{"https://api.example.com/orders", "admin/delete"}, true},
+		{Capability{"*", "*"}, Capability{"https://api.example.com/orders", "orders/read"}, true},
+		{Capability{"https://api.example.com/orders", "orders/read"}, Capability{"https://api.example.com/orders", "orders/write"}, false},
+		{Capability{"https://api.example.com/orders", "orders/*"}, Capability{"https://api.example.com/orders", "ordersx/read"}, false},
+		{Capability{"https://api.example.com/orders", "orders/*"}, Capability{"https://api.example.com/orders", "*"}, false},
+		{Capability{"https://api.example.com/orders/*", "orders/read"}, Capability{"https://api.example.com/*", "orders/read"}, false},
+		{Capability{"https://api.example.com/orders/*", "orders/read"}, Capability{"https://api.example.com/orders/", "orders/read"}, false},
+		{Capability{"https://api.example.com/orders", "orders/read"}, Capability{"https://api.example.com/orders2", "orders/read"}, false},
+	} {
+		require.Equal(t, tc.covers, tc.parent.Covers(tc.child), "%s covers %s", tc.parent, tc.child)
+	}
+
+	parent := Capabilities{{"https://api.example.com/*", "orders/*"}, {"ipfs://bafy", "*"}}
+	require.True(t, Capabilities{{"https://api.example.com/orders", "orders/read"}, {"ipfs://bafy", "pin/add"}}.IsAttenuationOf(parent))
+	require.False(t, Capabilities{{"https://api.example.com/orders", "orders/read"}, {"ipfs://other", "pin/add"}}.IsAttenuationOf(parent))
+	require.False(t, Capabilities(nil).IsAttenuationOf(parent))
+	require.True(t, parent.IsAttenuationOf(nil))
+	require.True(t, parent.Allows("https://api.example.com/orders/1", "orders/cancel"))
+	require.False(t, parent.Allows("https://api.example.com/orders/1", "users/read"))
+	require.True(t, Capabilities(nil).Allows("https://api.example.com/orders/1", "users/read"))
+}
+
+func TestDelegatedCapabilities(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.RegisterValidator(ValidateDelegatedProof)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	dappKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	serviceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// the user delegates to the dapp at login, which hands a narrower token to a service
+	exp := time.Now().Add(time.Hour).Unix()
+	root := Delegation{
+		Delegate: crypto.PubkeyToAddress(dappKey.PublicKey).Hex(), ExpiresAt: exp, App: "ETHAuthTest",
+		Capabilities: Capabilities{{With: "https://api.example.com/*", Can: "*"}},
+	}
+	require.NoError(t, SignDelegation(&root, wallet.PrivateKey()))
+
+	delegate := func(capabilities Capabilities) []Delegation {
+		service := Delegation{Delegate: crypto.PubkeyToAddress(serviceKey.PublicKey).Hex(), ExpiresAt: exp, App: "ETHAuthTest", Capabilities: capabilities}
+		require.NoError(t, SignDelegation(&service, dappKey))
+		return []Delegation{root, service}
+	}
+	newProof := func(delegations []Delegation) *Proof {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignDelegatedProof(proof, wallet.Address().Hex(), delegations, serviceKey))
+		return proof
+	}
+
+	proofString, err := ethAuth.EncodeProof(newProof(delegate(Capabilities{{With: "https://api.example.com/orders/*", Can: "orders/read"}})))
+	require.NoError(t, err)
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	capabilities, err := proof.DelegatedCapabilities()
+	require.NoError(t, err)
+	require.True(t, capabilities.Allows("https://api.example.com/orders/42", "orders/read"))
+	require.False(t, capabilities.Allows("https://api.example.com/orders/42", "orders/cancel"))
+	require.False(t, capabilities.Allows("https://api.example.com/users/1", "orders/read"))
+
+	// delegations can't widen the capabilities of their parent
+	for _, widened := range []Capabilities{
+		nil,
+		{{With: "https://other.example.com/*", Can: "orders/read"}},
+		{{With: "*", Can: "orders/read"}},
+	} {
+		_, err = ethAuth.EncodeProof(newProof(delegate(widened)))
+		require.ErrorIs(t, err, ErrInvalidSignature)
+	}
+
+	// capabilities are signed by the delegation
+	delegations := delegate(Capabilities{{With: "https://api.example.com/orders/*", Can: "orders/read"}})
+	delegations[1].Capabilities = Capabilities{{With: "https://api.example.com/*", Can: "*"}}
+	_, err = ethAuth.EncodeProof(newProof(delegations))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	invalid := Delegation{Delegate: root.Delegate, ExpiresAt: exp, App: "ETHAuthTest", Capabilities: Capabilities{{With: "https://api.example.com/orders", Can: "orders read"}}}
+	require.Error(t, SignDelegation(&invalid, wallet.PrivateKey()))
+}
@@ -0,0 +1,174 @@
+package ethauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborProof is the CBOR envelope of a proof, an array of
+// `[address, claims, signature, extra, guardianSignature]`. The claims are a map, except for
+// EIP-191 proofs, which sign the claims JSON itself, and so carry it as a byte string.
+type cborProof struct {
+	_                 struct{} `cbor:",toarray"`
+	Address           []byte
+	Claims            cbor.RawMessage
+	Signature         []byte
+	Extra             []byte
+	GuardianSignature []byte
+}
+
+// cborMajorTypeByteString is the CBOR major type of byte strings.
+const cborMajorTypeByteString = 2
+
+var (
+	cborEncMode = func() cbor.EncMode {
+		mode, err := cbor.CoreDetEncOptions().EncMode()
+		if err != nil {
+			panic(err)
+		}
+		return mode
+	}()
+
+	cborDecMode = func() cbor.DecMode {
+		mode, err := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)), DupMapKey: cbor.DupMapKeyEnforcedAPF}.DecMode()
+		if err != nil {
+			panic(err)
+		}
+		return mode
+	}()
+)
+
+// EncodeCBOR serializes the proof into its deterministic CBOR (RFC 8949 core deterministic
+// encoding) form, a compact binary alternative to the proof string for constrained transports
+// such as MQTT, QR codes or NFC. Only the envelope differs from the proof string, the proof
+// signature is the same EIP712 signature of the claims. Note, EncodeCBOR does not validate the
+// proof signature or claims, see ETHAuth.EncodeCBOR for that.
+func (t *Proof) EncodeCBOR() ([]byte, error) {
+	if err := t.validateEncoding(); err != nil {
+		return nil, err
+	}
+	address, err := t.AddressBytes()
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: only proofs of Ethereum accounts can be CBOR encoded - %w", err)
+	}
+
+	var claims []byte
+	if t.Claims.Type == ProofTypeEIP191 {
+		message, err := t.Message()
+		if err != nil {
+			return nil, err
+		}
+		claims, err = cborEncMode.Marshal(message)
+	} else {
+		claims, err = cborClaims(t.Claims)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: cannot marshal proof claims - %w", err)
+	}
+
+	cp := cborProof{Address: address.Bytes(), Claims: claims}
+	cp.Signature, err = ethcoder.HexDecode(t.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid signature encoding - %w", err)
+	}
+	if t.Extra != "" {
+		cp.Extra, err = ethcoder.HexDecode(t.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: invalid extra encoding - %w", err)
+		}
+	}
+	if t.GuardianSignature != "" {
+		cp.GuardianSignature, err = ethcoder.HexDecode(t.GuardianSignature)
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: invalid guardian signature encoding - %w", err)
+		}
+	}
+	return cborEncMode.Marshal(cp)
+}
+
+// ParseCBOR decodes the CBOR form of a proof, see Proof.EncodeCBOR. Note, ParseCBOR does not
+// validate the proof signature or claims, see ETHAuth.DecodeCBOR for that.
+func ParseCBOR(data []byte) (*Proof, error) {
+	var cp cborProof
+	err := cborDecMode.Unmarshal(data, &cp)
+	if err != nil || len(cp.Address) != common.AddressLength || len(cp.Claims) == 0 {
+		return nil, fmt.Errorf("ethauth: invalid CBOR proof")
+	}
+
+	var claimsJSON []byte
+	if cp.Claims[0]>>5 == cborMajorTypeByteString {
+		err = cborDecMode.Unmarshal(cp.Claims, &claimsJSON)
+	} else {
+		var claims map[string]interface{}
+		if err = cborDecMode.Unmarshal(cp.Claims, &claims); err == nil {
+			claimsJSON, err = json.Marshal(claims)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: decoding failed, invalid claims")
+	}
+
+	var claims Claims
+	err = json.Unmarshal(claimsJSON, &claims)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: decoding failed, cannot unmarshal claims")
+	}
+
+	proof := NewProof()
+	proof.Address = ethcoder.HexEncode(cp.Address)
+	proof.Claims = claims
+	proof.Signature = ethcoder.HexEncode(cp.Signature)
+	if len(cp.Extra) > 0 {
+		proof.Extra = ethcoder.HexEncode(cp.Extra)
+	}
+	if len(cp.GuardianSignature) > 0 {
+		proof.GuardianSignature = ethcoder.HexEncode(cp.GuardianSignature)
+	}
+	proof.claimsJSON = claimsJSON
+	return proof, nil
+}
+
+// cborClaims encodes the claims JSON as a CBOR map, with its numbers as CBOR integers.
+func cborClaims(claims Claims) ([]byte, error) {
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(claimsJSON))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return cborEncMode.Marshal(cborValue(m))
+}
+
+func cborValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = cborValue(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = cborValue(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,84 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBOR(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	for _, typ := range []string{"", ProofTypeEIP191, ProofTypeSIWE} {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", Type: typ, Nonce: 1<<64 - 1, Origin: "https://app.example.com", ChainID: 1, Scope: Scopes{"read"}, ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignProof(proof, wallet.PrivateKey()))
+
+		data, err := ethAuth.EncodeCBOR(proof)
+		require.NoError(t, err, typ)
+
+		// the encoding is deterministic, and more compact than the proof string
+		again, err := proof.EncodeCBOR()
+		require.NoError(t, err)
+		require.Equal(t, data, again)
+		proofString, err := proof.Encode()
+		require.NoError(t, err)
+		require.Less(t, len(data), len(proofString))
+
+		ok, decoded, err := ethAuth.DecodeCBOR(data)
+		require.NoError(t, err, typ)
+		require.True(t, ok)
+		require.Equal(t, proof.Claims, decoded.Claims)
+		require.Equal(t, proof.Signature, decoded.Signature)
+
+		// the CBOR and string forms of the proof are interchangeable
+		decodedString, err := decoded.Encode()
+		require.NoError(t, err)
+		_, _, err = ethAuth.DecodeProof(decodedString)
+		require.NoError(t, err, typ)
+	}
+
+	_, err = ParseCBOR([]byte("eth"))
+	require.Error(t, err)
+	_, err = ParseCBOR(nil)
+	require.Error(t, err)
+}
+
+func TestCBORExtra(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion, Custom: &testCustomClaims{Role: "admin", Tenant: 7}}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proof := signTestProof(t, wallet, claims)
+	proof.Extra = "0x1234"
+	proof.GuardianSignature = "0xabcd"
+
+	data, err := proof.EncodeCBOR()
+	require.NoError(t, err)
+	parsed, err := ParseCBOR(data)
+	require.NoError(t, err)
+	require.Equal(t, "0x1234", parsed.Extra)
+	require.Equal(t, "0xabcd", parsed.GuardianSignature)
+
+	// custom claims are decoded the same as from a proof string
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigCustomClaims(func() ClaimsProvider { return &testCustomClaims{} })
+
+	proof.Extra, proof.GuardianSignature = "", ""
+	data, err = proof.EncodeCBOR()
+	require.NoError(t, err)
+	_, decoded, err := ethAuth.DecodeCBOR(data)
+	require.NoError(t, err)
+	require.Equal(t, claims.Custom, decoded.Claims.Custom)
+}
@@ -0,0 +1,187 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Challenge is a server-generated nonce, which a proof must carry as its `n` claim to be
+// accepted by an ETHAuth instance configured with a ChallengeManager.
+type Challenge struct {
+	// Nonce is the `n` claim the proof must carry
+	Nonce uint64
+
+	// Address, if set, is the only account which may answer the challenge
+	Address string
+
+	// Origin, if set, is the `ogn` claim the proof must carry
+	Origin string
+
+	// ExpiresAt is when the challenge expires, unanswered
+	ExpiresAt time.Time
+}
+
+// ChallengeStore records the outstanding challenges of a ChallengeManager.
+type ChallengeStore interface {
+	// Put records the outstanding challenge until it expires.
+	Put(ctx context.Context, challenge Challenge) error
+
+	// Get returns the outstanding challenge of the nonce without removing it, and returns
+	// ErrInvalidChallenge if there is none.
+	Get(ctx context.Context, nonce uint64) (Challenge, error)
+
+	// Take removes and returns the outstanding challenge of the nonce, and returns
+	// ErrInvalidChallenge if there is none.
+	Take(ctx context.Context, nonce uint64) (Challenge, error)
+}
+
+// ChallengeManager issues one-time challenge nonces for clients to sign, so proofs can't
+// be signed ahead of time with a nonce chosen by the client. Once configured with
+// ETHAuth.ConfigChallengeManager, proofs are only accepted in answer to an outstanding
+// challenge, which each proof consumes.
+type ChallengeManager struct {
+	store ChallengeStore
+	ttl   time.Duration
+
+	// clock is the clock of the ETHAuth instance the manager is configured with
+	clock func() time.Time
+}
+
+// DefaultChallengeTTL is how long challenges may be answered for when no TTL is given.
+const DefaultChallengeTTL = 5 * time.Minute
+
+// NewChallengeManager returns a ChallengeManager issuing challenges answerable for the ttl,
+// or DefaultChallengeTTL if 0, which are recorded in the store, or a MemoryChallengeStore
+// if none is given.
+func NewChallengeManager(ttl time.Duration, optStore ...ChallengeStore) *ChallengeManager {
+	if ttl == 0 {
+		ttl = DefaultChallengeTTL
+	}
+	m := &ChallengeManager{ttl: ttl, clock: time.Now}
+	if len(optStore) > 0 && optStore[0] != nil {
+		m.store = optStore[0]
+	} else {
+		m.store = NewMemoryChallengeStore()
+	}
+	return m
+}
+
+// Issue returns a new challenge, bound to the account address and origin if not empty.
+func (m *ChallengeManager) Issue(ctx context.Context, address, origin string) (Challenge, error) {
+	nonce, err := randomNonce()
+	if err != nil {
+		return Challenge{}, err
+	}
+	challenge := Challenge{
+		Nonce:     nonce,
+		Address:   strings.ToLower(address),
+		Origin:    origin,
+		ExpiresAt: m.clock().Add(m.ttl),
+	}
+	if err := m.store.Put(ctx, challenge); err != nil {
+		return Challenge{}, err
+	}
+	return challenge, nil
+}
+
+// ConfigClock sets the clock the challenges are issued and expire by, which
+// ETHAuth.ConfigChallengeManager sets to the clock of the instance.
+func (m *ChallengeManager) ConfigClock(clock func() time.Time) {
+	m.clock = clock
+}
+
+// Verify consumes the outstanding challenge of the proof nonce, and returns ErrInvalidChallenge
+// if the proof doesn't answer an outstanding challenge. The challenge is only consumed once
+// the proof answers it, so proofs of other accounts or origins can't consume the challenges
+// issued to them.
+func (m *ChallengeManager) Verify(ctx context.Context, proof *Proof) error {
+	if proof.Claims.Nonce == 0 {
+		return ErrMissingNonce
+	}
+	challenge, err := m.store.Get(ctx, proof.Claims.Nonce)
+	if err != nil {
+		return err
+	}
+	if m.clock().After(challenge.ExpiresAt) {
+		return fmt.Errorf("%w, challenge has expired", ErrInvalidChallenge)
+	}
+	if challenge.Address != "" && challenge.Address != strings.ToLower(proof.Address) {
+		return fmt.Errorf("%w, challenge was issued to another account", ErrInvalidChallenge)
+	}
+	if challenge.Origin != "" && !sameOrigin(challenge.Origin, proof.Claims.Origin) {
+		return fmt.Errorf("%w, challenge was issued to another origin", ErrInvalidChallenge)
+	}
+	_, err = m.store.Take(ctx, proof.Claims.Nonce)
+	return err
+}
+
+// randomNonce returns a random `n` claim, which is never 0, as 0 is an empty `n` claim.
+func randomNonce() (uint64, error) {
+	var random [8]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return 0, fmt.Errorf("ethauth: unable to generate nonce - %w", err)
+	}
+	return binary.BigEndian.Uint64(random[:]) | 1, nil
+}
+
+// MemoryChallengeStore is an in-process ChallengeStore.
+type MemoryChallengeStore struct {
+	challenges map[uint64]Challenge
+	lastPurge  time.Time
+	mu         sync.Mutex
+}
+
+var _ ChallengeStore = &MemoryChallengeStore{}
+
+func NewMemoryChallengeStore() *MemoryChallengeStore {
+	return &MemoryChallengeStore{
+		challenges: map[uint64]Challenge{},
+		lastPurge:  time.Now(),
+	}
+}
+
+func (s *MemoryChallengeStore) Put(ctx context.Context, challenge Challenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) > time.Minute {
+		for k, c := range s.challenges {
+			if now.After(c.ExpiresAt) {
+				delete(s.challenges, k)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	s.challenges[challenge.Nonce] = challenge
+	return nil
+}
+
+func (s *MemoryChallengeStore) Get(ctx context.Context, nonce uint64) (Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.challenges[nonce]
+	if !ok {
+		return Challenge{}, ErrInvalidChallenge
+	}
+	return challenge, nil
+}
+
+func (s *MemoryChallengeStore) Take(ctx context.Context, nonce uint64) (Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.challenges[nonce]
+	if !ok {
+		return Challenge{}, ErrInvalidChallenge
+	}
+	delete(s.challenges, nonce)
+	return challenge, nil
+}
@@ -0,0 +1,70 @@
+package ethauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallengeManager(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	challenges := NewChallengeManager(time.Minute)
+	ethAuth.ConfigChallengeManager(challenges)
+
+	ctx := context.Background()
+	encode := func(nonce uint64, origin string) string {
+		claims := Claims{App: "ETHAuthTest", Nonce: nonce, Origin: origin, ETHAuthVersion: ETHAuthVersion}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(5 * time.Minute)
+		proofString, err := signTestProof(t, wallet, claims).Encode()
+		require.NoError(t, err)
+		return proofString
+	}
+
+	// proofs must answer an outstanding challenge
+	_, _, err = ethAuth.DecodeProof(encode(0, ""))
+	require.ErrorIs(t, err, ErrMissingNonce)
+	_, _, err = ethAuth.DecodeProof(encode(42, ""))
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+
+	challenge, err := challenges.Issue(ctx, wallet.Address().Hex(), "https://app.example.com")
+	require.NoError(t, err)
+	require.NotZero(t, challenge.Nonce)
+
+	proofString := encode(challenge.Nonce, "https://app.example.com")
+	ok, _, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// each challenge is answered once
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+
+	// challenges are bound to their origin and address, and proofs of other origins or
+	// accounts don't consume them
+	challenge, err = challenges.Issue(ctx, "", "https://app.example.com")
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(encode(challenge.Nonce, "https://evil.example.com"))
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+	_, _, err = ethAuth.DecodeProof(encode(challenge.Nonce, "https://app.example.com"))
+	require.NoError(t, err)
+
+	challenge, err = challenges.Issue(ctx, "0x1111111111111111111111111111111111111111", "")
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(encode(challenge.Nonce, ""))
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+
+	// and expire by the clock of the instance
+	challenge, err = challenges.Issue(ctx, "", "")
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return time.Now().Add(2 * time.Minute) }))
+	_, _, err = ethAuth.DecodeProof(encode(challenge.Nonce, ""))
+	require.ErrorIs(t, err, ErrInvalidChallenge)
+}
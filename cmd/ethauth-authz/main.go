@@ -0,0 +1,83 @@
+// Command ethauth-authz runs an Envoy external authorization server verifying ETHAuth proofs,
+// see package authz.
+//
+// Usage:
+//
+//	ethauth-authz [-listen :9191] [-rpc url] [-chain-id id] [-app name,...] [-aud aud,...]
+//
+// Configure it as the grpc_service of Envoy's envoy.filters.http.ext_authz filter, with
+// transport_api_version V3.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/authz"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	listen := flag.String("listen", ":9191", "address of the gRPC listener")
+	rpcURL := flag.String("rpc", "", "ethereum JSON-RPC url, required to verify contract wallet (EIP-1271) proofs")
+	chainID := flag.Int64("chain-id", 0, "chain id of the JSON-RPC provider")
+	apps := flag.String("app", "", "comma-separated list of allowed apps")
+	audiences := flag.String("aud", "", "comma-separated list of expected audiences")
+	flag.Parse()
+
+	if err := run(*listen, *rpcURL, *chainID, splitList(*apps), splitList(*audiences)); err != nil {
+		fmt.Fprintf(os.Stderr, "ethauth-authz: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, rpcURL string, chainID int64, apps, audiences []string) error {
+	ethAuth, err := ethauth.New()
+	if err != nil {
+		return err
+	}
+	if rpcURL != "" {
+		var optChainID []int64
+		if chainID != 0 {
+			optChainID = append(optChainID, chainID)
+		}
+		if err := ethAuth.ConfigJsonRpcProvider(rpcURL, optChainID...); err != nil {
+			return err
+		}
+	}
+	if len(apps) > 0 {
+		if err := ethAuth.ConfigAllowedApps(apps...); err != nil {
+			return err
+		}
+	}
+	if len(audiences) > 0 {
+		if err := ethAuth.ConfigExpectedAudience(audiences...); err != nil {
+			return err
+		}
+	}
+
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	grpcServer := grpc.NewServer()
+	authz.NewServer(ethAuth).Register(grpcServer)
+
+	log.Printf("ethauth-authz: listening on %s", lis.Addr())
+	return grpcServer.Serve(lis)
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,37 @@
+// Thin JS binding of ethauth.wasm. Requires the wasm_exec.js of the Go distribution used to
+// build ethauth.wasm, ie. `$(go env GOROOT)/lib/wasm/wasm_exec.js`, to be loaded first.
+//
+//   const ethauth = await loadETHAuth('/ethauth.wasm')
+//   const typedData = ethauth.typedData(address, { app: 'my-dapp', iat, exp, v: ethauth.version })
+//   const signature = await provider.request({ method: 'eth_signTypedData_v4', params: [address, typedData] })
+//   const proof = ethauth.encodeProof(address, claims, signature)
+
+function unwrap(ret) {
+  if (ret.error) {
+    throw new Error(ret.error)
+  }
+  return ret.result
+}
+
+function claimsJSON(claims) {
+  return typeof claims === 'string' ? claims : JSON.stringify(claims)
+}
+
+export async function loadETHAuth(url) {
+  const go = new Go()
+  const { instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject)
+  go.run(instance)
+
+  const exports = globalThis.ethauth
+  return {
+    version: exports.version,
+    typedData: (address, claims) => unwrap(exports.typedData(address, claimsJSON(claims))),
+    messageDigest: (address, claims) => unwrap(exports.messageDigest(address, claimsJSON(claims))),
+    encodeProof: (address, claims, signature, extra) =>
+      unwrap(exports.encodeProof(address, claimsJSON(claims), signature, extra)),
+    parseProof: (proof) => {
+      const parsed = unwrap(exports.parseProof(proof))
+      return { ...parsed, claims: JSON.parse(parsed.claims) }
+    },
+  }
+}
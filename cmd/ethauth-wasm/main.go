@@ -0,0 +1,132 @@
+//go:build js && wasm
+
+// Command ethauth-wasm exposes the claims typed data construction and proof encoding of
+// go-ethauth to JavaScript, so browser frontends compute byte-for-byte the same typed data
+// and digests as the Go backend. Build it with:
+//
+//	GOOS=js GOARCH=wasm go build -o ethauth.wasm ./cmd/ethauth-wasm
+//
+// and load it with ethauth.js, alongside the wasm_exec.js of the Go distribution.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/go-ethauth"
+)
+
+func main() {
+	exports := js.Global().Get("Object").New()
+	exports.Set("version", ethauth.ETHAuthVersion)
+	exports.Set("typedData", export(typedData))
+	exports.Set("messageDigest", export(messageDigest))
+	exports.Set("encodeProof", export(encodeProof))
+	exports.Set("parseProof", export(parseProof))
+	js.Global().Set("ethauth", exports)
+
+	// keep the exported functions alive
+	select {}
+}
+
+// export wraps fn as a JS function returning { result } or { error }.
+func export(fn func(args []js.Value) (interface{}, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result, err := fn(args)
+		if err != nil {
+			return map[string]interface{}{"error": err.Error()}
+		}
+		return map[string]interface{}{"result": result}
+	})
+}
+
+func stringArg(args []js.Value, i int, name string) (string, error) {
+	if len(args) <= i || args[i].Type() != js.TypeString {
+		return "", fmt.Errorf("ethauth: %s argument must be a string", name)
+	}
+	return args[i].String(), nil
+}
+
+func proofArg(args []js.Value) (*ethauth.Proof, error) {
+	address, err := stringArg(args, 0, "address")
+	if err != nil {
+		return nil, err
+	}
+	claimsJSON, err := stringArg(args, 1, "claims")
+	if err != nil {
+		return nil, err
+	}
+	proof := ethauth.NewProof()
+	proof.Address = address
+	if err := json.Unmarshal([]byte(claimsJSON), &proof.Claims); err != nil {
+		return nil, fmt.Errorf("ethauth: invalid claims - %w", err)
+	}
+	return proof, nil
+}
+
+// typedData(address, claimsJSON) returns the claims typed data JSON to sign with eth_signTypedData_v4.
+func typedData(args []js.Value) (interface{}, error) {
+	proof, err := proofArg(args)
+	if err != nil {
+		return nil, err
+	}
+	data, err := proof.TypedDataJSON()
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// messageDigest(address, claimsJSON) returns the hex digest signed by the proof signature.
+func messageDigest(args []js.Value) (interface{}, error) {
+	proof, err := proofArg(args)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return nil, err
+	}
+	return ethcoder.HexEncode(digest), nil
+}
+
+// encodeProof(address, claimsJSON, signature, extra?) returns the encoded proof string.
+func encodeProof(args []js.Value) (interface{}, error) {
+	proof, err := proofArg(args)
+	if err != nil {
+		return nil, err
+	}
+	proof.Signature, err = stringArg(args, 2, "signature")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) > 3 && args[3].Type() == js.TypeString {
+		proof.Extra = args[3].String()
+	}
+	return proof.Encode()
+}
+
+// parseProof(proofString) returns the address, claims and signature of the proof, without
+// validating it.
+func parseProof(args []js.Value) (interface{}, error) {
+	proofString, err := stringArg(args, 0, "proof")
+	if err != nil {
+		return nil, err
+	}
+	proof, err := ethauth.Parse(proofString)
+	if err != nil {
+		return nil, err
+	}
+	claimsJSON, err := json.Marshal(proof.Claims)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"address":   proof.Address,
+		"claims":    string(claimsJSON),
+		"signature": proof.Signature,
+		"extra":     proof.Extra,
+	}, nil
+}
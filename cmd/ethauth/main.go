@@ -0,0 +1,221 @@
+// Command ethauth signs, verifies and inspects ETHAuth proofs from the terminal.
+//
+// Usage:
+//
+//	ethauth sign -claims claims.json (-key keyfile | -mnemonic "..." [-path m/44'/60'/0'/0/0]) [-ttl 1h]
+//	ethauth verify [-rpc url] [-chain-id id] <proof>
+//	ethauth inspect <proof>
+//
+// Claims and proofs may be passed inline, as a file path, or as "-" to read them from stdin.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-ethauth"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "sign":
+		err = sign(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	case "inspect":
+		err = inspect(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ethauth: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ethauth <sign|verify|inspect> [flags]")
+	os.Exit(2)
+}
+
+func sign(args []string) error {
+	flags := flag.NewFlagSet("sign", flag.ExitOnError)
+	claimsFile := flags.String("claims", "-", "claims JSON file, or - for stdin")
+	keyFile := flags.String("key", "", "file containing a hex-encoded private key")
+	mnemonic := flags.String("mnemonic", "", "wallet mnemonic")
+	path := flags.String("path", "m/44'/60'/0'/0/0", "derivation path of the mnemonic account")
+	ttl := flags.Duration("ttl", time.Hour, "proof lifetime, used when the claims have no exp")
+	flags.Parse(args)
+
+	var signer ethauth.Signer
+	switch {
+	case *keyFile != "":
+		data, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid private key - %w", err)
+		}
+		signer = ethauth.NewPrivateKeySigner(privateKey)
+	case *mnemonic != "":
+		var err error
+		signer, err = ethauth.NewMnemonicSigner(*mnemonic, *path)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("sign requires -key or -mnemonic")
+	}
+
+	data, err := readInput(*claimsFile)
+	if err != nil {
+		return err
+	}
+	proof := ethauth.NewProof()
+	if err := json.Unmarshal(data, &proof.Claims); err != nil {
+		return fmt.Errorf("invalid claims - %w", err)
+	}
+	if proof.Claims.IssuedAt == 0 {
+		proof.Claims.SetIssuedAtNow()
+	}
+	if proof.Claims.ExpiresAt == 0 {
+		proof.Claims.SetExpiryIn(*ttl)
+	}
+	if proof.Claims.ETHAuthVersion == "" {
+		proof.Claims.ETHAuthVersion = ethauth.ETHAuthVersion
+	}
+
+	if err := ethauth.SignProofWithSigner(context.Background(), proof, signer); err != nil {
+		return err
+	}
+	proofString, err := proof.Encode()
+	if err != nil {
+		return err
+	}
+	fmt.Println(proofString)
+	return nil
+}
+
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	rpcURL := flags.String("rpc", "", "ethereum JSON-RPC url, required to verify contract wallet (EIP-1271) proofs")
+	chainID := flags.Int64("chain-id", 0, "chain id of the JSON-RPC provider")
+	flags.Parse(args)
+
+	proofString, err := proofArg(flags)
+	if err != nil {
+		return err
+	}
+
+	ethAuth, err := ethauth.New()
+	if err != nil {
+		return err
+	}
+	if *rpcURL != "" {
+		var optChainID []int64
+		if *chainID != 0 {
+			optChainID = append(optChainID, *chainID)
+		}
+		if err := ethAuth.ConfigJsonRpcProvider(*rpcURL, optChainID...); err != nil {
+			return err
+		}
+	}
+
+	_, proof, err := ethAuth.DecodeProof(proofString)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("valid proof for %s\n", proof.Address)
+	return nil
+}
+
+func inspect(args []string) error {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	flags.Parse(args)
+
+	proofString, err := proofArg(flags)
+	if err != nil {
+		return err
+	}
+	proof, err := ethauth.Parse(proofString)
+	if err != nil {
+		return err
+	}
+
+	claims, err := json.MarshalIndent(proof.Claims, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("prefix:    %s\n", proof.Prefix)
+	fmt.Printf("address:   %s\n", proof.Address)
+	fmt.Printf("recovered: %s\n", recoverAddress(proof))
+	if proof.Claims.IssuedAt != 0 {
+		fmt.Printf("issued:    %s\n", time.Unix(proof.Claims.IssuedAt, 0).UTC())
+	}
+	if proof.Claims.ExpiresAt != 0 {
+		fmt.Printf("expires:   %s\n", time.Unix(proof.Claims.ExpiresAt, 0).UTC())
+	}
+	if proof.Extra != "" {
+		fmt.Printf("extra:     %s\n", proof.Extra)
+	}
+	fmt.Printf("claims:    %s\n", claims)
+	return nil
+}
+
+// recoverAddress returns the EOA address recovered from the proof signature. Contract
+// wallet signatures don't recover to the proof address.
+func recoverAddress(proof *ethauth.Proof) string {
+	sig, err := ethcoder.HexDecode(proof.Signature)
+	if err != nil || len(sig) != 65 {
+		return "(not an EOA signature)"
+	}
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return fmt.Sprintf("(%v)", err)
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pubKey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return fmt.Sprintf("(%v)", err)
+	}
+	return crypto.PubkeyToAddress(*pubKey).String()
+}
+
+func proofArg(flags *flag.FlagSet) (string, error) {
+	if flags.NArg() != 1 {
+		return "", fmt.Errorf("%s requires a proof argument", flags.Name())
+	}
+	data, err := readInput(flags.Arg(0))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readInput(arg string) ([]byte, error) {
+	if arg == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return os.ReadFile(arg)
+	}
+	return []byte(arg), nil
+}
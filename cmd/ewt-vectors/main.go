@@ -0,0 +1,179 @@
+// Command ewt-vectors emits a JSON corpus of test vectors of the claims, typed data, message,
+// digest, signature and proof string of claims signed by the ethauthtest accounts, so
+// implementations in other languages can assert byte-level compatibility in their CI.
+//
+// Usage:
+//
+//	ewt-vectors [-accounts 3] [-o vectors.json]
+//
+// The vectors of Alice, the first account, are the golden vectors of ethauthtest.Vectors. All
+// vector proofs are valid at ethauthtest.Now.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+)
+
+// Corpus is the test vector corpus of the known test accounts.
+type Corpus struct {
+	Mnemonic string    `json:"mnemonic"`
+	Now      int64     `json:"now"`
+	Accounts []Account `json:"accounts"`
+	Vectors  []Vector  `json:"vectors"`
+}
+
+// Account is a known test account, derived from the corpus mnemonic.
+type Account struct {
+	Index      uint32 `json:"index"`
+	Path       string `json:"path"`
+	Address    string `json:"address"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// Vector is a test vector of claims signed by an account of the corpus.
+type Vector struct {
+	Account uint32 `json:"account"`
+	ethauthtest.Vector
+}
+
+// domainApp is the app of the vectors signed under testDomain.
+const domainApp = "ETHAuthDomainTest"
+
+var testDomain = ethauth.DomainConfig{Name: "ETHAuthDomainTest", Version: "2", ChainID: 10}
+
+// vectorClaims are the claims of the vectors of each account, which cover each standard claim,
+// and the personal_sign proof types.
+var vectorClaims = []struct {
+	name   string
+	claims ethauth.Claims
+	opts   []ethauth.IssueOption
+}{
+	{name: "minimal", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300}},
+	{name: "nonce-origin", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 42, Origin: "https://app.example.com"}},
+	{name: "chain-id", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, ChainID: 137}},
+	{name: "audience-subject-id", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Audience: "https://api.example.com", Subject: "user-1", ID: "5f0c9b2e"}},
+	{name: "scope", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Scope: ethauth.Scopes{"read:orders", "write:orders"}}},
+	{name: "large-nonce", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 1<<64 - 1}},
+	{name: "eip191", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Type: ethauth.ProofTypeEIP191}},
+	{name: "siwe", claims: ethauth.Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 42, Type: ethauth.ProofTypeSIWE, Origin: "https://app.example.com", ChainID: 1}},
+	{name: "domain", claims: ethauth.Claims{App: domainApp, IssuedAt: 1700000000, ExpiresAt: 1700000300}, opts: []ethauth.IssueOption{ethauth.WithDomain(testDomain)}},
+}
+
+func main() {
+	accounts := flag.Int("accounts", 3, "number of test accounts to sign the vectors with")
+	output := flag.String("o", "-", "output file, or - for stdout")
+	flag.Parse()
+
+	err := run(*accounts, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ewt-vectors: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(accounts int, output string) error {
+	if accounts < 1 {
+		return fmt.Errorf("at least one account is required")
+	}
+
+	// the vectors are verified before they are emitted
+	ethAuth, err := ethauth.New()
+	if err != nil {
+		return err
+	}
+	if err := ethAuth.ConfigClock(func() time.Time { return ethauthtest.Now }); err != nil {
+		return err
+	}
+	if err := ethAuth.ConfigAppDomains(map[string]ethauth.DomainConfig{domainApp: testDomain}); err != nil {
+		return err
+	}
+
+	corpus := Corpus{Mnemonic: ethauthtest.Mnemonic, Now: ethauthtest.Now.Unix()}
+	for i := 0; i < accounts; i++ {
+		account := ethauthtest.NewAccount(uint32(i))
+		corpus.Accounts = append(corpus.Accounts, Account{
+			Index:      account.Index,
+			Path:       fmt.Sprintf("m/44'/60'/0'/0/%d", account.Index),
+			Address:    account.Address().Hex(),
+			PrivateKey: ethcoder.HexEncode(crypto.FromECDSA(account.Wallet.PrivateKey())),
+		})
+
+		for _, tc := range vectorClaims {
+			v, err := newVector(account, tc.name, tc.claims, tc.opts...)
+			if err != nil {
+				return fmt.Errorf("vector %s of account %d - %w", tc.name, i, err)
+			}
+			if _, _, err := ethAuth.DecodeProof(v.Proof); err != nil {
+				return fmt.Errorf("vector %s of account %d is invalid - %w", tc.name, i, err)
+			}
+			corpus.Vectors = append(corpus.Vectors, v)
+		}
+	}
+
+	data, err := json.MarshalIndent(corpus, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(output, data, 0644)
+}
+
+func newVector(account ethauthtest.Account, name string, claims ethauth.Claims, opts ...ethauth.IssueOption) (Vector, error) {
+	claims.ETHAuthVersion = ethauth.ETHAuthVersion
+	for _, opt := range opts {
+		opt(&claims)
+	}
+	proof := ethauth.NewProof()
+	proof.Claims = claims
+	if err := ethauth.SignProofWithSigner(context.Background(), proof, account.Signer()); err != nil {
+		return Vector{}, err
+	}
+
+	claimsJSON, err := json.Marshal(proof.Claims)
+	if err != nil {
+		return Vector{}, err
+	}
+	message, err := proof.Message()
+	if err != nil {
+		return Vector{}, err
+	}
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return Vector{}, err
+	}
+	proofString, err := proof.Encode()
+	if err != nil {
+		return Vector{}, err
+	}
+
+	v := Vector{Account: account.Index, Vector: ethauthtest.Vector{
+		Name:      name,
+		Address:   proof.Address,
+		Claims:    claimsJSON,
+		Message:   ethcoder.HexEncode(message),
+		Digest:    ethcoder.HexEncode(digest),
+		Signature: proof.Signature,
+		Proof:     proofString,
+	}}
+	if claims.Type == "" {
+		v.TypedData, err = proof.TypedDataJSON()
+		if err != nil {
+			return Vector{}, err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,104 @@
+package ethauth
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+)
+
+// ETHAuthCompressedPrefix is the prefix of compressed proof strings, see Proof.EncodeCompressed.
+const ETHAuthCompressedPrefix = "eth2"
+
+// EncodeCompressed serializes the proof into the compressed proof string format of
+// `eth2.<address>.<claims>.<signature>[.<extra>][.<guardianSignature>]`, for proofs with large
+// claims which would exceed the 8KB header limit of some proxies. The claims JSON is deflated
+// (RFC 1951) before it is base64 url-encoded, and the signature, extra data and guardian
+// signature are base64 url-encoded bytes rather than hex. Only the envelope differs from the
+// proof string of Encode, the proof signature is the same signature of the claims, so Parse
+// and ETHAuth.DecodeProof accept both. Note, EncodeCompressed does not validate the proof
+// signature or claims, see ETHAuth.EncodeCompressedProof for that.
+func (t *Proof) EncodeCompressed() (string, error) {
+	return t.encode(true)
+}
+
+// EncodeCompressedProof will validate a Proof object and return its compressed proof string,
+// see Proof.EncodeCompressed.
+func (w *ETHAuth) EncodeCompressedProof(proof *Proof) (string, error) {
+	if _, err := w.EncodeProof(proof); err != nil {
+		return "", err
+	}
+	return proof.EncodeCompressed()
+}
+
+// IsCompressedProof reports whether the proof string is a compressed proof string.
+func IsCompressedProof(proofString string) bool {
+	return strings.HasPrefix(proofString, ETHAuthCompressedPrefix+".")
+}
+
+// isProofString reports whether the string is a proof string, compressed or not.
+func isProofString(s string) bool {
+	return strings.HasPrefix(s, ETHAuthPrefix+".") || IsCompressedProof(s)
+}
+
+// deflateClaims compresses the claims JSON of a compressed proof string.
+func deflateClaims(claimsJSON []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(claimsJSON); err != nil {
+		return nil, fmt.Errorf("ethauth: cannot compress proof claims - %w", err)
+	}
+	if err := fw.Close(); err != nil {
+		return nil, fmt.Errorf("ethauth: cannot compress proof claims - %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// inflateClaims decodes and decompresses the claims of a compressed proof string, rejecting
+// claims over maxLength once inflated, so small proof strings can't inflate to large claims.
+func inflateClaims(messageBase64 string, maxLength int64) ([]byte, error) {
+	deflated, err := strictBase64UrlDecode(messageBase64)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: decoding failed, invalid claims")
+	}
+	fr := flate.NewReader(bytes.NewReader(deflated))
+	defer fr.Close()
+	claimsJSON, err := io.ReadAll(io.LimitReader(fr, maxLength+1))
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: decoding failed, invalid compressed claims")
+	}
+	if int64(len(claimsJSON)) > maxLength {
+		return nil, fmt.Errorf("ethauth: decoding failed, claims exceed %d bytes", maxLength)
+	}
+	return claimsJSON, nil
+}
+
+// compressHex encodes a hex part of a proof string as base64 url-encoded bytes.
+func compressHex(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	data, err := ethcoder.HexDecode(s)
+	if err != nil {
+		return "", err
+	}
+	return Base64UrlEncode(data), nil
+}
+
+// decompressHex decodes a part of a compressed proof string into its hex encoding.
+func decompressHex(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	data, err := strictBase64UrlDecode(s)
+	if err != nil {
+		return "", err
+	}
+	return ethcoder.HexEncode(data), nil
+}
@@ -0,0 +1,94 @@
+package ethauth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedProof(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	var scopes []string
+	for i := 0; i < 200; i++ {
+		scopes = append(scopes, "read:projects/"+strings.Repeat("x", 8)+"/"+time.Duration(i).String())
+	}
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithScope(scopes...))
+	require.NoError(t, err)
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+	guardianKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, CountersignProof(proof, guardianKey))
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigGuardian(crypto.PubkeyToAddress(guardianKey.PublicKey))
+
+	uncompressed, err := ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+	compressed, err := ethAuth.EncodeCompressedProof(proof)
+	require.NoError(t, err)
+	require.True(t, IsCompressedProof(compressed))
+	require.False(t, IsCompressedProof(uncompressed))
+	require.Less(t, len(compressed), len(uncompressed)/2)
+
+	// compressed proofs are decoded transparently, with the same signature
+	ok, decoded, err := ethAuth.DecodeProof(compressed)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, ETHAuthCompressedPrefix, decoded.Prefix)
+	require.Equal(t, proof.Claims.Scope, decoded.Claims.Scope)
+	require.Equal(t, proof.Signature, decoded.Signature)
+	require.Equal(t, proof.GuardianSignature, decoded.GuardianSignature)
+	require.Empty(t, decoded.Extra)
+
+	// and re-encode to the same proof string
+	reencoded, err := decoded.Encode()
+	require.NoError(t, err)
+	require.Equal(t, uncompressed, reencoded)
+
+	// compressed proofs can be encrypted
+	serverKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	encrypted, err := EncryptProof(compressed, &serverKey.PublicKey)
+	require.NoError(t, err)
+	decrypted, err := DecryptProof(encrypted, serverKey)
+	require.NoError(t, err)
+	require.Equal(t, compressed, decrypted)
+
+	// a tampered signature is rejected
+	parts := strings.Split(compressed, ".")
+	if parts[3][0] == 'A' {
+		parts[3] = "B" + parts[3][1:]
+	} else {
+		parts[3] = "A" + parts[3][1:]
+	}
+	_, _, err = ethAuth.DecodeProof(strings.Join(parts, "."))
+	require.Error(t, err)
+	parts[3] = parts[3] + "=="
+	_, err = Parse(strings.Join(parts, "."))
+	require.ErrorContains(t, err, "invalid signature")
+}
+
+func TestCompressedProofLimits(t *testing.T) {
+	proof, err := Parse(testProofString(t))
+	require.NoError(t, err)
+	compressed, err := proof.EncodeCompressed()
+	require.NoError(t, err)
+
+	// claims which inflate over the limit are rejected, however small their compressed form
+	deflated, err := deflateClaims([]byte(`{"app":"` + strings.Repeat("a", MaxClaimsLength) + `"}`))
+	require.NoError(t, err)
+	require.Less(t, len(deflated), 512)
+	_, err = Parse(withClaims(compressed, Base64UrlEncode(deflated)))
+	require.ErrorContains(t, err, "exceed")
+
+	_, err = Parse(withClaims(compressed, Base64UrlEncode([]byte("not deflated"))))
+	require.ErrorContains(t, err, "invalid compressed claims")
+}
@@ -0,0 +1,32 @@
+package ethauth
+
+import (
+	"context"
+)
+
+type contextKey struct {
+	name string
+}
+
+var proofCtxKey = &contextKey{"proof"}
+
+// WithProof returns a copy of the context carrying the verified proof.
+func WithProof(ctx context.Context, proof *Proof) context.Context {
+	return context.WithValue(ctx, proofCtxKey, proof)
+}
+
+// FromContext returns the verified proof stored in the context by WithProof or Middleware.
+func FromContext(ctx context.Context) (*Proof, bool) {
+	proof, ok := ctx.Value(proofCtxKey).(*Proof)
+	return proof, ok && proof != nil
+}
+
+// AddressFromContext returns the account of the verified proof stored in the context, see
+// Proof.Account.
+func AddressFromContext(ctx context.Context) (string, bool) {
+	proof, ok := FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return proof.Account(), true
+}
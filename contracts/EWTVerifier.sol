@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+interface IERC1271 {
+  function isValidSignature(bytes32 hash, bytes calldata signature) external view returns (bytes4);
+}
+
+/// @title EWTVerifier
+/// @notice Verifies ETHAuth proofs on-chain, ie. to gate a meta-transaction by the proof of
+/// its signer. Proofs are signed as the EIP712 typed data of their version 1 claims, whose
+/// Claims type only has the fields of the claims which are set, in the order of the fields of
+/// the Claims struct below. See Proof.ToCalldata of github.com/0xsequence/go-ethauth for the
+/// calldata of isValidProof.
+contract EWTVerifier {
+  /// @notice The claims of a proof, where unset claims are zero or empty. The scope claim is
+  /// the space separated list of scopes.
+  struct Claims {
+    string app;
+    int64 iat;
+    int64 exp;
+    uint64 n;
+    string typ;
+    string ogn;
+    uint64 cid;
+    string aud;
+    string sub;
+    string jti;
+    string scope;
+    string v;
+    string cnf;
+    string htm;
+    string htp;
+    string bdh;
+    string ip;
+    string ua;
+  }
+
+  bytes4 internal constant ERC1271_MAGIC_VALUE = 0x1626ba7e;
+
+  bytes32 internal constant DOMAIN_TYPEHASH = keccak256("EIP712Domain(string name,string version)");
+  bytes32 internal constant DOMAIN_CHAIN_TYPEHASH = keccak256("EIP712Domain(string name,string version,uint256 chainId)");
+  bytes32 internal constant DOMAIN_NAME_HASH = keccak256("ETHAuth");
+  bytes32 internal constant DOMAIN_VERSION_HASH = keccak256("1");
+  bytes32 internal constant CLAIMS_VERSION_HASH = keccak256("1");
+
+  // leeway of the iat claim for the clock drift of signers, as the default ValidatorConfig of
+  // the Go implementation
+  int256 internal constant IAT_LEEWAY = 5 minutes;
+
+  // upper bound of the s value of non-malleable secp256k1 signatures
+  uint256 internal constant SECP256K1_HALF_N = 0x7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0;
+
+  /// @notice Returns whether the signature is a valid proof of the claims by the account, and the
+  /// claims are not expired. Claims must be of version 1, expire, and not be issued more than
+  /// IAT_LEEWAY after the current block. Claims bound to a chain are only valid on that chain.
+  /// The account may be an EOA, or a contract account implementing EIP-1271. Unlike the Go
+  /// implementation, expired claims get no leeway, and the maximum age of claims is unchecked.
+  function isValidProof(address account, Claims calldata claims, bytes calldata signature) external view returns (bool) {
+    if (bytes(claims.app).length == 0 || keccak256(bytes(claims.v)) != CLAIMS_VERSION_HASH) {
+      return false;
+    }
+    if (claims.exp == 0 || int256(claims.exp) < int256(block.timestamp)) {
+      return false;
+    }
+    if (int256(claims.iat) > int256(block.timestamp) + IAT_LEEWAY) {
+      return false;
+    }
+    if (claims.cid != 0 && claims.cid != block.chainid) {
+      return false;
+    }
+
+    bytes32 digest = claimsDigest(claims);
+    if (account.code.length > 0) {
+      try IERC1271(account).isValidSignature(digest, signature) returns (bytes4 magicValue) {
+        return magicValue == ERC1271_MAGIC_VALUE;
+      } catch {
+        return false;
+      }
+    }
+    return recover(digest, signature) == account && account != address(0);
+  }
+
+  /// @notice Returns the EIP712 digest signed by the proof of the claims.
+  function claimsDigest(Claims calldata claims) public pure returns (bytes32) {
+    return keccak256(abi.encodePacked(hex"1901", domainSeparator(claims.cid), claimsHash(claims)));
+  }
+
+  /// @notice Returns the EIP712 domain separator of claims bound to the chain id, or of claims
+  /// unbound to a chain if chainId is zero.
+  function domainSeparator(uint64 chainId) public pure returns (bytes32) {
+    if (chainId == 0) {
+      return keccak256(abi.encode(DOMAIN_TYPEHASH, DOMAIN_NAME_HASH, DOMAIN_VERSION_HASH));
+    }
+    return keccak256(abi.encode(DOMAIN_CHAIN_TYPEHASH, DOMAIN_NAME_HASH, DOMAIN_VERSION_HASH, uint256(chainId)));
+  }
+
+  /// @notice Returns the EIP712 hashStruct of the claims.
+  function claimsHash(Claims calldata c) public pure returns (bytes32) {
+    bytes memory typ = "Claims(";
+    bytes memory enc;
+    (typ, enc) = _string(typ, enc, "app", c.app);
+    (typ, enc) = _int64(typ, enc, "iat", c.iat);
+    (typ, enc) = _int64(typ, enc, "exp", c.exp);
+    (typ, enc) = _uint64(typ, enc, "n", c.n);
+    (typ, enc) = _string(typ, enc, "typ", c.typ);
+    (typ, enc) = _string(typ, enc, "ogn", c.ogn);
+    (typ, enc) = _uint64(typ, enc, "cid", c.cid);
+    (typ, enc) = _string(typ, enc, "aud", c.aud);
+    (typ, enc) = _string(typ, enc, "sub", c.sub);
+    (typ, enc) = _string(typ, enc, "jti", c.jti);
+    (typ, enc) = _string(typ, enc, "scope", c.scope);
+    (typ, enc) = _string(typ, enc, "v", c.v);
+    (typ, enc) = _string(typ, enc, "cnf", c.cnf);
+    (typ, enc) = _string(typ, enc, "htm", c.htm);
+    (typ, enc) = _string(typ, enc, "htp", c.htp);
+    (typ, enc) = _string(typ, enc, "bdh", c.bdh);
+    (typ, enc) = _string(typ, enc, "ip", c.ip);
+    (typ, enc) = _string(typ, enc, "ua", c.ua);
+    return keccak256(abi.encodePacked(keccak256(abi.encodePacked(typ, ")")), enc));
+  }
+
+  function _field(bytes memory typ, string memory field) private pure returns (bytes memory) {
+    if (typ.length == 7) {
+      return abi.encodePacked(typ, field);
+    }
+    return abi.encodePacked(typ, ",", field);
+  }
+
+  function _string(bytes memory typ, bytes memory enc, string memory name, string calldata value) private pure returns (bytes memory, bytes memory) {
+    if (bytes(value).length == 0) {
+      return (typ, enc);
+    }
+    return (_field(typ, string.concat("string ", name)), abi.encodePacked(enc, keccak256(bytes(value))));
+  }
+
+  // Note, signed claims are encoded as their absolute value, as the Go implementation does.
+  function _int64(bytes memory typ, bytes memory enc, string memory name, int64 value) private pure returns (bytes memory, bytes memory) {
+    if (value == 0) {
+      return (typ, enc);
+    }
+    uint256 abs = value < 0 ? uint256(-int256(value)) : uint256(int256(value));
+    return (_field(typ, string.concat("int64 ", name)), abi.encodePacked(enc, abs));
+  }
+
+  function _uint64(bytes memory typ, bytes memory enc, string memory name, uint64 value) private pure returns (bytes memory, bytes memory) {
+    if (value == 0) {
+      return (typ, enc);
+    }
+    return (_field(typ, string.concat("uint64 ", name)), abi.encodePacked(enc, uint256(value)));
+  }
+
+  function recover(bytes32 digest, bytes calldata signature) internal pure returns (address) {
+    if (signature.length != 65) {
+      return address(0);
+    }
+    bytes32 r = bytes32(signature[0:32]);
+    bytes32 s = bytes32(signature[32:64]);
+    uint8 v = uint8(signature[64]);
+    if (v < 27) {
+      v += 27;
+    }
+    if (uint256(s) > SECP256K1_HALF_N || (v != 27 && v != 28)) {
+      return address(0);
+    }
+    return ecrecover(digest, v, r, s);
+  }
+}
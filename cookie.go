@@ -0,0 +1,141 @@
+package ethauth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultCookieName is the name of the proof cookie, whose chunks are the `ewt.0`, `ewt.1`,
+	// ... cookies, see SetProofCookie.
+	DefaultCookieName = "ewt"
+
+	// MaxCookieSize is the size browsers store for each cookie, of its name, value and
+	// attributes, so the chunks of proof cookies are at most MaxCookieSize bytes.
+	MaxCookieSize = 4096
+
+	// MaxCookieChunks is the maximum number of chunks of a proof cookie.
+	MaxCookieChunks = 8
+)
+
+// SetProofCookie sets the proof string as the proof cookie of the response, split across as
+// many `<name>.0`, `<name>.1`, ... cookies as it takes for each of them to fit the 4KB per
+// cookie browser limit. The cookie sets the name, defaulting to DefaultCookieName, and the
+// attributes of the chunks, which default to a Secure, HttpOnly, SameSite=Lax cookie of path
+// "/". The chunks of a previous, longer proof cookie of the request are expired, so they
+// aren't reassembled with the new proof, and r may be nil for responses to requests without
+// a proof cookie.
+//
+// Cookies are sent by browsers with cross-site requests, so cookie sessions should keep the
+// SameSite attribute, and may check the Origin header with MiddlewareOptions.VerifyOrigin.
+func SetProofCookie(w http.ResponseWriter, r *http.Request, proofString string, optCookie ...http.Cookie) error {
+	cookie := proofCookie(optCookie)
+	if proofString == "" {
+		return fmt.Errorf("ethauth: proof string is empty")
+	}
+
+	// the size of the attributes of a chunk, with the longest chunk name
+	empty := cookie
+	empty.Name = cookieChunkName(cookie.Name, MaxCookieChunks-1)
+	chunkSize := MaxCookieSize - len(empty.String())
+	if chunkSize < MaxCookieSize/2 {
+		return fmt.Errorf("ethauth: proof cookie attributes leave no room for the proof")
+	}
+	chunks := (len(proofString) + chunkSize - 1) / chunkSize
+	if chunks > MaxCookieChunks {
+		return fmt.Errorf("ethauth: proof string exceeds %d cookies", MaxCookieChunks)
+	}
+
+	for i := 0; i < chunks; i++ {
+		chunk := cookie
+		chunk.Name = cookieChunkName(cookie.Name, i)
+		chunk.Value = proofString[i*chunkSize : min((i+1)*chunkSize, len(proofString))]
+		if err := chunk.Valid(); err != nil {
+			return fmt.Errorf("ethauth: invalid proof cookie - %w", err)
+		}
+		http.SetCookie(w, &chunk)
+	}
+	if r != nil {
+		expireCookieChunks(w, r, cookie, chunks)
+	}
+	return nil
+}
+
+// ReadProofCookie reassembles the proof string of the proof cookie of the request, see
+// SetProofCookie, of the name, defaulting to DefaultCookieName. It returns an empty proof
+// string if the request has no proof cookie.
+func ReadProofCookie(r *http.Request, optName ...string) string {
+	name := DefaultCookieName
+	if len(optName) > 0 && optName[0] != "" {
+		name = optName[0]
+	}
+	return readCookieChunks(r.Cookies(), name)
+}
+
+// ClearProofCookie expires the chunks of the proof cookie of the request, ie. on logout. The
+// cookie must have the name, path and domain the proof cookie was set with.
+func ClearProofCookie(w http.ResponseWriter, r *http.Request, optCookie ...http.Cookie) {
+	expireCookieChunks(w, r, proofCookie(optCookie), 0)
+}
+
+// requestCookies parses the cookies of a Cookie request header.
+func requestCookies(header string) []*http.Cookie {
+	if header == "" {
+		return nil
+	}
+	return (&http.Request{Header: http.Header{"Cookie": {header}}}).Cookies()
+}
+
+// proofCookie returns the template cookie of the chunks of a proof cookie.
+func proofCookie(optCookie []http.Cookie) http.Cookie {
+	if len(optCookie) == 0 {
+		return http.Cookie{Name: DefaultCookieName, Path: "/", Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+	}
+	cookie := optCookie[0]
+	if cookie.Name == "" {
+		cookie.Name = DefaultCookieName
+	}
+	cookie.Value = ""
+	return cookie
+}
+
+func cookieChunkName(name string, i int) string {
+	return name + "." + strconv.Itoa(i)
+}
+
+// readCookieChunks concatenates the values of the consecutive chunks of the cookie of the name.
+func readCookieChunks(cookies []*http.Cookie, name string) string {
+	values := make(map[string]string, len(cookies))
+	for _, c := range cookies {
+		// the first cookie of a name is the one of the most specific path
+		if _, ok := values[c.Name]; !ok {
+			values[c.Name] = c.Value
+		}
+	}
+	var proofString string
+	for i := 0; i < MaxCookieChunks; i++ {
+		value, ok := values[cookieChunkName(name, i)]
+		if !ok {
+			break
+		}
+		proofString += value
+	}
+	return proofString
+}
+
+// expireCookieChunks expires the chunks of the cookie of the request from the from-th chunk.
+func expireCookieChunks(w http.ResponseWriter, r *http.Request, cookie http.Cookie, from int) {
+	for i := from; i < MaxCookieChunks; i++ {
+		name := cookieChunkName(cookie.Name, i)
+		if _, err := r.Cookie(name); err != nil {
+			continue
+		}
+		expired := cookie
+		expired.Name = name
+		expired.MaxAge = -1
+		expired.Expires = time.Time{}
+		http.SetCookie(w, &expired)
+	}
+}
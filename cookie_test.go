@@ -0,0 +1,99 @@
+package ethauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofCookie(t *testing.T) {
+	// a proof string of three chunks
+	proofString := "eth." + strings.Repeat("a", 10000)
+	rec := httptest.NewRecorder()
+	require.NoError(t, SetProofCookie(rec, nil, proofString))
+	setCookies := rec.Result().Cookies()
+	require.Len(t, setCookies, 3)
+	for i, c := range setCookies {
+		require.Equal(t, cookieChunkName(DefaultCookieName, i), c.Name)
+		require.True(t, c.Secure && c.HttpOnly)
+		require.Equal(t, http.SameSiteLaxMode, c.SameSite)
+	}
+	for _, header := range rec.Header()["Set-Cookie"] {
+		require.LessOrEqual(t, len(header), MaxCookieSize)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	for _, c := range setCookies {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	req.AddCookie(&http.Cookie{Name: "other", Value: "1"})
+	require.Equal(t, proofString, ReadProofCookie(req))
+	require.Empty(t, ReadProofCookie(req, "session"))
+
+	// the chunks of a longer proof cookie are expired, so they aren't reassembled
+	rec = httptest.NewRecorder()
+	require.NoError(t, SetProofCookie(rec, req, "eth.short"))
+	setCookies = rec.Result().Cookies()
+	require.Len(t, setCookies, 3)
+	require.Equal(t, "eth.short", setCookies[0].Value)
+	for _, c := range setCookies[1:] {
+		require.Equal(t, -1, c.MaxAge)
+	}
+
+	rec = httptest.NewRecorder()
+	ClearProofCookie(rec, req)
+	require.Len(t, rec.Result().Cookies(), 3)
+
+	// the attributes of the cookie are kept, and count toward the size of the chunks
+	rec = httptest.NewRecorder()
+	cookie := http.Cookie{Name: "session", Path: "/api", Domain: "example.com", Expires: time.Now().Add(time.Hour), Secure: true, SameSite: http.SameSiteStrictMode}
+	require.NoError(t, SetProofCookie(rec, nil, proofString, cookie))
+	for _, header := range rec.Header()["Set-Cookie"] {
+		require.LessOrEqual(t, len(header), MaxCookieSize)
+		require.True(t, strings.HasPrefix(header, "session."))
+		require.Contains(t, header, "Domain=example.com")
+	}
+
+	require.Error(t, SetProofCookie(httptest.NewRecorder(), nil, strings.Repeat("a", MaxCookieChunks*MaxCookieSize)))
+	require.Error(t, SetProofCookie(httptest.NewRecorder(), nil, "eth;1"))
+	require.Error(t, SetProofCookie(httptest.NewRecorder(), nil, ""))
+}
+
+func TestMiddlewareCookie(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	var scopes []string
+	for i := 0; i < 400; i++ {
+		scopes = append(scopes, "read:"+strings.Repeat("x", 8)+"/"+time.Duration(i).String())
+	}
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithScope(scopes...))
+	require.NoError(t, err)
+	require.Greater(t, len(proofString), MaxCookieSize)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	require.NoError(t, SetProofCookie(rec, nil, proofString))
+	req := httptest.NewRequest("GET", "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+	}
+
+	// the proof cookie is only read if enabled
+	_, err = Authenticate(ethAuth, NewAuthRequest(req), MiddlewareOptions{})
+	require.Error(t, err)
+	proof, err := Authenticate(ethAuth, NewAuthRequest(req), MiddlewareOptions{Cookie: DefaultCookieName})
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), proof.Address)
+
+	// a missing chunk truncates the proof string
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "ewt.0", Value: rec.Result().Cookies()[0].Value})
+	_, err = Authenticate(ethAuth, NewAuthRequest(req), MiddlewareOptions{Cookie: DefaultCookieName})
+	require.Error(t, err)
+}
@@ -0,0 +1,301 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ProofTypeDelegated is the `typ` claim of proofs signed by a session key, which the account
+// authorized through the chain of delegations carried in the proof Extra.
+const ProofTypeDelegated = "delegated"
+
+// Delegation authorizes the Delegate session key to sign proofs of the App, or further
+// delegations, on behalf of the signer of the delegation until ExpiresAt. The first delegation
+// of a chain is signed by the account, and each following one by the Delegate of the previous
+// delegation.
+type Delegation struct {
+	// Delegate is the address of the secp256k1 session key being authorized
+	Delegate string `json:"delegate"`
+
+	// ExpiresAt is the unix time the delegation expires at
+	ExpiresAt int64 `json:"exp"`
+
+	// App is the `app` claim of the proofs the delegate may sign, so a session key delegated
+	// to one app can't sign proofs for another
+	App string `json:"app"`
+
+	// Audience and ChainID, if set, are the `aud` and `cid` claims of the proofs the delegate
+	// may sign
+	Audience string `json:"aud,omitempty"`
+	ChainID  uint64 `json:"cid,omitempty"`
+
+	// Scope is the space-separated list of scopes the delegate is restricted to. An empty
+	// scope doesn't restrict the delegate.
+	Scope string `json:"scope,omitempty"`
+
+	// Capabilities are the UCAN-style capabilities the delegate is attenuated to, see
+	// Capabilities.IsAttenuationOf. Empty capabilities don't restrict the delegate.
+	Capabilities Capabilities `json:"att,omitempty"`
+
+	// Signature of the delegation by its signer (in hex)
+	Signature string `json:"sig"`
+}
+
+func (d Delegation) TypedData() *ethcoder.TypedData {
+	td := &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+			},
+			"Delegation": {
+				{Name: "delegate", Type: "address"},
+				{Name: "exp", Type: "int64"},
+				{Name: "scope", Type: "string"},
+				{Name: "app", Type: "string"},
+			},
+		},
+		PrimaryType: "Delegation",
+		Domain:      eip712Domain,
+		Message: map[string]interface{}{
+			"delegate": common.HexToAddress(d.Delegate),
+			"exp":      d.ExpiresAt,
+			"scope":    d.Scope,
+			"app":      d.App,
+		},
+	}
+	if d.Audience != "" {
+		td.Types["Delegation"] = append(td.Types["Delegation"], ethcoder.TypedDataArgument{Name: "aud", Type: "string"})
+		td.Message["aud"] = d.Audience
+	}
+	if d.ChainID != 0 {
+		td.Types["Delegation"] = append(td.Types["Delegation"], ethcoder.TypedDataArgument{Name: "cid", Type: "uint64"})
+		td.Message["cid"] = d.ChainID
+	}
+
+	// capabilities are only part of the typed data of delegations attenuating them, so the
+	// signatures of delegations without capabilities are unchanged
+	if len(d.Capabilities) > 0 {
+		td.Types["Delegation"] = append(td.Types["Delegation"], ethcoder.TypedDataArgument{Name: "att", Type: "string[]"})
+		att := make([]interface{}, len(d.Capabilities))
+		for i, capability := range d.Capabilities {
+			att[i] = capability.String()
+		}
+		td.Message["att"] = att
+	}
+	return td
+}
+
+// MessageDigest returns the EIP712 digest of the delegation signed by its signer.
+func (d Delegation) MessageDigest() ([]byte, error) {
+	if !common.IsHexAddress(d.Delegate) {
+		return nil, fmt.Errorf("ethauth: delegate is not a valid Ethereum address")
+	}
+	if d.App == "" {
+		return nil, fmt.Errorf("ethauth: delegation app is empty")
+	}
+	for _, capability := range d.Capabilities {
+		if err := capability.Valid(); err != nil {
+			return nil, err
+		}
+	}
+	digest, _, err := d.TypedData().Encode()
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: failed to encode delegation typed data - %w", err)
+	}
+	return digest, nil
+}
+
+// SignDelegation signs the delegation with the private key of the account, or of the delegate
+// of the previous delegation in the chain.
+func SignDelegation(delegation *Delegation, privateKey *ecdsa.PrivateKey) error {
+	digest, err := delegation.MessageDigest()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(digest, privateKey)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to sign delegation - %w", err)
+	}
+	sig[64] += 27
+	delegation.Signature = ethcoder.HexEncode(sig)
+	return nil
+}
+
+// SignDelegatedProof signs the proof claims with the session key authorized by the last
+// delegation of the chain, on behalf of the account address which signed the first one.
+func SignDelegatedProof(proof *Proof, address string, delegations []Delegation, sessionKey *ecdsa.PrivateKey) error {
+	if len(delegations) == 0 {
+		return fmt.Errorf("ethauth: delegated proof requires at least one delegation")
+	}
+	if !strings.EqualFold(delegations[len(delegations)-1].Delegate, crypto.PubkeyToAddress(sessionKey.PublicKey).Hex()) {
+		return fmt.Errorf("ethauth: session key is not the delegate of the last delegation")
+	}
+
+	extra, err := json.Marshal(delegations)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to encode delegations - %w", err)
+	}
+
+	proof.Address = address
+	proof.Claims.Type = ProofTypeDelegated
+	proof.Extra = ethcoder.HexEncode(extra)
+
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(digest, sessionKey)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to sign proof - %w", err)
+	}
+	sig[64] += 27
+	proof.Signature = ethcoder.HexEncode(sig)
+	return nil
+}
+
+// Delegations returns the delegation chain of a delegated proof.
+func (t *Proof) Delegations() ([]Delegation, error) {
+	if t.Claims.Type != ProofTypeDelegated {
+		return nil, fmt.Errorf("ethauth: proof is not a delegated proof")
+	}
+	extra, err := ethcoder.HexDecode(t.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid delegations encoding - %w", err)
+	}
+	var delegations []Delegation
+	if err := json.Unmarshal(extra, &delegations); err != nil {
+		return nil, fmt.Errorf("ethauth: invalid delegations encoding - %w", err)
+	}
+	if len(delegations) == 0 {
+		return nil, fmt.Errorf("ethauth: delegated proof has no delegations")
+	}
+	return delegations, nil
+}
+
+// DelegatedScope returns the scopes the delegated proof is restricted to, or nil if the
+// delegations don't restrict it.
+func (t *Proof) DelegatedScope() (Scopes, error) {
+	delegations, err := t.Delegations()
+	if err != nil {
+		return nil, err
+	}
+	return ParseScopes(delegations[len(delegations)-1].Scope), nil
+}
+
+// DelegatedCapabilities returns the capabilities the delegated proof is attenuated to, or nil
+// if the delegations don't restrict it, see Capabilities.Allows.
+func (t *Proof) DelegatedCapabilities() (Capabilities, error) {
+	delegations, err := t.Delegations()
+	if err != nil {
+		return nil, err
+	}
+	return delegations[len(delegations)-1].Capabilities, nil
+}
+
+// ValidateDelegatedProof verifies delegated proofs, walking the delegation chain back to the
+// account: the first delegation must be signed by the account, either as an EOA or, when a
+// provider is configured, an EIP-1271 contract wallet, and each following delegation by the
+// previous delegate. Delegations may only narrow the scope, capabilities and expiry of their
+// parent, and keep its app, audience and chain, and the proof must be signed by the last
+// delegate, be narrower in scope and expiry than its delegation, and carry its app, audience
+// and chain claims.
+func ValidateDelegatedProof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+	if proof.Claims.Type != ProofTypeDelegated {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. proof is not a delegated proof")
+	}
+	signer, err := proof.AddressBytes()
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. address is not a valid Ethereum address")
+	}
+	delegations, err := proof.Delegations()
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. %w", err)
+	}
+
+	var parent *Delegation
+	for i, delegation := range delegations {
+		if parent != nil {
+			if delegation.ExpiresAt > parent.ExpiresAt {
+				return false, "", fmt.Errorf("ValidateDelegatedProof failed. delegation %d outlives its parent", i)
+			}
+			if !isSubScope(delegation.Scope, parent.Scope) {
+				return false, "", fmt.Errorf("ValidateDelegatedProof failed. delegation %d widens the scope of its parent", i)
+			}
+			if !delegation.Capabilities.IsAttenuationOf(parent.Capabilities) {
+				return false, "", fmt.Errorf("ValidateDelegatedProof failed. delegation %d widens the capabilities of its parent", i)
+			}
+			if delegation.App != parent.App || (parent.Audience != "" && delegation.Audience != parent.Audience) || (parent.ChainID != 0 && delegation.ChainID != parent.ChainID) {
+				return false, "", fmt.Errorf("ValidateDelegatedProof failed. delegation %d is for another app, audience or chain than its parent", i)
+			}
+		}
+
+		digest, err := delegation.MessageDigest()
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateDelegatedProof failed. delegation %d - %w", i, err)
+		}
+		signature, err := ethcoder.HexDecode(delegation.Signature)
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateDelegatedProof failed. HexDecode of delegation %d signature failed - %w", i, err)
+		}
+
+		isValid, _ := EOASignatureValidator{}.IsValidSignature(ctx, signer, digest, signature)
+		if !isValid && i == 0 && provider != nil {
+			isValid, err = ContractSignatureValidator{Provider: provider}.IsValidSignature(ctx, signer, digest, signature)
+			if err != nil {
+				return false, "", fmt.Errorf("ValidateDelegatedProof failed. %w", err)
+			}
+		}
+		if !isValid {
+			return false, "", fmt.Errorf("ValidateDelegatedProof failed. invalid signature of delegation %d", i)
+		}
+
+		signer = common.HexToAddress(delegation.Delegate)
+		parent = &delegations[i]
+	}
+
+	if proof.Claims.ExpiresAt == 0 || proof.Claims.ExpiresAt > parent.ExpiresAt {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. proof outlives its delegation")
+	}
+	if !isSubScope(proof.Claims.Scope.String(), parent.Scope) {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. proof scope is wider than its delegation")
+	}
+	if proof.Claims.App != parent.App || (parent.Audience != "" && proof.Claims.Audience != parent.Audience) || (parent.ChainID != 0 && proof.Claims.ChainID != parent.ChainID) {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. proof is for another app, audience or chain than its delegation")
+	}
+
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. Unable to compute ethauth message digest, because %w", err)
+	}
+	signature, err := ethcoder.HexDecode(proof.Signature)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. HexDecode of proof.signature failed - %w", err)
+	}
+	isValid, _ := EOASignatureValidator{}.IsValidSignature(ctx, signer, digest, signature)
+	if !isValid {
+		return false, "", fmt.Errorf("ValidateDelegatedProof failed. invalid session key signature")
+	}
+	return true, proof.Address, nil
+}
+
+// isSubScope reports whether the space-separated scope is narrower than the parent scope,
+// where an empty scope is unrestricted.
+func isSubScope(scope, parent string) bool {
+	if parent == "" {
+		return true
+	}
+	if scope == "" {
+		return false
+	}
+	return ParseScopes(parent).Has(ParseScopes(scope)...)
+}
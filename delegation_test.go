@@ -0,0 +1,114 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegatedProof(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.RegisterValidator(ValidateDelegatedProof)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	sessionKey1, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sessionKey2, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	// the wallet delegates to the first session key, which delegates to the second
+	exp := time.Now().Add(time.Hour).Unix()
+	delegations := []Delegation{
+		{Delegate: crypto.PubkeyToAddress(sessionKey1.PublicKey).Hex(), ExpiresAt: exp, App: "ETHAuthTest", Scope: "read write"},
+		{Delegate: crypto.PubkeyToAddress(sessionKey2.PublicKey).Hex(), ExpiresAt: exp - 60, App: "ETHAuthTest", Scope: "read"},
+	}
+	require.NoError(t, SignDelegation(&delegations[0], wallet.PrivateKey()))
+	require.NoError(t, SignDelegation(&delegations[1], sessionKey1))
+
+	newProof := func(delegations []Delegation) *Proof {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", Scope: Scopes{"read"}, ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignDelegatedProof(proof, wallet.Address().Hex(), delegations, sessionKey2))
+		return proof
+	}
+
+	proofString, err := ethAuth.EncodeProof(newProof(delegations))
+	require.NoError(t, err)
+
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	scope, err := proof.DelegatedScope()
+	require.NoError(t, err)
+	require.Equal(t, Scopes{"read"}, scope)
+
+	// delegations can't widen the scope of their parent
+	widened := []Delegation{delegations[0], {Delegate: delegations[1].Delegate, ExpiresAt: delegations[1].ExpiresAt, App: "ETHAuthTest", Scope: "admin"}}
+	require.NoError(t, SignDelegation(&widened[1], sessionKey1))
+	_, err = ethAuth.EncodeProof(newProof(widened))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// delegations must be signed by their parent delegate
+	forged := []Delegation{delegations[0], delegations[1]}
+	require.NoError(t, SignDelegation(&forged[1], sessionKey2))
+	_, err = ethAuth.EncodeProof(newProof(forged))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// proofs can't outlive their delegation
+	expired := newProof(delegations)
+	expired.Claims.ExpiresAt = exp
+	require.NoError(t, SignDelegatedProof(expired, wallet.Address().Hex(), delegations, sessionKey2))
+	_, err = ethAuth.EncodeProof(expired)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// proofs can't be wider in scope than their delegation
+	wide := newProof(delegations)
+	wide.Claims.Scope = Scopes{"read", "write"}
+	require.NoError(t, SignDelegatedProof(wide, wallet.Address().Hex(), delegations, sessionKey2))
+	_, err = ethAuth.EncodeProof(wide)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// delegations are bound to their app, audience and chain
+	otherApp := newProof(delegations)
+	otherApp.Claims.App = "OtherApp"
+	require.NoError(t, SignDelegatedProof(otherApp, wallet.Address().Hex(), delegations, sessionKey2))
+	_, err = ethAuth.EncodeProof(otherApp)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	switched := []Delegation{delegations[0], {Delegate: delegations[1].Delegate, ExpiresAt: delegations[1].ExpiresAt, App: "OtherApp", Scope: "read"}}
+	require.NoError(t, SignDelegation(&switched[1], sessionKey1))
+	_, err = ethAuth.EncodeProof(newProof(switched))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	scoped := []Delegation{{Delegate: delegations[1].Delegate, ExpiresAt: exp, App: "ETHAuthTest", Audience: "orders", ChainID: 1}}
+	require.NoError(t, SignDelegation(&scoped[0], wallet.PrivateKey()))
+	_, err = ethAuth.EncodeProof(newProof(scoped))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	audienced := newProof(scoped)
+	audienced.Claims.Audience = "orders"
+	audienced.Claims.ChainID = 1
+	require.NoError(t, SignDelegatedProof(audienced, wallet.Address().Hex(), scoped, sessionKey2))
+	_, err = ethAuth.EncodeProof(audienced)
+	require.NoError(t, err)
+
+	// the app is signed by the delegation
+	tampered := []Delegation{delegations[0], delegations[1]}
+	tampered[0].App = "OtherApp"
+	tampered[1].App = "OtherApp"
+	otherApp.Claims.App = "OtherApp"
+	require.NoError(t, SignDelegatedProof(otherApp, wallet.Address().Hex(), tampered, sessionKey2))
+	_, err = ethAuth.EncodeProof(otherApp)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	require.Error(t, SignDelegation(&Delegation{Delegate: delegations[0].Delegate, ExpiresAt: exp}, wallet.PrivateKey()))
+
+	// the session key must be the last delegate
+	require.Error(t, SignDelegatedProof(NewProof(), wallet.Address().Hex(), delegations, sessionKey1))
+}
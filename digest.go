@@ -0,0 +1,303 @@
+package ethauth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// claimsDigester computes the EIP712 digest of version 1 claims directly, without building
+// their typed data, reusing its keccak state and buffers across verifications.
+type claimsDigester struct {
+	h   crypto.KeccakState
+	sum [32]byte
+
+	// buf is the input of hash, and enc the encoded Claims struct
+	buf []byte
+	enc []byte
+}
+
+var claimsDigesterPool = sync.Pool{
+	New: func() any {
+		return &claimsDigester{h: crypto.NewKeccakState(), buf: make([]byte, 0, 1024), enc: make([]byte, 0, 32*(len(claimsFieldsV1)+1))}
+	},
+}
+
+// claimsFieldsV1 are the fields of the EIP712 Claims type of version 1 claims, in order.
+var claimsFieldsV1 = [...]struct{ name, typ string }{
+	{"app", "string"}, {"iat", "int64"}, {"exp", "int64"}, {"n", "uint64"}, {"typ", "string"},
+	{"ogn", "string"}, {"cid", "uint64"}, {"aud", "string"}, {"sub", "string"}, {"jti", "string"},
+	{"scope", "string"}, {"v", "string"}, {"cnf", "string"}, {"htm", "string"}, {"htp", "string"},
+	{"bdh", "string"}, {"ip", "string"}, {"ua", "string"}, {"statement", "string"},
+	{"dom", "string"}, {"mrk", "string"},
+}
+
+// claimsTypeHashes caches the type hashes of the Claims types of each set of claims fields.
+var (
+	claimsTypeHashesMu sync.RWMutex
+	claimsTypeHashes   = map[uint32][32]byte{}
+)
+
+// claimsFieldsMask returns the set of claims fields of the EIP712 Claims type of the claims.
+func claimsFieldsMask(c *Claims) uint32 {
+	present := [len(claimsFieldsV1)]bool{
+		c.App != "", c.IssuedAt != 0, c.ExpiresAt != 0, c.Nonce != 0, c.Type != "",
+		c.Origin != "", c.ChainID != 0, c.Audience != "", c.Subject != "", c.ID != "",
+		len(c.Scope) > 0, c.ETHAuthVersion != "", c.Confirmation != "", c.RequestMethod != "", c.RequestPath != "",
+		c.RequestBodyHash != "", c.ClientIP != "", c.UserAgent != "", c.Statement != "",
+		c.Host != "", c.MerkleRoot != "",
+	}
+	var mask uint32
+	for i, ok := range present {
+		if ok {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// encodeV1 writes the EIP712 encoded message of version 1 claims without custom claims,
+// returning false for claims whose message must be encoded from their typed data.
+func (c *Claims) encodeV1(message *[66]byte) (bool, error) {
+	if !c.isV1() {
+		return false, nil
+	}
+	mask := claimsFieldsMask(c)
+	if mask == 0 {
+		return false, fmt.Errorf("ethauth: claims is empty")
+	}
+
+	d := claimsDigesterPool.Get().(*claimsDigester)
+	defer claimsDigesterPool.Put(d)
+	d.encode(c, mask)
+	copy(message[:], d.buf)
+	return true, nil
+}
+
+// digestV1 writes the EIP712 digest of version 1 claims without custom claims, see encodeV1.
+func (c *Claims) digestV1(digest *[32]byte) (bool, error) {
+	if !c.isV1() {
+		return false, nil
+	}
+	mask := claimsFieldsMask(c)
+	if mask == 0 {
+		return false, fmt.Errorf("ethauth: claims is empty")
+	}
+
+	d := claimsDigesterPool.Get().(*claimsDigester)
+	defer claimsDigesterPool.Put(d)
+	d.encode(c, mask)
+	*digest = d.hash(d.buf)
+	return true, nil
+}
+
+// isV1 reports whether the claims are version 1 claims without custom claims.
+func (c *Claims) isV1() bool {
+	return c.Custom == nil && (c.ETHAuthVersion == "" || c.ETHAuthVersion == ETHAuthVersion)
+}
+
+// encode writes the EIP712 encoded message of the claims into buf.
+func (d *claimsDigester) encode(c *Claims, mask uint32) {
+	domainSeparator := c.DomainSeparator()
+	structHash := d.structHash(c, mask)
+	d.buf = append(d.buf[:0], 0x19, 0x01)
+	d.buf = append(d.buf, domainSeparator[:]...)
+	d.buf = append(d.buf, structHash[:]...)
+}
+
+// hash returns the keccak256 hash of data.
+func (d *claimsDigester) hash(data []byte) [32]byte {
+	d.h.Reset()
+	d.h.Write(data)
+	d.h.Read(d.sum[:])
+	return d.sum
+}
+
+// hashString returns the keccak256 hash of a string, as the EIP712 encoding of a string value.
+func (d *claimsDigester) hashString(s string) [32]byte {
+	d.buf = append(d.buf[:0], s...)
+	return d.hash(d.buf)
+}
+
+// structHash returns the EIP712 hash of the Claims struct of the claims fields in the mask.
+func (d *claimsDigester) structHash(c *Claims, mask uint32) [32]byte {
+	// the encoded struct is the type hash followed by a 32 byte word for each field, where
+	// string values are encoded as their hash
+	typeHash := d.claimsTypeHash(mask)
+	d.enc = append(d.enc[:0], typeHash[:]...)
+	for i := range claimsFieldsV1 {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		var word [32]byte
+		switch claimsFieldsV1[i].name {
+		case "app":
+			word = d.hashString(c.App)
+		case "iat":
+			word = int64Word(c.IssuedAt)
+		case "exp":
+			word = int64Word(c.ExpiresAt)
+		case "n":
+			word = uint64Word(c.Nonce)
+		case "typ":
+			word = d.hashString(c.Type)
+		case "ogn":
+			word = d.hashString(c.Origin)
+		case "cid":
+			word = uint64Word(c.ChainID)
+		case "aud":
+			word = d.hashString(c.Audience)
+		case "sub":
+			word = d.hashString(c.Subject)
+		case "jti":
+			word = d.hashString(c.ID)
+		case "scope":
+			d.buf = d.buf[:0]
+			for j, scope := range c.Scope {
+				if j > 0 {
+					d.buf = append(d.buf, ' ')
+				}
+				d.buf = append(d.buf, scope...)
+			}
+			word = d.hash(d.buf)
+		case "v":
+			word = d.hashString(c.ETHAuthVersion)
+		case "cnf":
+			word = d.hashString(c.Confirmation)
+		case "htm":
+			word = d.hashString(c.RequestMethod)
+		case "htp":
+			word = d.hashString(c.RequestPath)
+		case "bdh":
+			word = d.hashString(c.RequestBodyHash)
+		case "ip":
+			word = d.hashString(c.ClientIP)
+		case "ua":
+			word = d.hashString(c.UserAgent)
+		case "statement":
+			word = d.hashString(c.Statement)
+		case "dom":
+			word = d.hashString(c.Host)
+		case "mrk":
+			word = d.hashString(c.MerkleRoot)
+		}
+		d.enc = append(d.enc, word[:]...)
+	}
+	return d.hash(d.enc)
+}
+
+// claimsTypeHash returns the hash of the EIP712 Claims type of the claims fields in the mask.
+func (d *claimsDigester) claimsTypeHash(mask uint32) [32]byte {
+	claimsTypeHashesMu.RLock()
+	typeHash, ok := claimsTypeHashes[mask]
+	claimsTypeHashesMu.RUnlock()
+	if ok {
+		return typeHash
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Claims(")
+	first := true
+	for i, f := range claimsFieldsV1 {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		if !first {
+			sb.WriteByte(',')
+		}
+		first = false
+		sb.WriteString(f.typ + " " + f.name)
+	}
+	sb.WriteByte(')')
+	typeHash = d.hashString(sb.String())
+
+	claimsTypeHashesMu.Lock()
+	claimsTypeHashes[mask] = typeHash
+	claimsTypeHashesMu.Unlock()
+	return typeHash
+}
+
+// int64Word returns the encoding of an int64 value as ethcoder encodes it. Note, ethcoder
+// packs the absolute value of signed integers, so negative values are encoded as their
+// absolute value rather than sign extended.
+func int64Word(v int64) [32]byte {
+	if v < 0 {
+		return uint64Word(uint64(-v))
+	}
+	return uint64Word(uint64(v))
+}
+
+// uint64Word returns the EIP712 encoding of a uint64 value.
+func uint64Word(v uint64) [32]byte {
+	var word [32]byte
+	binary.BigEndian.PutUint64(word[24:], v)
+	return word
+}
+
+// recoverEOADigest reports whether the hex signature of the digest is an EOA signature of
+// the hex address, as ethwallet.IsValid191Signature does for the EIP712 message of the digest.
+func recoverEOADigest(address string, digest *[32]byte, signatureHex string) (bool, error) {
+	var addr [20]byte
+	if len(address) != 42 || address[0:2] != "0x" && address[0:2] != "0X" {
+		return false, fmt.Errorf("address is not a valid Ethereum address")
+	}
+	if !decodeHexString(addr[:], address[2:]) {
+		return false, fmt.Errorf("address is not a valid Ethereum address")
+	}
+
+	var sig [65]byte
+	if len(signatureHex) != 2+2*len(sig) || signatureHex[0:2] != "0x" && signatureHex[0:2] != "0X" {
+		return false, fmt.Errorf("signature is not of proper length")
+	}
+	if !decodeHexString(sig[:], signatureHex[2:]) {
+		return false, fmt.Errorf("signature is an invalid hex string")
+	}
+	if err := normalizeSignature(sig[:]); err != nil {
+		return false, err
+	}
+
+	d := claimsDigesterPool.Get().(*claimsDigester)
+	defer claimsDigesterPool.Put(d)
+	d.buf = append(d.buf[:0], digest[:]...)
+	d.buf = append(d.buf, sig[:]...)
+	pubkey, err := crypto.Ecrecover(d.buf[:32], d.buf[32:])
+	if err != nil {
+		return false, err
+	}
+	pubkeyHash := d.hash(pubkey[1:])
+	if [20]byte(pubkeyHash[12:]) != addr {
+		return false, fmt.Errorf("invalid signature")
+	}
+	return true, nil
+}
+
+// decodeHexString decodes the hex string s into dst, which must be exactly half its length.
+func decodeHexString(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) {
+		return false
+	}
+	for i := range dst {
+		hi, ok1 := fromHexChar(s[2*i])
+		lo, ok2 := fromHexChar(s[2*i+1])
+		if !ok1 || !ok2 {
+			return false
+		}
+		dst[i] = hi<<4 | lo
+	}
+	return true
+}
+
+func fromHexChar(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
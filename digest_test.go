@@ -0,0 +1,165 @@
+package ethauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func benchmarkProof(b *testing.B) (*ETHAuth, string, *Proof) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(b, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithExpiresIn(time.Hour), WithNonce(1234), WithScope("read", "write"))
+	require.NoError(b, err)
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(b, err)
+	proof, err := Parse(proofString)
+	require.NoError(b, err)
+	return ethAuth, proofString, proof
+}
+
+func TestClaimsDigestV1(t *testing.T) {
+	values := Claims{
+		App: "ETHAuthTest", IssuedAt: -1700000000, ExpiresAt: 1700003600, Nonce: 1 << 63, Type: ProofTypeRequest,
+		Origin: "https://app.example.com", ChainID: 137, Audience: "https://api.example.com", Subject: "alice",
+		ID: "jti-1", Scope: Scopes{"read", "write"}, ETHAuthVersion: ETHAuthVersion, Confirmation: "0xabc",
+		RequestMethod: "POST", RequestPath: "/v1/orders", RequestBodyHash: "0x1234", ClientIP: "24:0x56", UserAgent: "Go-http-client/1.1",
+		Statement: "Sign in to Example.app", Host: "*.example.com",
+		MerkleRoot: "0x2c0c8e8bbd8a1e8bc9fe4b3a0d5bb4c4d9e1d0c3f6a4d9e2b1c0f9e8d7c6b5a4",
+	}
+
+	// each field on its own, and a sample of the sets of fields
+	var masks []uint32
+	for i := range claimsFieldsV1 {
+		masks = append(masks, 1<<i)
+	}
+	masks = append(masks, 1<<len(claimsFieldsV1)-1)
+	for mask := uint32(3); mask < 1<<len(claimsFieldsV1); mask += 7919 {
+		masks = append(masks, mask)
+	}
+
+	for _, mask := range masks {
+		var claims Claims
+		for i := range claimsFieldsV1 {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			switch claimsFieldsV1[i].name {
+			case "app":
+				claims.App = values.App
+			case "iat":
+				claims.IssuedAt = values.IssuedAt
+			case "exp":
+				claims.ExpiresAt = values.ExpiresAt
+			case "n":
+				claims.Nonce = values.Nonce
+			case "typ":
+				claims.Type = values.Type
+			case "ogn":
+				claims.Origin = values.Origin
+			case "cid":
+				claims.ChainID = values.ChainID
+			case "aud":
+				claims.Audience = values.Audience
+			case "sub":
+				claims.Subject = values.Subject
+			case "jti":
+				claims.ID = values.ID
+			case "scope":
+				claims.Scope = values.Scope
+			case "v":
+				claims.ETHAuthVersion = values.ETHAuthVersion
+			case "cnf":
+				claims.Confirmation = values.Confirmation
+			case "htm":
+				claims.RequestMethod = values.RequestMethod
+			case "htp":
+				claims.RequestPath = values.RequestPath
+			case "bdh":
+				claims.RequestBodyHash = values.RequestBodyHash
+			case "ip":
+				claims.ClientIP = values.ClientIP
+			case "ua":
+				claims.UserAgent = values.UserAgent
+			case "statement":
+				claims.Statement = values.Statement
+			case "dom":
+				claims.Host = values.Host
+			case "mrk":
+				claims.MerkleRoot = values.MerkleRoot
+			}
+		}
+		require.Equal(t, mask, claimsFieldsMask(&claims))
+
+		typedData, err := claimsTypedDataV1(claims)
+		require.NoError(t, err)
+		digest, expected, err := typedData.Encode()
+		require.NoError(t, err)
+
+		message, err := claims.Message()
+		require.NoError(t, err)
+		require.Equal(t, expected, message, "mask %x", mask)
+		messageDigest, err := claims.MessageDigest()
+		require.NoError(t, err)
+		require.Equal(t, digest, messageDigest, "mask %x", mask)
+	}
+
+	_, err := Claims{}.MessageDigest()
+	require.Error(t, err)
+}
+
+func TestValidateEOAProofAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops values with the race detector enabled")
+	}
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithScope("read"))
+	require.NoError(t, err)
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		ok, _, err := ValidateEOAProof(context.Background(), nil, nil, proof)
+		require.True(t, ok)
+		require.NoError(t, err)
+	})
+	require.Less(t, allocs, 3.0)
+}
+
+func BenchmarkClaimsMessageDigest(b *testing.B) {
+	_, _, proof := benchmarkProof(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := proof.Claims.MessageDigest(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateEOAProof(b *testing.B) {
+	_, _, proof := benchmarkProof(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, _, err := ValidateEOAProof(ctx, nil, nil, proof); !ok || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeProof(b *testing.B) {
+	ethAuth, proofString, _ := benchmarkProof(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if ok, _, err := ethAuth.DecodeProof(proofString); !ok || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package ethauth
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// DomainConfig is the EIP712 domain proofs are signed under, so each deployment may isolate
+// its proofs from those of other deployments, see ETHAuth.ConfigDomain and WithDomain. Zero
+// values are absent from the domain, and the chainId of claims bound to a chain by their `cid`
+// claim takes precedence over ChainID.
+type DomainConfig struct {
+	Name              string
+	Version           string
+	ChainID           uint64
+	VerifyingContract common.Address
+	Salt              common.Hash
+}
+
+// DefaultDomainConfig is the default EIP712 domain of proofs, `{name: "ETHAuth", version: "1"}`.
+var DefaultDomainConfig = DomainConfig{Name: eip712Domain.Name, Version: eip712Domain.Version}
+
+// key returns the domain key of the domain of claims bound to the chain id, or unbound to a
+// chain if chainID is zero.
+func (d DomainConfig) key(chainID uint64) domainKey {
+	if chainID == 0 {
+		chainID = d.ChainID
+	}
+	return domainKey{
+		name:              d.Name,
+		version:           d.Version,
+		chainID:           chainID,
+		hasChainID:        chainID != 0,
+		verifyingContract: d.VerifyingContract,
+		salt:              d.Salt,
+		hasSalt:           d.Salt != (common.Hash{}),
+	}
+}
+
+// ConfigAppDomains sets the EIP712 domain of the proofs of each app, by app name, so a gateway
+// fronting several dapps verifies the proofs of each under its own domain. Proofs of apps
+// without a domain are verified under the domain of ConfigDomain, see ConfigAllowedApps to
+// reject them.
+func (w *ETHAuth) ConfigAppDomains(domains map[string]DomainConfig) error {
+	appDomains := make(map[string]*DomainConfig, len(domains))
+	for app, domain := range domains {
+		if app == "" {
+			return fmt.Errorf("ethauth: app domain requires an app name")
+		}
+		if domain.Name == "" {
+			return fmt.Errorf("ethauth: app domain %q name is empty", app)
+		}
+		appDomains[app] = &domain
+	}
+	w.appDomains = appDomains
+
+	// precompute the domain separators of the claims unbound to a chain
+	for _, domain := range appDomains {
+		Claims{domain: domain}.DomainSeparator()
+	}
+	return nil
+}
+
+// proofDomain returns the EIP712 domain the proof is verified under, or nil for the default
+// domain.
+func (w *ETHAuth) proofDomain(proof *Proof) *DomainConfig {
+	if domain, ok := w.appDomains[proof.Claims.App]; ok {
+		return domain
+	}
+	return w.domain
+}
+
+// domainKey identifies an EIP712 domain in the domain separator cache. The chain id of
+// cached domains must fit in a uint64, and zero addresses and salts are absent fields.
+type domainKey struct {
+	name              string
+	version           string
+	chainID           uint64
+	hasChainID        bool
+	verifyingContract common.Address
+	salt              common.Hash
+	hasSalt           bool
+}
+
+// maxDomainSeparators bounds the domain separator cache, as the chain id of the claims
+// domain is chosen by the proof.
+const maxDomainSeparators = 1024
+
+var (
+	domainSeparatorsMu sync.RWMutex
+	domainSeparators   = map[domainKey]common.Hash{}
+)
+
+// DomainSeparator returns the EIP712 domain separator of the domain, ie. the hashStruct of the
+// domain which prefixes the message of each typed data signature, for contracts verifying
+// proofs on-chain. Domain separators are computed once per domain, and then cached.
+func DomainSeparator(domain ethcoder.TypedDataDomain) (common.Hash, error) {
+	key := domainKey{name: domain.Name, version: domain.Version}
+	if domain.ChainID != nil {
+		if !domain.ChainID.IsUint64() {
+			return computeDomainSeparator(domain)
+		}
+		key.chainID, key.hasChainID = domain.ChainID.Uint64(), true
+	}
+	if domain.VerifyingContract != nil {
+		key.verifyingContract = *domain.VerifyingContract
+	}
+	if domain.Salt != nil {
+		key.salt, key.hasSalt = *domain.Salt, true
+	}
+	return cachedDomainSeparator(key)
+}
+
+// DomainSeparator returns the EIP712 domain separator of the claims, see Claims.Domain.
+func (c Claims) DomainSeparator() common.Hash {
+	separator, _ := cachedDomainSeparator(c.domainConfig().key(c.ChainID))
+	return separator
+}
+
+// domainConfig returns the EIP712 domain config the claims are signed under.
+func (c *Claims) domainConfig() *DomainConfig {
+	if c.domain != nil {
+		return c.domain
+	}
+	return &DefaultDomainConfig
+}
+
+// cachedDomainSeparator returns the cached domain separator of the key, computing it on a
+// cache miss.
+func cachedDomainSeparator(key domainKey) (common.Hash, error) {
+	domainSeparatorsMu.RLock()
+	separator, ok := domainSeparators[key]
+	domainSeparatorsMu.RUnlock()
+	if ok {
+		return separator, nil
+	}
+
+	separator, err := computeDomainSeparator(key.domain())
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	domainSeparatorsMu.Lock()
+	if len(domainSeparators) < maxDomainSeparators {
+		domainSeparators[key] = separator
+	}
+	domainSeparatorsMu.Unlock()
+	return separator, nil
+}
+
+// domain returns the EIP712 domain of the key.
+func (k domainKey) domain() ethcoder.TypedDataDomain {
+	domain := ethcoder.TypedDataDomain{Name: k.name, Version: k.version}
+	if k.hasChainID {
+		domain.ChainID = new(big.Int).SetUint64(k.chainID)
+	}
+	if k.verifyingContract != (common.Address{}) {
+		verifyingContract := k.verifyingContract
+		domain.VerifyingContract = &verifyingContract
+	}
+	if k.hasSalt {
+		salt := k.salt
+		domain.Salt = &salt
+	}
+	return domain
+}
+
+// computeDomainSeparator computes the EIP712 domain separator of the domain, see domainType.
+func computeDomainSeparator(domain ethcoder.TypedDataDomain) (common.Hash, error) {
+	td := &ethcoder.TypedData{Types: ethcoder.TypedDataTypes{"EIP712Domain": domainType(domain)}}
+	separator, err := td.HashStruct("EIP712Domain", domain.Map())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(separator), nil
+}
+
+// domainType returns the EIP712Domain type of the domain, with the fields present in the
+// domain in the order of EIP712.
+func domainType(domain ethcoder.TypedDataDomain) []ethcoder.TypedDataArgument {
+	values := domain.Map()
+	fields := []ethcoder.TypedDataArgument{}
+	for _, arg := range []ethcoder.TypedDataArgument{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+		{Name: "salt", Type: "bytes32"},
+	} {
+		if _, ok := values[arg.Name]; ok {
+			fields = append(fields, arg)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,188 @@
+package ethauth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainSeparator(t *testing.T) {
+	for _, chainID := range []uint64{0, 1, 137} {
+		claims := Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ChainID: chainID, ETHAuthVersion: ETHAuthVersion}
+		message, err := claims.Message()
+		require.NoError(t, err)
+
+		// the message is prefixed by the domain separator
+		separator := claims.DomainSeparator()
+		require.Equal(t, message[2:34], separator.Bytes())
+		s, err := DomainSeparator(claims.Domain())
+		require.NoError(t, err)
+		require.Equal(t, separator, s)
+	}
+	require.NotEqual(t, Claims{}.DomainSeparator(), Claims{ChainID: 1}.DomainSeparator())
+
+	verifyingContract := common.HexToAddress("0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0")
+	salt := common.HexToHash("0x01")
+	for _, domain := range []ethcoder.TypedDataDomain{
+		{Name: "Verifier", Version: "2", ChainID: big.NewInt(1), VerifyingContract: &verifyingContract},
+		{Name: "Verifier", Version: "2", VerifyingContract: &verifyingContract, Salt: &salt},
+		{Name: "Verifier", ChainID: new(big.Int).Lsh(big.NewInt(1), 100)},
+	} {
+		types := []ethcoder.TypedDataArgument{}
+		for _, arg := range []ethcoder.TypedDataArgument{{Name: "name", Type: "string"}, {Name: "version", Type: "string"}, {Name: "chainId", Type: "uint256"}, {Name: "verifyingContract", Type: "address"}, {Name: "salt", Type: "bytes32"}} {
+			if _, ok := domain.Map()[arg.Name]; ok {
+				types = append(types, arg)
+			}
+		}
+		td := &ethcoder.TypedData{Types: ethcoder.TypedDataTypes{"EIP712Domain": types}}
+		expected, err := td.HashStruct("EIP712Domain", domain.Map())
+		require.NoError(t, err)
+
+		separator, err := DomainSeparator(domain)
+		require.NoError(t, err)
+		require.Equal(t, expected, separator.Bytes())
+	}
+}
+
+func TestDomainSeparatorCacheBound(t *testing.T) {
+	for i := uint64(0); i < 2*maxDomainSeparators; i++ {
+		Claims{ChainID: 1<<40 + i}.DomainSeparator()
+	}
+	domainSeparatorsMu.RLock()
+	defer domainSeparatorsMu.RUnlock()
+	require.LessOrEqual(t, len(domainSeparators), maxDomainSeparators)
+}
+
+func TestConfigDomain(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	domain := DomainConfig{
+		Name: "Acme", Version: "2", ChainID: 10,
+		VerifyingContract: common.HexToAddress("0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0"), Salt: common.HexToHash("0x01"),
+	}
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithDomain(domain))
+	require.NoError(t, err)
+
+	// the proof is only valid under its domain
+	defaultAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	_, _, err = defaultAuth.DecodeProof(proofString)
+	require.Error(t, err)
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.Error(t, ethAuth.ConfigDomain(DomainConfig{Version: "2"}))
+	require.NoError(t, ethAuth.ConfigDomain(domain))
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "Acme", proof.Claims.Domain().Name)
+	require.Equal(t, big.NewInt(10), proof.Claims.Domain().ChainID)
+
+	otherAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.NoError(t, otherAuth.ConfigDomain(DomainConfig{Name: "Acme", Version: "3"}))
+	_, _, err = otherAuth.DecodeProof(proofString)
+	require.Error(t, err)
+
+	// proofs of the default domain are rejected by the configured instance
+	proofString, err = Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.Error(t, err)
+	_, _, err = defaultAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	// the proof is not verifiable by the EWTVerifier contract of the default domain
+	_, err = proof.ToCalldata()
+	require.Error(t, err)
+}
+
+func TestDomainConfigMessage(t *testing.T) {
+	for _, domain := range []DomainConfig{
+		DefaultDomainConfig,
+		{Name: "Acme"},
+		{Name: "Acme", Version: "2", ChainID: 10},
+		{Name: "Acme", Version: "2", VerifyingContract: common.HexToAddress("0x01"), Salt: common.HexToHash("0x02")},
+	} {
+		for _, chainID := range []uint64{0, 137} {
+			claims := Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ChainID: chainID, domain: &domain}
+
+			// the cid claim takes precedence over the chain id of the domain
+			expectedChainID := chainID
+			if chainID == 0 {
+				expectedChainID = domain.ChainID
+			}
+			if expectedChainID == 0 {
+				require.Nil(t, claims.Domain().ChainID)
+			} else {
+				require.Equal(t, new(big.Int).SetUint64(expectedChainID), claims.Domain().ChainID)
+			}
+
+			typedData, err := claimsTypedDataV1(claims)
+			require.NoError(t, err)
+			_, expected, err := typedData.Encode()
+			require.NoError(t, err)
+			message, err := claims.Message()
+			require.NoError(t, err)
+			require.Equal(t, expected, message)
+			require.Equal(t, message[2:34], claims.DomainSeparator().Bytes())
+		}
+	}
+	require.Equal(t, Claims{}.Domain(), Claims{domain: &DefaultDomainConfig}.Domain())
+}
+
+func TestConfigAppDomains(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	signer := NewWalletSigner(wallet)
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.Error(t, ethAuth.ConfigAppDomains(map[string]DomainConfig{"": {Name: "Acme"}}))
+	require.Error(t, ethAuth.ConfigAppDomains(map[string]DomainConfig{"acme": {}}))
+	require.NoError(t, ethAuth.ConfigAppDomains(map[string]DomainConfig{
+		"acme":   {Name: "Acme", Version: "1"},
+		"globex": {Name: "Globex", Version: "2", ChainID: 1},
+	}))
+
+	for _, tc := range []struct {
+		app    string
+		domain *DomainConfig
+		valid  bool
+	}{
+		{"acme", &DomainConfig{Name: "Acme", Version: "1"}, true},
+		{"globex", &DomainConfig{Name: "Globex", Version: "2", ChainID: 1}, true},
+		{"other", nil, true},
+		{"acme", &DomainConfig{Name: "Globex", Version: "2", ChainID: 1}, false},
+		{"globex", nil, false},
+		{"other", &DomainConfig{Name: "Acme", Version: "1"}, false},
+	} {
+		opts := []IssueOption{WithApp(tc.app)}
+		if tc.domain != nil {
+			opts = append(opts, WithDomain(*tc.domain))
+		}
+		proofString, err := Issue(signer, opts...)
+		require.NoError(t, err)
+
+		ok, _, err := ethAuth.DecodeProof(proofString)
+		if tc.valid {
+			require.NoError(t, err, tc.app)
+			require.True(t, ok)
+		} else {
+			require.Error(t, err, tc.app)
+		}
+	}
+
+	// apps without a domain fall back to the configured domain
+	require.NoError(t, ethAuth.ConfigDomain(DomainConfig{Name: "Initech"}))
+	proofString, err := Issue(signer, WithApp("other"), WithDomain(DomainConfig{Name: "Initech"}))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+package ethauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto/ecies"
+)
+
+// EncryptedPrefix is the prefix of encrypted proof strings, see EncryptProof.
+const EncryptedPrefix = "ewe"
+
+// eciesOverhead is the length ECIES adds to the proof strings it encrypts: the ephemeral public
+// key, the IV and the MAC of the ciphertext.
+const eciesOverhead = 65 + 16 + 32
+
+// eciesSharedInfo separates the keys derived to encrypt proofs from other uses of the server key.
+var eciesSharedInfo = []byte("ethauth:ewe")
+
+// EncryptProof encrypts a signed proof string to the secp256k1 public key of the server with
+// ECIES, returning an encrypted proof string of `ewe.<ciphertext>`, so its address and
+// confidential claims, ie. an email or internal user id, can't be read by anyone seeing the
+// bearer proof in transit or in logs. The encrypted proof is decoded by ETHAuth instances
+// configured with the server private key, see ConfigDecryptionKey.
+func EncryptProof(proofString string, serverKey *ecdsa.PublicKey) (string, error) {
+	if serverKey == nil {
+		return "", fmt.Errorf("ethauth: encryption key is nil")
+	}
+	if !isProofString(proofString) {
+		return "", fmt.Errorf("ethauth: not an ethauth proof")
+	}
+	ciphertext, err := ecies.Encrypt(rand.Reader, ecies.ImportECDSAPublic(serverKey), []byte(proofString), eciesSharedInfo, nil)
+	if err != nil {
+		return "", fmt.Errorf("ethauth: failed to encrypt proof - %w", err)
+	}
+	return EncryptedPrefix + "." + Base64UrlEncode(ciphertext), nil
+}
+
+// DecryptProof decrypts an encrypted proof string with the server private key, returning
+// the signed proof string. Encrypted proofs longer than the encryption of a proof string of
+// MaxProofLength, or of the MaxProofLength of the limits, are rejected before decoding.
+func DecryptProof(encryptedProof string, serverKey *ecdsa.PrivateKey, optLimits ...ParseLimits) (string, error) {
+	if serverKey == nil {
+		return "", fmt.Errorf("ethauth: encrypted proof requires a decryption key")
+	}
+	maxLength := len(EncryptedPrefix) + 1 + base64.URLEncoding.EncodedLen(int(parseLimits(optLimits).MaxProofLength)+eciesOverhead)
+	if len(encryptedProof) > maxLength {
+		return "", fmt.Errorf("ethauth: invalid encrypted proof string, exceeds %d bytes", maxLength)
+	}
+	ciphertextBase64, ok := strings.CutPrefix(encryptedProof, EncryptedPrefix+".")
+	if !ok {
+		return "", fmt.Errorf("ethauth: not an encrypted ethauth proof")
+	}
+	ciphertext, err := Base64UrlDecode(ciphertextBase64)
+	if err != nil {
+		return "", ErrInvalidEncryptedProof
+	}
+	plaintext, err := ecies.ImportECDSA(serverKey).Decrypt(ciphertext, eciesSharedInfo, nil)
+	if err != nil || !isProofString(string(plaintext)) {
+		return "", ErrInvalidEncryptedProof
+	}
+	return string(plaintext), nil
+}
+
+// IsEncryptedProof reports whether the proof string is an encrypted proof, see EncryptProof.
+func IsEncryptedProof(proofString string) bool {
+	return strings.HasPrefix(proofString, EncryptedPrefix+".")
+}
+
+// ConfigDecryptionKey sets the server private key decrypting encrypted proofs, so
+// DecodeProof accepts both encrypted and plain proof strings.
+func (w *ETHAuth) ConfigDecryptionKey(key *ecdsa.PrivateKey) {
+	w.decryptionKey = key
+}
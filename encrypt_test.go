@@ -0,0 +1,74 @@
+package ethauth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedProofs(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithSubject("alice@example.com"))
+	require.NoError(t, err)
+
+	serverKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	encrypted, err := EncryptProof(proofString, &serverKey.PublicKey)
+	require.NoError(t, err)
+	require.True(t, IsEncryptedProof(encrypted))
+	require.True(t, strings.HasPrefix(encrypted, "ewe."))
+
+	// the claims and address aren't readable from the encrypted proof
+	_, err = Parse(encrypted)
+	require.Error(t, err)
+	for _, s := range []string{"alice", Base64UrlEncode([]byte("alice@example.com")), "e0c9828dee3411a28ccb4bb82a18d0aad24489e0"} {
+		require.NotContains(t, strings.ToLower(encrypted), strings.ToLower(s))
+	}
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	// encrypted proofs require the decryption key
+	_, _, err = ethAuth.DecodeProof(encrypted)
+	require.Error(t, err)
+
+	ethAuth.ConfigDecryptionKey(serverKey)
+	ok, proof, err := ethAuth.DecodeProof(encrypted)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "alice@example.com", proof.Claims.Subject)
+
+	// and plain proofs are still accepted
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	// proofs encrypted to another key, or tampered, can't be decrypted
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := EncryptProof(proofString, &otherKey.PublicKey)
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(other)
+	require.ErrorIs(t, err, ErrInvalidEncryptedProof)
+
+	tampered := encrypted[:len(encrypted)-4] + "AAAA"
+	_, _, err = ethAuth.DecodeProof(tampered)
+	require.ErrorIs(t, err, ErrInvalidEncryptedProof)
+
+	_, err = EncryptProof("not a proof", &serverKey.PublicKey)
+	require.Error(t, err)
+
+	// encrypted proofs longer than the encryption of the longest proof string are rejected
+	// before decoding
+	longest, err := EncryptProof(ETHAuthPrefix+"."+strings.Repeat("a", MaxProofLength-len(ETHAuthPrefix)-1), &serverKey.PublicKey)
+	require.NoError(t, err)
+	_, err = DecryptProof(longest, serverKey)
+	require.NoError(t, err)
+	_, err = DecryptProof(longest+"AAAA", serverKey)
+	require.ErrorContains(t, err, "exceeds")
+	_, err = DecryptProof(longest, serverKey, ParseLimits{MaxProofLength: 1024})
+	require.ErrorContains(t, err, "exceeds")
+}
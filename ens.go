@@ -0,0 +1,139 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ENSRegistryAddress is the address of the ENS registry on Ethereum mainnet and its testnets.
+var ENSRegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+var ensABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[
+		{"name":"resolver","type":"function","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+		{"name":"addr","type":"function","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"address"}]},
+		{"name":"name","type":"function","stateMutability":"view","inputs":[{"name":"node","type":"bytes32"}],"outputs":[{"name":"","type":"string"}]}]`))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// ENSResolver resolves ENS names of proof addresses. Once configured with
+// ETHAuth.ConfigENSResolver, decoded proofs carry the reverse-resolved ENS name of their
+// address in Proof.ENSName, and proofs may be issued for an ENS name in place of an address,
+// see ETHAuth.ResolveProofAddress.
+type ENSResolver struct {
+	provider *ethrpc.Provider
+	registry common.Address
+}
+
+// NewENSResolver returns an ENSResolver resolving names with the ENS registry of the provider
+// chain, which defaults to ENSRegistryAddress.
+func NewENSResolver(provider *ethrpc.Provider, optRegistry ...common.Address) (*ENSResolver, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethauth: ENS resolver provider is nil")
+	}
+	r := &ENSResolver{provider: provider, registry: ENSRegistryAddress}
+	if len(optRegistry) > 0 {
+		r.registry = optRegistry[0]
+	}
+	return r, nil
+}
+
+// ResolveName returns the address an ENS name resolves to.
+func (r *ENSResolver) ResolveName(ctx context.Context, name string) (common.Address, error) {
+	node, err := ENSNameHash(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var addr common.Address
+	err = r.resolve(ctx, node, "addr", &addr)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if addr == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("ethauth: ENS name %q does not resolve to an address", name)
+	}
+	return addr, nil
+}
+
+// LookupAddress returns the primary ENS name of an address, or an empty name if the address
+// has none. As required by ENS, the name is only returned if it resolves back to the address.
+func (r *ENSResolver) LookupAddress(ctx context.Context, address common.Address) (string, error) {
+	node, err := ENSNameHash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+	if err != nil {
+		return "", err
+	}
+	var name string
+	err = r.resolve(ctx, node, "name", &name)
+	if err != nil || name == "" {
+		return "", err
+	}
+	resolved, err := r.ResolveName(ctx, name)
+	if err != nil || resolved != address {
+		return "", nil
+	}
+	return name, nil
+}
+
+// resolve calls the method of the resolver of the node, returning a zero result if the node
+// has no resolver.
+func (r *ENSResolver) resolve(ctx context.Context, node common.Hash, method string, result interface{}) error {
+	var resolver common.Address
+	if err := r.call(ctx, r.registry, "resolver", node, &resolver); err != nil {
+		return err
+	}
+	if resolver == (common.Address{}) {
+		return nil
+	}
+	return r.call(ctx, resolver, method, node, result)
+}
+
+func (r *ENSResolver) call(ctx context.Context, contract common.Address, method string, node common.Hash, result interface{}) error {
+	input, err := ensABI.Pack(method, node)
+	if err != nil {
+		return fmt.Errorf("ethauth: unable to encode ENS %s call - %w", method, err)
+	}
+	output, err := r.provider.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: input}, nil)
+	if err != nil {
+		return fmt.Errorf("ethauth: ENS %s call failed - %w", method, err)
+	}
+	if len(output) == 0 {
+		return nil
+	}
+	values, err := ensABI.Unpack(method, output)
+	if err != nil || len(values) != 1 {
+		return fmt.Errorf("ethauth: unable to decode ENS %s result", method)
+	}
+	return ensABI.Methods[method].Outputs.Copy(result, values)
+}
+
+// ENSNameHash returns the EIP-137 namehash of an ENS name. Names are normalized by
+// lowercasing, so only ASCII names are supported.
+func ENSNameHash(name string) (common.Hash, error) {
+	var node common.Hash
+	if name == "" {
+		return node, nil
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		if labels[i] == "" {
+			return common.Hash{}, fmt.Errorf("ethauth: invalid ENS name %q", name)
+		}
+		node = crypto.Keccak256Hash(node[:], crypto.Keccak256([]byte(labels[i])))
+	}
+	return node, nil
+}
+
+// isENSName reports whether a proof address is an ENS name rather than a hex address.
+func isENSName(address string) bool {
+	return strings.Contains(address, ".") && !strings.HasPrefix(address, "0x")
+}
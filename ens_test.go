@@ -0,0 +1,145 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// newENSTestServer returns a JSON-RPC server answering the ENS eth_calls of a registry where
+// name resolves to address, and address reverse-resolves to name.
+func newENSTestServer(t *testing.T, name string, address common.Address) *httptest.Server {
+	resolver := common.HexToAddress("0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41")
+	nameNode, err := ENSNameHash(name)
+	require.NoError(t, err)
+	reverseNode, err := ENSNameHash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "eth_call", req.Method)
+
+		var msg struct {
+			To    common.Address `json:"to"`
+			Input string         `json:"input"`
+			Data  string         `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(req.Params[0], &msg))
+		input := msg.Input
+		if input == "" {
+			input = msg.Data
+		}
+		data := ethcoder.MustHexDecode(input)
+		method, err := ensABI.MethodById(data[:4])
+		require.NoError(t, err)
+		node := common.BytesToHash(data[4:])
+
+		var result []byte
+		switch {
+		case msg.To == ENSRegistryAddress && method.Name == "resolver" && (node == nameNode || node == reverseNode):
+			result, err = method.Outputs.Pack(resolver)
+		case msg.To == resolver && method.Name == "addr" && node == nameNode:
+			result, err = method.Outputs.Pack(address)
+		case msg.To == resolver && method.Name == "name" && node == reverseNode:
+			result, err = method.Outputs.Pack(name)
+		case method.Name == "name":
+			result, err = method.Outputs.Pack("")
+		default:
+			result, err = method.Outputs.Pack(common.Address{})
+		}
+		require.NoError(t, err)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": ethcoder.HexEncode(result)})
+	}))
+}
+
+func TestENSNameHash(t *testing.T) {
+	node, err := ENSNameHash("")
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{}, node)
+
+	// EIP-137 test vectors
+	node, err = ENSNameHash("eth")
+	require.NoError(t, err)
+	require.Equal(t, "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", node.Hex())
+	node, err = ENSNameHash("foo.eth")
+	require.NoError(t, err)
+	require.Equal(t, "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", node.Hex())
+
+	upper, err := ENSNameHash("FOO.eth")
+	require.NoError(t, err)
+	require.Equal(t, node, upper)
+
+	_, err = ENSNameHash("foo..eth")
+	require.Error(t, err)
+}
+
+func TestENSResolver(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	server := newENSTestServer(t, "alice.eth", wallet.Address())
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+	resolver, err := NewENSResolver(provider)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	address, err := resolver.ResolveName(ctx, "alice.eth")
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address(), address)
+	_, err = resolver.ResolveName(ctx, "bob.eth")
+	require.Error(t, err)
+
+	name, err := resolver.LookupAddress(ctx, wallet.Address())
+	require.NoError(t, err)
+	require.Equal(t, "alice.eth", name)
+	name, err = resolver.LookupAddress(ctx, common.HexToAddress("0x1111111111111111111111111111111111111111"))
+	require.NoError(t, err)
+	require.Empty(t, name)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proof := signTestProof(t, wallet, claims)
+	proof.Address = "alice.eth"
+
+	// an ENS name address requires a resolver
+	_, err = ethAuth.EncodeProof(proof)
+	require.Error(t, err)
+
+	ethAuth.ConfigENSResolver(resolver)
+
+	// the ENS name is resolved and pinned at issuance
+	proofString, err := ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address().Hex(), proof.Address)
+	require.Equal(t, "alice.eth", proof.ENSName)
+	require.True(t, strings.HasPrefix(proofString, "eth."+strings.ToLower(wallet.Address().Hex())+"."))
+
+	// and reverse-resolved at verification
+	ok, decoded, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "alice.eth", decoded.ENSName)
+}
@@ -0,0 +1,44 @@
+package ethauth
+
+import (
+	"errors"
+)
+
+var (
+	ErrProofExpired             = errors.New("claims: proof has expired")
+	ErrIssuedInFuture           = errors.New("claims: proof is issued from the future - check if device clock is synced.")
+	ErrMissingApp               = errors.New("claims: app is empty")
+	ErrMissingIssuedAt          = errors.New("claims: iat is empty")
+	ErrInvalidExpiry            = errors.New("claims: exp must be after iat")
+	ErrBadVersion               = errors.New("claims: ethauth version is empty")
+	ErrUnsupportedVersion       = errors.New("claims: ethauth version is not supported")
+	ErrDeprecatedVersion        = errors.New("claims: ethauth version is no longer accepted")
+	ErrInvalidAudience          = errors.New("claims: proof audience is not accepted")
+	ErrInvalidChainID           = errors.New("claims: proof chainId is not accepted")
+	ErrInvalidApp               = errors.New("claims: proof app is not accepted")
+	ErrInvalidOrigin            = errors.New("claims: proof origin is not accepted")
+	ErrMissingScope             = errors.New("claims: proof scope is insufficient")
+	ErrLifetimeExceeded         = errors.New("claims: proof lifetime exceeds the app policy")
+	ErrScopeNotAllowed          = errors.New("claims: proof scope is not allowed by the app policy")
+	ErrInvalidAddress           = errors.New("ethauth: invalid address")
+	ErrInvalidSignature         = errors.New("ethauth: proof signature is invalid")
+	ErrInvalidGuardianSignature = errors.New("ethauth: proof guardian signature is invalid")
+	ErrMalleableSignature       = errors.New("ethauth: signature s value is not in the lower half of the curve order")
+	ErrMissingNonce             = errors.New("claims: n is empty")
+	ErrUnboundedNonce           = errors.New("claims: proofs with a nonce require an exp or iat claim")
+	ErrInvalidChallenge         = errors.New("ethauth: proof nonce does not answer an outstanding challenge")
+	ErrNonceUsed                = errors.New("ethauth: proof nonce has already been used")
+	ErrProofRevoked             = errors.New("ethauth: proof has been revoked")
+	ErrInvalidConfirmation      = errors.New("claims: cnf is not a key address or certificate thumbprint")
+	ErrInvalidPoP               = errors.New("ethauth: proof-of-possession is invalid")
+	ErrInvalidRequestBinding    = errors.New("ethauth: proof is not bound to the request")
+	ErrInvalidClientBinding     = errors.New("ethauth: proof is not bound to the client")
+	ErrInvalidHostBinding       = errors.New("ethauth: proof is not bound to the request host")
+	ErrInvalidMerkleProof       = errors.New("ethauth: merkle proof of the proof account is invalid")
+	ErrUnknownSmartAccount      = errors.New("ethauth: proof account is not a smart account of a known factory and implementation")
+	ErrTokenOwnership           = errors.New("ethauth: proof account does not hold the tokens of the token gate")
+	ErrInvalidEncryptedProof    = errors.New("ethauth: encrypted proof can't be decrypted")
+	ErrUntrustedExchanger       = errors.New("ethauth: exchanged proof is not signed by a trusted exchanger")
+	ErrInvalidExchangeAudience  = errors.New("ethauth: tokens can't be exchanged for audience")
+	ErrInvalidExchangeScope     = errors.New("ethauth: exchanged scope is wider than the scope of the subject token")
+)
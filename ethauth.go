@@ -3,22 +3,67 @@ package ethauth
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 )
 
+// ETHAuth handles the full lifecycle of an ETHAuth proof: encoding a signed Proof into
+// a proof string, and decoding a proof string back into a Proof while validating its
+// claims and signature against the configured validators.
 type ETHAuth struct {
 	validators []ValidatorFunc
 
 	ethereumJsonRpcURL string
 	provider           *ethrpc.Provider
 	chainID            *big.Int
+
+	validatorConfig ValidatorConfig
+	clock           func() time.Time
+	audiences       []string
+	apps            []string
+	chains          []uint64
+	origins         []originPattern
+	requiredScopes  []string
+	customClaims    func() ClaimsProvider
+	nonceStore      NonceStore
+	replayStore     ReplayStore
+	tokenGates      *tokenGates
+	roleResolver    RoleResolver
+	smartAccounts   *smartAccounts
+	revocationStore RevocationStore
+	cache           *VerificationCache
+	guardian        common.Address
+	guardianKeys    *GuardianKeySet
+	versionSunsets  map[string]time.Time
+	ensResolver     *ENSResolver
+	challenges      *ChallengeManager
+	hooks           Hooks
+	auditLogger     AuditLogger
+	appPolicies     map[string]AppPolicy
+
+	ipBinding        BindingMode
+	userAgentBinding BindingMode
+	decryptionKey    *ecdsa.PrivateKey
+	domain           *DomainConfig
+	appDomains       map[string]*DomainConfig
+	exchangers       []common.Address
+	sessionRegistry  SessionStore
+	signatureBlocks  *signatureBlocks
+	failover         *failoverClient
+	witnessVerifiers map[string]WitnessVerifier
+	tracer           Tracer
+	logger           Logger
 }
 
 const (
@@ -32,10 +77,13 @@ var eip712Domain = ethcoder.TypedDataDomain{
 	Version: ETHAuthVersion,
 }
 
+// New returns an ETHAuth instance using the validators passed, or the default
+// ValidateEOAProof, ValidateContractAccountProof and ValidateERC6492Proof validators
+// if none are given.
 func New(validators ...ValidatorFunc) (*ETHAuth, error) {
-	ea := &ETHAuth{validators: validators}
+	ea := &ETHAuth{validators: validators, validatorConfig: DefaultValidatorConfig, clock: time.Now}
 	if len(ea.validators) == 0 {
-		ea.validators = []ValidatorFunc{ValidateEOAProof, ValidateContractAccountProof}
+		ea.validators = []ValidatorFunc{ValidateEOAProof, ValidateContractAccountProof, ValidateERC6492Proof}
 	}
 	err := ea.ConfigValidators(ea.validators...)
 	if err != nil {
@@ -45,23 +93,37 @@ func New(validators ...ValidatorFunc) (*ETHAuth, error) {
 }
 
 func (w *ETHAuth) ConfigJsonRpcProvider(ethereumJsonRpcURL string, optChainId ...int64) error {
-	var err error
-
-	w.provider, err = ethrpc.NewProvider(ethereumJsonRpcURL)
+	provider, err := ethrpc.NewProvider(ethereumJsonRpcURL)
 	if err != nil {
 		return err
 	}
+	if err := w.configProvider(provider, ethereumJsonRpcURL, optChainId); err != nil {
+		return err
+	}
+	w.failover = nil
+	return nil
+}
 
+// configProvider sets the JSON-RPC provider, and the chain ID, which is fetched from the
+// provider unless given.
+func (w *ETHAuth) configProvider(provider *ethrpc.Provider, ethereumJsonRpcURL string, optChainId []int64) error {
+	var chainID *big.Int
 	if len(optChainId) > 0 {
-		w.chainID = big.NewInt(optChainId[0])
+		chainID = big.NewInt(optChainId[0])
 	} else {
-		chainID, err := w.provider.ChainID(context.Background())
+		var err error
+		chainID, err = provider.ChainID(context.Background())
 		if err != nil {
 			return err
 		}
-		w.chainID = chainID
 	}
 
+	// precompute the domain separator of the claims of the chain
+	if chainID.IsUint64() {
+		Claims{ChainID: chainID.Uint64(), domain: w.domain}.DomainSeparator()
+	}
+
+	w.provider, w.chainID = provider, chainID
 	w.ethereumJsonRpcURL = ethereumJsonRpcURL
 	return nil
 }
@@ -74,19 +136,173 @@ func (w *ETHAuth) ConfigValidators(validators ...ValidatorFunc) error {
 	return nil
 }
 
+// ConfigValidatorConfig sets the time-based validation config of the proof claims,
+// see DefaultValidatorConfig for the default.
+func (w *ETHAuth) ConfigValidatorConfig(cfg ValidatorConfig) {
+	w.validatorConfig = cfg
+}
+
+// ConfigClock sets the clock used to validate the proof claims, which defaults to time.Now.
+// This is useful for tests, or to validate a proof as of a specific point in time.
+func (w *ETHAuth) ConfigClock(clock func() time.Time) error {
+	if clock == nil {
+		return fmt.Errorf("ethauth: clock is nil")
+	}
+	w.clock = clock
+	return nil
+}
+
+// ConfigExpectedAudience scopes the proofs accepted by this ETHAuth instance to those
+// whose `aud` claim matches one of the audiences passed. Proofs without an audience
+// are rejected once an expected audience has been configured.
+func (w *ETHAuth) ConfigExpectedAudience(audiences ...string) error {
+	for _, aud := range audiences {
+		if aud == "" {
+			return fmt.Errorf("ethauth: expected audience is empty")
+		}
+	}
+	w.audiences = audiences
+	return nil
+}
+
+// ConfigAllowedApps scopes the proofs accepted by this ETHAuth instance to those whose
+// `app` claim is one of the apps passed, so a backend serving several dapps can reject
+// proofs issued for another one.
+func (w *ETHAuth) ConfigAllowedApps(apps ...string) error {
+	for _, app := range apps {
+		if app == "" {
+			return fmt.Errorf("ethauth: allowed app is empty")
+		}
+	}
+	w.apps = apps
+	return nil
+}
+
+// ConfigAllowedChains scopes the proofs accepted by this ETHAuth instance to those whose
+// `cid` claim is one of the chains passed, so a multi-chain product rejects the identities of
+// other chains. Proofs unbound to a chain are rejected once allowed chains have been
+// configured.
+func (w *ETHAuth) ConfigAllowedChains(chainIDs ...uint64) error {
+	for _, chainID := range chainIDs {
+		if chainID == 0 {
+			return fmt.Errorf("ethauth: allowed chainId is zero")
+		}
+	}
+	w.chains = chainIDs
+	return nil
+}
+
+// ConfigAllowedOrigins scopes the proofs accepted by this ETHAuth instance to those whose
+// `ogn` claim matches one of the origins passed. Origins may be exact origins such as
+// "https://app.example.com", hosts matching any scheme such as "app.example.com", or
+// wildcard subdomains such as "*.example.com" or "https://*.example.com". Proofs without an
+// origin are rejected once allowed origins have been configured.
+func (w *ETHAuth) ConfigAllowedOrigins(origins ...string) error {
+	patterns := make([]originPattern, 0, len(origins))
+	for _, origin := range origins {
+		p, err := parseOriginPattern(origin)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, p)
+	}
+	w.origins = patterns
+	return nil
+}
+
+// ConfigRequiredScopes sets the scopes every decoded proof must have in its `scope` claim.
+// Use RequireScope to require further scopes for specific handlers.
+func (w *ETHAuth) ConfigRequiredScopes(scopes ...string) {
+	w.requiredScopes = scopes
+}
+
+// ConfigDeprecatedVersion deprecates proofs of a registered claims version, which are
+// accepted until the sunset time and rejected with ErrDeprecatedVersion from then on. Use
+// a zero sunset time to reject proofs of the version right away.
+func (w *ETHAuth) ConfigDeprecatedVersion(version string, sunset time.Time) error {
+	if _, ok := LookupClaimsVersion(version); !ok {
+		return fmt.Errorf("ethauth: %w, version %q", ErrUnsupportedVersion, version)
+	}
+	if w.versionSunsets == nil {
+		w.versionSunsets = map[string]time.Time{}
+	}
+	w.versionSunsets[version] = sunset
+	return nil
+}
+
+// ConfigDomain sets the EIP712 domain proofs are signed under, isolating the proofs of this
+// deployment from those signed under DefaultDomainConfig or the domain of other deployments.
+// Proofs are then only valid when signed under the domain, see WithDomain to issue them.
+func (w *ETHAuth) ConfigDomain(domain DomainConfig) error {
+	if domain.Name == "" {
+		return fmt.Errorf("ethauth: domain name is empty")
+	}
+	w.domain = &domain
+
+	// precompute the domain separator of the claims unbound to a chain
+	Claims{domain: w.domain}.DomainSeparator()
+	return nil
+}
+
+// ConfigCustomClaims sets the constructor of the custom application claims, which DecodeProof
+// uses to decode the custom claims of a proof into Claims.Custom. The constructor must return
+// a pointer so the custom claims can be unmarshalled into it. Proofs with claims which are
+// neither standard nor custom claims are rejected.
+func (w *ETHAuth) ConfigCustomClaims(newClaims func() ClaimsProvider) {
+	w.customClaims = newClaims
+}
+
+// ConfigNonceStore enables replay protection of decoded proofs. Once set, DecodeProof
+// requires proofs to carry a nonce `n` claim, which is consumed from the store so the
+// same proof can't be decoded twice within its lifetime, including the expiration leeway.
+// Proofs without an `exp` claim are remembered for the MaxAge of the validator config from
+// their `iat` claim, and proofs with neither are rejected with ErrUnboundedNonce.
+func (w *ETHAuth) ConfigNonceStore(store NonceStore) {
+	if s, ok := store.(clockedStore); ok {
+		s.ConfigClock(func() time.Time { return w.clock() })
+	}
+	w.nonceStore = store
+}
+
+// ConfigChallengeManager requires decoded proofs to answer an outstanding challenge of the
+// ChallengeManager, which the proof `n` claim consumes, or disables challenges if nil.
+func (w *ETHAuth) ConfigChallengeManager(challenges *ChallengeManager) {
+	if challenges != nil {
+		challenges.ConfigClock(func() time.Time { return w.clock() })
+	}
+	w.challenges = challenges
+}
+
+// ConfigCache sets a cache of successful proof signature verifications, or disables
+// caching if nil.
+func (w *ETHAuth) ConfigCache(cache *VerificationCache) {
+	w.cache = cache
+}
+
+// ConfigENSResolver sets the resolver of the ENS names of proof addresses, or disables
+// ENS resolution if nil.
+func (w *ETHAuth) ConfigENSResolver(resolver *ENSResolver) {
+	w.ensResolver = resolver
+}
+
+// ConfigRevocationStore enables revocation checks of decoded proofs.
+func (w *ETHAuth) ConfigRevocationStore(store RevocationStore) {
+	w.revocationStore = store
+}
+
 // EncodeProof will encode a Proof object, validate it and return the ETHAuth proof string
 func (w *ETHAuth) EncodeProof(proof *Proof) (string, error) {
 	if proof == nil {
 		return "", fmt.Errorf("ethauth: proof is nil")
 	}
-	if proof.Address == "" || len(proof.Address) != 42 || proof.Address[0:2] != "0x" {
-		return "", fmt.Errorf("ethauth: invalid address")
+	if err := proof.parseAccountID(); err != nil {
+		return "", err
 	}
-	if proof.Signature == "" || proof.Signature[0:2] != "0x" {
-		return "", fmt.Errorf("ethauth: signature")
+	if err := w.ResolveProofAddress(context.Background(), proof); err != nil {
+		return "", err
 	}
-	if proof.Extra != "" && !strings.HasPrefix(proof.Extra, "0x") {
-		return "", fmt.Errorf("ethauth: invalid extra encoding, expecting hex data")
+	if err := proof.validateEncoding(); err != nil {
+		return "", err
 	}
 
 	// Validate proof signature and claims
@@ -95,129 +311,384 @@ func (w *ETHAuth) EncodeProof(proof *Proof) (string, error) {
 		return "", err
 	}
 
-	claimsJSON, err := json.Marshal(proof.Claims)
-	if err != nil {
-		return "", fmt.Errorf("ethauth: cannot marshal proof claims - %w", err)
+	// Normalize the proof address to its EIP-55 checksum
+	if address, err := proof.AddressBytes(); err == nil {
+		proof.Address = address.Hex()
 	}
 
-	// Encode the proof string
-	var pb bytes.Buffer
+	return proof.Encode()
+}
 
-	// prefix
-	pb.WriteString(ETHAuthPrefix)
-	pb.WriteString(".")
+// ResolveProofAddress forward-resolves the proof address if it is an ENS name, pinning the
+// resolved address as the proof address and the name as its ENSName. EncodeProof resolves
+// the proof address, so an ENS name may be given as the address of a proof at issuance, but
+// SIWE proofs, whose message includes the address, must be resolved before they are signed.
+func (w *ETHAuth) ResolveProofAddress(ctx context.Context, proof *Proof) error {
+	if !isENSName(proof.Address) {
+		return nil
+	}
+	if w.ensResolver == nil {
+		return fmt.Errorf("ethauth: proof address is an ENS name, but no ENS resolver is configured")
+	}
+	address, err := w.ensResolver.ResolveName(ctx, proof.Address)
+	if err != nil {
+		return err
+	}
+	proof.ENSName = strings.ToLower(proof.Address)
+	proof.Address = address.Hex()
+	return nil
+}
 
-	// address
-	pb.WriteString(strings.ToLower(proof.Address))
-	pb.WriteString(".")
+// DecodeProof will decode an ETHAuth proof string, validate it, and return a Proof object
+func (w *ETHAuth) DecodeProof(proofString string) (bool, *Proof, error) {
+	return w.DecodeProofContext(context.Background(), proofString)
+}
 
-	// message base64 encoded
-	pb.WriteString(Base64UrlEncode(claimsJSON))
-	pb.WriteString(".")
+// DecodeProofContext is DecodeProof with the context of the request of the proof, which the
+// RPC calls of its validators and the calls of its stores are made with.
+func (w *ETHAuth) DecodeProofContext(ctx context.Context, proofString string) (bool, *Proof, error) {
+	return w.decodeProof(ctx, proofString, nil)
+}
 
-	// signature
-	pb.WriteString(proof.Signature)
+// decodeProof decodes and verifies a proof string, running the check of the request of the
+// proof, if any, before the proof is recorded by the stores, see verifyParsedProof.
+func (w *ETHAuth) decodeProof(ctx context.Context, proofString string, check func(proof *Proof) error) (bool, *Proof, error) {
+	ctx, start := w.startVerification(ctx)
+	var err error
+	if IsEncryptedProof(proofString) {
+		proofString, err = DecryptProof(proofString, w.decryptionKey)
+		if err != nil {
+			w.observeVerification(ctx, start, nil, err)
+			return false, nil, err
+		}
+	}
+	_, span := startSpan(ctx, SpanParse)
+	proof, err := Parse(proofString)
+	span.End(err)
+	if err != nil {
+		w.observeVerification(ctx, start, nil, err)
+		return false, nil, err
+	}
+	ok, proof, err := w.verifyParsedProof(ctx, proof, check)
+	w.observeVerification(ctx, start, proof, err)
+	return ok, proof, err
+}
 
-	// extra
-	if proof.Extra != "" {
-		pb.WriteString(".")
-		pb.WriteString(proof.Extra)
+// EncodeCBOR will validate a Proof object and return its CBOR encoding, see Proof.EncodeCBOR.
+func (w *ETHAuth) EncodeCBOR(proof *Proof) ([]byte, error) {
+	if _, err := w.EncodeProof(proof); err != nil {
+		return nil, err
 	}
+	return proof.EncodeCBOR()
+}
 
-	return pb.String(), nil
+// DecodeCBOR will decode the CBOR encoding of a proof, validate it, and return a Proof object.
+func (w *ETHAuth) DecodeCBOR(data []byte) (bool, *Proof, error) {
+	return w.DecodeCBORContext(context.Background(), data)
 }
 
-// DecodeProof will decode an ETHAuth proof string, validate it, and return a Proof object
-func (w *ETHAuth) DecodeProof(proofString string) (bool, *Proof, error) {
-	parts := strings.Split(proofString, ".")
-	if len(parts) < 4 || len(parts) > 5 {
-		return false, nil, fmt.Errorf("ethauth: invalid proof string")
+// DecodeCBORContext is DecodeCBOR with the context of the request of the proof.
+func (w *ETHAuth) DecodeCBORContext(ctx context.Context, data []byte) (bool, *Proof, error) {
+	ctx, start := w.startVerification(ctx)
+	_, span := startSpan(ctx, SpanParse)
+	proof, err := ParseCBOR(data)
+	span.End(err)
+	if err != nil {
+		w.observeVerification(ctx, start, nil, err)
+		return false, nil, err
 	}
+	ok, proof, err := w.verifyParsedProof(ctx, proof, nil)
+	w.observeVerification(ctx, start, proof, err)
+	return ok, proof, err
+}
 
-	prefix := parts[0]
-	address := parts[1]
-	messageBase64 := parts[2]
-	signature := parts[3]
-	extra := ""
-	if len(parts) == 5 {
-		extra = parts[4]
+// verifyParsedProof decodes the custom claims of a parsed proof, and validates it. The check,
+// if not nil, verifies the proof against its request, ie. its origin and bindings, and runs
+// before the nonce, challenge and session stores, so a proof presented by another client
+// isn't recorded by them.
+func (w *ETHAuth) verifyParsedProof(ctx context.Context, proof *Proof, check func(proof *Proof) error) (bool, *Proof, error) {
+	var err error
+	if proof.claimsJSON != nil {
+		err = w.decodeCustomClaims(proof)
+		if err != nil {
+			return false, nil, err
+		}
 	}
 
-	// check prefix
-	if prefix != ETHAuthPrefix {
-		return false, nil, fmt.Errorf("ethauth: not an ethauth proof")
+	// Validate proof signature and claims
+	_, err = w.validateProof(ctx, proof)
+	if err != nil {
+		return false, proof, err
 	}
 
-	// decode message base64
-	messageBytes, err := Base64UrlDecode(messageBase64)
-	if err != nil {
-		return false, nil, fmt.Errorf("ethauth: decoding failed, invalid claims")
+	// Ensure the proof has not been revoked
+	if w.revocationStore != nil {
+		storeCtx, span := startStoreSpan(ctx, "revocation")
+		revoked, err := w.revocationStore.IsRevoked(storeCtx, proof)
+		span.End(err)
+		if err != nil {
+			return false, proof, err
+		}
+		if revoked {
+			return false, proof, ErrProofRevoked
+		}
 	}
 
-	var claims Claims
-	err = json.Unmarshal(messageBytes, &claims)
-	if err != nil {
-		return false, nil, fmt.Errorf("ethauth: decoding failed, cannot unmarshal claims")
+	// Ensure the proof account is a smart account of a known factory and implementation
+	if w.smartAccounts != nil {
+		err = w.verifySmartAccount(ctx, proof)
+		if err != nil {
+			return false, proof, err
+		}
 	}
 
-	// prepare proof
-	proof := NewProof()
-	proof.Prefix = prefix
-	proof.Address = address
-	proof.Claims = claims
-	proof.Signature = signature
-	proof.Extra = extra
+	// Ensure the proof account holds the tokens of the token gates
+	if w.tokenGates != nil {
+		err = w.verifyTokenGates(ctx, proof)
+		if err != nil {
+			return false, proof, err
+		}
+	}
 
-	// Validate proof signature and claims
-	_, err = w.ValidateProof(proof)
-	if err != nil {
-		return false, proof, err
+	// Verify the proof against its request
+	if check != nil {
+		err = check(proof)
+		if err != nil {
+			return false, proof, err
+		}
 	}
 
+	// Consume the proof nonce, so the proof can't be replayed. It is consumed before the
+	// challenge and the session, so replays of the proof are rejected before touching them.
+	if w.nonceStore != nil {
+		if proof.Claims.Nonce == 0 {
+			return false, proof, ErrMissingNonce
+		}
+		// the nonce is remembered as long as the proof is accepted, including its leeway
+		exp, ok := w.validatorConfig.acceptedUntil(proof.Claims)
+		if !ok {
+			return false, proof, ErrUnboundedNonce
+		}
+		storeCtx, span := startStoreSpan(ctx, "nonce")
+		err = w.nonceStore.Consume(storeCtx, proof.Address, proof.Claims.Nonce, exp)
+		span.End(err)
+		if err != nil {
+			return false, proof, err
+		}
+	}
+
+	// Consume the challenge answered by the proof
+	if w.challenges != nil {
+		storeCtx, span := startStoreSpan(ctx, "challenge")
+		err = w.challenges.Verify(storeCtx, proof)
+		span.End(err)
+		if err != nil {
+			return false, proof, err
+		}
+	}
+
+	// Record the session of the proof, unless it has been logged out
+	if w.sessionRegistry != nil {
+		storeCtx, span := startStoreSpan(ctx, "session")
+		err = w.sessionRegistry.Record(storeCtx, NewSessionInfo(proof, w.clock()))
+		span.End(err)
+		if err != nil {
+			return false, proof, err
+		}
+	}
+
+	// Attach the ENS name of the proof address. Proofs of addresses without a primary ENS
+	// name, or whose name can't be resolved, are valid all the same.
+	if w.ensResolver != nil {
+		address, _ := proof.AddressBytes()
+		proof.ENSName, err = w.ensResolver.LookupAddress(ctx, address)
+		if err != nil {
+			w.log().Warn(ctx, "ethauth: ENS lookup failed", "address", proof.Address, "error", err)
+		}
+	}
+
+	proof.roleResolver = w.roleResolver
 	return true, proof, nil
 }
 
+// decodeCustomClaims decodes the non-standard claims of a parsed proof into the custom claims
+// of ConfigCustomClaims. Unknown claims are rejected, as they may not be signed by the proof.
+func (w *ETHAuth) decodeCustomClaims(proof *Proof) error {
+	extra := proof.Claims.Unknown
+	if w.customClaims == nil {
+		return proof.Claims.rejectUnknown()
+	}
+
+	extraJSON, err := json.Marshal(extra)
+	if err != nil {
+		return fmt.Errorf("ethauth: decoding failed, cannot unmarshal custom claims")
+	}
+	custom := w.customClaims()
+	dec := json.NewDecoder(bytes.NewReader(extraJSON))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(custom); err != nil {
+		return fmt.Errorf("ethauth: decoding failed, cannot unmarshal custom claims")
+	}
+	proof.Claims.Custom = custom
+	proof.Claims.Unknown = nil
+	return nil
+}
+
+// ValidateProof validates the proof claims and the proof signature.
 func (w *ETHAuth) ValidateProof(proof *Proof) (bool, error) {
+	return w.validateProof(context.Background(), proof)
+}
+
+func (w *ETHAuth) validateProof(ctx context.Context, proof *Proof) (bool, error) {
+	if err := proof.validAddress(); err != nil {
+		return false, err
+	}
 	valid, err := w.ValidateProofClaims(proof)
 	if !valid || err != nil {
 		return false, fmt.Errorf("ethauth: proof claims are invalid - %w", err)
 	}
-	valid = w.ValidateProofSignature(proof)
-	if !valid {
-		return false, fmt.Errorf("ethauth: proof signature is invalid")
+	_, err = w.VerifyProofSignature(ctx, proof)
+	if err != nil {
+		// the errors of the validators are logged, while the proof is rejected with ErrInvalidSignature
+		w.log().Debug(ctx, "ethauth: proof signature is invalid", "address", proof.Address, "error", err)
+		return false, ErrInvalidSignature
+	}
+	err = w.ValidateGuardianSignature(proof)
+	if err != nil {
+		return false, err
 	}
 	return true, nil
 }
 
 func (w *ETHAuth) ValidateProofSignature(proof *Proof) bool {
-	retIsValid := make([]bool, len(w.validators))
+	_, err := w.VerifyProofSignature(context.Background(), proof)
+	return err == nil
+}
 
-	for i, v := range w.validators {
-		isValid, _, _ := v(context.Background(), w.provider, w.chainID, proof)
-		retIsValid[i] = isValid
-		if isValid {
-			// preemptively return true if we've determined it to be valid
-			return true
+// VerifyProofSignature tries each of the configured validators in order and returns the
+// index of the first validator in Validators() which considers the proof signature valid.
+// If none of them do, an error joining each of the validator errors is returned.
+func (w *ETHAuth) VerifyProofSignature(ctx context.Context, proof *Proof) (int, error) {
+	// proofs are verified under the domain of this instance, whatever domain they were built with
+	if domain := w.proofDomain(proof); proof.Claims.domain != domain {
+		proof.Claims.domain = domain
+	}
+
+	if w.tracer == nil {
+		i, _, err := w.verifyProofSignature(ctx, proof)
+		return i, err
+	}
+	if _, ok := ctx.Value(tracerCtxKey).(Tracer); !ok {
+		ctx = context.WithValue(ctx, tracerCtxKey, w.tracer)
+	}
+	ctx, span := w.tracer.Start(ctx, SpanSignature)
+	i, cached, err := w.verifyProofSignature(ctx, proof)
+	span.SetAttribute(AttributeCacheHit, cached)
+	if i >= 0 {
+		span.SetAttribute(AttributeValidator, validatorName(w.validators[i]))
+	}
+	span.End(err)
+	return i, err
+}
+
+// verifyProofSignature is VerifyProofSignature, also reporting whether the signature was found
+// in the verification cache.
+func (w *ETHAuth) verifyProofSignature(ctx context.Context, proof *Proof) (int, bool, error) {
+
+	var cacheKey [32]byte
+	if w.cache != nil {
+		var err error
+		cacheKey, err = verificationCacheKey(proof)
+		if err != nil {
+			return -1, false, fmt.Errorf("%w - %w", ErrInvalidSignature, err)
 		}
+		if i, ok := w.cache.get(cacheKey, w.clock()); ok {
+			if w.hooks.OnCacheHit != nil {
+				w.hooks.OnCacheHit(ctx, proof)
+			}
+			return i, true, nil
+		}
+	}
+	if w.hooks.OnRPCCall != nil {
+		ctx = context.WithValue(ctx, hooksCtxKey, &w.hooks)
+	}
+	if w.signatureBlocks != nil {
+		ctx = context.WithValue(ctx, signatureBlockCtxKey, &signatureBlockSearch{blocks: w.signatureBlocks, now: w.clock(), maxAge: w.validatorConfig.MaxAge})
+	}
+	if w.witnessVerifiers != nil {
+		ctx = context.WithValue(ctx, witnessCtxKey, w.witnessVerifiers)
+	}
+	if w.logger != nil {
+		ctx = context.WithValue(ctx, loggerCtxKey, w.logger)
 	}
 
-	// Valid if one of the validators says so
-	for _, isValid := range retIsValid {
+	var errs []error
+	for i, v := range w.validators {
+		isValid, _, err := v(ctx, w.provider, w.chainID, proof)
 		if isValid {
-			return true
+			if w.cache != nil {
+				var exp time.Time
+				if proof.Claims.ExpiresAt != 0 {
+					exp = time.Unix(proof.Claims.ExpiresAt, 0)
+				}
+				w.cache.add(cacheKey, i, w.clock(), exp)
+			}
+			// preemptively return if we've determined it to be valid
+			return i, false, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return false
+	return -1, false, fmt.Errorf("%w - %w", ErrInvalidSignature, errors.Join(errs...))
 }
 
 func (w *ETHAuth) ValidateProofClaims(proof *Proof) (bool, error) {
-	err := proof.Claims.Valid()
+	err := proof.Claims.ValidAt(w.clock(), w.validatorConfig)
 	if err != nil {
 		return false, err
 	}
+	if sunset, ok := w.versionSunsets[proof.Claims.ETHAuthVersion]; ok && !w.clock().Before(sunset) {
+		return false, ErrDeprecatedVersion
+	}
+	if proof.Claims.ChainID != 0 && w.chainID != nil && (!w.chainID.IsUint64() || w.chainID.Uint64() != proof.Claims.ChainID) {
+		return false, fmt.Errorf("%w, proof is for chainId %d, expecting %s", ErrInvalidChainID, proof.Claims.ChainID, w.chainID.String())
+	}
+	if err := w.verifyExchanger(proof); err != nil {
+		return false, err
+	}
+	if proof.AccountChainID != 0 && proof.AccountChainID != proof.Claims.ChainID {
+		return false, fmt.Errorf("%w, proof account is on chainId %d, but the proof is for chainId %d", ErrInvalidChainID, proof.AccountChainID, proof.Claims.ChainID)
+	}
+	if len(w.chains) > 0 && !slices.Contains(w.chains, proof.Claims.ChainID) {
+		return false, fmt.Errorf("%w, proof is for chainId %d", ErrInvalidChainID, proof.Claims.ChainID)
+	}
+	if len(w.audiences) > 0 && !slices.Contains(w.audiences, proof.Claims.Audience) {
+		return false, ErrInvalidAudience
+	}
+	if len(w.apps) > 0 && !slices.Contains(w.apps, proof.Claims.App) {
+		return false, ErrInvalidApp
+	}
+	if len(w.origins) > 0 && !slices.ContainsFunc(w.origins, func(p originPattern) bool { return p.match(proof.Claims.Origin) }) {
+		return false, ErrInvalidOrigin
+	}
+	if !proof.Claims.Scope.Has(w.requiredScopes...) {
+		return false, ErrMissingScope
+	}
+	if policy, ok := w.appPolicies[proof.Claims.App]; ok {
+		if err := policy.Check(proof.Claims); err != nil {
+			return false, err
+		}
+	}
 	return true, nil
 }
 
+// RegisterValidator appends a validator to the list of validators tried in order
+// when validating a proof signature.
+func (w *ETHAuth) RegisterValidator(validator ValidatorFunc) {
+	w.validators = append(w.validators, validator)
+}
+
 func (w *ETHAuth) Validators() []ValidatorFunc {
 	return w.validators
 }
@@ -1,12 +1,16 @@
 package ethauth
 
 import (
+	"context"
+	"fmt"
+	"math/big"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,6 +25,16 @@ func TestClaims(t *testing.T) {
 
 	_, err = typedData.EncodeDigest()
 	require.NoError(t, err)
+
+	// optional claims are only included in the typed data when set
+	require.Len(t, typedData.Types["Claims"], 2)
+	claims.Subject = "user-1"
+	claims.ID = "a5f1c2"
+	typedData, err = claims.TypedData()
+	require.NoError(t, err)
+	require.Len(t, typedData.Types["Claims"], 4)
+	require.Equal(t, "user-1", typedData.Message["sub"])
+	require.Equal(t, "a5f1c2", typedData.Message["jti"])
 }
 
 func TestEncodeDecodeFromEOA(t *testing.T) {
@@ -104,6 +118,7 @@ func TestProofClaims(t *testing.T) {
 		}
 		require.Error(t, claims.Valid())
 		require.Contains(t, claims.Valid().Error(), "from the future")
+		require.ErrorIs(t, claims.Valid(), ErrIssuedInFuture)
 
 		// invalid -- expiry is in the past
 		claims = Claims{
@@ -114,6 +129,7 @@ func TestProofClaims(t *testing.T) {
 		}
 		require.Error(t, claims.Valid())
 		require.Contains(t, claims.Valid().Error(), "expired")
+		require.ErrorIs(t, claims.Valid(), ErrProofExpired)
 
 		// invalid -- expiry is unset
 		claims = Claims{
@@ -126,3 +142,520 @@ func TestProofClaims(t *testing.T) {
 	}
 
 }
+
+func TestProofEncodeParse(t *testing.T) {
+	proof := NewProof()
+	proof.Address = "0x89D9F8f31817BAdb5D718CD6fb483b71DbD2dfeD"
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+	proof.Signature = "0x1234"
+
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(proofString, ETHAuthPrefix+"."))
+
+	parsed, err := Parse(proofString)
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(proof.Address), parsed.Address)
+	require.Equal(t, proof.Claims, parsed.Claims)
+	require.Equal(t, proof.Signature, parsed.Signature)
+
+	_, err = Parse("eth.0x89d9f8f31817badb5d718cd6fb483b71dbd2dfed")
+	require.Error(t, err)
+
+	_, err = Parse("jwt." + strings.TrimPrefix(proofString, ETHAuthPrefix+"."))
+	require.Error(t, err)
+}
+
+func TestDecodeERC6492Signature(t *testing.T) {
+	factory := common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+	factoryCalldata := []byte{0xde, 0xad, 0xbe, 0xef}
+	innerSignature := []byte{0x01, 0x02, 0x03}
+
+	wrapped, err := ethcoder.ABIPackArguments([]string{"address", "bytes", "bytes"}, []interface{}{factory, factoryCalldata, innerSignature})
+	require.NoError(t, err)
+	wrapped = append(wrapped, ethcoder.MustHexDecode(ERC6492MagicSuffix)...)
+	require.True(t, IsERC6492Signature(wrapped))
+
+	f, fc, sig, err := DecodeERC6492Signature(wrapped)
+	require.NoError(t, err)
+	require.Equal(t, factory, f)
+	require.Equal(t, factoryCalldata, fc)
+	require.Equal(t, innerSignature, sig)
+
+	require.False(t, IsERC6492Signature(innerSignature))
+	_, _, _, err = DecodeERC6492Signature(innerSignature)
+	require.Error(t, err)
+}
+
+func TestVerifyProofSignature(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proof := signTestProof(t, wallet, claims)
+
+	ethAuth, err := New(ValidateContractAccountProof)
+	require.NoError(t, err)
+
+	_, err = ethAuth.VerifyProofSignature(context.Background(), proof)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	ethAuth.RegisterValidator(NewSignatureValidatorFunc(EOASignatureValidator{}))
+	idx, err := ethAuth.VerifyProofSignature(context.Background(), proof)
+	require.NoError(t, err)
+	require.Equal(t, 1, idx)
+}
+
+func TestSignProof(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	proof := NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+
+	err = SignProof(proof, wallet.PrivateKey())
+	require.NoError(t, err)
+	require.Equal(t, wallet.Address().String(), proof.Address)
+
+	proofString, err := ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+
+	ok, _, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func signTestProof(t *testing.T, wallet *ethwallet.Wallet, claims Claims) *Proof {
+	encodedTypedData, err := claims.Message()
+	require.NoError(t, err)
+
+	sig, err := wallet.SignData(encodedTypedData)
+	require.NoError(t, err)
+
+	proof := NewProof()
+	proof.Address = wallet.Address().String()
+	proof.Claims = claims
+	proof.Signature = ethcoder.HexEncode(sig)
+	return proof
+}
+
+func TestMnemonicSigner(t *testing.T) {
+	mnemonic := "outdoor sentence roast truly flower surface power begin ocean silent debate funny"
+
+	signer, err := NewMnemonicSigner(mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", signer.Address().Hex())
+
+	signer0, err := NewMnemonicAccountSigner(mnemonic, 0)
+	require.NoError(t, err)
+	require.Equal(t, signer.Address(), signer0.Address())
+
+	signer1, err := NewMnemonicAccountSigner(mnemonic, 1)
+	require.NoError(t, err)
+	signer1Path, err := NewMnemonicSigner(mnemonic, "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	require.Equal(t, signer1Path.Address(), signer1.Address())
+	require.NotEqual(t, signer.Address(), signer1.Address())
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	proof := NewProof()
+	proof.Claims = Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+	require.NoError(t, SignProofWithSigner(context.Background(), proof, signer1))
+	_, err = ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+
+	_, err = NewMnemonicSigner("not a mnemonic")
+	require.Error(t, err)
+}
+
+func TestClaimsChainID(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ChainID: 1, ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+
+	typedData, err := claims.TypedData()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), typedData.Domain.ChainID.Int64())
+
+	// proof is bound to chainId 1, so its digest differs from the same claims on chainId 137
+	digest1, err := claims.MessageDigest()
+	require.NoError(t, err)
+	claims137 := claims
+	claims137.ChainID = 137
+	digest137, err := claims137.MessageDigest()
+	require.NoError(t, err)
+	require.NotEqual(t, digest1, digest137)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.chainID = big.NewInt(137)
+
+	proof := signTestProof(t, wallet, claims)
+	_, err = ethAuth.EncodeProof(proof)
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrInvalidChainID)
+
+	ethAuth.chainID = big.NewInt(1)
+	_, err = ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+}
+
+func TestExpectedAudience(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigExpectedAudience("https://api.example.com"))
+
+	claims := Claims{App: "ETHAuthTest", Audience: "https://api.example.com", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	claims.Audience = "https://admin.example.com"
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.Error(t, err)
+
+	claims.Audience = ""
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.Error(t, err)
+}
+
+func TestAllowedApps(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigAllowedApps("my-dapp", "my-admin"))
+	require.Error(t, ethAuth.ConfigAllowedApps(""))
+
+	claims := Claims{App: "my-admin", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	claims.App = "other-dapp"
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.ErrorIs(t, err, ErrInvalidApp)
+}
+
+type testCustomClaims struct {
+	Role   string `json:"role"`
+	Tenant uint64 `json:"tenant"`
+}
+
+func (c *testCustomClaims) Map() map[string]interface{} {
+	return map[string]interface{}{"role": c.Role, "tenant": c.Tenant}
+}
+
+func (c *testCustomClaims) TypedDataTypes() []ethcoder.TypedDataArgument {
+	return []ethcoder.TypedDataArgument{{Name: "role", Type: "string"}, {Name: "tenant", Type: "uint64"}}
+}
+
+func (c *testCustomClaims) Valid() error {
+	if c.Role == "" {
+		return fmt.Errorf("role is empty")
+	}
+	return nil
+}
+
+func TestCustomClaims(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigCustomClaims(func() ClaimsProvider { return &testCustomClaims{} })
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion, Custom: &testCustomClaims{Role: "admin", Tenant: 7}}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, &testCustomClaims{Role: "admin", Tenant: 7}, proof.Claims.Custom)
+
+	// custom claims are part of the signed message
+	tampered := signTestProof(t, wallet, claims)
+	tampered.Claims.Custom = &testCustomClaims{Role: "superadmin", Tenant: 7}
+	_, err = ethAuth.EncodeProof(tampered)
+	require.Error(t, err)
+
+	// custom claims are validated
+	claims.Custom = &testCustomClaims{}
+	require.Error(t, claims.Valid())
+}
+
+func TestClaimsValidAt(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	claims := Claims{
+		App:            "TestClaimsValidAt",
+		IssuedAt:       at.Unix(),
+		ExpiresAt:      at.Add(time.Hour).Unix(),
+		ETHAuthVersion: ETHAuthVersion,
+	}
+
+	require.NoError(t, claims.ValidAt(at, DefaultValidatorConfig))
+	require.NoError(t, claims.ValidAt(at.Add(time.Hour+time.Minute), DefaultValidatorConfig))
+	require.Error(t, claims.ValidAt(at.Add(2*time.Hour), DefaultValidatorConfig))
+
+	// tighter leeway
+	strict := ValidatorConfig{Leeway: 30 * time.Second, MaxAge: 2 * time.Hour, RequireExp: true, RequireIat: true}
+	require.Error(t, claims.ValidAt(at.Add(time.Hour+time.Minute), strict))
+
+	// max age
+	require.Error(t, claims.ValidAt(at, ValidatorConfig{Leeway: time.Minute, MaxAge: 30 * time.Minute, RequireExp: true}))
+
+	// separate leeways of the `iat` and `exp` claims, for clock-skewed clients
+	skewed := ValidatorConfig{IssuedAtLeeway: 10 * time.Minute, MaxAge: 2 * time.Hour, RequireExp: true}
+	require.NoError(t, claims.ValidAt(at.Add(-9*time.Minute), skewed))
+	require.ErrorIs(t, claims.ValidAt(at.Add(-11*time.Minute), skewed), ErrIssuedInFuture)
+	require.NoError(t, claims.ValidAt(at.Add(time.Hour), skewed))
+	require.ErrorIs(t, claims.ValidAt(at.Add(time.Hour+time.Second), skewed), ErrProofExpired)
+	skewed.ExpirationLeeway = time.Minute
+	require.NoError(t, claims.ValidAt(at.Add(time.Hour+time.Minute), skewed))
+	skewed = ValidatorConfig{Leeway: 5 * time.Minute, ExpirationLeeway: time.Second, MaxAge: 2 * time.Hour}
+	require.NoError(t, claims.ValidAt(at.Add(-4*time.Minute), skewed))
+	require.ErrorIs(t, claims.ValidAt(at.Add(time.Hour+2*time.Second), skewed), ErrProofExpired)
+
+	// optional exp, required iat
+	claims.ExpiresAt = 0
+	require.Error(t, claims.ValidAt(at, DefaultValidatorConfig))
+	require.NoError(t, claims.ValidAt(at, ValidatorConfig{Leeway: time.Minute, MaxAge: time.Hour}))
+	claims.IssuedAt = 0
+	require.Error(t, claims.ValidAt(at, ValidatorConfig{Leeway: time.Minute, MaxAge: time.Hour, RequireIat: true}))
+}
+
+func TestConfigClock(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	at := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	claims := Claims{
+		App:            "ETHAuthTest",
+		IssuedAt:       at.Unix(),
+		ExpiresAt:      at.Add(5 * time.Minute).Unix(),
+		ETHAuthVersion: ETHAuthVersion,
+	}
+	proof := signTestProof(t, wallet, claims)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	_, err = ethAuth.EncodeProof(proof)
+	require.Error(t, err)
+
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return at }))
+	_, err = ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+}
+
+func TestNonceStore(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(NewMemoryNonceStore())
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+
+	// nonce is required
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrMissingNonce)
+
+	// nonce can only be used once
+	claims.Nonce = 1337
+	proofString, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	ok, _, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+
+	// nonces of expired proofs accepted within the leeway can't be reused
+	claims.Nonce = 1338
+	claims.IssuedAt = time.Now().Add(-time.Minute).Unix()
+	claims.ExpiresAt = time.Now().Add(-10 * time.Second).Unix()
+	proofString, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+
+	// nor those of proofs without exp, which are remembered from their iat
+	ethAuth.ConfigValidatorConfig(ValidatorConfig{Leeway: time.Minute, MaxAge: time.Hour})
+	claims.Nonce = 1339
+	claims.SetIssuedAtNow()
+	claims.ExpiresAt = 0
+	proofString, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+
+	// proofs with neither can't be remembered
+	claims.Nonce = 1340
+	claims.IssuedAt = 0
+	proofString, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrUnboundedNonce)
+
+	// nonces expire by the clock of the instance, not by the time of the store
+	now := time.Now().Add(-time.Hour)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now }))
+	ethAuth.ConfigValidatorConfig(DefaultValidatorConfig)
+	claims.Nonce = 1341
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(time.Minute).Unix()
+	proofString, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+}
+
+func TestNonceStoreOrder(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(NewMemoryNonceStore())
+	challenges := NewChallengeManager(time.Minute)
+	ethAuth.ConfigChallengeManager(challenges)
+	registry := NewSessionRegistry()
+	defer registry.Close()
+	ethAuth.ConfigSessionRegistry(registry)
+
+	challenge, err := challenges.Issue(ctx, wallet.Address().Hex(), "")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithNonce(challenge.Nonce), WithID("laptop"))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	// replays are rejected by the nonce store before the challenge and session are touched
+	now := time.Now().Add(time.Minute)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now }))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+	sessions, err := registry.Sessions(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.True(t, sessions[0].LastSeen.Before(now.Add(-30*time.Second)))
+}
+
+func TestRevocationStore(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	store := NewMemoryRevocationStore()
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigRevocationStore(store)
+
+	claims := Claims{App: "ETHAuthTest", ID: "proof-1", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	// revoke by id
+	require.NoError(t, store.RevokeID(ctx, "proof-1", time.Unix(claims.ExpiresAt, 0)))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrProofRevoked)
+
+	// revoke by issued at
+	claims.ID = "proof-2"
+	proofString, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeIssuedBefore(ctx, wallet.Address().String(), time.Now().Add(time.Second)))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrProofRevoked)
+
+	// revoke by address
+	store = NewMemoryRevocationStore()
+	ethAuth.ConfigRevocationStore(store)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeAddress(ctx, wallet.Address().String()))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrProofRevoked)
+}
+
+func TestProofAddressBytes(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	checksum := wallet.Address().Hex()
+
+	for _, address := range []string{checksum, strings.ToLower(checksum), "0x" + strings.ToUpper(checksum[2:])} {
+		proof := &Proof{Address: address}
+		addr, err := proof.AddressBytes()
+		require.NoError(t, err, address)
+		require.Equal(t, wallet.Address(), addr)
+	}
+
+	for _, address := range []string{"", "e0C9828dee3411A28CcB4bb82a18d0aAd24489E0", "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489", "0xE0C9828dee3411A28CcB4bb82a18d0aAd24489E0", "0xz0c9828dee3411a28ccb4bb82a18d0aad24489e0", "0x0000000000000000000000000000000000000000"} {
+		proof := &Proof{Address: address}
+		_, err := proof.AddressBytes()
+		require.ErrorIs(t, err, ErrInvalidAddress, address)
+	}
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+
+	// the address is normalized to its checksum on issuance
+	proof := signTestProof(t, wallet, claims)
+	proof.Address = strings.ToLower(checksum)
+	_, err = ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+	require.Equal(t, checksum, proof.Address)
+
+	// and zero addresses are rejected
+	proof.Address = "0x0000000000000000000000000000000000000000"
+	_, err = ethAuth.ValidateProof(proof)
+	require.ErrorIs(t, err, ErrInvalidAddress)
+}
@@ -0,0 +1,121 @@
+// Package ethauthgql authenticates gqlgen GraphQL APIs with ETHAuth proofs, with an @auth
+// directive requiring a verified proof per field, instead of a separate HTTP middleware.
+//
+// Declare the directive in the schema:
+//
+//	directive @auth(scopes: [String!]) on FIELD_DEFINITION
+//
+//	type Query {
+//		me: Account! @auth
+//		orders: [Order!]! @auth(scopes: ["read:orders"])
+//	}
+//
+// and configure it with the extension verifying the proof of each operation:
+//
+//	cfg := generated.Config{Resolvers: resolvers}
+//	cfg.Directives.Auth = ethauthgql.Directive
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
+//	srv.Use(ethauthgql.Extension(ethAuth))
+//
+// Resolvers read the proof of the viewer with ForViewer.
+package ethauthgql
+
+import (
+	"context"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes of the errors extensions of requests failing authentication.
+const (
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeForbidden       = "FORBIDDEN"
+)
+
+type contextKey struct {
+	name string
+}
+
+var authErrCtxKey = &contextKey{"authErr"}
+
+// Extension returns a gqlgen handler extension verifying the proof of each operation, read
+// from the `Authorization: Bearer <proof>` header of the request, or the `Authorization` of
+// the connection_init payload of websocket subscriptions. The verified proof is passed to the
+// resolvers in the context. Operations without a proof are executed anonymously, and
+// operations with an invalid proof are executed with the verification error, so only fields
+// requiring @auth fail.
+func Extension(ethAuth *ethauth.ETHAuth) graphql.HandlerExtension {
+	return &extension{ethAuth: ethAuth}
+}
+
+type extension struct {
+	ethAuth *ethauth.ETHAuth
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &extension{}
+
+func (e *extension) ExtensionName() string {
+	return "ETHAuth"
+}
+
+func (e *extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e *extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	authorization := graphql.GetOperationContext(ctx).Headers.Get("Authorization")
+	if authorization == "" {
+		authorization = transport.GetInitPayload(ctx).Authorization()
+	}
+
+	// operations don't carry the request target signed by a proof-of-possession, so proofs
+	// bound to a client key or certificate are rejected
+	proof, err := ethauth.Authenticate(e.ethAuth, ethauth.AuthRequest{Context: ctx, Authorization: authorization}, ethauth.MiddlewareOptions{Optional: true})
+	if err != nil {
+		return next(context.WithValue(ctx, authErrCtxKey, err))
+	}
+	if proof != nil {
+		ctx = ethauth.WithProof(ctx, proof)
+	}
+	return next(ctx)
+}
+
+// Directive implements the @auth directive, resolving the field only if the operation carries
+// a verified proof, with all of the scopes, if any. Fields are otherwise resolved to null with
+// an error of extensions code CodeUnauthenticated, or CodeForbidden if a scope is missing.
+func Directive(ctx context.Context, obj interface{}, next graphql.Resolver, scopes []string) (interface{}, error) {
+	proof, err := ForViewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !proof.Claims.Scope.Has(scopes...) {
+		return nil, newError("ethauth: missing scope", CodeForbidden)
+	}
+	return next(ctx)
+}
+
+// ForViewer returns the verified proof of the viewer of the operation, or an error of
+// extensions code CodeUnauthenticated if the operation carries no valid proof.
+func ForViewer(ctx context.Context) (*ethauth.Proof, error) {
+	if err, ok := ctx.Value(authErrCtxKey).(error); ok {
+		return nil, newError(err.Error(), CodeUnauthenticated)
+	}
+	proof, ok := ethauth.FromContext(ctx)
+	if !ok {
+		return nil, newError("ethauth: missing proof", CodeUnauthenticated)
+	}
+	return proof, nil
+}
+
+func newError(message, code string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
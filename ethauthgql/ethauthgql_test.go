@@ -0,0 +1,78 @@
+package ethauthgql
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-ethauth"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestDirective(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	proof := ethauth.NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.Scope = ethauth.Scopes{"read:orders"}
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(time.Hour)
+	require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+
+	// execute runs a field resolver guarded by @auth(scopes) in an operation authorized by
+	// the header
+	execute := func(authorization string, scopes ...string) (interface{}, error) {
+		headers := http.Header{}
+		if authorization != "" {
+			headers.Set("Authorization", authorization)
+		}
+		ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{Headers: headers})
+
+		var res interface{}
+		var err error
+		Extension(ethAuth).(graphql.OperationInterceptor).InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+			res, err = Directive(ctx, nil, func(ctx context.Context) (interface{}, error) {
+				viewer, err := ForViewer(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return viewer.Address, nil
+			}, scopes)
+			return nil
+		})
+		return res, err
+	}
+
+	requireCode := func(err error, code string) {
+		var gqlErr *gqlerror.Error
+		require.ErrorAs(t, err, &gqlErr)
+		require.Equal(t, code, gqlErr.Extensions["code"])
+	}
+
+	res, err := execute("Bearer " + proofString)
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), res)
+
+	res, err = execute("Bearer "+proofString, "read:orders")
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), res)
+
+	_, err = execute("Bearer "+proofString, "write:orders")
+	requireCode(err, CodeForbidden)
+
+	_, err = execute("")
+	requireCode(err, CodeUnauthenticated)
+
+	_, err = execute("Bearer eth.invalid")
+	requireCode(err, CodeUnauthenticated)
+}
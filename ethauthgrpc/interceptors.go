@@ -0,0 +1,114 @@
+// Package ethauthgrpc provides gRPC interceptors for authenticating requests with ETHAuth proofs.
+package ethauthgrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0xsequence/go-ethauth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataKey is the gRPC metadata key carrying the `Bearer <proof>` value.
+const MetadataKey = "authorization"
+
+// ProofSourceFunc returns the proof string attached to outgoing client calls.
+type ProofSourceFunc func(ctx context.Context) (string, error)
+
+// UnaryServerInterceptor returns a server interceptor which decodes and validates the proof
+// from the incoming metadata, and passes the verified proof to the handler in the context.
+func UnaryServerInterceptor(ethAuth *ethauth.ETHAuth) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, ethAuth)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor which decodes and validates the proof
+// from the incoming metadata, and passes the verified proof to the handler in the stream context.
+func StreamServerInterceptor(ethAuth *ethauth.ETHAuth) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), ethAuth)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// UnaryClientInterceptor returns a client interceptor which attaches the proof string
+// returned by proofSource to the outgoing metadata.
+func UnaryClientInterceptor(proofSource ProofSourceFunc) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := attachProof(ctx, proofSource)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor which attaches the proof string
+// returned by proofSource to the outgoing metadata.
+func StreamClientInterceptor(proofSource ProofSourceFunc) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := attachProof(ctx, proofSource)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func authenticate(ctx context.Context, ethAuth *ethauth.ETHAuth) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(MetadataKey)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "ethauth: missing proof")
+	}
+
+	scheme, proofString, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || proofString == "" {
+		return nil, status.Error(codes.Unauthenticated, "ethauth: invalid authorization metadata, expecting bearer proof")
+	}
+
+	// calls are verified as requests without a proof-of-possession, so proofs bound to a
+	// client key are rejected, as are request proofs, which sign a single HTTP request, before
+	// the proof is recorded by the stores of ethAuth
+	req := ethauth.AuthRequest{Context: ctx, Authorization: values[0]}
+	if p, ok := peer.FromContext(ctx); ok {
+		req.RemoteAddr = p.Addr.String()
+	}
+	if ua := md.Get("user-agent"); len(ua) > 0 {
+		req.UserAgent = ua[0]
+	}
+	proof, err := ethauth.Authenticate(ethAuth, req, ethauth.MiddlewareOptions{})
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return ethauth.WithProof(ctx, proof), nil
+}
+
+func attachProof(ctx context.Context, proofSource ProofSourceFunc) (context.Context, error) {
+	proofString, err := proofSource(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "ethauth: unable to get proof - %v", err)
+	}
+	return metadata.AppendToOutgoingContext(ctx, MetadataKey, "Bearer "+proofString), nil
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
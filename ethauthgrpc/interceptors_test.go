@@ -0,0 +1,146 @@
+package ethauthgrpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testServerStream is a server stream of the incoming context, for StreamServerInterceptor.
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func incomingContext(authorization ...string) context.Context {
+	md := metadata.MD{}
+	for _, value := range authorization {
+		md.Append(MetadataKey, value)
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(ethauth.NewMemoryNonceStore())
+	interceptor := UnaryServerInterceptor(ethAuth)
+
+	call := func(ctx context.Context) (*ethauth.Proof, error) {
+		var proof *ethauth.Proof
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			proof, _ = ethauth.FromContext(ctx)
+			return nil, nil
+		})
+		return proof, err
+	}
+
+	// valid proof
+	claims := ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Nonce = 1
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, claims)
+	proof, err := call(incomingContext("Bearer " + proofString))
+	require.NoError(t, err)
+	require.NotNil(t, proof)
+	require.Equal(t, strings.ToLower(ethauthtest.Alice.Address().Hex()), proof.Address)
+
+	// replayed proof
+	_, err = call(incomingContext("Bearer " + proofString))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), ethauth.ErrNonceUsed.Error())
+
+	// missing proof
+	_, err = call(context.Background())
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = call(incomingContext("Basic " + proofString))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// expired proof
+	_, err = call(incomingContext("Bearer " + ethauthtest.Mint(t, ethauthtest.Alice, ethauthtest.ExpiredClaims("ETHAuthTest"))))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// invalid signature
+	_, err = call(incomingContext("Bearer " + ethauthtest.MintInvalidSignature(t, ethauthtest.Alice, ethauthtest.ValidClaims("ETHAuthTest"))))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// proofs bound to a client key are rejected, as calls carry no proof-of-possession
+	claims = ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Confirmation = ethauth.KeyConfirmation(ethauthtest.Bob.Address())
+	_, err = call(incomingContext("Bearer " + ethauthtest.Mint(t, ethauthtest.Alice, claims)))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(ethauth.NewMemoryNonceStore())
+	interceptor := StreamServerInterceptor(ethAuth)
+
+	call := func(ctx context.Context) (*ethauth.Proof, error) {
+		var proof *ethauth.Proof
+		err := interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(srv interface{}, ss grpc.ServerStream) error {
+			proof, _ = ethauth.FromContext(ss.Context())
+			return nil
+		})
+		return proof, err
+	}
+
+	// valid proof
+	claims := ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Nonce = 1
+	proofString := ethauthtest.Mint(t, ethauthtest.Bob, claims)
+	proof, err := call(incomingContext("Bearer " + proofString))
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(ethauthtest.Bob.Address().Hex()), proof.Address)
+
+	// replayed proof
+	_, err = call(incomingContext("Bearer " + proofString))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// missing proof
+	_, err = call(context.Background())
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// expired proof
+	_, err = call(incomingContext("Bearer " + ethauthtest.Mint(t, ethauthtest.Bob, ethauthtest.ExpiredClaims("ETHAuthTest"))))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestClientInterceptors(t *testing.T) {
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, ethauthtest.ValidClaims("ETHAuthTest"))
+	source := func(ctx context.Context) (string, error) { return proofString, nil }
+
+	err := UnaryClientInterceptor(source)(context.Background(), "/test.Service/Method", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		require.Equal(t, []string{"Bearer " + proofString}, md.Get(MetadataKey))
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = StreamClientInterceptor(source)(context.Background(), &grpc.StreamDesc{}, nil, "/test.Service/Stream", func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		require.Equal(t, []string{"Bearer " + proofString}, md.Get(MetadataKey))
+		return nil, nil
+	})
+	require.NoError(t, err)
+
+	// calls fail when the source has no proof
+	failing := func(ctx context.Context) (string, error) { return "", context.Canceled }
+	err = UnaryClientInterceptor(failing)(context.Background(), "/test.Service/Method", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		t.Fatal("call was invoked")
+		return nil
+	})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,62 @@
+// Package ethauthotel traces ETHAuth proof verifications with OpenTelemetry.
+//
+//	ethAuth.ConfigTracer(ethauthotel.NewTracer(otel.GetTracerProvider()))
+package ethauthotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/go-ethauth"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans of the tracer.
+const ScopeName = "github.com/0xsequence/go-ethauth"
+
+// Tracer is an ethauth.Tracer starting OpenTelemetry spans of the span names of ethauth, ie.
+// ethauth.SpanVerify, with the ethauth attributes of the spans, ie. ethauth.AttributeApp.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+var _ ethauth.Tracer = &Tracer{}
+
+// NewTracer returns a Tracer of the tracer provider.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(ScopeName)}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, ethauth.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx, otelSpan{span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttribute(key string, value any) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
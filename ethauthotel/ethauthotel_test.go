@@ -0,0 +1,62 @@
+package ethauthotel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-ethauth"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	ethAuth.ConfigTracer(NewTracer(provider))
+	ethAuth.ConfigNonceStore(ethauth.NewMemoryNonceStore())
+
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	proofString, err := ethauth.Issue(ethauth.NewWalletSigner(wallet), ethauth.WithApp("ETHAuthTest"), ethauth.WithNonce(1), ethauth.WithExpiresIn(5*time.Minute))
+	require.NoError(t, err)
+
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for _, name := range []string{ethauth.SpanVerify, ethauth.SpanParse, ethauth.SpanSignature, ethauth.SpanDigest, ethauth.SpanRecover, ethauth.SpanStore} {
+		require.Contains(t, spans, name)
+	}
+	verify := spans[ethauth.SpanVerify]
+	require.Contains(t, verify.Attributes(), attribute.String(ethauth.AttributeApp, "ETHAuthTest"))
+	require.Contains(t, spans[ethauth.SpanSignature].Attributes(), attribute.String(ethauth.AttributeValidator, "ValidateEOAProof"))
+	require.Contains(t, spans[ethauth.SpanSignature].Attributes(), attribute.Bool(ethauth.AttributeCacheHit, false))
+	require.Contains(t, spans[ethauth.SpanStore].Attributes(), attribute.String(ethauth.AttributeStore, "nonce"))
+
+	// the spans of the steps are children of the verify span
+	for _, name := range []string{ethauth.SpanParse, ethauth.SpanSignature, ethauth.SpanStore} {
+		require.Equal(t, verify.SpanContext().SpanID(), spans[name].Parent().SpanID(), name)
+	}
+	require.Equal(t, spans[ethauth.SpanSignature].SpanContext().SpanID(), spans[ethauth.SpanRecover].Parent().SpanID())
+
+	// replayed proofs fail the verify span
+	ended := len(recorder.Ended())
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ethauth.ErrNonceUsed)
+	for _, span := range recorder.Ended()[ended:] {
+		if span.Name() == ethauth.SpanVerify {
+			require.Equal(t, codes.Error, span.Status().Code)
+			require.Contains(t, span.Attributes(), attribute.String(ethauth.AttributeFailureReason, "replayed"))
+		}
+	}
+}
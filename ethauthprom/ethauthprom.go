@@ -0,0 +1,110 @@
+// Package ethauthprom exports Prometheus metrics of ETHAuth proof verifications.
+//
+//	collector := ethauthprom.NewCollector("myapp")
+//	prometheus.MustRegister(collector)
+//	ethAuth.ConfigHooks(collector.Hooks())
+package ethauthprom
+
+import (
+	"context"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector of the metrics of the verifications observed by its Hooks:
+//
+//   - ethauth_verify_duration_seconds, a histogram of verification latency by result
+//   - ethauth_verify_failures_total, verification failures by ethauth.FailureReason
+//   - ethauth_cache_hits_total, proof signatures found in the verification cache
+//   - ethauth_rpc_calls_total and ethauth_rpc_call_duration_seconds, JSON-RPC calls of the
+//     contract account validators by method and result
+type Collector struct {
+	verifyDuration  *prometheus.HistogramVec
+	verifyFailures  *prometheus.CounterVec
+	cacheHits       prometheus.Counter
+	rpcCalls        *prometheus.CounterVec
+	rpcCallDuration *prometheus.HistogramVec
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// NewCollector returns a Collector whose metrics are prefixed by the namespace, if not empty.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		verifyDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "ethauth",
+			Name:      "verify_duration_seconds",
+			Help:      "Duration of ETHAuth proof verifications.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"result"}),
+		verifyFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ethauth",
+			Name:      "verify_failures_total",
+			Help:      "ETHAuth proof verification failures by reason.",
+		}, []string{"reason"}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ethauth",
+			Name:      "cache_hits_total",
+			Help:      "ETHAuth proof signatures found in the verification cache.",
+		}),
+		rpcCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ethauth",
+			Name:      "rpc_calls_total",
+			Help:      "JSON-RPC calls made to verify ETHAuth contract account proofs.",
+		}, []string{"method", "result"}),
+		rpcCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "ethauth",
+			Name:      "rpc_call_duration_seconds",
+			Help:      "Duration of the JSON-RPC calls made to verify ETHAuth contract account proofs.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+}
+
+// Hooks returns the hooks recording the metrics of the collector, to pass to
+// ETHAuth.ConfigHooks.
+func (c *Collector) Hooks() ethauth.Hooks {
+	return ethauth.Hooks{
+		OnVerifySuccess: func(ctx context.Context, proof *ethauth.Proof, duration time.Duration) {
+			c.verifyDuration.WithLabelValues("success").Observe(duration.Seconds())
+		},
+		OnVerifyFail: func(ctx context.Context, proof *ethauth.Proof, err error, duration time.Duration) {
+			c.verifyDuration.WithLabelValues("failure").Observe(duration.Seconds())
+			c.verifyFailures.WithLabelValues(ethauth.FailureReason(err)).Inc()
+		},
+		OnCacheHit: func(ctx context.Context, proof *ethauth.Proof) {
+			c.cacheHits.Inc()
+		},
+		OnRPCCall: func(ctx context.Context, method string, duration time.Duration, err error) {
+			result := "success"
+			if err != nil {
+				result = "error"
+			}
+			c.rpcCalls.WithLabelValues(method, result).Inc()
+			c.rpcCallDuration.WithLabelValues(method).Observe(duration.Seconds())
+		},
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.verifyDuration.Describe(ch)
+	c.verifyFailures.Describe(ch)
+	c.cacheHits.Describe(ch)
+	c.rpcCalls.Describe(ch)
+	c.rpcCallDuration.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.verifyDuration.Collect(ch)
+	c.verifyFailures.Collect(ch)
+	c.cacheHits.Collect(ch)
+	c.rpcCalls.Collect(ch)
+	c.rpcCallDuration.Collect(ch)
+}
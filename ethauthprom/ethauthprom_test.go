@@ -0,0 +1,42 @@
+package ethauthprom
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-ethauth"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector("")
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	ethAuth.ConfigHooks(collector.Hooks())
+
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	proof := ethauth.NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+	require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof("eth.invalid")
+	require.Error(t, err)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(collector.verifyFailures.WithLabelValues("invalid_proof")))
+	count, err := testutil.GatherAndCount(registry, "ethauth_verify_duration_seconds")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,185 @@
+// Package ethauthtest provides deterministic accounts, a mock signer and helpers to mint proofs
+// of arbitrary claims, including invalid and expired claims, so services can unit-test their
+// ETHAuth authentication without real keys or a JSON-RPC provider.
+//
+//	proofString := ethauthtest.Mint(t, ethauthtest.Alice, ethauthtest.ValidClaims("myapp"))
+//	req.Header.Set("Authorization", "Bearer "+proofString)
+package ethauthtest
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/go-ethauth"
+)
+
+// Mnemonic is the BIP-39 mnemonic of the test accounts. Never use it outside of tests.
+const Mnemonic = "outdoor sentence roast truly flower surface power begin ocean silent debate funny"
+
+// Account is a deterministic test account, derived from Mnemonic.
+type Account struct {
+	Index  uint32
+	Wallet *ethwallet.Wallet
+}
+
+// NewAccount returns the test account at the derivation path m/44'/60'/0'/0/<index> of Mnemonic.
+func NewAccount(index uint32) Account {
+	wallet, err := ethwallet.NewWalletFromMnemonic(Mnemonic, fmt.Sprintf("m/44'/60'/0'/0/%d", index))
+	if err != nil {
+		panic(fmt.Sprintf("ethauthtest: failed to derive account %d - %v", index, err))
+	}
+	return Account{Index: index, Wallet: wallet}
+}
+
+// Fixed test accounts. The address of Alice is 0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0.
+var (
+	Alice = NewAccount(0)
+	Bob   = NewAccount(1)
+	Carol = NewAccount(2)
+)
+
+// Address returns the address of the account.
+func (a Account) Address() common.Address {
+	return a.Wallet.Address()
+}
+
+// Signer returns a Signer of the account.
+func (a Account) Signer() ethauth.Signer {
+	return ethauth.NewWalletSigner(a.Wallet)
+}
+
+// Now is the fixed time of the claims of the golden vectors, see Vectors.
+var Now = time.Unix(1700000100, 0)
+
+// ValidClaims returns claims of the app, issued now and expiring in an hour.
+func ValidClaims(app string) ethauth.Claims {
+	claims := ethauth.Claims{App: app, ETHAuthVersion: ethauth.ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(time.Hour)
+	return claims
+}
+
+// ExpiredClaims returns claims of the app, issued two hours ago and expired an hour ago.
+func ExpiredClaims(app string) ethauth.Claims {
+	claims := ethauth.Claims{App: app, ETHAuthVersion: ethauth.ETHAuthVersion}
+	claims.IssuedAt = time.Now().Add(-2 * time.Hour).Unix()
+	claims.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	return claims
+}
+
+// Sign signs a proof of the claims by the account, without validating the claims, so it may
+// be used to sign invalid claims.
+func Sign(tb testing.TB, account Account, claims ethauth.Claims) *ethauth.Proof {
+	tb.Helper()
+	proof := ethauth.NewProof()
+	proof.Claims = claims
+	if err := ethauth.SignProofWithSigner(context.Background(), proof, account.Signer()); err != nil {
+		tb.Fatalf("ethauthtest: failed to sign proof - %v", err)
+	}
+	return proof
+}
+
+// Mint returns the proof string of the claims signed by the account, without validating the
+// claims, so it may be used to mint expired or otherwise invalid proofs.
+func Mint(tb testing.TB, account Account, claims ethauth.Claims) string {
+	tb.Helper()
+	return encode(tb, Sign(tb, account, claims))
+}
+
+// MintInvalidSignature returns the proof string of the claims for the account, signed by
+// another account, so its signature is invalid.
+func MintInvalidSignature(tb testing.TB, account Account, claims ethauth.Claims) string {
+	tb.Helper()
+	other := Bob
+	if account.Address() == Bob.Address() {
+		other = Alice
+	}
+	proof := Sign(tb, other, claims)
+	proof.Address = account.Address().Hex()
+	return encode(tb, proof)
+}
+
+func encode(tb testing.TB, proof *ethauth.Proof) string {
+	tb.Helper()
+	proofString, err := proof.Encode()
+	if err != nil {
+		tb.Fatalf("ethauthtest: failed to encode proof - %v", err)
+	}
+	return proofString
+}
+
+// MockSigner is an ethauth.Signer recording the proofs it signs, signing them with its
+// account, or failing with Err.
+type MockSigner struct {
+	// Account signs the proofs, defaulting to Alice
+	Account *Account
+
+	// Err, if set, is returned by Sign instead of a signature
+	Err error
+
+	signed []*ethauth.Proof
+	mu     sync.Mutex
+}
+
+var _ ethauth.Signer = &MockSigner{}
+
+func (s *MockSigner) account() Account {
+	if s.Account != nil {
+		return *s.Account
+	}
+	return Alice
+}
+
+func (s *MockSigner) Address() common.Address {
+	return s.account().Address()
+}
+
+func (s *MockSigner) Sign(ctx context.Context, proof *ethauth.Proof) ([]byte, error) {
+	s.mu.Lock()
+	s.signed = append(s.signed, proof)
+	s.mu.Unlock()
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return s.account().Signer().Sign(ctx, proof)
+}
+
+// Signed returns the proofs signed by the signer, in order.
+func (s *MockSigner) Signed() []*ethauth.Proof {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*ethauth.Proof(nil), s.signed...)
+}
+
+// Vector is a golden test vector of the message, digest and signature of claims signed by
+// Alice, which clients in other languages can test their encoding against. The vector proofs
+// are valid at Now.
+type Vector struct {
+	Name      string          `json:"name"`
+	Address   string          `json:"address"`
+	Claims    json.RawMessage `json:"claims"`
+	TypedData json.RawMessage `json:"typedData,omitempty"`
+	Message   string          `json:"message"`
+	Digest    string          `json:"digest"`
+	Signature string          `json:"signature"`
+	Proof     string          `json:"proof"`
+}
+
+//go:embed testdata/vectors.json
+var vectorsJSON []byte
+
+// Vectors returns the golden test vectors.
+func Vectors() []Vector {
+	var vectors []Vector
+	if err := json.Unmarshal(vectorsJSON, &vectors); err != nil {
+		panic(fmt.Sprintf("ethauthtest: invalid vectors - %v", err))
+	}
+	return vectors
+}
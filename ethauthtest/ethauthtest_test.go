@@ -0,0 +1,79 @@
+package ethauthtest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounts(t *testing.T) {
+	require.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", Alice.Address().Hex())
+	require.Equal(t, Alice.Address(), NewAccount(0).Address())
+	require.NotEqual(t, Alice.Address(), Bob.Address())
+	require.NotEqual(t, Bob.Address(), Carol.Address())
+}
+
+func TestMint(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	handler := ethauth.Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address, _ := ethauth.AddressFromContext(r.Context())
+		w.Write([]byte(address))
+	}))
+	serve := func(proofString string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", "Bearer "+proofString)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve(Mint(t, Bob, ValidClaims("ETHAuthTest")))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	require.Equal(t, http.StatusUnauthorized, serve(Mint(t, Alice, ExpiredClaims("ETHAuthTest"))).Code)
+	require.Equal(t, http.StatusUnauthorized, serve(MintInvalidSignature(t, Alice, ValidClaims("ETHAuthTest"))).Code)
+	require.Equal(t, http.StatusUnauthorized, serve(Mint(t, Alice, ethauth.Claims{App: "ETHAuthTest", ETHAuthVersion: ethauth.ETHAuthVersion})).Code)
+}
+
+func TestMockSigner(t *testing.T) {
+	signer := &MockSigner{Account: &Carol}
+	proofString, err := ethauth.Issue(signer, ethauth.WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	require.Len(t, signer.Signed(), 1)
+
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	_, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.Equal(t, Carol.Address(), signer.Address())
+	address, err := proof.AddressBytes()
+	require.NoError(t, err)
+	require.Equal(t, Carol.Address(), address)
+
+	signer = &MockSigner{Err: errors.New("user rejected")}
+	_, err = ethauth.Issue(signer, ethauth.WithApp("ETHAuthTest"))
+	require.ErrorContains(t, err, "user rejected")
+	require.Equal(t, Alice.Address(), signer.Address())
+}
+
+func TestVectors(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return Now }))
+
+	vectors := Vectors()
+	require.NotEmpty(t, vectors)
+	for _, v := range vectors {
+		_, proof, err := ethAuth.DecodeProof(v.Proof)
+		require.NoError(t, err, v.Name)
+		require.Equal(t, v.Signature, proof.Signature, v.Name)
+		require.Equal(t, Alice.Address().Hex(), v.Address, v.Name)
+	}
+}
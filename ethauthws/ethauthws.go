@@ -0,0 +1,233 @@
+// Package ethauthws authenticates gorilla/websocket connections with ETHAuth proofs.
+//
+// Browsers can't set the Authorization header of websocket handshakes, so the proof may also
+// be passed in the `proof` query parameter, or as the first message of the connection:
+//
+//	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+//		conn, err := ethauthws.UpgradeWithAuth(ethAuth, &upgrader, w, r, nil)
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		log.Printf("connected %s", conn.Address())
+//	})
+package ethauthws
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultQueryParam is the query parameter carrying the proof string of the handshake.
+const DefaultQueryParam = "proof"
+
+// DefaultFirstMessageTimeout is how long to wait for the proof in the first message.
+const DefaultFirstMessageTimeout = 10 * time.Second
+
+// ErrProofExpired is the reason the connection is closed with once its proof expires.
+var ErrProofExpired = errors.New("ethauthws: proof has expired")
+
+// Options configures UpgradeWithAuth.
+type Options struct {
+	// QueryParam is the query parameter carrying the proof string, defaulting to
+	// DefaultQueryParam. The Authorization header takes precedence over the query parameter.
+	QueryParam string
+
+	// FirstMessage reads the proof from the first text message of the connection when the
+	// handshake carries none, instead of rejecting the upgrade.
+	FirstMessage bool
+
+	// FirstMessageTimeout is how long to wait for the first message, defaulting to
+	// DefaultFirstMessageTimeout.
+	FirstMessageTimeout time.Duration
+
+	// ReauthBefore is how long before the proof expires OnReauth is called.
+	ReauthBefore time.Duration
+
+	// OnReauth, if set, is called ReauthBefore the proof of the connection expires, ie. to
+	// ask the client for a fresh proof to pass to Conn.Reauthenticate. Connections are
+	// closed when their proof expires.
+	OnReauth func(conn *Conn)
+
+	// Middleware are the options the proofs of the connection are authenticated with against
+	// the handshake request, as by ethauth.Middleware, ie. to verify their origin and host.
+	// Middleware.Optional and Middleware.ErrorHandler are ignored.
+	Middleware ethauth.MiddlewareOptions
+}
+
+// Conn is a websocket connection authenticated by an ETHAuth proof. Connections are closed
+// with a policy violation once their proof expires, unless reauthenticated with a fresh proof.
+type Conn struct {
+	*websocket.Conn
+
+	ethAuth *ethauth.ETHAuth
+	opts    Options
+	req     ethauth.AuthRequest
+	proof   *ethauth.Proof
+	timers  []*time.Timer
+	closed  bool
+	mu      sync.Mutex
+}
+
+// UpgradeWithAuth authenticates the websocket handshake with ethauth.Authenticate and
+// Options.Middleware, and upgrades the connection. The upgrade is rejected with a 401
+// Unauthorized status if the handshake carries an invalid proof, or no proof unless
+// Options.FirstMessage is set, in which case connections whose first message isn't a valid
+// proof are closed with a policy violation.
+func UpgradeWithAuth(ethAuth *ethauth.ETHAuth, upgrader *websocket.Upgrader, w http.ResponseWriter, r *http.Request, responseHeader http.Header, optOptions ...Options) (*Conn, error) {
+	var opts Options
+	if len(optOptions) > 0 {
+		opts = optOptions[0]
+	}
+	if opts.QueryParam == "" {
+		opts.QueryParam = DefaultQueryParam
+	}
+	if opts.FirstMessageTimeout == 0 {
+		opts.FirstMessageTimeout = DefaultFirstMessageTimeout
+	}
+
+	req := ethauth.NewAuthRequest(r)
+	if proofString := r.URL.Query().Get(opts.QueryParam); req.Authorization == "" && proofString != "" {
+		req.Authorization = "Bearer " + proofString
+	}
+	mwOpts := opts.Middleware
+	mwOpts.Optional = opts.FirstMessage
+	proof, err := ethauth.Authenticate(ethAuth, req, mwOpts)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return nil, err
+	}
+
+	wsConn, err := upgrader.Upgrade(w, r, responseHeader)
+	if err != nil {
+		return nil, err
+	}
+	conn := &Conn{Conn: wsConn, ethAuth: ethAuth, opts: opts, req: req}
+
+	if proof == nil {
+		proof, err = conn.readFirstMessage()
+		if err != nil {
+			conn.closeWithReason(err)
+			return nil, err
+		}
+	}
+	conn.setProof(proof)
+	return conn, nil
+}
+
+func (c *Conn) readFirstMessage() (*ethauth.Proof, error) {
+	c.SetReadDeadline(time.Now().Add(c.opts.FirstMessageTimeout))
+	messageType, message, err := c.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	c.SetReadDeadline(time.Time{})
+	if messageType != websocket.TextMessage {
+		return nil, fmt.Errorf("ethauth: missing proof")
+	}
+	return c.authenticate(strings.TrimSpace(string(message)))
+}
+
+// authenticate authenticates a proof sent over the connection against the handshake request,
+// with ethauth.Authenticate. The handshake carries no proof-of-possession or body for the
+// proof, so proofs bound to a client key or certificate, and request proofs, are rejected.
+func (c *Conn) authenticate(proofString string) (*ethauth.Proof, error) {
+	if proofString == "" {
+		return nil, fmt.Errorf("ethauth: missing proof")
+	}
+	req := c.req
+	req.Authorization = "Bearer " + proofString
+	req.PoP = ""
+	req.TLS = nil
+	req.Body = nil
+	mwOpts := c.opts.Middleware
+	mwOpts.Optional = false
+	return ethauth.Authenticate(c.ethAuth, req, mwOpts)
+}
+
+// Proof returns the verified proof of the connection.
+func (c *Conn) Proof() *ethauth.Proof {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.proof
+}
+
+// Address returns the account address of the verified proof of the connection.
+func (c *Conn) Address() string {
+	return c.Proof().Address
+}
+
+// Reauthenticate replaces the proof of the connection with a fresh proof of the same account,
+// extending the connection until the fresh proof expires.
+func (c *Conn) Reauthenticate(proofString string) error {
+	proof, err := c.authenticate(proofString)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(proof.Address, c.Address()) {
+		return fmt.Errorf("ethauthws: proof is for another account")
+	}
+	c.setProof(proof)
+	return nil
+}
+
+// Close stops the expiry of the connection, and closes it.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.stopTimers()
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+func (c *Conn) setProof(proof *ethauth.Proof) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.proof = proof
+	c.stopTimers()
+	if proof.Claims.ExpiresAt == 0 {
+		return
+	}
+
+	exp := time.Unix(proof.Claims.ExpiresAt, 0)
+	if c.opts.OnReauth != nil {
+		c.timers = append(c.timers, time.AfterFunc(time.Until(exp.Add(-c.opts.ReauthBefore)), func() {
+			c.opts.OnReauth(c)
+		}))
+	}
+	c.timers = append(c.timers, time.AfterFunc(time.Until(exp), func() {
+		c.mu.Lock()
+		expired := c.proof == proof && !c.closed
+		c.mu.Unlock()
+		if expired {
+			c.closeWithReason(ErrProofExpired)
+		}
+	}))
+}
+
+func (c *Conn) stopTimers() {
+	for _, t := range c.timers {
+		t.Stop()
+	}
+	c.timers = nil
+}
+
+// closeWithReason closes the connection with a policy violation.
+func (c *Conn) closeWithReason(err error) {
+	reason := err.Error()
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason), time.Now().Add(time.Second))
+	c.Close()
+}
@@ -0,0 +1,203 @@
+package ethauthws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-ethauth"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProof(t *testing.T, wallet *ethwallet.Wallet, ttl time.Duration) string {
+	proof := ethauth.NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+	return proofString
+}
+
+func newTestServer(t *testing.T, ethAuth *ethauth.ETHAuth, opts Options, reauth chan *Conn) *httptest.Server {
+	// origins are checked by ethauth, see Options.Middleware
+	upgrader := &websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	opts.OnReauth = func(conn *Conn) { reauth <- conn }
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := UpgradeWithAuth(ethAuth, upgrader, w, r, nil, opts)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(string(message), "eth.") {
+				err = conn.Reauthenticate(string(message))
+			}
+			if err == nil {
+				message = []byte(conn.Address())
+			} else {
+				message = []byte(err.Error())
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestUpgradeWithAuth(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	server := newTestServer(t, ethAuth, Options{}, make(chan *Conn, 1))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// upgrades without a valid proof are rejected
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	_, resp, err = websocket.DefaultDialer.Dial(wsURL+"?proof=eth.invalid", nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// from the query string
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?proof="+newTestProof(t, wallet, time.Minute), nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), string(message))
+	conn.Close()
+
+	// and from the authorization header
+	conn, _, err = websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer " + newTestProof(t, wallet, time.Minute)}})
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestUpgradeWithAuthFirstMessage(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	reauth := make(chan *Conn, 1)
+	server := newTestServer(t, ethAuth, Options{FirstMessage: true, ReauthBefore: time.Second}, reauth)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// connections whose first message isn't a valid proof are closed
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+	conn.Close()
+
+	// connections are reauthenticated before their proof expires
+	conn, _, err = websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(newTestProof(t, wallet, 2*time.Second))))
+
+	select {
+	case <-reauth:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not reauthenticated")
+	}
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(newTestProof(t, wallet, time.Minute))))
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), string(message))
+
+	// proofs of another account are rejected
+	other, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(newTestProof(t, other, time.Minute))))
+	_, message, err = conn.ReadMessage()
+	require.NoError(t, err)
+	require.Contains(t, string(message), "another account")
+
+	// and connections which aren't reauthenticated are closed once their proof expires
+	expiring, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer expiring.Close()
+	require.NoError(t, expiring.WriteMessage(websocket.TextMessage, []byte(newTestProof(t, wallet, 2*time.Second))))
+	expiring.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = expiring.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+}
+
+func TestUpgradeWithAuthMiddleware(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	opts := Options{FirstMessage: true, Middleware: ethauth.MiddlewareOptions{VerifyOrigin: true}}
+	server := newTestServer(t, ethAuth, opts, make(chan *Conn, 1))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	header := http.Header{"Origin": {"https://evil.example.com"}}
+
+	newOriginProof := func(origin string) string {
+		proof := ethauth.NewProof()
+		proof.Claims.App = "ETHAuthTest"
+		proof.Claims.Origin = origin
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(time.Minute)
+		require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+		proofString, err := proof.Encode()
+		require.NoError(t, err)
+		return proofString
+	}
+
+	// handshakes from another origin than the proof are rejected, as by ethauth.Middleware
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?proof="+newOriginProof("https://app.example.com"), header)
+	require.Error(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// as are first messages
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(newOriginProof("https://app.example.com"))))
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+
+	conn, _, err = websocket.DefaultDialer.Dial(wsURL+"?proof="+newOriginProof("https://evil.example.com"), header)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// proofs bound to a client key are only accepted with the proof-of-possession of the handshake
+	proof := ethauth.NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.Origin = "https://evil.example.com"
+	proof.Claims.Confirmation = ethauth.KeyConfirmation(wallet.Address())
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(time.Minute)
+	require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(proofString)))
+	_, message, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Contains(t, string(message), ethauth.ErrInvalidPoP.Error())
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(newOriginProof("https://evil.example.com"))))
+	_, message, err = conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), string(message))
+}
@@ -0,0 +1,6 @@
+// Package ewtverifier is the Go binding of the EWTVerifier contract, which verifies ETHAuth
+// proofs on-chain, see contracts/EWTVerifier.sol. Use ethauth.Proof.ToCalldata for the calldata
+// of an isValidProof call, ie. to gate a meta-transaction by a proof.
+package ewtverifier
+
+//go:generate ethkit abigen --abiFile=../contracts/EWTVerifier.abi --pkg=ewtverifier --type=EWTVerifier --outFile=ewtverifier.gen.go
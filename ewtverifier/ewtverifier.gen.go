@@ -0,0 +1,327 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package ewtverifier
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// EWTVerifierClaims is an auto generated low-level Go binding around an user-defined struct.
+type EWTVerifierClaims struct {
+	App   string
+	Iat   int64
+	Exp   int64
+	N     uint64
+	Typ   string
+	Ogn   string
+	Cid   uint64
+	Aud   string
+	Sub   string
+	Jti   string
+	Scope string
+	V     string
+	Cnf   string
+	Htm   string
+	Htp   string
+	Bdh   string
+	Ip    string
+	Ua    string
+}
+
+// EWTVerifierMetaData contains all meta data concerning the EWTVerifier contract.
+var EWTVerifierMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"app\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"iat\",\"type\":\"int64\"},{\"internalType\":\"int64\",\"name\":\"exp\",\"type\":\"int64\"},{\"internalType\":\"uint64\",\"name\":\"n\",\"type\":\"uint64\"},{\"internalType\":\"string\",\"name\":\"typ\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ogn\",\"type\":\"string\"},{\"internalType\":\"uint64\",\"name\":\"cid\",\"type\":\"uint64\"},{\"internalType\":\"string\",\"name\":\"aud\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"sub\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"jti\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"scope\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"v\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"cnf\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"htm\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"htp\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"bdh\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ip\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ua\",\"type\":\"string\"}],\"internalType\":\"structEWTVerifier.Claims\",\"name\":\"claims\",\"type\":\"tuple\"}],\"name\":\"claimsDigest\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"pure\",\"type\":\"function\"},{\"inputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"app\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"iat\",\"type\":\"int64\"},{\"internalType\":\"int64\",\"name\":\"exp\",\"type\":\"int64\"},{\"internalType\":\"uint64\",\"name\":\"n\",\"type\":\"uint64\"},{\"internalType\":\"string\",\"name\":\"typ\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ogn\",\"type\":\"string\"},{\"internalType\":\"uint64\",\"name\":\"cid\",\"type\":\"uint64\"},{\"internalType\":\"string\",\"name\":\"aud\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"sub\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"jti\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"scope\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"v\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"cnf\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"htm\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"htp\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"bdh\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ip\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ua\",\"type\":\"string\"}],\"internalType\":\"structEWTVerifier.Claims\",\"name\":\"c\",\"type\":\"tuple\"}],\"name\":\"claimsHash\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"pure\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint64\",\"name\":\"chainId\",\"type\":\"uint64\"}],\"name\":\"domainSeparator\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"pure\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"account\",\"type\":\"address\"},{\"components\":[{\"internalType\":\"string\",\"name\":\"app\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"iat\",\"type\":\"int64\"},{\"internalType\":\"int64\",\"name\":\"exp\",\"type\":\"int64\"},{\"internalType\":\"uint64\",\"name\":\"n\",\"type\":\"uint64\"},{\"internalType\":\"string\",\"name\":\"typ\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ogn\",\"type\":\"string\"},{\"internalType\":\"uint64\",\"name\":\"cid\",\"type\":\"uint64\"},{\"internalType\":\"string\",\"name\":\"aud\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"sub\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"jti\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"scope\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"v\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"cnf\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"htm\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"htp\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"bdh\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ip\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ua\",\"type\":\"string\"}],\"internalType\":\"structEWTVerifier.Claims\",\"name\":\"claims\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"signature\",\"type\":\"bytes\"}],\"name\":\"isValidProof\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+}
+
+// EWTVerifierABI is the input ABI used to generate the binding from.
+// Deprecated: Use EWTVerifierMetaData.ABI instead.
+var EWTVerifierABI = EWTVerifierMetaData.ABI
+
+// EWTVerifier is an auto generated Go binding around an Ethereum contract.
+type EWTVerifier struct {
+	EWTVerifierCaller     // Read-only binding to the contract
+	EWTVerifierTransactor // Write-only binding to the contract
+	EWTVerifierFilterer   // Log filterer for contract events
+}
+
+// EWTVerifierCaller is an auto generated read-only Go binding around an Ethereum contract.
+type EWTVerifierCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EWTVerifierTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type EWTVerifierTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EWTVerifierFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type EWTVerifierFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// EWTVerifierSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type EWTVerifierSession struct {
+	Contract     *EWTVerifier      // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// EWTVerifierCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type EWTVerifierCallerSession struct {
+	Contract *EWTVerifierCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts      // Call options to use throughout this session
+}
+
+// EWTVerifierTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type EWTVerifierTransactorSession struct {
+	Contract     *EWTVerifierTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts      // Transaction auth options to use throughout this session
+}
+
+// EWTVerifierRaw is an auto generated low-level Go binding around an Ethereum contract.
+type EWTVerifierRaw struct {
+	Contract *EWTVerifier // Generic contract binding to access the raw methods on
+}
+
+// EWTVerifierCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type EWTVerifierCallerRaw struct {
+	Contract *EWTVerifierCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// EWTVerifierTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type EWTVerifierTransactorRaw struct {
+	Contract *EWTVerifierTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewEWTVerifier creates a new instance of EWTVerifier, bound to a specific deployed contract.
+func NewEWTVerifier(address common.Address, backend bind.ContractBackend) (*EWTVerifier, error) {
+	contract, err := bindEWTVerifier(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &EWTVerifier{EWTVerifierCaller: EWTVerifierCaller{contract: contract}, EWTVerifierTransactor: EWTVerifierTransactor{contract: contract}, EWTVerifierFilterer: EWTVerifierFilterer{contract: contract}}, nil
+}
+
+// NewEWTVerifierCaller creates a new read-only instance of EWTVerifier, bound to a specific deployed contract.
+func NewEWTVerifierCaller(address common.Address, caller bind.ContractCaller) (*EWTVerifierCaller, error) {
+	contract, err := bindEWTVerifier(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EWTVerifierCaller{contract: contract}, nil
+}
+
+// NewEWTVerifierTransactor creates a new write-only instance of EWTVerifier, bound to a specific deployed contract.
+func NewEWTVerifierTransactor(address common.Address, transactor bind.ContractTransactor) (*EWTVerifierTransactor, error) {
+	contract, err := bindEWTVerifier(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EWTVerifierTransactor{contract: contract}, nil
+}
+
+// NewEWTVerifierFilterer creates a new log filterer instance of EWTVerifier, bound to a specific deployed contract.
+func NewEWTVerifierFilterer(address common.Address, filterer bind.ContractFilterer) (*EWTVerifierFilterer, error) {
+	contract, err := bindEWTVerifier(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &EWTVerifierFilterer{contract: contract}, nil
+}
+
+// bindEWTVerifier binds a generic wrapper to an already deployed contract.
+func bindEWTVerifier(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := EWTVerifierMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_EWTVerifier *EWTVerifierRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _EWTVerifier.Contract.EWTVerifierCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_EWTVerifier *EWTVerifierRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EWTVerifier.Contract.EWTVerifierTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_EWTVerifier *EWTVerifierRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _EWTVerifier.Contract.EWTVerifierTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_EWTVerifier *EWTVerifierCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _EWTVerifier.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_EWTVerifier *EWTVerifierTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _EWTVerifier.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_EWTVerifier *EWTVerifierTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _EWTVerifier.Contract.contract.Transact(opts, method, params...)
+}
+
+// ClaimsDigest is a free data retrieval call binding the contract method 0xcb43c6f4.
+//
+// Solidity: function claimsDigest((string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) claims) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierCaller) ClaimsDigest(opts *bind.CallOpts, claims EWTVerifierClaims) ([32]byte, error) {
+	var out []interface{}
+	err := _EWTVerifier.contract.Call(opts, &out, "claimsDigest", claims)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// ClaimsDigest is a free data retrieval call binding the contract method 0xcb43c6f4.
+//
+// Solidity: function claimsDigest((string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) claims) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierSession) ClaimsDigest(claims EWTVerifierClaims) ([32]byte, error) {
+	return _EWTVerifier.Contract.ClaimsDigest(&_EWTVerifier.CallOpts, claims)
+}
+
+// ClaimsDigest is a free data retrieval call binding the contract method 0xcb43c6f4.
+//
+// Solidity: function claimsDigest((string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) claims) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierCallerSession) ClaimsDigest(claims EWTVerifierClaims) ([32]byte, error) {
+	return _EWTVerifier.Contract.ClaimsDigest(&_EWTVerifier.CallOpts, claims)
+}
+
+// ClaimsHash is a free data retrieval call binding the contract method 0x7bc049b9.
+//
+// Solidity: function claimsHash((string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) c) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierCaller) ClaimsHash(opts *bind.CallOpts, c EWTVerifierClaims) ([32]byte, error) {
+	var out []interface{}
+	err := _EWTVerifier.contract.Call(opts, &out, "claimsHash", c)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// ClaimsHash is a free data retrieval call binding the contract method 0x7bc049b9.
+//
+// Solidity: function claimsHash((string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) c) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierSession) ClaimsHash(c EWTVerifierClaims) ([32]byte, error) {
+	return _EWTVerifier.Contract.ClaimsHash(&_EWTVerifier.CallOpts, c)
+}
+
+// ClaimsHash is a free data retrieval call binding the contract method 0x7bc049b9.
+//
+// Solidity: function claimsHash((string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) c) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierCallerSession) ClaimsHash(c EWTVerifierClaims) ([32]byte, error) {
+	return _EWTVerifier.Contract.ClaimsHash(&_EWTVerifier.CallOpts, c)
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xe9b8305a.
+//
+// Solidity: function domainSeparator(uint64 chainId) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierCaller) DomainSeparator(opts *bind.CallOpts, chainId uint64) ([32]byte, error) {
+	var out []interface{}
+	err := _EWTVerifier.contract.Call(opts, &out, "domainSeparator", chainId)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xe9b8305a.
+//
+// Solidity: function domainSeparator(uint64 chainId) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierSession) DomainSeparator(chainId uint64) ([32]byte, error) {
+	return _EWTVerifier.Contract.DomainSeparator(&_EWTVerifier.CallOpts, chainId)
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xe9b8305a.
+//
+// Solidity: function domainSeparator(uint64 chainId) pure returns(bytes32)
+func (_EWTVerifier *EWTVerifierCallerSession) DomainSeparator(chainId uint64) ([32]byte, error) {
+	return _EWTVerifier.Contract.DomainSeparator(&_EWTVerifier.CallOpts, chainId)
+}
+
+// IsValidProof is a free data retrieval call binding the contract method 0x9d5a3087.
+//
+// Solidity: function isValidProof(address account, (string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) claims, bytes signature) view returns(bool)
+func (_EWTVerifier *EWTVerifierCaller) IsValidProof(opts *bind.CallOpts, account common.Address, claims EWTVerifierClaims, signature []byte) (bool, error) {
+	var out []interface{}
+	err := _EWTVerifier.contract.Call(opts, &out, "isValidProof", account, claims, signature)
+
+	if err != nil {
+		return *new(bool), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(bool)).(*bool)
+
+	return out0, err
+
+}
+
+// IsValidProof is a free data retrieval call binding the contract method 0x9d5a3087.
+//
+// Solidity: function isValidProof(address account, (string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) claims, bytes signature) view returns(bool)
+func (_EWTVerifier *EWTVerifierSession) IsValidProof(account common.Address, claims EWTVerifierClaims, signature []byte) (bool, error) {
+	return _EWTVerifier.Contract.IsValidProof(&_EWTVerifier.CallOpts, account, claims, signature)
+}
+
+// IsValidProof is a free data retrieval call binding the contract method 0x9d5a3087.
+//
+// Solidity: function isValidProof(address account, (string,int64,int64,uint64,string,string,uint64,string,string,string,string,string,string,string,string,string,string,string) claims, bytes signature) view returns(bool)
+func (_EWTVerifier *EWTVerifierCallerSession) IsValidProof(account common.Address, claims EWTVerifierClaims, signature []byte) (bool, error) {
+	return _EWTVerifier.Contract.IsValidProof(&_EWTVerifier.CallOpts, account, claims, signature)
+}
@@ -0,0 +1,238 @@
+package ethauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// ProofTypeExchanged is the `typ` claim of proofs issued by a TokenExchanger, which are signed
+// by the key of the exchanging service on behalf of the account of their `sub` claim. They are
+// only accepted from the exchangers trusted by ETHAuth.ConfigTrustedExchangers.
+const ProofTypeExchanged = "exchanged"
+
+const (
+	// GrantTypeTokenExchange is the RFC 8693 grant type of token exchange requests.
+	GrantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// TokenTypeProof is the RFC 8693 token type of ETHAuth proofs.
+	TokenTypeProof = "urn:ethauth:params:oauth:token-type:proof"
+)
+
+// ExchangeOptions configures a TokenExchanger.
+type ExchangeOptions struct {
+	// Audiences are the audiences tokens may be exchanged for. Empty allows any audience.
+	Audiences []string
+
+	// TTL is the lifetime of the exchanged tokens, which defaults to 5 minutes. Exchanged
+	// tokens never outlive the token they are exchanged for.
+	TTL time.Duration
+}
+
+// ExchangeRequest is the downstream audience and scope a token is exchanged for.
+type ExchangeRequest struct {
+	// Audience is the `aud` claim of the exchanged token, ie. the downstream service
+	Audience string
+
+	// Scope is the scope of the exchanged token, which must be narrower than the scope of the
+	// subject token. Empty keeps the scope of the subject token.
+	Scope Scopes
+
+	// Request, if set, is the request the subject token is presented with, which subject tokens
+	// bound to a client key or certificate, client or host, and request proofs, are verified
+	// against as by Authenticate. Bound subject tokens are rejected without a request, as the
+	// exchanged tokens don't carry their bindings.
+	Request *AuthRequest
+}
+
+// TokenExchanger exchanges valid proofs for downstream-scoped proofs signed by a service key,
+// with RFC 8693 token exchange semantics, for hop-by-hop delegation through microservices:
+// the exchanged proof has the audience of the downstream service, a narrower scope and a
+// shorter expiry, and carries the account of the subject token as its `sub` claim. Tokens
+// exchanged by a TokenExchanger may be exchanged again for the next hop.
+//
+// TokenExchanger is an http.Handler serving RFC 8693 token exchange requests, passing the
+// proof as the `subject_token` form value of POST requests.
+type TokenExchanger struct {
+	ethAuth *ETHAuth
+	signer  Signer
+	options ExchangeOptions
+}
+
+// NewTokenExchanger returns a TokenExchanger which validates subject tokens with ethAuth, and
+// signs the exchanged tokens with the signer. Services accepting the exchanged tokens must
+// trust the signer address with ETHAuth.ConfigTrustedExchangers.
+func NewTokenExchanger(ethAuth *ETHAuth, signer Signer, optOptions ...ExchangeOptions) (*TokenExchanger, error) {
+	if ethAuth == nil {
+		return nil, fmt.Errorf("ethauth: token exchanger requires an ETHAuth instance")
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("ethauth: signer is nil")
+	}
+	x := &TokenExchanger{ethAuth: ethAuth, signer: signer}
+	if len(optOptions) > 0 {
+		x.options = optOptions[0]
+	}
+	if x.options.TTL == 0 {
+		x.options.TTL = 5 * time.Minute
+	}
+	return x, nil
+}
+
+// Exchange validates the subject token, and returns the proof string and proof of the token
+// it is exchanged for.
+func (x *TokenExchanger) Exchange(ctx context.Context, subjectToken string, req ExchangeRequest) (string, *Proof, error) {
+	if req.Audience == "" {
+		return "", nil, fmt.Errorf("ethauth: exchange audience is empty")
+	}
+	if len(x.options.Audiences) > 0 && !slices.Contains(x.options.Audiences, req.Audience) {
+		return "", nil, fmt.Errorf("%w %q", ErrInvalidExchangeAudience, req.Audience)
+	}
+
+	_, subject, err := x.ethAuth.decodeProof(ctx, subjectToken, func(proof *Proof) error {
+		return x.verifySubjectBinding(proof, req.Request)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	scope := req.Scope
+	if len(scope) == 0 {
+		scope = subject.Claims.Scope
+	}
+	if !isSubScope(scope.String(), subject.Claims.Scope.String()) {
+		return "", nil, ErrInvalidExchangeScope
+	}
+
+	account := subject.Account()
+	now := x.ethAuth.clock()
+	exp := now.Add(x.options.TTL).Unix()
+	if subject.Claims.ExpiresAt != 0 && subject.Claims.ExpiresAt < exp {
+		exp = subject.Claims.ExpiresAt
+	}
+
+	// exchanged tokens carry a random nonce, so services with a NonceStore accept each once
+	nonce, err := randomNonce()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the exchanged token is issued and validated by the clock of the instance, as Issue does
+	// by the wall clock
+	proof := NewProof()
+	proof.Claims.App = subject.Claims.App
+	proof.Claims.Type = ProofTypeExchanged
+	proof.Claims.ChainID = subject.Claims.ChainID
+	proof.Claims.Audience = req.Audience
+	proof.Claims.Subject = account
+	proof.Claims.Scope = scope
+	proof.Claims.Nonce = nonce
+	proof.Claims.IssuedAt = now.Unix()
+	proof.Claims.ExpiresAt = exp
+	proof.Claims = proof.Claims.Canonicalize()
+	if err := proof.Claims.ValidAt(now, DefaultValidatorConfig); err != nil {
+		return "", nil, fmt.Errorf("ethauth: invalid claims - %w", err)
+	}
+	if err := SignProofWithSigner(ctx, proof, x.signer); err != nil {
+		return "", nil, err
+	}
+	proofString, err := proof.Encode()
+	if err != nil {
+		return "", nil, err
+	}
+	return proofString, proof, nil
+}
+
+// verifySubjectBinding verifies the bindings of the subject token against the request it is
+// presented with, including the host of its `dom` claim, or rejects bound subject tokens if
+// there is no request.
+func (x *TokenExchanger) verifySubjectBinding(proof *Proof, req *AuthRequest) error {
+	if req == nil {
+		if proof.Claims.ClientIP != "" || proof.Claims.UserAgent != "" {
+			return fmt.Errorf("%w, subject token is bound to its client", ErrInvalidClientBinding)
+		}
+		req = &AuthRequest{}
+	}
+	return verifyAuthRequest(x.ethAuth, proof, *req, MiddlewareOptions{VerifyHost: proof.Claims.Host != ""})
+}
+
+func (x *TokenExchanger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if r.PostFormValue("grant_type") != GrantTypeTokenExchange {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+	subjectToken := r.PostFormValue("subject_token")
+	if subjectToken == "" || r.PostFormValue("subject_token_type") != TokenTypeProof {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "missing proof subject_token"})
+		return
+	}
+	if t := r.PostFormValue("requested_token_type"); t != "" && t != TokenTypeProof {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "unsupported requested_token_type"})
+		return
+	}
+	audience := r.PostFormValue("audience")
+	if audience == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "missing audience"})
+		return
+	}
+
+	authReq := NewAuthRequest(r)
+	token, proof, err := x.Exchange(r.Context(), subjectToken, ExchangeRequest{Audience: audience, Scope: ParseScopes(r.PostFormValue("scope")), Request: &authReq})
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrInvalidExchangeAudience):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_target"})
+		return
+	case errors.Is(err, ErrInvalidExchangeScope):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_scope"})
+		return
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token":      token,
+		"issued_token_type": TokenTypeProof,
+		"token_type":        "Bearer",
+		"expires_in":        proof.Claims.ExpiresAt - x.ethAuth.clock().Unix(),
+		"scope":             proof.Claims.Scope.String(),
+	})
+}
+
+// ConfigTrustedExchangers sets the addresses of the TokenExchanger signers whose exchanged
+// proofs are accepted, on behalf of the account of their `sub` claim. Exchanged proofs are
+// rejected unless they are signed by a trusted exchanger.
+func (w *ETHAuth) ConfigTrustedExchangers(exchangers ...common.Address) error {
+	for _, exchanger := range exchangers {
+		if exchanger == (common.Address{}) {
+			return fmt.Errorf("ethauth: trusted exchanger address is zero")
+		}
+	}
+	w.exchangers = exchangers
+	return nil
+}
+
+// verifyExchanger returns ErrUntrustedExchanger if the proof is an exchanged proof which is not
+// signed by a trusted exchanger.
+func (w *ETHAuth) verifyExchanger(proof *Proof) error {
+	if proof.Claims.Type != ProofTypeExchanged {
+		return nil
+	}
+	address, err := proof.AddressBytes()
+	if err != nil || !slices.Contains(w.exchangers, address) {
+		return ErrUntrustedExchanger
+	}
+	if proof.Claims.Subject == "" {
+		return fmt.Errorf("%w, exchanged proof has no subject", ErrUntrustedExchanger)
+	}
+	return nil
+}
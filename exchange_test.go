@@ -0,0 +1,257 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenExchanger(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	serviceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	serviceSigner := NewPrivateKeySigner(serviceKey)
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigTrustedExchangers(serviceSigner.Address()))
+	exchanger, err := NewTokenExchanger(ethAuth, serviceSigner, ExchangeOptions{Audiences: []string{"orders", "payments"}, TTL: time.Minute})
+	require.NoError(t, err)
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithScope("orders:read", "orders:write", "payments:read"), WithExpiresIn(time.Hour))
+	require.NoError(t, err)
+
+	// the exchanged token is signed by the service, on behalf of the account
+	exchanged, proof, err := exchanger.Exchange(context.Background(), proofString, ExchangeRequest{Audience: "orders", Scope: Scopes{"orders:read"}})
+	require.NoError(t, err)
+	require.Equal(t, ProofTypeExchanged, proof.Claims.Type)
+	require.Equal(t, "orders", proof.Claims.Audience)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), proof.Claims.Subject)
+	require.Equal(t, Scopes{"orders:read"}, proof.Claims.Scope)
+	require.LessOrEqual(t, proof.Claims.ExpiresAt, time.Now().Add(time.Minute).Unix())
+
+	orders, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.NoError(t, orders.ConfigExpectedAudience("orders"))
+	_, _, err = orders.DecodeProof(exchanged)
+	require.ErrorIs(t, err, ErrUntrustedExchanger)
+	require.NoError(t, orders.ConfigTrustedExchangers(serviceSigner.Address()))
+	ok, decoded, err := orders.DecodeProof(exchanged)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), decoded.Claims.Subject)
+
+	// each exchanged token carries its own nonce, so it is accepted once by a NonceStore
+	require.NotZero(t, proof.Claims.Nonce)
+	orders.ConfigNonceStore(NewMemoryNonceStore())
+	_, _, err = orders.DecodeProof(exchanged)
+	require.NoError(t, err)
+	_, _, err = orders.DecodeProof(exchanged)
+	require.ErrorIs(t, err, ErrNonceUsed)
+	_, again, err := exchanger.Exchange(context.Background(), proofString, ExchangeRequest{Audience: "orders", Scope: Scopes{"orders:read"}})
+	require.NoError(t, err)
+	require.NotEqual(t, proof.Claims.Nonce, again.Claims.Nonce)
+
+	// exchanged tokens are issued by the clock of the instance
+	now := time.Now().Add(10 * time.Minute)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now }))
+	_, clocked, err := exchanger.Exchange(context.Background(), proofString, ExchangeRequest{Audience: "orders"})
+	require.NoError(t, err)
+	require.Equal(t, now.Unix(), clocked.Claims.IssuedAt)
+	require.Equal(t, now.Add(time.Minute).Unix(), clocked.Claims.ExpiresAt)
+	require.NoError(t, ethAuth.ConfigClock(time.Now))
+
+	// the exchanged token may be exchanged again, for the next hop
+	_, next, err := exchanger.Exchange(context.Background(), exchanged, ExchangeRequest{Audience: "payments"})
+	require.NoError(t, err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), next.Claims.Subject)
+	require.Equal(t, Scopes{"orders:read"}, next.Claims.Scope)
+	require.LessOrEqual(t, next.Claims.ExpiresAt, proof.Claims.ExpiresAt)
+
+	// exchanges can't widen the scope, nor target other audiences
+	_, _, err = exchanger.Exchange(context.Background(), exchanged, ExchangeRequest{Audience: "payments", Scope: Scopes{"payments:read"}})
+	require.ErrorIs(t, err, ErrInvalidExchangeScope)
+	_, _, err = exchanger.Exchange(context.Background(), proofString, ExchangeRequest{Audience: "admin"})
+	require.ErrorIs(t, err, ErrInvalidExchangeAudience)
+	_, _, err = exchanger.Exchange(context.Background(), proofString, ExchangeRequest{})
+	require.Error(t, err)
+
+	// subject tokens bound to a client key are only exchanged with the proof-of-possession of
+	// the exchange request, as their exchanged tokens are bearer tokens
+	clientKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bound, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithScope("orders:read"), WithConfirmation(KeyConfirmation(crypto.PubkeyToAddress(clientKey.PublicKey))))
+	require.NoError(t, err)
+	_, _, err = exchanger.Exchange(context.Background(), bound, ExchangeRequest{Audience: "orders"})
+	require.ErrorIs(t, err, ErrInvalidPoP)
+	exchangeReq := AuthRequest{Method: "POST", Host: "exchange.example.com", Path: "/token"}
+	_, _, err = exchanger.Exchange(context.Background(), bound, ExchangeRequest{Audience: "orders", Request: &exchangeReq})
+	require.ErrorIs(t, err, ErrInvalidPoP)
+	boundProof, err := Parse(bound)
+	require.NoError(t, err)
+	exchangeReq.PoP, err = SignPoP(boundProof, clientKey, "POST", "exchange.example.com", "/token")
+	require.NoError(t, err)
+	_, _, err = exchanger.Exchange(context.Background(), bound, ExchangeRequest{Audience: "orders", Request: &exchangeReq})
+	require.NoError(t, err)
+
+	// as are subject tokens bound to their client or host
+	clientBound, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithUserAgent("test-agent"))
+	require.NoError(t, err)
+	_, _, err = exchanger.Exchange(context.Background(), clientBound, ExchangeRequest{Audience: "orders"})
+	require.ErrorIs(t, err, ErrInvalidClientBinding)
+	hostBound, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithHost("app.example.com"))
+	require.NoError(t, err)
+	_, _, err = exchanger.Exchange(context.Background(), hostBound, ExchangeRequest{Audience: "orders"})
+	require.ErrorIs(t, err, ErrInvalidHostBinding)
+
+	// and, once enforced, the client binding of subject tokens is verified against the client of
+	// the exchange request
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingExact, BindingOff))
+	ipBound, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithClientIP(netip.MustParsePrefix("192.0.2.1/32")))
+	require.NoError(t, err)
+	_, _, err = exchanger.Exchange(context.Background(), ipBound, ExchangeRequest{Audience: "orders", Request: &AuthRequest{RemoteAddr: "198.51.100.1:1234"}})
+	require.ErrorIs(t, err, ErrInvalidClientBinding)
+	_, _, err = exchanger.Exchange(context.Background(), ipBound, ExchangeRequest{Audience: "orders", Request: &AuthRequest{RemoteAddr: "192.0.2.1:1234"}})
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingOff, BindingOff))
+
+	// accounts can't exchange tokens for themselves
+	forged, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithAudience("orders"), WithSubject("0x0000000000000000000000000000000000000001"), func(claims *Claims) {
+		claims.Type = ProofTypeExchanged
+	})
+	require.NoError(t, err)
+	_, _, err = orders.DecodeProof(forged)
+	require.ErrorIs(t, err, ErrUntrustedExchanger)
+	require.Equal(t, "untrusted_exchanger", FailureReason(err))
+}
+
+func TestTokenExchangerHandler(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	serviceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	exchanger, err := NewTokenExchanger(ethAuth, NewPrivateKeySigner(serviceKey), ExchangeOptions{Audiences: []string{"orders"}})
+	require.NoError(t, err)
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithScope("read", "write"))
+	require.NoError(t, err)
+
+	post := func(form url.Values) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		exchanger.ServeHTTP(rec, req)
+		resp := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		return rec.Code, resp
+	}
+	exchange := func(audience, scope string) url.Values {
+		return url.Values{
+			"grant_type":         {GrantTypeTokenExchange},
+			"subject_token":      {proofString},
+			"subject_token_type": {TokenTypeProof},
+			"audience":           {audience},
+			"scope":              {scope},
+		}
+	}
+
+	status, resp := post(exchange("orders", "read"))
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, TokenTypeProof, resp["issued_token_type"])
+	require.Equal(t, "Bearer", resp["token_type"])
+	require.Equal(t, "read", resp["scope"])
+	proof, err := Parse(resp["access_token"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "orders", proof.Claims.Audience)
+
+	for form, expected := range map[*url.Values]string{
+		ptr(exchange("admin", "read")):                        "invalid_target",
+		ptr(exchange("orders", "admin")):                      "invalid_scope",
+		ptr(exchange("", "read")):                             "invalid_request",
+		ptr(url.Values{"grant_type": {"client_credentials"}}): "unsupported_grant_type",
+	} {
+		status, resp := post(*form)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, expected, resp["error"])
+	}
+
+	form := exchange("orders", "read")
+	form.Set("subject_token", "eth.invalid")
+	status, resp = post(form)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalid_grant", resp["error"])
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestTokenExchangerAccount(t *testing.T) {
+	alice, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	bob, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	serviceKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	serviceSigner := NewPrivateKeySigner(serviceKey)
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	exchanger, err := NewTokenExchanger(ethAuth, serviceSigner)
+	require.NoError(t, err)
+	exchange := func(wallet *ethwallet.Wallet) string {
+		proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+		require.NoError(t, err)
+		exchanged, _, err := exchanger.Exchange(context.Background(), proofString, ExchangeRequest{Audience: "orders"})
+		require.NoError(t, err)
+		return exchanged
+	}
+
+	orders, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	require.NoError(t, orders.ConfigTrustedExchangers(serviceSigner.Address()))
+	revocations := NewMemoryRevocationStore()
+	orders.ConfigRevocationStore(revocations)
+
+	// exchanged proofs are the proofs of their account, not of the exchanger
+	aliceToken, bobToken := exchange(alice), exchange(bob)
+	var address string
+	handler := Middleware(orders)(RateLimitMiddleware(RateLimitOptions{Limit: RateLimit{Rate: 1, Burst: 1}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address, _ = AddressFromContext(r.Context())
+	})))
+	request := func(proofString string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, request(aliceToken))
+	require.Equal(t, strings.ToLower(alice.Address().Hex()), address)
+
+	// each account has its own rate limit bucket
+	require.Equal(t, http.StatusTooManyRequests, request(aliceToken))
+	require.Equal(t, http.StatusOK, request(bobToken))
+	require.Equal(t, strings.ToLower(bob.Address().Hex()), address)
+
+	// and revoking the account revokes its exchanged proofs
+	require.NoError(t, revocations.RevokeAddress(context.Background(), alice.Address().Hex()))
+	_, _, err = orders.DecodeProof(aliceToken)
+	require.ErrorIs(t, err, ErrProofRevoked)
+	_, _, err = orders.DecodeProof(bobToken)
+	require.NoError(t, err)
+}
@@ -0,0 +1,358 @@
+package ethauth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// FailoverPolicy configures the failover of the JSON-RPC providers of
+// ETHAuth.ConfigJsonRpcProviders. Zero values take the defaults of DefaultFailoverPolicy.
+type FailoverPolicy struct {
+	// Timeout is the timeout of each attempt of a JSON-RPC call
+	Timeout time.Duration
+
+	// MaxAttempts is the number of attempts of a JSON-RPC call across the providers, which
+	// defaults to two attempts per provider
+	MaxAttempts int
+
+	// Backoff is the delay before retrying the providers once each of them failed a call,
+	// which doubles each round of retries up to MaxBackoff. Failing over to the next provider
+	// of a round is immediate.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// BreakerThreshold is the number of consecutive failures of a provider which opens its
+	// circuit breaker, so the provider is skipped until BreakerCooldown has elapsed. The
+	// breaker then lets a single trial call through, closing the circuit if it succeeds.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DefaultFailoverPolicy times out attempts after 5 seconds, makes at most two attempts per
+// provider, and opens the circuit of a provider for 30 seconds after 5 consecutive failures.
+var DefaultFailoverPolicy = FailoverPolicy{
+	Timeout:          5 * time.Second,
+	Backoff:          100 * time.Millisecond,
+	MaxBackoff:       2 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// BreakerState is the state of the circuit breaker of a JSON-RPC provider.
+type BreakerState int
+
+const (
+	// BreakerClosed providers are called
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen providers are skipped until the breaker cooldown has elapsed
+	BreakerOpen
+
+	// BreakerHalfOpen providers are let a single trial call through
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// RPCProviderStatus is the circuit breaker status of a JSON-RPC provider, see ETHAuth.RPCStatus.
+type RPCProviderStatus struct {
+	// Endpoint is the scheme and host of the provider URL, whose path and query, which often
+	// carry an access key, are redacted
+	Endpoint string
+
+	State BreakerState
+
+	// Failures is the number of consecutive failures of the provider
+	Failures int
+}
+
+// ErrRPCUnavailable is returned by JSON-RPC calls when every provider has failed the call, or
+// has an open circuit breaker.
+var ErrRPCUnavailable = errors.New("ethauth: json-rpc providers are unavailable")
+
+// failoverClient is the HTTP client of the ethrpc.Provider of ETHAuth.ConfigJsonRpcProviders,
+// sending each JSON-RPC request to the first available provider, and failing over to the next
+// provider when a request fails.
+type failoverClient struct {
+	endpoints []*rpcEndpoint
+	policy    FailoverPolicy
+	hooks     *Hooks
+	logger    *Logger
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+type rpcEndpoint struct {
+	url      *url.URL
+	endpoint string
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// ConfigJsonRpcProviders configures JSON-RPC providers which fail over to one another, so the
+// EIP-1271 validation of contract account proofs survives the outage of a provider. Calls are
+// sent to the providers in order of preference, each attempt with the timeout of the policy,
+// and retried with backoff, while a circuit breaker skips failing providers. Breaker state
+// changes are reported by the OnRPCBreaker hook, see also RPCStatus. Only transport errors,
+// timeouts and 5xx or 429 responses fail over; JSON-RPC errors, ie. reverts, are returned.
+func (w *ETHAuth) ConfigJsonRpcProviders(urls []string, policy FailoverPolicy, optChainId ...int64) error {
+	if len(urls) == 0 {
+		return fmt.Errorf("ethauth: json-rpc provider list is empty")
+	}
+	client := &failoverClient{policy: policy.withDefaults(len(urls)), hooks: &w.hooks, logger: &w.logger, now: time.Now}
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("ethauth: invalid json-rpc provider url")
+		}
+		client.endpoints = append(client.endpoints, &rpcEndpoint{url: u, endpoint: u.Scheme + "://" + u.Host})
+	}
+
+	provider, err := ethrpc.NewProvider(urls[0], ethrpc.WithHTTPClient(client))
+	if err != nil {
+		return err
+	}
+	if err := w.configProvider(provider, urls[0], optChainId); err != nil {
+		return err
+	}
+	w.failover = client
+	return nil
+}
+
+// RPCStatus returns the circuit breaker status of the providers of ConfigJsonRpcProviders, ie.
+// for health checks, or nil if the providers don't fail over.
+func (w *ETHAuth) RPCStatus() []RPCProviderStatus {
+	if w.failover == nil {
+		return nil
+	}
+	c := w.failover
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := make([]RPCProviderStatus, len(c.endpoints))
+	for i, e := range c.endpoints {
+		status[i] = RPCProviderStatus{Endpoint: e.endpoint, State: e.state, Failures: e.failures}
+		if e.state == BreakerOpen && c.now().Sub(e.openedAt) >= c.policy.BreakerCooldown {
+			status[i].State = BreakerHalfOpen
+		}
+	}
+	return status
+}
+
+func (p FailoverPolicy) withDefaults(providers int) FailoverPolicy {
+	d := DefaultFailoverPolicy
+	if p.Timeout <= 0 {
+		p.Timeout = d.Timeout
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 2 * providers
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = d.Backoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = d.MaxBackoff
+	}
+	if p.BreakerThreshold <= 0 {
+		p.BreakerThreshold = d.BreakerThreshold
+	}
+	if p.BreakerCooldown <= 0 {
+		p.BreakerCooldown = d.BreakerCooldown
+	}
+	return p
+}
+
+func (c *failoverClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	tried := map[*rpcEndpoint]bool{}
+	round := 0
+	for attempt := 0; attempt < c.policy.MaxAttempts; attempt++ {
+		e := c.acquire(ctx, tried)
+		if e == nil && len(tried) > 0 {
+			// each available provider failed the call, which is retried after the backoff
+			round++
+			clear(tried)
+			if err := sleepContext(ctx, c.backoff(round)); err != nil {
+				return nil, err
+			}
+			e = c.acquire(ctx, tried)
+		}
+		if e == nil {
+			errs = append(errs, fmt.Errorf("every circuit breaker is open"))
+			break
+		}
+		tried[e] = true
+
+		res, err := c.send(ctx, req, e, body)
+		if ctx.Err() != nil {
+			// the call was canceled, the provider didn't fail
+			c.release(e)
+			return nil, ctx.Err()
+		}
+		if err == nil {
+			c.succeeded(ctx, e)
+			return res, nil
+		}
+		c.failed(ctx, e)
+		errs = append(errs, fmt.Errorf("%s: %w", e.endpoint, err))
+	}
+	return nil, fmt.Errorf("%w - %w", ErrRPCUnavailable, errors.Join(errs...))
+}
+
+// send sends the request to the provider, and returns its response, read within the timeout
+// of the attempt.
+func (c *failoverClient) send(ctx context.Context, req *http.Request, e *rpcEndpoint, body []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.policy.Timeout)
+	defer cancel()
+
+	r := req.Clone(ctx)
+	r.URL = e.url
+	r.Host = e.url.Host
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("status code %d", res.StatusCode)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	return res, nil
+}
+
+func (c *failoverClient) backoff(round int) time.Duration {
+	d := c.policy.Backoff
+	for i := 1; i < round && d < c.policy.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, c.policy.MaxBackoff)
+}
+
+// acquire returns the first provider not yet tried whose circuit breaker lets a call through,
+// or nil.
+func (c *failoverClient) acquire(ctx context.Context, tried map[*rpcEndpoint]bool) *rpcEndpoint {
+	c.mu.Lock()
+	now := c.now()
+	var acquired, halfOpened *rpcEndpoint
+	for _, e := range c.endpoints {
+		if tried[e] {
+			continue
+		}
+		switch e.state {
+		case BreakerClosed:
+			acquired = e
+		case BreakerOpen:
+			if now.Sub(e.openedAt) >= c.policy.BreakerCooldown {
+				e.state, e.trial = BreakerHalfOpen, true
+				acquired, halfOpened = e, e
+			}
+		case BreakerHalfOpen:
+			if !e.trial {
+				e.trial = true
+				acquired = e
+			}
+		}
+		if acquired != nil {
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if halfOpened != nil {
+		c.notify(ctx, halfOpened, BreakerHalfOpen)
+	}
+	return acquired
+}
+
+func (c *failoverClient) succeeded(ctx context.Context, e *rpcEndpoint) {
+	c.mu.Lock()
+	closed := e.state != BreakerClosed
+	e.state, e.failures, e.trial = BreakerClosed, 0, false
+	c.mu.Unlock()
+
+	if closed {
+		c.notify(ctx, e, BreakerClosed)
+	}
+}
+
+func (c *failoverClient) failed(ctx context.Context, e *rpcEndpoint) {
+	c.mu.Lock()
+	e.failures++
+	e.trial = false
+	opened := e.state == BreakerHalfOpen || (e.state == BreakerClosed && e.failures >= c.policy.BreakerThreshold)
+	if opened {
+		e.state, e.openedAt = BreakerOpen, c.now()
+	}
+	c.mu.Unlock()
+
+	if opened {
+		c.notify(ctx, e, BreakerOpen)
+	}
+}
+
+// release releases the trial call of a half open provider, whose call was canceled.
+func (c *failoverClient) release(e *rpcEndpoint) {
+	c.mu.Lock()
+	e.trial = false
+	c.mu.Unlock()
+}
+
+func (c *failoverClient) notify(ctx context.Context, e *rpcEndpoint, state BreakerState) {
+	if logger := *c.logger; logger != nil && state == BreakerOpen {
+		logger.Warn(ctx, "ethauth: json-rpc provider circuit breaker opened", "endpoint", e.endpoint)
+	}
+	if c.hooks.OnRPCBreaker != nil {
+		c.hooks.OnRPCBreaker(ctx, e.endpoint, state)
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,145 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFailoverTestServer returns a JSON-RPC server answering 0x89 to every call, which fails
+// with the status code while failing is set, and counts its requests.
+func newFailoverTestServer(failing *atomic.Int32, requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if status := failing.Load(); status != 0 {
+			w.WriteHeader(int(status))
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x89"})
+	}))
+}
+
+func TestConfigJsonRpcProviders(t *testing.T) {
+	var primaryFailing, primaryRequests, secondaryFailing, secondaryRequests atomic.Int32
+	primary := newFailoverTestServer(&primaryFailing, &primaryRequests)
+	defer primary.Close()
+	secondary := newFailoverTestServer(&secondaryFailing, &secondaryRequests)
+	defer secondary.Close()
+
+	var mu sync.Mutex
+	var transitions []string
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigHooks(Hooks{OnRPCBreaker: func(ctx context.Context, endpoint string, state BreakerState) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotContains(t, endpoint, "/key")
+		transitions = append(transitions, state.String())
+	}})
+
+	policy := FailoverPolicy{Backoff: time.Millisecond, BreakerThreshold: 2, BreakerCooldown: time.Minute}
+	require.NoError(t, ethAuth.ConfigJsonRpcProviders([]string{primary.URL + "/key", secondary.URL + "/key"}, policy))
+	require.Equal(t, int64(137), ethAuth.chainID.Int64())
+	require.Equal(t, int32(1), primaryRequests.Load())
+	require.Zero(t, secondaryRequests.Load())
+
+	now := time.Now()
+	ethAuth.failover.now = func() time.Time { return now }
+
+	// the primary provider fails over to the secondary provider
+	primaryFailing.Store(http.StatusServiceUnavailable)
+	number, err := ethAuth.provider.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(137), number)
+	require.Equal(t, int32(2), primaryRequests.Load())
+	require.Equal(t, int32(1), secondaryRequests.Load())
+
+	// the circuit of the primary provider opens after consecutive failures, so it is skipped
+	_, err = ethAuth.provider.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(3), primaryRequests.Load())
+	_, err = ethAuth.provider.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int32(3), primaryRequests.Load())
+	require.Equal(t, int32(3), secondaryRequests.Load())
+
+	status := ethAuth.RPCStatus()
+	require.Len(t, status, 2)
+	require.Equal(t, RPCProviderStatus{Endpoint: primary.URL, State: BreakerOpen, Failures: 2}, status[0])
+	require.Equal(t, RPCProviderStatus{Endpoint: secondary.URL, State: BreakerClosed}, status[1])
+
+	// every provider failing, calls are retried before failing
+	secondaryFailing.Store(http.StatusTooManyRequests)
+	_, err = ethAuth.provider.BlockNumber(context.Background())
+	require.ErrorIs(t, err, ErrRPCUnavailable)
+	require.ErrorContains(t, err, "status code 429")
+
+	// once both circuits are open, calls fail fast
+	requests := secondaryRequests.Load()
+	_, err = ethAuth.provider.BlockNumber(context.Background())
+	require.ErrorContains(t, err, "every circuit breaker is open")
+	require.Equal(t, requests, secondaryRequests.Load())
+
+	// after the cooldown, a trial call closes the circuit of the recovered provider
+	primaryFailing.Store(0)
+	now = now.Add(time.Minute)
+	require.Equal(t, BreakerHalfOpen, ethAuth.RPCStatus()[0].State)
+	_, err = ethAuth.provider.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, RPCProviderStatus{Endpoint: primary.URL, State: BreakerClosed}, ethAuth.RPCStatus()[0])
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"open", "open", "half_open", "closed"}, transitions)
+}
+
+func TestFailoverTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	var failing, requests atomic.Int32
+	fast := newFailoverTestServer(&failing, &requests)
+	defer fast.Close()
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	policy := FailoverPolicy{Timeout: 50 * time.Millisecond, MaxAttempts: 2}
+	require.NoError(t, ethAuth.ConfigJsonRpcProviders([]string{slow.URL, fast.URL}, policy))
+	require.Equal(t, int64(137), ethAuth.chainID.Int64())
+	require.Equal(t, int32(1), requests.Load())
+	require.Equal(t, 1, ethAuth.RPCStatus()[0].Failures)
+
+	// canceled calls don't count as failures of the provider
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ethAuth.provider.BlockNumber(ctx)
+	require.Error(t, err)
+	require.Equal(t, 1, ethAuth.RPCStatus()[0].Failures)
+	require.Equal(t, int32(1), requests.Load())
+
+	require.ErrorContains(t, ethAuth.ConfigJsonRpcProviders(nil, policy), "empty")
+	require.Error(t, ethAuth.ConfigJsonRpcProviders([]string{"localhost"}, policy, 1))
+	require.True(t, strings.HasPrefix(ethAuth.RPCStatus()[0].Endpoint, "http://127.0.0.1"))
+
+	// a single provider doesn't fail over
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(fast.URL, 1))
+	require.Nil(t, ethAuth.RPCStatus())
+}
@@ -0,0 +1,282 @@
+package ethauth
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// GuardianDigest returns the digest countersigned by the guardian, which commits to the
+// proof address, claims and account signature.
+func (t *Proof) GuardianDigest() ([]byte, error) {
+	address, err := t.AddressBytes()
+	if err != nil {
+		return nil, err
+	}
+	messageDigest, err := t.MessageDigest()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := ethcoder.HexDecode(t.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid signature encoding - %w", err)
+	}
+	return crypto.Keccak256(address.Bytes(), messageDigest, signature), nil
+}
+
+// CountersignProof countersigns the account-signed proof with the guardian key, and sets the
+// proof guardian signature. Once an ETHAuth instance is configured with ConfigGuardian, it only
+// accepts proofs countersigned by the guardian, so rotating the guardian key invalidates all
+// of the proofs countersigned with the previous key, unless the keys are rotated through a
+// GuardianKeySet.
+func CountersignProof(proof *Proof, guardianKey *ecdsa.PrivateKey) error {
+	digest, err := proof.GuardianDigest()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(digest, guardianKey)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to countersign proof - %w", err)
+	}
+	sig[64] += 27
+	proof.GuardianSignature = ethcoder.HexEncode(sig)
+	return nil
+}
+
+// ConfigGuardian requires the proofs accepted by this ETHAuth instance to be countersigned
+// by the guardian address, see CountersignProof. The zero address disables the requirement.
+// ConfigGuardian replaces the key set of ConfigGuardianKeys.
+func (w *ETHAuth) ConfigGuardian(guardian common.Address) {
+	w.guardian = guardian
+	w.guardianKeys = nil
+}
+
+// ConfigGuardianKeys requires the proofs accepted by this ETHAuth instance to be countersigned
+// by a key of the guardian key set, whose keys can be rotated without invalidating the proofs
+// countersigned by the previous key. ConfigGuardianKeys replaces the guardian of ConfigGuardian.
+func (w *ETHAuth) ConfigGuardianKeys(keys *GuardianKeySet) {
+	w.guardianKeys = keys
+	w.guardian = common.Address{}
+}
+
+// ValidateGuardianSignature validates the proof countersignature by the configured guardian.
+func (w *ETHAuth) ValidateGuardianSignature(proof *Proof) error {
+	if w.guardianKeys != nil {
+		_, err := w.guardianKeys.Verify(proof)
+		return err
+	}
+	if w.guardian == (common.Address{}) {
+		return nil
+	}
+	if proof.GuardianSignature == "" {
+		return fmt.Errorf("%w, missing guardian signature", ErrInvalidGuardianSignature)
+	}
+	digest, err := proof.GuardianDigest()
+	if err != nil {
+		return fmt.Errorf("%w - %w", ErrInvalidGuardianSignature, err)
+	}
+	signature, err := ethcoder.HexDecode(proof.GuardianSignature)
+	if err != nil {
+		return fmt.Errorf("%w - %w", ErrInvalidGuardianSignature, err)
+	}
+	isValid, err := isValidEOASignature(w.guardian, digest, signature)
+	if err != nil || !isValid {
+		return ErrInvalidGuardianSignature
+	}
+	return nil
+}
+
+// GuardianKey is a countersigning key of a GuardianKeySet.
+type GuardianKey struct {
+	// ID identifies the key in the key set
+	ID string
+
+	// Address of the key, which the countersignatures of proofs recover to
+	Address common.Address
+
+	// PrivateKey of the key, which is only required to countersign proofs with the key set,
+	// see GuardianKeySet.Countersign
+	PrivateKey *ecdsa.PrivateKey
+
+	// RetiredAt is the time the key was retired at, or zero while the key is active. Retired
+	// keys no longer countersign proofs, but their countersignatures stay valid for the grace
+	// period of the key set.
+	RetiredAt time.Time
+}
+
+// GuardianKeySet is the set of guardian keys of the server-countersigned mode, see
+// ETHAuth.ConfigGuardianKeys. Proofs are countersigned by the most recent active key, and
+// accepted when countersigned by any active key, or by a key retired within the grace period,
+// so rotating the guardian key doesn't log out the accounts whose proofs were countersigned by
+// the previous key. Compromised keys should be removed rather than retired.
+type GuardianKeySet struct {
+	keys  []GuardianKey
+	grace time.Duration
+	now   func() time.Time
+	mu    sync.RWMutex
+}
+
+// NewGuardianKeySet returns a GuardianKeySet of the active keys, whose retired keys stay valid
+// for the grace period, ie. the lifetime of the countersigned proofs.
+func NewGuardianKeySet(grace time.Duration, keys ...GuardianKey) (*GuardianKeySet, error) {
+	s := &GuardianKeySet{grace: grace, now: time.Now}
+	for _, key := range keys {
+		if err := s.Add(key); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add adds an active key to the key set, which countersigns proofs from now on if it has a
+// private key.
+func (s *GuardianKeySet) Add(key GuardianKey) error {
+	return s.add(key, false)
+}
+
+// Rotate adds the active key to the key set and retires the previously active keys, whose
+// countersignatures stay valid for the grace period.
+func (s *GuardianKeySet) Rotate(key GuardianKey) error {
+	return s.add(key, true)
+}
+
+func (s *GuardianKeySet) add(key GuardianKey, rotate bool) error {
+	if key.ID == "" {
+		return fmt.Errorf("ethauth: guardian key requires an id")
+	}
+	if key.PrivateKey != nil {
+		address := crypto.PubkeyToAddress(key.PrivateKey.PublicKey)
+		if key.Address != (common.Address{}) && key.Address != address {
+			return fmt.Errorf("ethauth: guardian key %q address is not the address of its private key", key.ID)
+		}
+		key.Address = address
+	}
+	if key.Address == (common.Address{}) {
+		return fmt.Errorf("ethauth: guardian key %q requires an address", key.ID)
+	}
+	key.RetiredAt = time.Time{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.ContainsFunc(s.keys, func(k GuardianKey) bool { return k.ID == key.ID }) {
+		return fmt.Errorf("ethauth: guardian key %q already exists", key.ID)
+	}
+	if rotate {
+		now := s.now()
+		for i := range s.keys {
+			if s.keys[i].RetiredAt.IsZero() {
+				s.keys[i].RetiredAt = now
+			}
+		}
+	}
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+// Retire retires the active key, whose countersignatures stay valid for the grace period, and
+// reports whether the key was active.
+func (s *GuardianKeySet) Retire(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.keys, func(k GuardianKey) bool { return k.ID == id })
+	if i < 0 || !s.keys[i].RetiredAt.IsZero() {
+		return false
+	}
+	s.keys[i].RetiredAt = s.now()
+	return true
+}
+
+// Remove removes the key from the key set, so its countersignatures are rejected immediately,
+// ie. once the key is compromised, and reports whether the key was in the key set.
+func (s *GuardianKeySet) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.keys, func(k GuardianKey) bool { return k.ID == id })
+	if i < 0 {
+		return false
+	}
+	s.keys = slices.Delete(s.keys, i, i+1)
+	return true
+}
+
+// Keys returns the keys of the key set, without their private keys, including the retired keys
+// whose grace period has elapsed until they are removed.
+func (s *GuardianKeySet) Keys() []GuardianKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]GuardianKey, len(s.keys))
+	for i, key := range s.keys {
+		key.PrivateKey = nil
+		keys[i] = key
+	}
+	return keys
+}
+
+// Countersign countersigns the proof with the most recent active key of the key set which has a
+// private key, and returns the id of the key.
+func (s *GuardianKeySet) Countersign(proof *Proof) (string, error) {
+	s.mu.RLock()
+	var key *GuardianKey
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i].RetiredAt.IsZero() && s.keys[i].PrivateKey != nil {
+			key = &s.keys[i]
+			break
+		}
+	}
+	var id string
+	var privateKey *ecdsa.PrivateKey
+	if key != nil {
+		id, privateKey = key.ID, key.PrivateKey
+	}
+	s.mu.RUnlock()
+
+	if privateKey == nil {
+		return "", fmt.Errorf("ethauth: guardian key set has no active private key")
+	}
+	if err := CountersignProof(proof, privateKey); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Verify validates the countersignature of the proof by an active key of the key set, or by a
+// key retired within the grace period, and returns the id of the key.
+func (s *GuardianKeySet) Verify(proof *Proof) (string, error) {
+	if proof.GuardianSignature == "" {
+		return "", fmt.Errorf("%w, missing guardian signature", ErrInvalidGuardianSignature)
+	}
+	digest, err := proof.GuardianDigest()
+	if err != nil {
+		return "", fmt.Errorf("%w - %w", ErrInvalidGuardianSignature, err)
+	}
+	signature, err := ethcoder.HexDecode(proof.GuardianSignature)
+	if err != nil {
+		return "", fmt.Errorf("%w - %w", ErrInvalidGuardianSignature, err)
+	}
+	address, err := recoverSignerAddress(digest, signature)
+	if err != nil {
+		return "", ErrInvalidGuardianSignature
+	}
+
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	err = ErrInvalidGuardianSignature
+	for _, key := range s.keys {
+		if key.Address != address {
+			continue
+		}
+		if !key.RetiredAt.IsZero() && !now.Before(key.RetiredAt.Add(s.grace)) {
+			err = fmt.Errorf("%w, guardian key %q was retired", ErrInvalidGuardianSignature, key.ID)
+			continue
+		}
+		return key.ID, nil
+	}
+	return "", err
+}
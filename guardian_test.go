@@ -0,0 +1,136 @@
+package ethauth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardianCountersignature(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	guardianKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ethAuth.ConfigGuardian(crypto.PubkeyToAddress(guardianKey.PublicKey))
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proof := signTestProof(t, wallet, claims)
+
+	// the wallet signature alone is not enough
+	_, err = ethAuth.EncodeProof(proof)
+	require.ErrorIs(t, err, ErrInvalidGuardianSignature)
+
+	require.NoError(t, CountersignProof(proof, guardianKey))
+	proofString, err := ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(proofString, "."), 6)
+
+	ok, decoded, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "", decoded.Extra)
+	require.Equal(t, proof.GuardianSignature, decoded.GuardianSignature)
+
+	// rotating the guardian key invalidates the countersigned proofs
+	newGuardianKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	ethAuth.ConfigGuardian(crypto.PubkeyToAddress(newGuardianKey.PublicKey))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrInvalidGuardianSignature)
+
+	_, err = Parse(proofString + ".")
+	require.Error(t, err)
+}
+
+func TestGuardianKeySet(t *testing.T) {
+	newKey := func(id string) GuardianKey {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		return GuardianKey{ID: id, PrivateKey: key}
+	}
+	keys, err := NewGuardianKeySet(time.Hour, newKey("k1"))
+	require.NoError(t, err)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigGuardianKeys(keys)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	newProof := func() *Proof {
+		claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(5 * time.Minute)
+		return signTestProof(t, wallet, claims)
+	}
+
+	proof := newProof()
+	_, err = ethAuth.EncodeProof(proof)
+	require.ErrorIs(t, err, ErrInvalidGuardianSignature)
+	id, err := keys.Countersign(proof)
+	require.NoError(t, err)
+	require.Equal(t, "k1", id)
+	_, err = ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+
+	// proofs countersigned by the previous key stay valid for the grace period
+	require.NoError(t, keys.Rotate(newKey("k2")))
+	id, err = keys.Verify(proof)
+	require.NoError(t, err)
+	require.Equal(t, "k1", id)
+
+	rotated := newProof()
+	id, err = keys.Countersign(rotated)
+	require.NoError(t, err)
+	require.Equal(t, "k2", id)
+	_, err = ethAuth.EncodeProof(rotated)
+	require.NoError(t, err)
+
+	all := keys.Keys()
+	require.Len(t, all, 2)
+	require.Equal(t, now, all[0].RetiredAt)
+	require.True(t, all[1].RetiredAt.IsZero())
+	require.Nil(t, all[1].PrivateKey)
+
+	now = now.Add(time.Hour)
+	_, err = ethAuth.EncodeProof(proof)
+	require.ErrorIs(t, err, ErrInvalidGuardianSignature)
+	require.ErrorContains(t, err, `guardian key "k1" was retired`)
+	_, err = ethAuth.EncodeProof(rotated)
+	require.NoError(t, err)
+
+	// removed keys are rejected immediately
+	require.True(t, keys.Remove("k2"))
+	require.False(t, keys.Remove("k2"))
+	_, err = ethAuth.EncodeProof(rotated)
+	require.ErrorIs(t, err, ErrInvalidGuardianSignature)
+	_, err = keys.Countersign(newProof())
+	require.Error(t, err)
+
+	// keys without private keys only verify countersignatures
+	guardianKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, keys.Add(GuardianKey{ID: "k3", Address: crypto.PubkeyToAddress(guardianKey.PublicKey)}))
+	require.NoError(t, CountersignProof(rotated, guardianKey))
+	_, err = ethAuth.EncodeProof(rotated)
+	require.NoError(t, err)
+	require.True(t, keys.Retire("k3"))
+	require.False(t, keys.Retire("k3"))
+
+	require.Error(t, keys.Add(GuardianKey{ID: "k3", Address: crypto.PubkeyToAddress(guardianKey.PublicKey)}))
+	require.Error(t, keys.Add(GuardianKey{ID: "k4"}))
+	require.Error(t, keys.Add(GuardianKey{Address: crypto.PubkeyToAddress(guardianKey.PublicKey)}))
+	require.Error(t, keys.Add(GuardianKey{ID: "k5", Address: crypto.PubkeyToAddress(guardianKey.PublicKey), PrivateKey: newKey("").PrivateKey}))
+}
@@ -0,0 +1,82 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// HardwareWallet is an account held on a hardware wallet device, such as a Ledger or Trezor.
+// The device drivers are left to the application, so this package doesn't depend on USB/HID
+// libraries. For example, a go-ethereum accounts/usbwallet wallet can be adapted with:
+//
+//	func (w *ledger) SignTypedDataHash(ctx context.Context, domainSeparator, messageHash []byte) ([]byte, error) {
+//		data := append([]byte{0x19, 0x01}, append(domainSeparator, messageHash...)...)
+//		return w.wallet.SignData(w.account, accounts.MimetypeTypedData, data)
+//	}
+//
+//	func (w *ledger) SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error) {
+//		return w.wallet.SignText(w.account, message)
+//	}
+type HardwareWallet interface {
+	// Address of the account on the device
+	Address() common.Address
+
+	// SignTypedDataHash signs the EIP712 typed data given by its domain separator and
+	// message struct hash, as displayed by the device for approval
+	SignTypedDataHash(ctx context.Context, domainSeparator, messageHash []byte) ([]byte, error)
+
+	// SignPersonalMessage signs the EIP-191 personal_sign message
+	SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// NewHardwareSigner returns a Signer for the account of a hardware wallet, so proofs can be
+// signed with hardware-held keys without exporting them.
+func NewHardwareSigner(wallet HardwareWallet) Signer {
+	return &hardwareSigner{wallet: wallet}
+}
+
+type hardwareSigner struct {
+	wallet HardwareWallet
+}
+
+func (s *hardwareSigner) Address() common.Address {
+	return s.wallet.Address()
+}
+
+func (s *hardwareSigner) Sign(ctx context.Context, proof *Proof) ([]byte, error) {
+	message, err := proof.Message()
+	if err != nil {
+		return nil, err
+	}
+
+	var sig []byte
+	switch proof.Claims.Type {
+	case ProofTypeSIWE, ProofTypeEIP191:
+		sig, err = s.wallet.SignPersonalMessage(ctx, message)
+	default:
+		// the EIP712 message is encoded as 0x1901 || domainSeparator || messageHash
+		if len(message) != 66 {
+			return nil, fmt.Errorf("ethauth: unexpected EIP712 message encoding")
+		}
+		sig, err = s.wallet.SignTypedDataHash(ctx, message[2:34], message[34:66])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: hardware wallet failed to sign - %w", err)
+	}
+
+	// ensure the device signed with the expected account, ie. the same derivation path
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := recoverSignerAddress(digest, sig)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: hardware wallet returned an invalid signature - %w", err)
+	}
+	if signer != s.wallet.Address() {
+		return nil, fmt.Errorf("ethauth: hardware wallet signed with %s, expecting %s", signer.Hex(), s.wallet.Address().Hex())
+	}
+	return sig, nil
+}
@@ -0,0 +1,68 @@
+package ethauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testHardwareWallet emulates a hardware wallet device with an in-memory wallet.
+type testHardwareWallet struct {
+	wallet *ethwallet.Wallet
+}
+
+func (w *testHardwareWallet) Address() common.Address {
+	return w.wallet.Address()
+}
+
+func (w *testHardwareWallet) SignTypedDataHash(ctx context.Context, domainSeparator, messageHash []byte) ([]byte, error) {
+	digest := crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash)
+	return crypto.Sign(digest, w.wallet.PrivateKey())
+}
+
+func (w *testHardwareWallet) SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error) {
+	return w.wallet.SignMessage(message)
+}
+
+func TestHardwareSigner(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	signer := NewHardwareSigner(&testHardwareWallet{wallet: wallet})
+
+	for _, typ := range []string{"", ProofTypeEIP191} {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", Type: typ, ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignProofWithSigner(context.Background(), proof, signer))
+
+		_, err = ethAuth.EncodeProof(proof)
+		require.NoError(t, err, typ)
+	}
+
+	// signatures by another account of the device are rejected
+	other, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	signer = NewHardwareSigner(&mismatchedHardwareWallet{testHardwareWallet{wallet: other}, wallet.Address()})
+	proof := NewProof()
+	proof.Claims = Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+	require.Error(t, SignProofWithSigner(context.Background(), proof, signer))
+}
+
+type mismatchedHardwareWallet struct {
+	testHardwareWallet
+	address common.Address
+}
+
+func (w *mismatchedHardwareWallet) Address() common.Address {
+	return w.address
+}
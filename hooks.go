@@ -0,0 +1,125 @@
+package ethauth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Hooks are callbacks observing proof verifications, ie. to export metrics or structured
+// events. Any of the hooks may be nil. Hooks are called synchronously, so they must not block.
+type Hooks struct {
+	// OnVerifySuccess is called when a proof is decoded and verified, with the duration
+	// of the verification.
+	OnVerifySuccess func(ctx context.Context, proof *Proof, duration time.Duration)
+
+	// OnVerifyFail is called when a proof fails to decode or verify. The proof is nil if
+	// the proof string couldn't be parsed. See FailureReason to classify the error.
+	OnVerifyFail func(ctx context.Context, proof *Proof, err error, duration time.Duration)
+
+	// OnExpired is called when a proof is rejected because it has expired, before OnVerifyFail.
+	OnExpired func(ctx context.Context, proof *Proof)
+
+	// OnCacheHit is called when the signature of a proof is found in the verification cache.
+	OnCacheHit func(ctx context.Context, proof *Proof)
+
+	// OnRPCCall is called after each JSON-RPC call made by the contract account validators
+	// to verify a proof signature, ie. "eth_getCode" and "eth_call".
+	OnRPCCall func(ctx context.Context, method string, duration time.Duration, err error)
+
+	// OnRPCBreaker is called when the circuit breaker of a JSON-RPC provider configured with
+	// ConfigJsonRpcProviders changes state. The endpoint is the scheme and host of the provider.
+	OnRPCBreaker func(ctx context.Context, endpoint string, state BreakerState)
+
+	// OnReplay is called when an authenticated request uses a proof first used by another
+	// client, see ConfigReplayDetection.
+	OnReplay func(ctx context.Context, proof *Proof, event ReplayEvent)
+}
+
+// ConfigHooks sets the hooks observing proof verifications.
+func (w *ETHAuth) ConfigHooks(hooks Hooks) {
+	w.hooks = hooks
+}
+
+// observeVerification calls the verification hooks and the audit logger with the outcome of
+// decoding a proof, and ends its span, see startVerification.
+func (w *ETHAuth) observeVerification(ctx context.Context, start time.Time, proof *Proof, err error) {
+	endVerification(ctx, proof, err)
+	if w.auditLogger != nil {
+		w.logVerification(ctx, start, proof, err)
+	}
+	duration := time.Since(start)
+	if err == nil {
+		if w.hooks.OnVerifySuccess != nil {
+			w.hooks.OnVerifySuccess(ctx, proof, duration)
+		}
+		return
+	}
+	if w.hooks.OnExpired != nil && errors.Is(err, ErrProofExpired) {
+		w.hooks.OnExpired(ctx, proof)
+	}
+	if w.hooks.OnVerifyFail != nil {
+		w.hooks.OnVerifyFail(ctx, proof, err, duration)
+	}
+}
+
+var hooksCtxKey = &contextKey{"hooks"}
+
+// observeRPCCall calls the OnRPCCall hook of the ETHAuth instance verifying the proof, which
+// passes its hooks to the validators in the context.
+func observeRPCCall(ctx context.Context, method string, start time.Time, err error) {
+	hooks, ok := ctx.Value(hooksCtxKey).(*Hooks)
+	if ok && hooks.OnRPCCall != nil {
+		hooks.OnRPCCall(ctx, method, time.Since(start), err)
+	}
+}
+
+// failureReasons are the reasons returned by FailureReason, in the order errors are matched.
+var failureReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrProofExpired, "expired"},
+	{ErrIssuedInFuture, "issued_in_future"},
+	{ErrInvalidSignature, "invalid_signature"},
+	{ErrInvalidGuardianSignature, "invalid_guardian_signature"},
+	{ErrInvalidAddress, "invalid_address"},
+	{ErrInvalidAudience, "invalid_audience"},
+	{ErrInvalidChainID, "invalid_chain_id"},
+	{ErrInvalidApp, "invalid_app"},
+	{ErrInvalidOrigin, "invalid_origin"},
+	{ErrMissingScope, "missing_scope"},
+	{ErrLifetimeExceeded, "policy_violation"},
+	{ErrScopeNotAllowed, "policy_violation"},
+	{ErrMissingApp, "invalid_claims"},
+	{ErrMissingIssuedAt, "invalid_claims"},
+	{ErrBadVersion, "invalid_version"},
+	{ErrUnsupportedVersion, "invalid_version"},
+	{ErrDeprecatedVersion, "deprecated_version"},
+	{ErrMissingNonce, "missing_nonce"},
+	{ErrUnboundedNonce, "invalid_claims"},
+	{ErrNonceUsed, "replayed"},
+	{ErrInvalidChallenge, "invalid_challenge"},
+	{ErrProofRevoked, "revoked"},
+	{ErrInvalidConfirmation, "invalid_claims"},
+	{ErrInvalidPoP, "invalid_pop"},
+	{ErrInvalidRequestBinding, "invalid_request_binding"},
+	{ErrInvalidClientBinding, "invalid_client_binding"},
+	{ErrInvalidHostBinding, "invalid_host_binding"},
+	{ErrInvalidMerkleProof, "invalid_merkle_proof"},
+	{ErrUnknownSmartAccount, "unknown_smart_account"},
+	{ErrTokenOwnership, "insufficient_tokens"},
+	{ErrUntrustedExchanger, "untrusted_exchanger"},
+}
+
+// FailureReason classifies a verification error into a short reason, suitable as a metric
+// label, ie. "expired" or "invalid_signature". Errors of malformed proofs, and other errors,
+// are classified as "invalid_proof".
+func FailureReason(err error) string {
+	for _, r := range failureReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return "invalid_proof"
+}
@@ -0,0 +1,86 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigCache(NewVerificationCache(10, time.Minute))
+
+	var successes, expired, cacheHits int
+	var failures []string
+	ethAuth.ConfigHooks(Hooks{
+		OnVerifySuccess: func(ctx context.Context, proof *Proof, duration time.Duration) { successes++ },
+		OnVerifyFail: func(ctx context.Context, proof *Proof, err error, duration time.Duration) {
+			failures = append(failures, FailureReason(err))
+		},
+		OnExpired:  func(ctx context.Context, proof *Proof) { expired++ },
+		OnCacheHit: func(ctx context.Context, proof *Proof) { cacheHits++ },
+	})
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := signTestProof(t, wallet, claims).Encode()
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, _, err = ethAuth.DecodeProof(proofString)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, successes)
+	require.Equal(t, 1, cacheHits)
+
+	claims.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	expiredProof, err := signTestProof(t, wallet, claims).Encode()
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(expiredProof)
+	require.Error(t, err)
+	_, _, err = ethAuth.DecodeProof("eth.invalid")
+	require.Error(t, err)
+
+	require.Equal(t, 1, expired)
+	require.Equal(t, []string{"expired", "invalid_proof"}, failures)
+}
+
+func TestHooksRPCCall(t *testing.T) {
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	var ethCalls atomic.Int32
+	server := newMulticallTestServer(t, account, &ethCalls, nil, nil)
+	defer server.Close()
+
+	batch := NewBatchRemoteValidator()
+	ethAuth, err := New(batch.ValidateContractAccountProof)
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(server.URL, 1))
+
+	var rpcCalls []string
+	ethAuth.ConfigHooks(Hooks{
+		OnRPCCall: func(ctx context.Context, method string, duration time.Duration, err error) {
+			rpcCalls = append(rpcCalls, fmt.Sprintf("%s %v", method, err))
+		},
+	})
+
+	proof := NewProof()
+	proof.Address = account.Hex()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+	proof.Signature = "0x01"
+	_, err = ethAuth.ValidateProof(proof)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth_call <nil>"}, rpcCalls)
+}
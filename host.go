@@ -0,0 +1,78 @@
+package ethauth
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// HostRules are the rules of matching the Host of requests with the host a proof is bound to,
+// see MiddlewareOptions.VerifyHost.
+type HostRules struct {
+	// AllowWildcard accepts the `dom` claims of the form "*.example.com", which match the
+	// hosts of any subdomain of example.com. Wildcard claims are rejected otherwise.
+	AllowWildcard bool
+
+	// IgnorePort ignores the ports of the Host of the request and of the host of the proof, ie.
+	// for proofs issued by a frontend served from another port than the API
+	IgnorePort bool
+}
+
+// WithHost sets the `dom` claim binding the proof to the hosts it may be used on, ie.
+// "api.example.com", or "*.example.com" for any subdomain, see MiddlewareOptions.VerifyHost.
+func WithHost(host string) IssueOption {
+	return func(claims *Claims) {
+		claims.Host = host
+	}
+}
+
+// validHost validates that the `dom` claim is a host, with an optional port and "*." prefix.
+func (c Claims) validHost() error {
+	if c.Host == "" {
+		return nil
+	}
+	host := strings.TrimPrefix(c.Host, "*.")
+	if host == "" || strings.ContainsAny(host, "*/?#@ ") {
+		return fmt.Errorf("claims: dom is not a host")
+	}
+	return nil
+}
+
+// verifyHostBinding verifies that the request is made to the host the proof is bound to, by
+// its `dom` claim, or the host of its `ogn` claim for proofs without a `dom` claim.
+func verifyHostBinding(proof *Proof, req AuthRequest, rules HostRules) error {
+	pattern := proof.Claims.Host
+	if pattern == "" && proof.Claims.Origin != "" {
+		if u, err := url.Parse(proof.Claims.Origin); err == nil {
+			pattern = u.Host
+		}
+	}
+	if pattern == "" {
+		return fmt.Errorf("%w, proof has no dom or ogn claim", ErrInvalidHostBinding)
+	}
+	if req.Host == "" || !matchHost(pattern, req.Host, rules) {
+		return fmt.Errorf("%w, proof is bound to %s", ErrInvalidHostBinding, pattern)
+	}
+	return nil
+}
+
+// matchHost reports whether the host matches the host pattern of a proof with the rules.
+func matchHost(pattern, host string, rules HostRules) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if rules.IgnorePort {
+		pattern, host = stripPort(pattern), stripPort(host)
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return rules.AllowWildcard && strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == pattern
+}
+
+// stripPort returns the host without its port, if any, and IPv6 hosts without brackets.
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+}
@@ -0,0 +1,90 @@
+package ethauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchHost(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, host string
+		rules         HostRules
+		match         bool
+	}{
+		{"api.example.com", "api.example.com", HostRules{}, true},
+		{"api.example.com", "API.example.com", HostRules{}, true},
+		{"api.example.com", "staging.example.com", HostRules{}, false},
+		{"api.example.com", "api.example.com:8443", HostRules{}, false},
+		{"api.example.com", "api.example.com:8443", HostRules{IgnorePort: true}, true},
+		{"localhost:3000", "localhost:8080", HostRules{IgnorePort: true}, true},
+		{"[::1]", "[::1]:8080", HostRules{IgnorePort: true}, true},
+		{"*.example.com", "api.example.com", HostRules{}, false},
+		{"*.example.com", "api.example.com", HostRules{AllowWildcard: true}, true},
+		{"*.example.com", "a.b.example.com", HostRules{AllowWildcard: true}, true},
+		{"*.example.com", "example.com", HostRules{AllowWildcard: true}, false},
+		{"*.example.com", "evilexample.com", HostRules{AllowWildcard: true}, false},
+		{"*.example.com:8443", "api.example.com", HostRules{AllowWildcard: true, IgnorePort: true}, true},
+	} {
+		require.Equal(t, tc.match, matchHost(tc.pattern, tc.host, tc.rules), "%s %s %+v", tc.pattern, tc.host, tc.rules)
+	}
+}
+
+func TestMiddlewareVerifyHost(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	authenticate := func(host, proofString string, opts MiddlewareOptions) error {
+		req := httptest.NewRequest("GET", "http://"+host+"/", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		_, err := Authenticate(ethAuth, NewAuthRequest(req), opts)
+		return err
+	}
+	verifyHost := MiddlewareOptions{VerifyHost: true}
+
+	// proofs of the dom claim
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithOrigin("https://app.example.com"), WithHost("api.example.com"))
+	require.NoError(t, err)
+	require.NoError(t, authenticate("api.example.com", proofString, verifyHost))
+	require.ErrorIs(t, authenticate("api.staging.example.com", proofString, verifyHost), ErrInvalidHostBinding)
+	require.NoError(t, authenticate("api.staging.example.com", proofString, MiddlewareOptions{}))
+
+	// proofs of the ogn claim
+	proofString, err = Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithOrigin("https://staging.example.com"))
+	require.NoError(t, err)
+	require.NoError(t, authenticate("staging.example.com", proofString, verifyHost))
+	require.ErrorIs(t, authenticate("api.example.com", proofString, verifyHost), ErrInvalidHostBinding)
+	require.NoError(t, authenticate("staging.example.com:8443", proofString, MiddlewareOptions{VerifyHost: true, HostRules: HostRules{IgnorePort: true}}))
+
+	// wildcard proofs are only accepted if allowed
+	proofString, err = Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithHost("*.example.com"))
+	require.NoError(t, err)
+	require.ErrorIs(t, authenticate("api.example.com", proofString, verifyHost), ErrInvalidHostBinding)
+	require.NoError(t, authenticate("api.example.com", proofString, MiddlewareOptions{VerifyHost: true, HostRules: HostRules{AllowWildcard: true}}))
+
+	// proofs bound to no host
+	proofString, err = Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	err = authenticate("api.example.com", proofString, verifyHost)
+	require.ErrorIs(t, err, ErrInvalidHostBinding)
+	require.Equal(t, "invalid_host_binding", FailureReason(err))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "http://api.example.com/", nil)
+	req.Header.Set("Authorization", "Bearer "+proofString)
+	Middleware(ethAuth, verifyHost)(http.NotFoundHandler()).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	for _, host := range []string{"*", "https://api.example.com", "api.example.com/v1", "api.*.example.com"} {
+		claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion, Host: host}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(time.Hour)
+		require.Error(t, claims.Valid(), host)
+	}
+}
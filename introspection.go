@@ -0,0 +1,72 @@
+package ethauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// IntrospectionResponse is the RFC 7662-style response of IntrospectionHandler.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Address   string `json:"address,omitempty"`
+	App       string `json:"app,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+
+	// Confirmation is the `cnf` claim of proofs bound to a client key or certificate, whose
+	// proof-of-possession the caller must verify, see ETHAuth.VerifyPoP
+	Confirmation string `json:"cnf,omitempty"`
+}
+
+// IntrospectionHandler returns an RFC 7662-style http.Handler validating the proof passed as
+// the `token` form value of POST requests, so services not written in Go can delegate proof
+// validation to a sidecar. Requests must carry the shared secret as an `Authorization: Bearer
+// <secret>` header, or are rejected with a 401 Unauthorized status. Valid proofs are described
+// by an active IntrospectionResponse, and invalid proofs by an inactive one. The handler can't
+// verify the proof-of-possession of proofs bound to a client key or certificate, so their
+// `cnf` claim is returned for the caller to verify. Proofs bound to their client, see
+// ETHAuth.ConfigClientBinding, are verified against the client the caller received the proof
+// from, passed as the `client_ip` and `user_agent` form values.
+func IntrospectionHandler(ethAuth *ETHAuth, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+			return
+		}
+		if !authorizeIntrospection(w, r, secret) {
+			return
+		}
+
+		client := AuthRequest{RemoteAddr: r.PostFormValue("client_ip"), UserAgent: r.PostFormValue("user_agent")}
+		_, proof, err := ethAuth.decodeProof(r.Context(), r.PostFormValue("token"), func(proof *Proof) error {
+			return ethAuth.VerifyClientBinding(proof, client)
+		})
+		if err != nil {
+			writeJSON(w, http.StatusOK, IntrospectionResponse{Active: false})
+			return
+		}
+		writeJSON(w, http.StatusOK, IntrospectionResponse{
+			Active:    true,
+			Address:   proof.Account(),
+			App:       proof.Claims.App,
+			Scope:     proof.Claims.Scope.String(),
+			IssuedAt:  proof.Claims.IssuedAt,
+			ExpiresAt: proof.Claims.ExpiresAt,
+
+			Confirmation: proof.Claims.Confirmation,
+		})
+	})
+}
+
+// authorizeIntrospection reports whether the introspection request carries the shared secret
+// as an `Authorization: Bearer <secret>` header, or rejects it with a 401 Unauthorized status.
+func authorizeIntrospection(w http.ResponseWriter, r *http.Request, secret string) bool {
+	presented, err := ProofFromRequest(r)
+	if secret == "" || err != nil || subtle.ConstantTimeCompare([]byte(presented), []byte(secret)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ethauth"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospectionHandler(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", Scope: Scopes{"read", "write"}, ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	handler := IntrospectionHandler(ethAuth, "s3cret")
+	introspect := func(secret, token string, optClient ...string) (int, IntrospectionResponse) {
+		form := url.Values{"token": {token}}
+		if len(optClient) > 0 {
+			form.Set("client_ip", optClient[0])
+		}
+		req := httptest.NewRequest("POST", "/introspect", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var resp IntrospectionResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		}
+		return rec.Code, resp
+	}
+
+	status, resp := introspect("s3cret", proofString)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, IntrospectionResponse{
+		Active:    true,
+		Address:   strings.ToLower(wallet.Address().Hex()),
+		App:       "ETHAuthTest",
+		Scope:     "read write",
+		IssuedAt:  claims.IssuedAt,
+		ExpiresAt: claims.ExpiresAt,
+	}, resp)
+
+	status, resp = introspect("s3cret", "eth.invalid")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, IntrospectionResponse{Active: false}, resp)
+
+	// the caller verifies the proof-of-possession of bound proofs
+	claims.Confirmation = KeyConfirmation(wallet.Address())
+	boundString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	status, resp = introspect("s3cret", boundString)
+	require.Equal(t, http.StatusOK, status)
+	require.True(t, resp.Active)
+	require.Equal(t, KeyConfirmation(wallet.Address()), resp.Confirmation)
+
+	// proofs bound to their client are verified against the client of the caller
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingExact, BindingOff))
+	claims.Confirmation = ""
+	claims.ClientIP = IPBinding(netip.MustParsePrefix("192.0.2.1/32"))
+	clientString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	_, resp = introspect("s3cret", clientString)
+	require.False(t, resp.Active)
+	_, resp = introspect("s3cret", clientString, "198.51.100.1")
+	require.False(t, resp.Active)
+	_, resp = introspect("s3cret", clientString, "192.0.2.1")
+	require.True(t, resp.Active)
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingOff, BindingOff))
+
+	// the shared secret is required
+	status, _ = introspect("", proofString)
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = introspect("wrong", proofString)
+	require.Equal(t, http.StatusUnauthorized, status)
+}
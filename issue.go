@@ -0,0 +1,130 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultIssueTTL is the lifetime of proofs issued by Issue without WithExpiresIn.
+const DefaultIssueTTL = time.Hour
+
+// IssueOption sets a claim of the proofs issued by Issue.
+type IssueOption func(claims *Claims)
+
+// WithApp sets the `app` claim, which is required.
+func WithApp(app string) IssueOption {
+	return func(claims *Claims) {
+		claims.App = app
+	}
+}
+
+// WithExpiresIn sets the `exp` claim to expire the proof after the duration, instead of
+// DefaultIssueTTL.
+func WithExpiresIn(ttl time.Duration) IssueOption {
+	return func(claims *Claims) {
+		claims.SetExpiryIn(ttl)
+	}
+}
+
+// WithNonce sets the `n` claim.
+func WithNonce(nonce uint64) IssueOption {
+	return func(claims *Claims) {
+		claims.Nonce = nonce
+	}
+}
+
+// WithOrigin sets the `ogn` claim, ie. "https://app.example.com".
+func WithOrigin(origin string) IssueOption {
+	return func(claims *Claims) {
+		claims.Origin = origin
+	}
+}
+
+// WithScope adds the scopes to the `scope` claim.
+func WithScope(scopes ...string) IssueOption {
+	return func(claims *Claims) {
+		claims.Scope = append(claims.Scope, scopes...)
+	}
+}
+
+// WithAudience sets the `aud` claim.
+func WithAudience(audience string) IssueOption {
+	return func(claims *Claims) {
+		claims.Audience = audience
+	}
+}
+
+// WithChainID sets the `cid` claim.
+func WithChainID(chainID uint64) IssueOption {
+	return func(claims *Claims) {
+		claims.ChainID = chainID
+	}
+}
+
+// WithSubject sets the `sub` claim.
+func WithSubject(subject string) IssueOption {
+	return func(claims *Claims) {
+		claims.Subject = subject
+	}
+}
+
+// WithID sets the `jti` claim.
+func WithID(id string) IssueOption {
+	return func(claims *Claims) {
+		claims.ID = id
+	}
+}
+
+// WithConfirmation sets the `cnf` claim, binding the proof to a client key or certificate,
+// see KeyConfirmation and TLSConfirmation.
+func WithConfirmation(cnf string) IssueOption {
+	return func(claims *Claims) {
+		claims.Confirmation = cnf
+	}
+}
+
+// WithCustomClaims sets the custom application claims.
+func WithCustomClaims(custom ClaimsProvider) IssueOption {
+	return func(claims *Claims) {
+		claims.Custom = custom
+	}
+}
+
+// WithDomain signs the claims under the EIP712 domain, for verifiers configured with the
+// same domain by ETHAuth.ConfigDomain.
+func WithDomain(domain DomainConfig) IssueOption {
+	return func(claims *Claims) {
+		claims.domain = &domain
+	}
+}
+
+// Issue signs a proof of the claims set by the options with the signer, and returns the
+// encoded proof string. The proof is issued now, expires after DefaultIssueTTL unless
+// WithExpiresIn is given, and its claims are canonicalized and validated before signing, so
+// invalid claims are rejected at issuance rather than at verification.
+//
+//	proofString, err := ethauth.Issue(signer, ethauth.WithApp("myapp"), ethauth.WithExpiresIn(15*time.Minute))
+func Issue(signer Signer, opts ...IssueOption) (string, error) {
+	return IssueWithContext(context.Background(), signer, opts...)
+}
+
+// IssueWithContext is Issue, passing the context to the signer, ie. to bound the latency of
+// remote signers.
+func IssueWithContext(ctx context.Context, signer Signer, opts ...IssueOption) (string, error) {
+	proof := NewProof()
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(DefaultIssueTTL)
+	for _, opt := range opts {
+		opt(&proof.Claims)
+	}
+	proof.Claims = proof.Claims.Canonicalize()
+
+	if err := proof.Claims.Valid(); err != nil {
+		return "", fmt.Errorf("ethauth: invalid claims - %w", err)
+	}
+	if err := SignProofWithSigner(ctx, proof, signer); err != nil {
+		return "", err
+	}
+	return proof.Encode()
+}
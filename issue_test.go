@@ -0,0 +1,55 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssue(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	signer := NewWalletSigner(wallet)
+
+	proofString, err := Issue(signer,
+		WithApp("ETHAuthTest"),
+		WithExpiresIn(15*time.Minute),
+		WithNonce(7),
+		WithOrigin("HTTPS://App.Example.com/"),
+		WithScope("write:orders", "read:orders"),
+		WithScope("read:orders"),
+	)
+	require.NoError(t, err)
+
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "0xe0c9828dee3411a28ccb4bb82a18d0aad24489e0", proof.Address)
+	require.Equal(t, "ETHAuthTest", proof.Claims.App)
+	require.Equal(t, ETHAuthVersion, proof.Claims.ETHAuthVersion)
+	require.Equal(t, uint64(7), proof.Claims.Nonce)
+	require.Equal(t, "https://app.example.com", proof.Claims.Origin)
+	require.Equal(t, Scopes{"read:orders", "write:orders"}, proof.Claims.Scope)
+	require.InDelta(t, time.Now().Unix(), proof.Claims.IssuedAt, 5)
+	require.InDelta(t, time.Now().Add(15*time.Minute).Unix(), proof.Claims.ExpiresAt, 5)
+
+	// proofs expire after DefaultIssueTTL by default
+	proofString, err = Issue(signer, WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	_, proof, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.InDelta(t, time.Now().Add(DefaultIssueTTL).Unix(), proof.Claims.ExpiresAt, 5)
+
+	// invalid claims are rejected before signing
+	_, err = Issue(signer)
+	require.ErrorIs(t, err, ErrMissingApp)
+	_, err = Issue(signer, WithApp("ETHAuthTest"), WithExpiresIn(-time.Hour))
+	require.ErrorIs(t, err, ErrProofExpired)
+	_, err = Issue(nil, WithApp("ETHAuthTest"))
+	require.Error(t, err)
+}
@@ -0,0 +1,219 @@
+package ethauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// IssuerServer is an embeddable http.Handler which exchanges ETHAuth proofs for session
+// JWTs, making ETHAuth usable as a minimal OIDC-style identity provider. It serves:
+//
+//   - POST /token, exchanging the proof passed as the `proof` form value (or as a bearer
+//     authorization header) for a JWT
+//   - POST /introspect, returning the status of the JWT passed as the `token` form value, once
+//     enabled by ConfigIntrospectionSecret
+//   - GET /.well-known/jwks.json, returning the public key the JWTs are signed with
+type IssuerServer struct {
+	ethAuth             *ETHAuth
+	signingKey          crypto.Signer
+	options             JWTOptions
+	introspectionSecret string
+	mux                 *http.ServeMux
+}
+
+// NewIssuerServer returns an IssuerServer which validates proofs with ethAuth, and signs
+// JWTs with an *rsa.PrivateKey (RS256) or P-256 *ecdsa.PrivateKey (ES256) signing key.
+func NewIssuerServer(ethAuth *ETHAuth, signingKey crypto.Signer, optOptions ...JWTOptions) (*IssuerServer, error) {
+	if ethAuth == nil {
+		return nil, fmt.Errorf("ethauth: issuer server requires an ETHAuth instance")
+	}
+	if _, err := jwtAlgorithm(signingKey); err != nil {
+		return nil, err
+	}
+
+	s := &IssuerServer{ethAuth: ethAuth, signingKey: signingKey, mux: http.NewServeMux()}
+	if len(optOptions) > 0 {
+		s.options = optOptions[0]
+	}
+	if s.options.KeyID == "" {
+		s.options.KeyID = jwkThumbprint(signingKey.Public())
+	}
+
+	s.mux.HandleFunc("/token", s.handleToken)
+	s.mux.HandleFunc("/introspect", s.handleIntrospect)
+	s.mux.HandleFunc("/.well-known/jwks.json", s.handleJWKS)
+	return s, nil
+}
+
+// ConfigIntrospectionSecret enables the /introspect endpoint, whose requests must carry the
+// shared secret as an `Authorization: Bearer <secret>` header, as by IntrospectionHandler, or
+// disables it if empty. The endpoint is disabled by default, as the claims of the JWTs it
+// returns are only meant for the resource servers holding the secret.
+func (s *IssuerServer) ConfigIntrospectionSecret(secret string) {
+	s.introspectionSecret = secret
+}
+
+func (s *IssuerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *IssuerServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	proofString := r.PostFormValue("proof")
+	if proofString == "" {
+		proofString, _ = ProofFromRequest(r)
+	}
+	if proofString == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "missing proof"})
+		return
+	}
+
+	// proofs bound to a client key or certificate, or to their client, are only exchanged with
+	// the proof-of-possession of the token request, by their client, as the JWT is a bearer token
+	req := NewAuthRequest(r)
+	_, proof, err := s.ethAuth.decodeProof(r.Context(), proofString, func(proof *Proof) error {
+		return verifyAuthRequest(s.ethAuth, proof, req, MiddlewareOptions{})
+	})
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_grant", "error_description": err.Error()})
+		return
+	}
+
+	now := s.ethAuth.clock()
+	token, err := toJWT(proof, s.signingKey, s.options, now)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	claims, _ := verifyJWT(token, s.signingKey.Public(), now)
+	expiresIn, _ := claims["exp"].(float64)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(expiresIn) - now.Unix(),
+	})
+}
+
+func (s *IssuerServer) handleIntrospect(w http.ResponseWriter, r *http.Request) {
+	if s.introspectionSecret == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if !authorizeIntrospection(w, r, s.introspectionSecret) {
+		return
+	}
+
+	claims, err := verifyJWT(r.PostFormValue("token"), s.signingKey.Public(), s.ethAuth.clock())
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"active": false})
+		return
+	}
+	claims["active"] = true
+	writeJSON(w, http.StatusOK, claims)
+}
+
+func (s *IssuerServer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{jwk(s.signingKey.Public(), s.options.KeyID)},
+	})
+}
+
+// verifyJWT verifies the signature of a JWT signed with signJWT, and its expiry at the time now,
+// returning its claims.
+func verifyJWT(token string, publicKey crypto.PublicKey, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("ethauth: invalid jwt")
+	}
+	sig, err := Base64UrlDecode(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid jwt signature encoding")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, fmt.Errorf("ethauth: invalid jwt signature")
+		}
+	case *ecdsa.PublicKey:
+		if len(sig) != 64 || !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			return nil, fmt.Errorf("ethauth: invalid jwt signature")
+		}
+	default:
+		return nil, fmt.Errorf("ethauth: unsupported jwt public key type %T", publicKey)
+	}
+
+	claimsJSON, err := Base64UrlDecode(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid jwt claims encoding")
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("ethauth: invalid jwt claims")
+	}
+	if exp, ok := claims["exp"].(float64); !ok || int64(exp) < now.Unix() {
+		return nil, fmt.Errorf("ethauth: jwt has expired")
+	}
+	return claims, nil
+}
+
+func jwk(publicKey crypto.PublicKey, keyID string) map[string]string {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA", "use": "sig", "alg": "RS256", "kid": keyID,
+			"n": Base64UrlEncode(key.N.Bytes()),
+			"e": Base64UrlEncode(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return map[string]string{
+			"kty": "EC", "use": "sig", "alg": "ES256", "kid": keyID, "crv": "P-256",
+			"x": Base64UrlEncode(x),
+			"y": Base64UrlEncode(y),
+		}
+	default:
+		return nil
+	}
+}
+
+// jwkThumbprint returns the RFC 7638 thumbprint of the public key.
+func jwkThumbprint(publicKey crypto.PublicKey) string {
+	k := jwk(publicKey, "")
+	var members string
+	switch k["kty"] {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, k["e"], k["n"])
+	case "EC":
+		members = fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`, k["x"], k["y"])
+	}
+	sum := sha256.Sum256([]byte(members))
+	return Base64UrlEncode(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
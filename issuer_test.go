@@ -0,0 +1,146 @@
+package ethauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuerServer(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server, err := NewIssuerServer(ethAuth, signingKey, JWTOptions{Issuer: "ethauth"})
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	post := func(path string, form url.Values, optSecret ...string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if len(optSecret) > 0 {
+			req.Header.Set("Authorization", "Bearer "+optSecret[0])
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		var resp map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &resp) // not found responses are plain text
+		return rec.Code, resp
+	}
+
+	// token exchange
+	code, resp := post("/token", url.Values{"proof": {proofString}})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "Bearer", resp["token_type"])
+	token, _ := resp["access_token"].(string)
+	require.NotEmpty(t, token)
+
+	code, _ = post("/token", url.Values{"proof": {proofString + "x"}})
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	// proofs bound to a client key are only exchanged with the proof-of-possession of the token
+	// request
+	clientKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	claims.Confirmation = KeyConfirmation(crypto.PubkeyToAddress(clientKey.PublicKey))
+	bound := signTestProof(t, wallet, claims)
+	boundString, err := ethAuth.EncodeProof(bound)
+	require.NoError(t, err)
+	code, resp = post("/token", url.Values{"proof": {boundString}})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Contains(t, resp["error_description"], ErrInvalidPoP.Error())
+
+	pop, err := SignPoP(bound, clientKey, "POST", "example.com", "/token")
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/token", strings.NewReader(url.Values{"proof": {boundString}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set(HeaderPoP, pop)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// proofs bound to their client are only exchanged by their client
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingExact, BindingOff))
+	claims.Confirmation = ""
+	claims.ClientIP = IPBinding(netip.MustParsePrefix("198.51.100.1/32"))
+	clientString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	code, resp = post("/token", url.Values{"proof": {clientString}})
+	require.Equal(t, http.StatusUnauthorized, code)
+	require.Contains(t, resp["error_description"], ErrInvalidClientBinding.Error())
+	req = httptest.NewRequest("POST", "/token", strings.NewReader(url.Values{"proof": {clientString}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "198.51.100.1:1234"
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, ethAuth.ConfigClientBinding(BindingOff, BindingOff))
+
+	// introspection is disabled unless configured, and then requires the shared secret
+	code, _ = post("/introspect", url.Values{"token": {token}})
+	require.Equal(t, http.StatusNotFound, code)
+	server.ConfigIntrospectionSecret("s3cret")
+	code, _ = post("/introspect", url.Values{"token": {token}})
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = post("/introspect", url.Values{"token": {token}}, "wrong")
+	require.Equal(t, http.StatusUnauthorized, code)
+
+	code, resp = post("/introspect", url.Values{"token": {token}}, "s3cret")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, true, resp["active"])
+	require.Equal(t, strings.ToLower(wallet.Address().String()), resp["sub"])
+	require.Equal(t, "ethauth", resp["iss"])
+
+	_, resp = post("/introspect", url.Values{"token": {token[:len(token)-2]}}, "s3cret")
+	require.Equal(t, false, resp["active"])
+
+	// tokens are issued and introspected with the clock of the ETHAuth instance
+	now := time.Now()
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now.Add(2 * time.Minute) }))
+	code, resp = post("/token", url.Values{"proof": {proofString}})
+	require.Equal(t, http.StatusOK, code)
+	require.InDelta(t, claims.ExpiresAt-now.Add(2*time.Minute).Unix(), resp["expires_in"], 1)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now.Add(10 * time.Minute) }))
+	_, resp = post("/introspect", url.Values{"token": {token}}, "s3cret")
+	require.Equal(t, false, resp["active"])
+	require.NoError(t, ethAuth.ConfigClock(time.Now))
+
+	// jwks
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "EC", jwks.Keys[0]["kty"])
+	require.Equal(t, "ES256", jwks.Keys[0]["alg"])
+	require.NotEmpty(t, jwks.Keys[0]["kid"])
+
+	// HMAC keys can't be published in a JWKS
+	_, err = NewIssuerServer(ethAuth, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,144 @@
+package ethauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JWTOptions configures the JWT issued by ToJWT.
+type JWTOptions struct {
+	// Issuer is the `iss` claim of the JWT
+	Issuer string
+
+	// Audience is the `aud` claim of the JWT
+	Audience string
+
+	// TTL is the lifetime of the JWT, which defaults to 15 minutes. The JWT never
+	// outlives the expiry of the proof it is issued for.
+	TTL time.Duration
+
+	// KeyID is the `kid` header of the JWT
+	KeyID string
+}
+
+// ToJWT re-issues a verified proof as a short-lived JWT carrying the account address as
+// the `sub` claim, so infrastructure which only understands JWTs can consume ETHAuth
+// logins. The proof must have been verified, ie. returned by ETHAuth.DecodeProof.
+//
+// The signing key selects the JWT algorithm: a []byte key signs with HS256, an
+// *rsa.PrivateKey with RS256, and a P-256 *ecdsa.PrivateKey with ES256.
+func ToJWT(proof *Proof, signingKey interface{}, optOptions ...JWTOptions) (string, error) {
+	var opts JWTOptions
+	if len(optOptions) > 0 {
+		opts = optOptions[0]
+	}
+	return toJWT(proof, signingKey, opts, time.Now())
+}
+
+// toJWT issues the JWT of the proof at the time now, ie. of the clock of the ETHAuth instance
+// which verified the proof.
+func toJWT(proof *Proof, signingKey interface{}, opts JWTOptions, now time.Time) (string, error) {
+	if proof == nil {
+		return "", fmt.Errorf("ethauth: proof is nil")
+	}
+	if opts.TTL == 0 {
+		opts.TTL = 15 * time.Minute
+	}
+
+	iat := now.Unix()
+	exp := iat + int64(opts.TTL.Seconds())
+	if proof.Claims.ExpiresAt != 0 && proof.Claims.ExpiresAt < exp {
+		exp = proof.Claims.ExpiresAt
+	}
+
+	claims := map[string]interface{}{
+		"sub": strings.ToLower(proof.Address),
+		"iat": iat,
+		"exp": exp,
+	}
+	if opts.Issuer != "" {
+		claims["iss"] = opts.Issuer
+	}
+	if opts.Audience != "" {
+		claims["aud"] = opts.Audience
+	}
+	if proof.Claims.App != "" {
+		claims["app"] = proof.Claims.App
+	}
+
+	return signJWT(claims, signingKey, opts.KeyID)
+}
+
+func signJWT(claims map[string]interface{}, signingKey interface{}, keyID string) (string, error) {
+	alg, err := jwtAlgorithm(signingKey)
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := Base64UrlEncode(headerJSON) + "." + Base64UrlEncode(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var sig []byte
+	switch key := signingKey.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signingInput))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, serr := ecdsa.Sign(rand.Reader, key, digest[:])
+		if serr != nil {
+			return "", serr
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	if err != nil {
+		return "", fmt.Errorf("ethauth: failed to sign jwt - %w", err)
+	}
+
+	return signingInput + "." + Base64UrlEncode(sig), nil
+}
+
+func jwtAlgorithm(signingKey interface{}) (string, error) {
+	switch key := signingKey.(type) {
+	case []byte:
+		if len(key) == 0 {
+			return "", fmt.Errorf("ethauth: jwt signing key is empty")
+		}
+		return "HS256", nil
+	case *rsa.PrivateKey:
+		return "RS256", nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("ethauth: jwt ecdsa signing key must be on the P-256 curve")
+		}
+		return "ES256", nil
+	default:
+		return "", fmt.Errorf("ethauth: unsupported jwt signing key type %T", signingKey)
+	}
+}
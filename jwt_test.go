@@ -0,0 +1,59 @@
+package ethauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToJWT(t *testing.T) {
+	proof := NewProof()
+	proof.Address = "0x89D9F8f31817BAdb5D718CD6fb483b71DbD2dfeD"
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+
+	// HS256
+	key := []byte("secret")
+	token, err := ToJWT(proof, key, JWTOptions{Issuer: "ethauth", TTL: time.Hour})
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	require.Equal(t, Base64UrlEncode(mac.Sum(nil)), parts[2])
+
+	claimsJSON, err := Base64UrlDecode(parts[1])
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+	require.Equal(t, strings.ToLower(proof.Address), claims["sub"])
+	require.Equal(t, "ethauth", claims["iss"])
+	require.Equal(t, float64(proof.Claims.ExpiresAt), claims["exp"]) // capped to the proof expiry
+
+	// ES256
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	token, err = ToJWT(proof, ecKey)
+	require.NoError(t, err)
+
+	parts = strings.Split(token, ".")
+	sig, err := Base64UrlDecode(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.True(t, ecdsa.Verify(&ecKey.PublicKey, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])))
+
+	// unsupported key
+	_, err = ToJWT(proof, "secret")
+	require.Error(t, err)
+}
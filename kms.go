@@ -0,0 +1,148 @@
+package ethauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// KMSKey is a secp256k1 key held in a cloud KMS or HSM, such as an AWS KMS ECC_SECG_P256K1
+// key or a GCP Cloud KMS EC_SIGN_SECP256K1_SHA256 key. The KMS clients are left to the
+// application, so this package doesn't depend on the cloud SDKs. For example, with AWS KMS:
+//
+//	func (k *awsKey) PublicKey(ctx context.Context) ([]byte, error) {
+//		out, err := k.client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: &k.keyID})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.PublicKey, nil
+//	}
+//
+//	func (k *awsKey) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+//		out, err := k.client.Sign(ctx, &kms.SignInput{
+//			KeyId: &k.keyID, Message: digest, MessageType: types.MessageTypeDigest,
+//			SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+//		})
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.Signature, nil
+//	}
+//
+// With GCP Cloud KMS, PublicKey decodes the PEM returned by GetPublicKey, and SignDigest
+// passes the digest as the sha256 digest of an AsymmetricSign request.
+type KMSKey interface {
+	// PublicKey returns the DER encoded SubjectPublicKeyInfo of the key
+	PublicKey(ctx context.Context) ([]byte, error)
+
+	// SignDigest signs the 32-byte digest, returning a DER encoded ECDSA signature
+	SignDigest(ctx context.Context, digest []byte) ([]byte, error)
+}
+
+// NewKMSSigner returns a Signer for a KMS held secp256k1 key. The KMS signatures are converted
+// to the [R || S || V] Ethereum format, normalizing S to the lower half of the curve order as
+// required by Ethereum, and computing the recovery id against the key's public key.
+func NewKMSSigner(ctx context.Context, key KMSKey) (Signer, error) {
+	spki, err := key.PublicKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: failed to fetch kms public key - %w", err)
+	}
+	publicKey, err := parseSecp256k1PublicKeyInfo(spki)
+	if err != nil {
+		return nil, err
+	}
+	return &kmsSigner{key: key, publicKey: crypto.FromECDSAPub(publicKey), address: crypto.PubkeyToAddress(*publicKey)}, nil
+}
+
+type kmsSigner struct {
+	key       KMSKey
+	publicKey []byte
+	address   common.Address
+}
+
+func (s *kmsSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *kmsSigner) Sign(ctx context.Context, proof *Proof) ([]byte, error) {
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return nil, err
+	}
+	der, err := s.key.SignDigest(ctx, digest)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: kms failed to sign - %w", err)
+	}
+	return derToEthereumSignature(digest, der, s.publicKey)
+}
+
+var (
+	oidECPublicKey = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1   = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// parseSecp256k1PublicKeyInfo parses a DER encoded SubjectPublicKeyInfo of a secp256k1 key,
+// which crypto/x509 doesn't support.
+func parseSecp256k1PublicKeyInfo(der []byte) (*ecdsa.PublicKey, error) {
+	var spki struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}
+		PublicKey asn1.BitString
+	}
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("ethauth: invalid kms public key encoding")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidECPublicKey) || !spki.Algorithm.Parameters.Equal(oidSecp256k1) {
+		return nil, fmt.Errorf("ethauth: kms public key is not a secp256k1 key")
+	}
+	publicKey, err := crypto.UnmarshalPubkey(spki.PublicKey.RightAlign())
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid kms public key - %w", err)
+	}
+	return publicKey, nil
+}
+
+// derToEthereumSignature converts a DER encoded ECDSA signature of the digest to the 65-byte
+// [R || S || V] format, where V is 27 plus the recovery id of the uncompressed public key.
+func derToEthereumSignature(digest, der, publicKey []byte) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	rest, err := asn1.Unmarshal(der, &sig)
+	if err != nil || len(rest) != 0 || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return nil, fmt.Errorf("ethauth: invalid kms signature encoding")
+	}
+	if sig.R.Cmp(secp256k1N) >= 0 || sig.S.Cmp(secp256k1N) >= 0 {
+		return nil, fmt.Errorf("ethauth: invalid kms signature, r or s is not below the curve order")
+	}
+
+	// (r, s) and (r, N-s) are both valid, but Ethereum only accepts the low-S form
+	if sig.S.Cmp(secp256k1HalfN) > 0 {
+		sig.S = new(big.Int).Sub(secp256k1N, sig.S)
+	}
+
+	ethSig := make([]byte, 65)
+	sig.R.FillBytes(ethSig[0:32])
+	sig.S.FillBytes(ethSig[32:64])
+	for v := byte(0); v < 2; v++ {
+		ethSig[64] = v
+		recovered, err := crypto.Ecrecover(digest, ethSig)
+		if err == nil && bytes.Equal(recovered, publicKey) {
+			ethSig[64] += 27
+			return ethSig, nil
+		}
+	}
+	return nil, fmt.Errorf("ethauth: kms signature does not match the kms public key")
+}
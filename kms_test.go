@@ -0,0 +1,98 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testKMSKey emulates a KMS secp256k1 key, returning DER encoded public keys and signatures.
+type testKMSKey struct {
+	privateKey *ecdsa.PrivateKey
+	highS      bool
+}
+
+func (k *testKMSKey) PublicKey(ctx context.Context) ([]byte, error) {
+	type algorithm struct {
+		Algorithm  asn1.ObjectIdentifier
+		Parameters asn1.ObjectIdentifier
+	}
+	return asn1.Marshal(struct {
+		Algorithm algorithm
+		PublicKey asn1.BitString
+	}{
+		Algorithm: algorithm{oidECPublicKey, oidSecp256k1},
+		PublicKey: asn1.BitString{Bytes: crypto.FromECDSAPub(&k.privateKey.PublicKey), BitLength: 65 * 8},
+	})
+}
+
+func (k *testKMSKey) SignDigest(ctx context.Context, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, k.privateKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	// KMS signatures aren't normalized to low-S
+	if k.highS == (s.Cmp(secp256k1HalfN) <= 0) {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+func TestKMSSigner(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	for _, highS := range []bool{false, true} {
+		signer, err := NewKMSSigner(context.Background(), &testKMSKey{privateKey: privateKey, highS: highS})
+		require.NoError(t, err)
+		require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), signer.Address())
+
+		for i := 0; i < 8; i++ {
+			proof := NewProof()
+			proof.Claims = Claims{App: "ETHAuthTest", Nonce: uint64(i + 1), ETHAuthVersion: ETHAuthVersion}
+			proof.Claims.SetIssuedAtNow()
+			proof.Claims.SetExpiryIn(5 * time.Minute)
+			sig, err := signer.Sign(context.Background(), proof)
+			require.NoError(t, err)
+			require.Contains(t, []byte{27, 28}, sig[64])
+			require.NoError(t, SignProofWithSigner(context.Background(), proof, signer))
+
+			_, err = ethAuth.EncodeProof(proof)
+			require.NoError(t, err)
+		}
+	}
+
+	// r and s must be below the curve order, and fit in 32 bytes
+	digest := make([]byte, 32)
+	publicKey := crypto.FromECDSAPub(&privateKey.PublicKey)
+	for _, sig := range []struct{ R, S *big.Int }{
+		{secp256k1N, big.NewInt(1)},
+		{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 256)},
+		{new(big.Int).Lsh(big.NewInt(1), 264), big.NewInt(1)},
+	} {
+		der, err := asn1.Marshal(sig)
+		require.NoError(t, err)
+		_, err = derToEthereumSignature(digest, der, publicKey)
+		require.ErrorContains(t, err, "curve order")
+	}
+
+	// non-secp256k1 keys are rejected
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	spki, err := x509.MarshalPKIXPublicKey(&p256Key.PublicKey)
+	require.NoError(t, err)
+	_, err = parseSecp256k1PublicKeyInfo(spki)
+	require.Error(t, err)
+}
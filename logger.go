@@ -0,0 +1,74 @@
+package ethauth
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger logs the decisions of proof verifications at the debug level, ie. why a request was
+// rejected, and anomalies at the warn level, ie. failing JSON-RPC providers or ENS lookups.
+// The args are alternating keys and values, as of log/slog. See NewSlogLogger.
+type Logger interface {
+	Debug(ctx context.Context, msg string, args ...any)
+	Warn(ctx context.Context, msg string, args ...any)
+}
+
+// NopLogger is a Logger discarding every message, the logger of ETHAuth by default.
+type NopLogger struct{}
+
+var _ Logger = NopLogger{}
+
+func (NopLogger) Debug(ctx context.Context, msg string, args ...any) {}
+
+func (NopLogger) Warn(ctx context.Context, msg string, args ...any) {}
+
+// SlogLogger is a Logger writing to a log/slog logger.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+var _ Logger = &SlogLogger{}
+
+// NewSlogLogger returns a Logger writing to the slog logger, or to slog.Default if nil.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger}
+}
+
+func (l *SlogLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.logger.DebugContext(ctx, msg, args...)
+}
+
+func (l *SlogLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.logger.WarnContext(ctx, msg, args...)
+}
+
+// ConfigLogger sets the logger of proof verifications, of the validators and of Middleware.
+// A nil logger restores the NopLogger default.
+func (w *ETHAuth) ConfigLogger(logger Logger) {
+	if _, ok := logger.(NopLogger); ok {
+		logger = nil
+	}
+	w.logger = logger
+}
+
+// log returns the logger of the instance.
+func (w *ETHAuth) log() Logger {
+	if w.logger == nil {
+		return NopLogger{}
+	}
+	return w.logger
+}
+
+var loggerCtxKey = &contextKey{"logger"}
+
+// logFromContext returns the logger of the ETHAuth instance verifying the proof, which passes
+// its logger to the validators in the context.
+func logFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerCtxKey).(Logger); ok {
+		return logger
+	}
+	return NopLogger{}
+}
@@ -0,0 +1,53 @@
+package ethauth
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithExpiresIn(time.Hour))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	authenticate := func(proofString string) error {
+		_, err := Authenticate(ethAuth, AuthRequest{Authorization: "Bearer " + proofString, Method: "GET", Path: "/api"}, MiddlewareOptions{})
+		return err
+	}
+
+	require.NoError(t, authenticate(proofString))
+	require.Contains(t, buf.String(), `"msg":"ethauth: request authenticated"`)
+	require.Contains(t, buf.String(), `"app":"ETHAuthTest"`)
+
+	// the errors of the validators are logged, while the proof is rejected with ErrInvalidSignature
+	buf.Reset()
+	tampered, err := Parse(proofString)
+	require.NoError(t, err)
+	tampered.Claims.App = "Other"
+	tamperedString, err := tampered.Encode()
+	require.NoError(t, err)
+	require.ErrorIs(t, authenticate(tamperedString), ErrInvalidSignature)
+	require.Contains(t, buf.String(), `"msg":"ethauth: proof signature is invalid"`)
+	require.Contains(t, buf.String(), `ValidateEOASignature`)
+	require.Contains(t, buf.String(), `"msg":"ethauth: request rejected"`)
+	require.Contains(t, buf.String(), `"reason":"invalid_signature"`)
+
+	// nothing is logged above the level of the handler, nor by default
+	buf.Reset()
+	ethAuth.ConfigLogger(NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	require.NoError(t, authenticate(proofString))
+	require.Empty(t, buf.String())
+	ethAuth.ConfigLogger(nil)
+	require.Equal(t, NopLogger{}, ethAuth.log())
+	require.NoError(t, authenticate(proofString))
+}
@@ -0,0 +1,221 @@
+package ethauth
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// HeaderMerkleProof is the request header carrying the merkle proof of the membership of the
+// account of a proof in the merkle tree of its `mrk` claim, as the comma-separated hex hashes
+// of the proof, see MiddlewareOptions.Merkle.
+const HeaderMerkleProof = "ETHAuth-Merkle"
+
+// MaxMerkleProofLength is the maximum number of hashes of a merkle proof, which covers trees
+// of up to 2^32 leaves.
+const MaxMerkleProofLength = 32
+
+// WithMerkleRoot sets the `mrk` claim, the root of the merkle tree, ie. of an allow-list, the
+// account of the proof is a member of, see MerkleVerifier.
+func WithMerkleRoot(root common.Hash) IssueOption {
+	return func(claims *Claims) {
+		claims.MerkleRoot = root.Hex()
+	}
+}
+
+// validMerkleRoot validates that the `mrk` claim is a hex hash.
+func (c Claims) validMerkleRoot() error {
+	if c.MerkleRoot != "" && (len(c.MerkleRoot) != 66 || !strings.HasPrefix(c.MerkleRoot, "0x")) {
+		return fmt.Errorf("claims: mrk is not a merkle root")
+	}
+	return nil
+}
+
+// MerkleLeaf returns the leaf of an account in a merkle tree of accounts, the keccak256 of
+// the keccak256 of its ABI encoded address, as the leaves of the StandardMerkleTree of
+// OpenZeppelin, so an allow-list can be verified both on-chain and by MerkleVerifier.
+func MerkleLeaf(address common.Address) common.Hash {
+	var encoded [32]byte
+	copy(encoded[12:], address.Bytes())
+	return crypto.Keccak256Hash(crypto.Keccak256(encoded[:]))
+}
+
+// hashMerklePair returns the hash of the sorted pair of nodes, so merkle proofs need not
+// carry the side of each node.
+func hashMerklePair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
+
+// VerifyMerkleProof reports whether the merkle proof proves the membership of the leaf in the
+// tree of the root, of sorted pair hashing as the MerkleProof library of OpenZeppelin.
+func VerifyMerkleProof(root, leaf common.Hash, proof []common.Hash) bool {
+	node := leaf
+	for _, sibling := range proof {
+		node = hashMerklePair(node, sibling)
+	}
+	return node == root
+}
+
+// MerkleTree is a merkle tree of sorted pair hashing, ie. of the MerkleLeaf of the accounts of
+// an allow-list, to issue proofs with its root and give clients the merkle proof of their leaf.
+type MerkleTree struct {
+	// layers are the nodes of each layer, from the sorted leaves to the root
+	layers [][]common.Hash
+}
+
+// NewMerkleTree returns the merkle tree of the leaves, which must not be empty.
+func NewMerkleTree(leaves []common.Hash) (*MerkleTree, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("ethauth: merkle tree has no leaves")
+	}
+	layer := slices.Clone(leaves)
+	slices.SortFunc(layer, func(a, b common.Hash) int { return bytes.Compare(a[:], b[:]) })
+	layer = slices.Compact(layer)
+
+	t := &MerkleTree{layers: [][]common.Hash{layer}}
+	for len(layer) > 1 {
+		next := make([]common.Hash, 0, (len(layer)+1)/2)
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 == len(layer) {
+				// the odd node is promoted to the next layer
+				next = append(next, layer[i])
+			} else {
+				next = append(next, hashMerklePair(layer[i], layer[i+1]))
+			}
+		}
+		t.layers = append(t.layers, next)
+		layer = next
+	}
+	return t, nil
+}
+
+// Root returns the root of the tree.
+func (t *MerkleTree) Root() common.Hash {
+	return t.layers[len(t.layers)-1][0]
+}
+
+// Proof returns the merkle proof of the leaf, or false if the leaf isn't in the tree.
+func (t *MerkleTree) Proof(leaf common.Hash) ([]common.Hash, bool) {
+	i, ok := slices.BinarySearchFunc(t.layers[0], leaf, func(a, b common.Hash) int { return bytes.Compare(a[:], b[:]) })
+	if !ok {
+		return nil, false
+	}
+	var proof []common.Hash
+	for _, layer := range t.layers[:len(t.layers)-1] {
+		if sibling := i ^ 1; sibling < len(layer) {
+			proof = append(proof, layer[sibling])
+		}
+		i /= 2
+	}
+	return proof, true
+}
+
+// EncodeMerkleProof returns the HeaderMerkleProof header value of the merkle proof.
+func EncodeMerkleProof(proof []common.Hash) string {
+	hashes := make([]string, len(proof))
+	for i, h := range proof {
+		hashes[i] = h.Hex()
+	}
+	return strings.Join(hashes, ",")
+}
+
+// ParseMerkleProof decodes a HeaderMerkleProof header value, see EncodeMerkleProof.
+func ParseMerkleProof(header string) ([]common.Hash, error) {
+	if header == "" {
+		return nil, nil
+	}
+	hashes := strings.Split(header, ",")
+	if len(hashes) > MaxMerkleProofLength {
+		return nil, fmt.Errorf("ethauth: merkle proof exceeds %d hashes", MaxMerkleProofLength)
+	}
+	proof := make([]common.Hash, len(hashes))
+	for i, h := range hashes {
+		h = strings.TrimSpace(h)
+		if len(h) != 66 || !strings.HasPrefix(h, "0x") {
+			return nil, fmt.Errorf("ethauth: invalid merkle proof hash %q", h)
+		}
+		b, err := ethcoder.HexDecode(h)
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: invalid merkle proof hash %q", h)
+		}
+		proof[i] = common.BytesToHash(b)
+	}
+	return proof, nil
+}
+
+// MerkleVerifier verifies the membership of the accounts of proofs in the merkle trees of its
+// trusted roots, ie. of the allow-lists an API accepts, without a database lookup per request.
+// Proofs name the root of their tree in their `mrk` claim, and requests carry the merkle proof
+// of the leaf of their account in the HeaderMerkleProof header. It is safe for concurrent use,
+// so the roots can be rotated as allow-lists are updated.
+type MerkleVerifier struct {
+	mu    sync.RWMutex
+	roots map[common.Hash]struct{}
+
+	// leaf returns the leaf of the proof
+	leaf func(proof *Proof) (common.Hash, error)
+}
+
+// NewMerkleVerifier returns a MerkleVerifier of the trusted roots, whose leaves are the
+// MerkleLeaf of the accounts of the proofs, unless set by ConfigLeaf.
+func NewMerkleVerifier(roots ...common.Hash) *MerkleVerifier {
+	v := &MerkleVerifier{leaf: func(proof *Proof) (common.Hash, error) {
+		address, err := proof.AddressBytes()
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return MerkleLeaf(address), nil
+	}}
+	v.SetRoots(roots...)
+	return v
+}
+
+// SetRoots replaces the trusted roots, ie. once an allow-list is updated. Proofs of a root
+// which is no longer trusted are rejected.
+func (v *MerkleVerifier) SetRoots(roots ...common.Hash) {
+	m := make(map[common.Hash]struct{}, len(roots))
+	for _, root := range roots {
+		m[root] = struct{}{}
+	}
+	v.mu.Lock()
+	v.roots = m
+	v.mu.Unlock()
+}
+
+// ConfigLeaf sets the function returning the leaf of a proof, ie. the hash of its account and
+// of a custom claim, for trees of other leaves than accounts.
+func (v *MerkleVerifier) ConfigLeaf(leaf func(proof *Proof) (common.Hash, error)) {
+	v.leaf = leaf
+}
+
+// Verify verifies that the merkle proof proves the membership of the leaf of the proof in the
+// tree of the `mrk` claim of the proof, whose root must be trusted.
+func (v *MerkleVerifier) Verify(proof *Proof, merkleProof []common.Hash) error {
+	if proof.Claims.MerkleRoot == "" {
+		return fmt.Errorf("%w, proof has no mrk claim", ErrInvalidMerkleProof)
+	}
+	root := common.HexToHash(proof.Claims.MerkleRoot)
+	v.mu.RLock()
+	_, trusted := v.roots[root]
+	v.mu.RUnlock()
+	if !trusted {
+		return fmt.Errorf("%w, merkle root %s is not trusted", ErrInvalidMerkleProof, root.Hex())
+	}
+	leaf, err := v.leaf(proof)
+	if err != nil {
+		return fmt.Errorf("%w, %v", ErrInvalidMerkleProof, err)
+	}
+	if !VerifyMerkleProof(root, leaf, merkleProof) {
+		return ErrInvalidMerkleProof
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package ethauth
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleTree(t *testing.T) {
+	// leaves are the double keccak256 of the ABI encoded account, as of StandardMerkleTree
+	address := common.HexToAddress("0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0")
+	encoded, err := ethcoder.ABIPackArguments([]string{"address"}, []interface{}{address})
+	require.NoError(t, err)
+	require.Equal(t, crypto.Keccak256Hash(crypto.Keccak256(encoded)), MerkleLeaf(address))
+
+	_, err = NewMerkleTree(nil)
+	require.Error(t, err)
+
+	for n := 1; n <= 9; n++ {
+		var leaves []common.Hash
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, MerkleLeaf(common.BytesToAddress([]byte{0x10, byte(i)})))
+		}
+		tree, err := NewMerkleTree(leaves)
+		require.NoError(t, err)
+		for _, leaf := range leaves {
+			proof, ok := tree.Proof(leaf)
+			require.True(t, ok)
+			require.True(t, VerifyMerkleProof(tree.Root(), leaf, proof), "%d leaves", n)
+
+			parsed, err := ParseMerkleProof(EncodeMerkleProof(proof))
+			require.NoError(t, err)
+			require.Equal(t, len(proof), len(parsed))
+		}
+		_, ok := tree.Proof(MerkleLeaf(common.HexToAddress("0x01")))
+		require.False(t, ok)
+	}
+
+	_, err = ParseMerkleProof("0x1234")
+	require.Error(t, err)
+	_, err = ParseMerkleProof(strings.Repeat(common.Hash{}.Hex()+",", MaxMerkleProofLength) + common.Hash{}.Hex())
+	require.Error(t, err)
+}
+
+func TestMiddlewareMerkle(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	tree, err := NewMerkleTree([]common.Hash{
+		MerkleLeaf(wallet.Address()),
+		MerkleLeaf(common.HexToAddress("0x1111111111111111111111111111111111111111")),
+		MerkleLeaf(common.HexToAddress("0x2222222222222222222222222222222222222222")),
+	})
+	require.NoError(t, err)
+	merkleProof, ok := tree.Proof(MerkleLeaf(wallet.Address()))
+	require.True(t, ok)
+
+	verifier := NewMerkleVerifier(tree.Root())
+	opts := MiddlewareOptions{Merkle: verifier}
+	authenticate := func(proofString, merkleHeader string) error {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		if merkleHeader != "" {
+			req.Header.Set(HeaderMerkleProof, merkleHeader)
+		}
+		_, err := Authenticate(ethAuth, NewAuthRequest(req), opts)
+		return err
+	}
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithMerkleRoot(tree.Root()))
+	require.NoError(t, err)
+	require.NoError(t, authenticate(proofString, EncodeMerkleProof(merkleProof)))
+
+	// missing and invalid merkle proofs
+	err = authenticate(proofString, "")
+	require.ErrorIs(t, err, ErrInvalidMerkleProof)
+	require.Equal(t, "invalid_merkle_proof", FailureReason(err))
+	require.ErrorIs(t, authenticate(proofString, EncodeMerkleProof(merkleProof[1:])), ErrInvalidMerkleProof)
+	require.ErrorIs(t, authenticate(proofString, "0xzz"), ErrInvalidMerkleProof)
+
+	// proofs of another account of the tree can't be reused
+	other, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	otherProofString, err := Issue(NewWalletSigner(other), WithApp("ETHAuthTest"), WithMerkleRoot(tree.Root()))
+	require.NoError(t, err)
+	require.ErrorIs(t, authenticate(otherProofString, EncodeMerkleProof(merkleProof)), ErrInvalidMerkleProof)
+
+	// proofs without a root, or of a root which is no longer trusted
+	proofStringWithoutRoot, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	require.ErrorIs(t, authenticate(proofStringWithoutRoot, EncodeMerkleProof(merkleProof)), ErrInvalidMerkleProof)
+	verifier.SetRoots(common.HexToHash("0x01"))
+	require.ErrorIs(t, authenticate(proofString, EncodeMerkleProof(merkleProof)), ErrInvalidMerkleProof)
+}
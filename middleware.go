@@ -0,0 +1,208 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MiddlewareOptions configures the behaviour of Middleware.
+type MiddlewareOptions struct {
+	// Optional will pass requests without a proof through to the next handler
+	// unauthenticated, instead of rejecting them. Requests carrying an invalid
+	// proof are always rejected.
+	Optional bool
+
+	// VerifyOrigin rejects requests whose Origin header doesn't match the `ogn` claim of
+	// the proof, so a proof issued to one site can't be relayed from another. Requests
+	// without an Origin header, ie. made outside of a browser, are not checked.
+	VerifyOrigin bool
+
+	// VerifyHost rejects requests whose Host doesn't match the `dom` claim of the proof, or the
+	// host of its `ogn` claim for proofs without a `dom` claim, so a proof captured on
+	// staging.example.com can't be used on api.example.com. Proofs with neither claim are
+	// rejected. Servers behind a proxy must pass the Host requested by the client to the proxied
+	// request. See HostRules.
+	VerifyHost bool
+
+	// HostRules are the wildcard and port rules of VerifyHost
+	HostRules HostRules
+
+	// Merkle, if set, rejects requests whose proof account isn't a member of the merkle tree
+	// of the `mrk` claim of the proof, by the merkle proof of the HeaderMerkleProof request
+	// header, ie. to verify allow-list membership without a database lookup per request
+	Merkle *MerkleVerifier
+
+	// Cookie is the name of the proof cookie of requests without an Authorization header, ie.
+	// DefaultCookieName, whose chunks are reassembled into the proof string, see SetProofCookie.
+	// Requests are only authenticated by their cookie if Cookie is set.
+	Cookie string
+
+	// RenewWithin sets the HeaderRenew response header of requests whose proof expires within
+	// RenewWithin, with a RenewalChallenge for the client to sign a new proof ahead of expiry,
+	// see RenewalHeader. Browser clients of other origins can only read the header if it is
+	// listed in the Access-Control-Expose-Headers of the CORS responses.
+	RenewWithin time.Duration
+
+	// ErrorHandler is called when a request fails authentication. By default, the
+	// request is rejected with a 401 Unauthorized status.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Middleware returns a net/http middleware which reads the ETHAuth proof string from the
+// `Authorization: Bearer <proof>` request header, or the proof cookie of opts.Cookie, decodes
+// and validates it, and passes the verified proof to the next handler in the request context.
+func Middleware(ethAuth *ETHAuth, optOptions ...MiddlewareOptions) func(next http.Handler) http.Handler {
+	var opts MiddlewareOptions
+	if len(optOptions) > 0 {
+		opts = optOptions[0]
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = defaultErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proof, err := Authenticate(ethAuth, NewAuthRequest(r), opts)
+			if err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+			if proof == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if renew := RenewalHeader(r.Context(), ethAuth, proof, opts); renew != "" {
+				w.Header().Set(HeaderRenew, renew)
+			}
+			next.ServeHTTP(w, r.WithContext(WithProof(r.Context(), proof)))
+		})
+	}
+}
+
+// Authenticate decodes and validates the proof of a request, as Middleware does, including
+// the proof-of-possession of proofs bound to a client key or certificate, see VerifyPoP, and
+// the request signed by request proofs, see ProofTypeRequest, and the client the proof is
+// bound to, see ConfigClientBinding. It
+// returns a nil proof without error if the request carries no proof and opts.Optional is set.
+// Authenticate is the verification core of Middleware, shared by the router adapters of the
+// middleware packages. Decisions are logged at the debug level by the logger of ethAuth, see
+// ETHAuth.ConfigLogger.
+func Authenticate(ethAuth *ETHAuth, req AuthRequest, opts MiddlewareOptions) (*Proof, error) {
+	if req.Context == nil {
+		req.Context = context.Background()
+	}
+	proof, err := authenticate(ethAuth, req, opts)
+	if logger := ethAuth.logger; logger != nil {
+		ctx := req.Context
+		switch {
+		case err != nil:
+			logger.Debug(ctx, "ethauth: request rejected", "method", req.Method, "path", req.Path, "reason", FailureReason(err), "error", err)
+		case proof != nil:
+			logger.Debug(ctx, "ethauth: request authenticated", "method", req.Method, "path", req.Path, "address", proof.Address, "app", proof.Claims.App)
+		}
+	}
+	return proof, err
+}
+
+func authenticate(ethAuth *ETHAuth, req AuthRequest, opts MiddlewareOptions) (*Proof, error) {
+	proofString, err := parseAuthorization(req.Authorization)
+	if err != nil {
+		return nil, err
+	}
+	if proofString == "" && opts.Cookie != "" {
+		proofString = readCookieChunks(requestCookies(req.Cookie), opts.Cookie)
+	}
+	if proofString == "" {
+		if opts.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ethauth: missing proof")
+	}
+
+	// the proof is verified against the request before the stores record it
+	_, proof, err := ethAuth.decodeProof(req.Context, proofString, func(proof *Proof) error {
+		return verifyAuthRequest(ethAuth, proof, req, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ethAuth.detectReplay(req.Context, proof, req)
+	return proof, nil
+}
+
+// verifyAuthRequest verifies the proof against the origin, host, proof-of-possession, signed
+// request, client and merkle proof of its request.
+func verifyAuthRequest(ethAuth *ETHAuth, proof *Proof, req AuthRequest, opts MiddlewareOptions) error {
+	if opts.VerifyOrigin && req.Origin != "" && !sameOrigin(req.Origin, proof.Claims.Origin) {
+		return ErrInvalidOrigin
+	}
+	if opts.VerifyHost {
+		if err := verifyHostBinding(proof, req, opts.HostRules); err != nil {
+			return err
+		}
+	}
+	if err := ethAuth.VerifyPoP(proof, req); err != nil {
+		return err
+	}
+	if err := verifyRequestBinding(proof, req); err != nil {
+		return err
+	}
+	if err := ethAuth.VerifyClientBinding(proof, req); err != nil {
+		return err
+	}
+	if opts.Merkle != nil {
+		merkleProof, err := ParseMerkleProof(req.MerkleProof)
+		if err != nil {
+			return fmt.Errorf("%w, %v", ErrInvalidMerkleProof, err)
+		}
+		if err := opts.Merkle.Verify(proof, merkleProof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequireScope returns a net/http middleware which rejects requests whose proof, as passed in
+// the request context by Middleware, doesn't have all of the scopes in its `scope` claim.
+// Requests without a proof are rejected with a 401 Unauthorized status, and requests
+// with an insufficient scope with a 403 Forbidden status.
+func RequireScope(scopes ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proof, ok := FromContext(r.Context())
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if !proof.Claims.Scope.Has(scopes...) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ProofFromRequest returns the proof string from the `Authorization: Bearer <proof>` request
+// header, or an empty string if the header is not set.
+func ProofFromRequest(r *http.Request) (string, error) {
+	return parseAuthorization(r.Header.Get("Authorization"))
+}
+
+func parseAuthorization(auth string) (string, error) {
+	if auth == "" {
+		return "", nil
+	}
+	scheme, proofString, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || proofString == "" {
+		return "", fmt.Errorf("ethauth: invalid authorization header, expecting bearer proof")
+	}
+	return strings.TrimSpace(proofString), nil
+}
+
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
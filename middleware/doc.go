@@ -0,0 +1,12 @@
+// Package middleware groups the adapters of ethauth.Middleware for the major Go routers:
+//
+//   - ethauthchi, for chi and other routers of net/http middlewares
+//   - ethauthecho, for echo
+//   - ethauthfiber, for fiber
+//   - ethauthgin, for gin
+//
+// The adapters verify requests with ethauth.Authenticate, configured by the same
+// ethauth.MiddlewareOptions as ethauth.Middleware, and pass the verified proof to handlers in
+// the request context, so ethauth.FromContext and the helpers reading it work the same way
+// with any router.
+package middleware
@@ -0,0 +1,38 @@
+// Package ethauthchi authenticates chi requests with ETHAuth proofs.
+//
+// chi routes plain net/http middlewares, so Middleware is ethauth.Middleware, provided for
+// symmetry with the other router adapters:
+//
+//	r := chi.NewRouter()
+//	r.Use(ethauthchi.Middleware(ethAuth))
+//	r.With(ethauthchi.RequireScope("write:orders")).Post("/orders", createOrder)
+package ethauthchi
+
+import (
+	"net/http"
+
+	"github.com/0xsequence/go-ethauth"
+)
+
+// Middleware returns a chi middleware which verifies the proof of the request, and passes
+// the verified proof to the next handler in the request context, see ethauth.Middleware.
+func Middleware(ethAuth *ethauth.ETHAuth, optOptions ...ethauth.MiddlewareOptions) func(next http.Handler) http.Handler {
+	return ethauth.Middleware(ethAuth, optOptions...)
+}
+
+// RequireScope returns a chi middleware which rejects requests whose proof doesn't have all
+// of the scopes, see ethauth.RequireScope.
+func RequireScope(scopes ...string) func(next http.Handler) http.Handler {
+	return ethauth.RequireScope(scopes...)
+}
+
+// RequireRole returns a chi middleware which rejects requests whose proof doesn't have all
+// of the roles, see ethauth.RequireRole.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return ethauth.RequireRole(roles...)
+}
+
+// FromContext returns the verified proof of the request, as passed by Middleware.
+func FromContext(r *http.Request) (*ethauth.Proof, bool) {
+	return ethauth.FromContext(r.Context())
+}
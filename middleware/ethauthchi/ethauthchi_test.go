@@ -0,0 +1,49 @@
+package ethauthchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func doRequest(t *testing.T, handler http.Handler, proofString string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/me", nil)
+	if proofString != "" {
+		req.Header.Set("Authorization", "Bearer "+proofString)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(Middleware(ethAuth))
+	r.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+		proof, ok := FromContext(r)
+		require.True(t, ok)
+		w.Write([]byte(proof.Address))
+	})
+	handler := r
+
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, ethauthtest.ValidClaims("ETHAuthTest"))
+	address := strings.ToLower(ethauthtest.Alice.Address().Hex())
+
+	rec := doRequest(t, handler, "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = doRequest(t, handler, "eth.invalid")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doRequest(t, handler, proofString)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, address, rec.Body.String())
+}
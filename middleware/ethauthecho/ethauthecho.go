@@ -0,0 +1,51 @@
+// Package ethauthecho authenticates echo requests with ETHAuth proofs.
+//
+//	e := echo.New()
+//	e.Use(ethauthecho.Middleware(ethAuth))
+//	e.GET("/me", func(c echo.Context) error {
+//		proof, _ := ethauthecho.FromContext(c)
+//		return c.String(http.StatusOK, proof.Address)
+//	})
+package ethauthecho
+
+import (
+	"github.com/0xsequence/go-ethauth"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns an echo middleware which verifies the proof of the request like
+// ethauth.Middleware, and passes the verified proof to the next handler in the request
+// context. Requests failing authentication are rejected with echo.ErrUnauthorized, or passed
+// to the ErrorHandler of the options.
+func Middleware(ethAuth *ethauth.ETHAuth, optOptions ...ethauth.MiddlewareOptions) echo.MiddlewareFunc {
+	var opts ethauth.MiddlewareOptions
+	if len(optOptions) > 0 {
+		opts = optOptions[0]
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			proof, err := ethauth.Authenticate(ethAuth, ethauth.NewAuthRequest(r), opts)
+			if err != nil {
+				if opts.ErrorHandler != nil {
+					opts.ErrorHandler(c.Response(), r, err)
+					return nil
+				}
+				return echo.ErrUnauthorized.WithInternal(err)
+			}
+			if proof != nil {
+				if renew := ethauth.RenewalHeader(r.Context(), ethAuth, proof, opts); renew != "" {
+					c.Response().Header().Set(ethauth.HeaderRenew, renew)
+				}
+				c.SetRequest(r.WithContext(ethauth.WithProof(r.Context(), proof)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// FromContext returns the verified proof of the request, as passed by Middleware.
+func FromContext(c echo.Context) (*ethauth.Proof, bool) {
+	return ethauth.FromContext(c.Request().Context())
+}
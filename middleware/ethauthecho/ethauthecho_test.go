@@ -0,0 +1,49 @@
+package ethauthecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func doRequest(t *testing.T, handler http.Handler, proofString string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/me", nil)
+	if proofString != "" {
+		req.Header.Set("Authorization", "Bearer "+proofString)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(Middleware(ethAuth))
+	e.GET("/me", func(c echo.Context) error {
+		proof, ok := FromContext(c)
+		require.True(t, ok)
+		return c.String(http.StatusOK, proof.Address)
+	})
+	handler := e
+
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, ethauthtest.ValidClaims("ETHAuthTest"))
+	address := strings.ToLower(ethauthtest.Alice.Address().Hex())
+
+	rec := doRequest(t, handler, "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = doRequest(t, handler, "eth.invalid")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doRequest(t, handler, proofString)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, address, rec.Body.String())
+}
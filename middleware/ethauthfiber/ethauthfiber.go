@@ -0,0 +1,61 @@
+// Package ethauthfiber authenticates fiber requests with ETHAuth proofs.
+//
+//	app := fiber.New()
+//	app.Use(ethauthfiber.Middleware(ethAuth))
+//	app.Get("/me", func(c *fiber.Ctx) error {
+//		proof, _ := ethauthfiber.FromContext(c)
+//		return c.SendString(proof.Address)
+//	})
+package ethauthfiber
+
+import (
+	"github.com/0xsequence/go-ethauth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware returns a fiber middleware which verifies the proof of the request like
+// ethauth.Middleware, and passes the verified proof to the next handlers in the user context
+// of the request. Requests failing authentication are rejected with fiber.ErrUnauthorized,
+// to be rendered by the error handler of the fiber app. fiber isn't built on net/http, so the
+// ErrorHandler of the options is not used.
+func Middleware(ethAuth *ethauth.ETHAuth, optOptions ...ethauth.MiddlewareOptions) fiber.Handler {
+	var opts ethauth.MiddlewareOptions
+	if len(optOptions) > 0 {
+		opts = optOptions[0]
+	}
+
+	return func(c *fiber.Ctx) error {
+		proof, err := ethauth.Authenticate(ethAuth, ethauth.AuthRequest{
+			Context:       c.UserContext(),
+			Authorization: c.Get(fiber.HeaderAuthorization),
+			Origin:        c.Get(fiber.HeaderOrigin),
+			Method:        c.Method(),
+			Host:          c.Hostname(),
+			Path:          string(c.Request().URI().PathOriginal()),
+			Cookie:        c.Get(fiber.HeaderCookie),
+			PoP:           c.Get(ethauth.HeaderPoP),
+			MerkleProof:   c.Get(ethauth.HeaderMerkleProof),
+			TLS:           c.Context().TLSConnectionState(),
+			RemoteAddr:    c.IP(),
+			UserAgent:     c.Get(fiber.HeaderUserAgent),
+			Body: func() ([]byte, error) {
+				return c.Body(), nil
+			},
+		}, opts)
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		if proof != nil {
+			if renew := ethauth.RenewalHeader(c.UserContext(), ethAuth, proof, opts); renew != "" {
+				c.Set(ethauth.HeaderRenew, renew)
+			}
+			c.SetUserContext(ethauth.WithProof(c.UserContext(), proof))
+		}
+		return c.Next()
+	}
+}
+
+// FromContext returns the verified proof of the request, as passed by Middleware.
+func FromContext(c *fiber.Ctx) (*ethauth.Proof, bool) {
+	return ethauth.FromContext(c.UserContext())
+}
@@ -0,0 +1,55 @@
+package ethauthfiber
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(Middleware(ethAuth, ethauth.MiddlewareOptions{Optional: true}))
+	app.Get("/me", func(c *fiber.Ctx) error {
+		proof, ok := FromContext(c)
+		if !ok {
+			return c.SendString("anonymous")
+		}
+		return c.SendString(proof.Address)
+	})
+
+	doRequest := func(proofString string) (int, string) {
+		req := httptest.NewRequest("GET", "/me", nil)
+		if proofString != "" {
+			req.Header.Set("Authorization", "Bearer "+proofString)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, ethauthtest.ValidClaims("ETHAuthTest"))
+
+	// requests without a proof are passed through as optional, invalid proofs are rejected
+	status, body := doRequest("")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "anonymous", body)
+	status, _ = doRequest("eth.invalid")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, body = doRequest(proofString)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, strings.ToLower(ethauthtest.Alice.Address().Hex()), body)
+}
@@ -0,0 +1,52 @@
+// Package ethauthgin authenticates gin requests with ETHAuth proofs.
+//
+//	router := gin.New()
+//	router.Use(ethauthgin.Middleware(ethAuth))
+//	router.GET("/me", func(c *gin.Context) {
+//		proof, _ := ethauthgin.FromContext(c)
+//		c.String(http.StatusOK, proof.Address)
+//	})
+package ethauthgin
+
+import (
+	"net/http"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware returns a gin middleware which verifies the proof of the request like
+// ethauth.Middleware, and passes the verified proof to the next handlers in the request
+// context. Requests failing authentication are aborted with a 401 Unauthorized status, or
+// passed to the ErrorHandler of the options.
+func Middleware(ethAuth *ethauth.ETHAuth, optOptions ...ethauth.MiddlewareOptions) gin.HandlerFunc {
+	var opts ethauth.MiddlewareOptions
+	if len(optOptions) > 0 {
+		opts = optOptions[0]
+	}
+
+	return func(c *gin.Context) {
+		proof, err := ethauth.Authenticate(ethAuth, ethauth.NewAuthRequest(c.Request), opts)
+		if err != nil {
+			if opts.ErrorHandler != nil {
+				opts.ErrorHandler(c.Writer, c.Request, err)
+				c.Abort()
+			} else {
+				c.AbortWithStatus(http.StatusUnauthorized)
+			}
+			return
+		}
+		if proof != nil {
+			if renew := ethauth.RenewalHeader(c.Request.Context(), ethAuth, proof, opts); renew != "" {
+				c.Header(ethauth.HeaderRenew, renew)
+			}
+			c.Request = c.Request.WithContext(ethauth.WithProof(c.Request.Context(), proof))
+		}
+		c.Next()
+	}
+}
+
+// FromContext returns the verified proof of the request, as passed by Middleware.
+func FromContext(c *gin.Context) (*ethauth.Proof, bool) {
+	return ethauth.FromContext(c.Request.Context())
+}
@@ -0,0 +1,155 @@
+package ethauth
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	var authenticated bool
+	handler := Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, authenticated = FromContext(r.Context())
+	}))
+
+	// valid proof
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+proofString)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.True(t, authenticated)
+
+	// missing proof
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// invalid proof
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+proofString+"00")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// expired proof
+	expired := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	expired.IssuedAt = time.Now().Add(-2 * time.Hour).Unix()
+	expired.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+	expiredString, err := signTestProof(t, wallet, expired).Encode()
+	require.NoError(t, err)
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+expiredString)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// replayed proof
+	ethAuth.ConfigNonceStore(NewMemoryNonceStore())
+	claims.Nonce = 1
+	nonceString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+	for _, code := range []int{http.StatusOK, http.StatusUnauthorized} {
+		req = httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+nonceString)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, code, rec.Code)
+	}
+}
+
+func TestMiddlewareStoreOrder(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(NewMemoryNonceStore())
+	registry := NewSessionRegistry()
+	defer registry.Close()
+	ethAuth.ConfigSessionRegistry(registry)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	clientKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithNonce(1), WithOrigin("https://app.example.com"),
+		WithConfirmation(KeyConfirmation(crypto.PubkeyToAddress(clientKey.PublicKey))))
+	require.NoError(t, err)
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+	pop, err := SignPoP(proof, clientKey, "GET", "api.example.com", "/orders")
+	require.NoError(t, err)
+
+	handler := Middleware(ethAuth, MiddlewareOptions{VerifyOrigin: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	doRequest := func(origin, pop string) int {
+		req := httptest.NewRequest("GET", "http://api.example.com/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		req.Header.Set("Origin", origin)
+		if pop != "" {
+			req.Header.Set(HeaderPoP, pop)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// proofs relayed without their proof-of-possession, or from another origin, are rejected
+	// before consuming their nonce or recording their session
+	require.Equal(t, http.StatusUnauthorized, doRequest("https://app.example.com", ""))
+	require.Equal(t, http.StatusUnauthorized, doRequest("https://evil.example.com", pop))
+	sessions, err := registry.Sessions(context.Background(), wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	require.Equal(t, http.StatusOK, doRequest("https://app.example.com", pop))
+	require.Equal(t, http.StatusUnauthorized, doRequest("https://app.example.com", pop))
+	sessions, err = registry.Sessions(context.Background(), wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+}
+
+func TestMiddlewareContext(t *testing.T) {
+	type ctxKey struct{}
+	var values []interface{}
+	ethAuth, err := New(func(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+		values = append(values, ctx.Value(ctxKey{}))
+		return ValidateEOAProof(ctx, provider, chainID, proof)
+	})
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+
+	// proofs are verified with the context of their request
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxKey{}, "request"))
+	req.Header.Set("Authorization", "Bearer "+proofString)
+	rec := httptest.NewRecorder()
+	Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	_, _, err = ethAuth.DecodeProofContext(context.WithValue(context.Background(), ctxKey{}, "decode"), proofString)
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"request", "decode"}, values)
+}
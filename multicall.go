@@ -0,0 +1,298 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most EVM chains.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+var multicall3ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(`[{"name":"aggregate3","type":"function","stateMutability":"payable",
+		"inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+		"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// SignatureCheck is an EIP-1271 isValidSignature call of an account contract.
+type SignatureCheck struct {
+	Address   common.Address
+	Digest    []byte
+	Signature []byte
+
+	// BlockNumber is the block the signature is checked at, or the latest block if nil
+	BlockNumber *big.Int
+}
+
+// BatchRemoteValidator validates EIP-1271 contract wallet signatures with Multicall3, so many
+// signatures are checked in a single eth_call instead of one RPC round trip each.
+//
+// Concurrent calls to IsValidSignature, and so to its ValidateContractAccountProof validator,
+// are coalesced into one multicall per provider and block, which is flushed once it holds
+// MaxBatchSize checks or Wait has elapsed. Use it in place of the standard contract account validator:
+//
+//	batch := ethauth.NewBatchRemoteValidator()
+//	ethAuth, err := ethauth.New(ethauth.ValidateEOAProof, batch.ValidateContractAccountProof, ethauth.ValidateERC6492Proof)
+type BatchRemoteValidator struct {
+	// MulticallAddress is the Multicall3 contract address, defaulting to Multicall3Address.
+	MulticallAddress common.Address
+
+	// MaxBatchSize is the maximum number of signatures checked per multicall, defaulting to 100.
+	MaxBatchSize int
+
+	// Wait is how long a batch waits for more signatures before it is sent, defaulting to 5ms.
+	Wait time.Duration
+
+	pending map[remoteBatchKey]*remoteBatch
+	mu      sync.Mutex
+}
+
+// remoteBatchKey is the provider and block, or "" for the latest block, of a pending batch.
+type remoteBatchKey struct {
+	provider *ethrpc.Provider
+	block    string
+}
+
+type remoteBatch struct {
+	ctx     context.Context
+	checks  []SignatureCheck
+	results []bool
+	err     error
+	done    chan struct{}
+}
+
+// NewBatchRemoteValidator returns a BatchRemoteValidator with the default settings.
+func NewBatchRemoteValidator() *BatchRemoteValidator {
+	return &BatchRemoteValidator{}
+}
+
+// IsValidSignatures checks the signatures of all the checks in a single Multicall3 eth_call
+// per block. Accounts without contract code are reported as invalid.
+func (v *BatchRemoteValidator) IsValidSignatures(ctx context.Context, provider *ethrpc.Provider, checks []SignatureCheck) ([]bool, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("BatchRemoteValidator failed. provider is nil")
+	}
+	if len(checks) == 0 {
+		return nil, nil
+	}
+
+	// the checks are grouped by block, in the order of their first check
+	var blocks []string
+	groups := map[string][]int{}
+	for i, check := range checks {
+		block := blockKey(check.BlockNumber)
+		if _, ok := groups[block]; !ok {
+			blocks = append(blocks, block)
+		}
+		groups[block] = append(groups[block], i)
+	}
+
+	valid := make([]bool, len(checks))
+	for _, block := range blocks {
+		indexes := groups[block]
+		group := make([]SignatureCheck, len(indexes))
+		for j, i := range indexes {
+			group[j] = checks[i]
+		}
+		results, err := v.multicall(ctx, provider, group[0].BlockNumber, group)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range indexes {
+			valid[i] = results[j]
+		}
+	}
+	return valid, nil
+}
+
+// blockKey returns the decimal block number, or "" for the latest block.
+func blockKey(number *big.Int) string {
+	if number == nil {
+		return ""
+	}
+	return number.String()
+}
+
+// multicall checks the signatures of the checks at the block in a single Multicall3 eth_call.
+func (v *BatchRemoteValidator) multicall(ctx context.Context, provider *ethrpc.Provider, blockNumber *big.Int, checks []SignatureCheck) ([]bool, error) {
+	calls := make([]multicall3Call, len(checks))
+	for i, check := range checks {
+		input, err := ethcoder.ABIEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []interface{}{
+			ethcoder.BytesToBytes32(check.Digest),
+			check.Signature,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("BatchRemoteValidator failed. EncodeMethodCalldata error")
+		}
+		calls[i] = multicall3Call{Target: check.Address, AllowFailure: true, CallData: input}
+	}
+
+	input, err := multicall3ABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("BatchRemoteValidator failed. unable to encode multicall - %w", err)
+	}
+
+	multicallAddress := v.MulticallAddress
+	if multicallAddress == (common.Address{}) {
+		multicallAddress = Multicall3Address
+	}
+	rpcCtx, call := startRPCCall(ctx, "eth_call")
+	output, err := provider.CallContract(rpcCtx, ethereum.CallMsg{To: &multicallAddress, Data: input}, blockNumber)
+	call.end(err)
+	if err != nil {
+		return nil, fmt.Errorf("BatchRemoteValidator failed. Provider CallContract failed - %w", err)
+	}
+
+	var results []multicall3Result
+	if err := multicall3ABI.UnpackIntoInterface(&results, "aggregate3", output); err != nil {
+		return nil, fmt.Errorf("BatchRemoteValidator failed. unable to decode multicall results - %w", err)
+	}
+	if len(results) != len(checks) {
+		return nil, fmt.Errorf("BatchRemoteValidator failed. expected %d multicall results, got %d", len(checks), len(results))
+	}
+
+	valid := make([]bool, len(results))
+	for i, result := range results {
+		valid[i] = result.Success && len(result.ReturnData) >= 4 && IsValidSignatureBytes32MagicValue == ethcoder.HexEncode(result.ReturnData[:4])
+	}
+	return valid, nil
+}
+
+// IsValidSignature adds the signature check to the pending multicall of the provider at the
+// block of the check, and waits for its result.
+func (v *BatchRemoteValidator) IsValidSignature(ctx context.Context, provider *ethrpc.Provider, check SignatureCheck) (bool, error) {
+	if provider == nil {
+		return false, fmt.Errorf("BatchRemoteValidator failed. provider is nil")
+	}
+
+	maxBatchSize := v.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = 100
+	}
+	wait := v.Wait
+	if wait <= 0 {
+		wait = 5 * time.Millisecond
+	}
+
+	v.mu.Lock()
+	if v.pending == nil {
+		v.pending = map[remoteBatchKey]*remoteBatch{}
+	}
+	key := remoteBatchKey{provider: provider, block: blockKey(check.BlockNumber)}
+	batch, ok := v.pending[key]
+	if !ok {
+		// the batch outlives the cancellation of the request which started it, as other
+		// requests are waiting on it
+		batch = &remoteBatch{ctx: context.WithoutCancel(ctx), done: make(chan struct{})}
+		v.pending[key] = batch
+		time.AfterFunc(wait, func() { v.flush(key, batch) })
+	}
+	i := len(batch.checks)
+	batch.checks = append(batch.checks, check)
+	full := len(batch.checks) >= maxBatchSize
+	v.mu.Unlock()
+
+	if full {
+		v.flush(key, batch)
+	}
+
+	select {
+	case <-batch.done:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+	if batch.err != nil {
+		return false, batch.err
+	}
+	return batch.results[i], nil
+}
+
+func (v *BatchRemoteValidator) flush(key remoteBatchKey, batch *remoteBatch) {
+	v.mu.Lock()
+	if v.pending[key] != batch {
+		// already flushed
+		v.mu.Unlock()
+		return
+	}
+	delete(v.pending, key)
+	v.mu.Unlock()
+
+	batch.results, batch.err = v.IsValidSignatures(batch.ctx, key.provider, batch.checks)
+	close(batch.done)
+}
+
+// ValidateContractAccountProof is a ValidatorFunc verifying contract wallet proofs like
+// ValidateContractAccountProof, with the EIP-1271 call batched with any concurrent ones.
+func (v *BatchRemoteValidator) ValidateContractAccountProof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+	// Verify the signature offline with the wallet witness of the proof, if any
+	if ok, err := verifyWitness(ctx, chainID, proof); ok {
+		if err != nil {
+			return false, "", fmt.Errorf("BatchRemoteValidator failed. %w", err)
+		}
+		return true, proof.Address, nil
+	}
+
+	if provider == nil {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. provider is nil")
+	}
+	if chainID == nil {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. chainID is nil")
+	}
+	if _, err := proof.AddressBytes(); err != nil {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. address is not a valid Ethereum address")
+	}
+
+	messageDigest, err := proof.MessageDigest()
+	if err != nil {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. Unable to compute ethauth message digest, because %w", err)
+	}
+	signature, err := ethcoder.HexDecode(proof.Signature)
+	if err != nil {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. HexDecode of proof.signature failed - %w", err)
+	}
+
+	// Verify the signature at the block of the `iat` claim, if configured
+	blockNumber, err := signatureBlockNumber(ctx, provider, proof)
+	if err != nil {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. %w", err)
+	}
+
+	isValid, err := v.IsValidSignature(ctx, provider, SignatureCheck{
+		Address:     common.HexToAddress(proof.Address),
+		Digest:      messageDigest,
+		Signature:   signature,
+		BlockNumber: blockNumber,
+	})
+	if err != nil {
+		return false, "", err
+	}
+	if !isValid {
+		return false, "", fmt.Errorf("BatchRemoteValidator failed. invalid signature")
+	}
+	return true, proof.Address, nil
+}
@@ -0,0 +1,199 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newMulticallTestServer returns a JSON-RPC server answering Multicall3 aggregate3 eth_calls,
+// where the isValidSignature calls to validAccount succeed, and the blocks of a chain whose
+// latest block is 1000. The block of each eth_call is appended to blocks, if not nil.
+func newMulticallTestServer(t *testing.T, validAccount common.Address, ethCalls *atomic.Int32, blocks *[]string, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Method == "eth_getBlockByNumber" {
+			n := int64(1000)
+			var s string
+			require.NoError(t, json.Unmarshal(req.Params[0], &s))
+			if s != "latest" {
+				number, err := hexutil.DecodeUint64(s)
+				require.NoError(t, err)
+				n = int64(number)
+			}
+			header := &types.Header{Number: big.NewInt(n), Time: uint64(1000 + 12*n), Difficulty: big.NewInt(0)}
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": header})
+			return
+		}
+		require.Equal(t, "eth_call", req.Method)
+		ethCalls.Add(1)
+		if blocks != nil {
+			var block string
+			require.NoError(t, json.Unmarshal(req.Params[1], &block))
+			mu.Lock()
+			*blocks = append(*blocks, block)
+			mu.Unlock()
+		}
+
+		var msg struct {
+			To    common.Address `json:"to"`
+			Input string         `json:"input"`
+			Data  string         `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(req.Params[0], &msg))
+		require.Equal(t, Multicall3Address, msg.To)
+		input := msg.Input
+		if input == "" {
+			input = msg.Data
+		}
+
+		method := multicall3ABI.Methods["aggregate3"]
+		args, err := method.Inputs.Unpack(ethcoder.MustHexDecode(input)[4:])
+		require.NoError(t, err)
+		var calls []multicall3Call
+		require.NoError(t, method.Inputs.Copy(&calls, args))
+
+		results := make([]multicall3Result, len(calls))
+		for i, call := range calls {
+			results[i].Success = true
+			if call.Target == validAccount {
+				results[i].ReturnData = common.RightPadBytes(ethcoder.MustHexDecode(IsValidSignatureBytes32MagicValue), 32)
+			} else {
+				results[i].ReturnData = make([]byte, 32)
+			}
+		}
+		output, err := method.Outputs.Pack(results)
+		require.NoError(t, err)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": ethcoder.HexEncode(output)})
+	}))
+}
+
+func TestBatchRemoteValidator(t *testing.T) {
+	validAccount := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	invalidAccount := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	var ethCalls atomic.Int32
+	server := newMulticallTestServer(t, validAccount, &ethCalls, nil, nil)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	batch := NewBatchRemoteValidator()
+	digest := make([]byte, 32)
+
+	valid, err := batch.IsValidSignatures(context.Background(), provider, []SignatureCheck{
+		{Address: validAccount, Digest: digest, Signature: []byte{1}},
+		{Address: invalidAccount, Digest: digest, Signature: []byte{1}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false}, valid)
+	require.Equal(t, int32(1), ethCalls.Load())
+
+	// concurrent checks are coalesced into a single multicall
+	var wg sync.WaitGroup
+	results := make([]bool, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			account := validAccount
+			if i%2 == 1 {
+				account = invalidAccount
+			}
+			var err error
+			results[i], err = batch.IsValidSignature(context.Background(), provider, SignatureCheck{Address: account, Digest: digest, Signature: []byte{1}})
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	for i, valid := range results {
+		require.Equal(t, i%2 == 0, valid)
+	}
+	require.Equal(t, int32(2), ethCalls.Load())
+}
+
+func TestBatchRemoteValidatorBlocks(t *testing.T) {
+	validAccount := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	var ethCalls atomic.Int32
+	var blocks []string
+	mu := &sync.Mutex{}
+	server := newMulticallTestServer(t, validAccount, &ethCalls, &blocks, mu)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	batch := NewBatchRemoteValidator()
+	digest := make([]byte, 32)
+
+	// checks are made in one multicall per block
+	valid, err := batch.IsValidSignatures(context.Background(), provider, []SignatureCheck{
+		{Address: validAccount, Digest: digest, Signature: []byte{1}},
+		{Address: validAccount, Digest: digest, Signature: []byte{1}, BlockNumber: big.NewInt(400)},
+		{Address: common.Address{}, Digest: digest, Signature: []byte{1}, BlockNumber: big.NewInt(400)},
+		{Address: validAccount, Digest: digest, Signature: []byte{1}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true, false, true}, valid)
+	require.Equal(t, []string{"latest", "0x190"}, blocks)
+
+	// concurrent checks are coalesced per block
+	blocks = nil
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			check := SignatureCheck{Address: validAccount, Digest: digest, Signature: []byte{1}}
+			if i%2 == 1 {
+				check.BlockNumber = big.NewInt(400)
+			}
+			valid, err := batch.IsValidSignature(context.Background(), provider, check)
+			require.NoError(t, err)
+			require.True(t, valid)
+		}(i)
+	}
+	wg.Wait()
+	require.ElementsMatch(t, []string{"latest", "0x190"}, blocks)
+
+	// the validator verifies signatures at the block of the `iat` claim, if configured
+	ethAuth, err := New(batch.ValidateContractAccountProof)
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(server.URL, 1))
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return time.Unix(1000+12*1000, 0) }))
+	require.NoError(t, ethAuth.ConfigSignatureBlock(SignatureBlockIssuedAt))
+
+	proof := NewProof()
+	proof.Address = validAccount.Hex()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.IssuedAt = 1000 + 12*396 + 8 // the minute following block 396
+	proof.Claims.ExpiresAt = 1000 + 12*1000 + 3600
+	proof.Signature = "0x01"
+
+	blocks = nil
+	ok, err := ethAuth.ValidateProof(proof)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []string{"0x18c"}, blocks)
+}
@@ -0,0 +1,104 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// MultisigPolicy is an m-of-n group of EOA signers, which may co-sign proofs on behalf of
+// the group Address. The proof signature of a multisig proof is the concatenation of the
+// 65-byte signatures of the co-signers over the proof message digest.
+type MultisigPolicy struct {
+	// Address identifying the group, used as the proof address
+	Address common.Address
+
+	// Signers of the group
+	Signers []common.Address
+
+	// Threshold is the number of distinct signers required to co-sign a proof
+	Threshold int
+}
+
+// NewMultisigValidator returns a ValidatorFunc accepting the proofs of each policy Address
+// which have been co-signed by at least Threshold of the policy Signers.
+func NewMultisigValidator(policies ...MultisigPolicy) (ValidatorFunc, error) {
+	for _, policy := range policies {
+		if policy.Threshold < 1 || policy.Threshold > len(policy.Signers) {
+			return nil, fmt.Errorf("ethauth: multisig threshold must be between 1 and the number of signers")
+		}
+	}
+
+	return func(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+		address, err := proof.AddressBytes()
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateMultisigProof failed. address is not a valid Ethereum address")
+		}
+		i := slices.IndexFunc(policies, func(p MultisigPolicy) bool { return p.Address == address })
+		if i < 0 {
+			return false, "", fmt.Errorf("ValidateMultisigProof failed. address has no multisig policy")
+		}
+		policy := policies[i]
+
+		messageDigest, err := proof.MessageDigest()
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateMultisigProof failed. Unable to compute ethauth message digest, because %w", err)
+		}
+		signatures, err := ethcoder.HexDecode(proof.Signature)
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateMultisigProof failed. HexDecode of proof.signature failed - %w", err)
+		}
+		if len(signatures) == 0 || len(signatures)%65 != 0 {
+			return false, "", fmt.Errorf("ValidateMultisigProof failed. signature is not a concatenation of 65-byte signatures")
+		}
+
+		var approvals []common.Address
+		for j := 0; j < len(signatures); j += 65 {
+			signer, err := recoverSignerAddress(messageDigest, signatures[j:j+65])
+			if err != nil {
+				return false, "", fmt.Errorf("ValidateMultisigProof failed. invalid signature %d", j/65)
+			}
+			if !slices.Contains(policy.Signers, signer) {
+				return false, "", fmt.Errorf("ValidateMultisigProof failed. signature %d is not by a signer of the policy", j/65)
+			}
+			if !slices.Contains(approvals, signer) {
+				approvals = append(approvals, signer)
+			}
+		}
+		if len(approvals) < policy.Threshold {
+			return false, "", fmt.Errorf("ValidateMultisigProof failed. %d of %d required signatures", len(approvals), policy.Threshold)
+		}
+		return true, proof.Address, nil
+	}, nil
+}
+
+// SignMultisigProof co-signs the proof claims with each of the signers on behalf of the
+// multisig group address, and sets the proof address and signature.
+func SignMultisigProof(ctx context.Context, proof *Proof, address common.Address, signers ...Signer) error {
+	if proof == nil {
+		return fmt.Errorf("ethauth: proof is nil")
+	}
+	proof.Address = address.Hex()
+
+	var signatures []byte
+	for _, signer := range signers {
+		sig, err := signer.Sign(ctx, proof)
+		if err != nil {
+			return fmt.Errorf("ethauth: failed to sign proof - %w", err)
+		}
+		if len(sig) != 65 {
+			return fmt.Errorf("ethauth: failed to sign proof, signature is not of proper length (=65)")
+		}
+		if sig[64] < 27 {
+			sig[64] += 27
+		}
+		signatures = append(signatures, sig...)
+	}
+	proof.Signature = ethcoder.HexEncode(signatures)
+	return nil
+}
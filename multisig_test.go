@@ -0,0 +1,57 @@
+package ethauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultisigProof(t *testing.T) {
+	var signers []Signer
+	var addresses []common.Address
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		signers = append(signers, NewPrivateKeySigner(key))
+		addresses = append(addresses, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	opsAddress := common.HexToAddress("0x0000000000000000000000000000000000000042")
+
+	validator, err := NewMultisigValidator(MultisigPolicy{Address: opsAddress, Signers: addresses, Threshold: 2})
+	require.NoError(t, err)
+	ethAuth, err := New(validator)
+	require.NoError(t, err)
+
+	newProof := func(signers ...Signer) *Proof {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignMultisigProof(context.Background(), proof, opsAddress, signers...))
+		return proof
+	}
+
+	_, err = ethAuth.EncodeProof(newProof(signers[0], signers[2]))
+	require.NoError(t, err)
+	_, err = ethAuth.EncodeProof(newProof(signers[2], signers[1], signers[0]))
+	require.NoError(t, err)
+
+	// below threshold, including repeated signatures of the same signer
+	_, err = ethAuth.EncodeProof(newProof(signers[1]))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = ethAuth.EncodeProof(newProof(signers[1], signers[1]))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// signatures by keys outside of the policy
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = ethAuth.EncodeProof(newProof(signers[0], NewPrivateKeySigner(otherKey)))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = NewMultisigValidator(MultisigPolicy{Address: opsAddress, Signers: addresses, Threshold: 4})
+	require.Error(t, err)
+}
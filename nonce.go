@@ -0,0 +1,82 @@
+package ethauth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NonceStore records the nonces of decoded proofs, so each `n` claim of an account
+// can only be used once within the lifetime of the proof.
+type NonceStore interface {
+	// Consume marks the nonce of the address as used until exp, and returns ErrNonceUsed
+	// if the nonce has already been consumed. exp is the last time the proof is accepted,
+	// ie. its `exp` claim plus the expiration leeway of the validator config.
+	Consume(ctx context.Context, address string, nonce uint64, exp time.Time) error
+}
+
+// MemoryNonceStore is an in-process NonceStore. Its nonces are sharded by address, and purged
+// as they expire by the timing wheel of their shard, so it scales to the verification rate of
+// many concurrent accounts.
+type MemoryNonceStore struct {
+	shards [memoryShards]nonceShard
+
+	// clock is the clock of the ETHAuth instance the store is configured with
+	clock func() time.Time
+}
+
+type nonceShard struct {
+	nonces map[memoryNonceKey]time.Time
+	wheel  expiryWheel[memoryNonceKey]
+	mu     sync.Mutex
+}
+
+type memoryNonceKey struct {
+	address string
+	nonce   uint64
+}
+
+var _ NonceStore = &MemoryNonceStore{}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	s := &MemoryNonceStore{clock: time.Now}
+	for i := range s.shards {
+		s.shards[i].nonces = map[memoryNonceKey]time.Time{}
+	}
+	return s
+}
+
+func (s *MemoryNonceStore) Consume(ctx context.Context, address string, nonce uint64, exp time.Time) error {
+	key := memoryNonceKey{address: strings.ToLower(address), nonce: nonce}
+	shard := &s.shards[shardIndex(key.address)]
+	now := s.clock()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.wheel.advance(now, func(key memoryNonceKey, exp int64) {
+		if e, ok := shard.nonces[key]; ok && e.Unix() == exp {
+			delete(shard.nonces, key)
+		}
+	})
+
+	if e, ok := shard.nonces[key]; ok && !now.After(e) {
+		return ErrNonceUsed
+	}
+	shard.nonces[key] = exp
+	shard.wheel.add(key, exp)
+	return nil
+}
+
+// ConfigClock sets the clock the nonces expire by, which ETHAuth.ConfigNonceStore sets to
+// the clock of the instance.
+func (s *MemoryNonceStore) ConfigClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// clockedStore is implemented by the stores which expire their entries by the clock of the
+// ETHAuth instance they are configured with, see ETHAuth.ConfigClock.
+type clockedStore interface {
+	ConfigClock(clock func() time.Time)
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package ethauth
+
+const raceEnabled = false
@@ -0,0 +1,56 @@
+package ethauth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// originPattern matches the `ogn` claim of proofs. An empty scheme matches any scheme, and
+// a host starting with "*." matches any subdomain of the rest of the host.
+type originPattern struct {
+	scheme string
+	host   string
+}
+
+func parseOriginPattern(pattern string) (originPattern, error) {
+	var p originPattern
+	host := pattern
+	if scheme, rest, ok := strings.Cut(pattern, "://"); ok {
+		p.scheme, host = strings.ToLower(scheme), rest
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "/"))
+	if host == "" || strings.ContainsAny(host, "/?#") || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return originPattern{}, fmt.Errorf("ethauth: invalid allowed origin %q", pattern)
+	}
+	p.host = host
+	return p, nil
+}
+
+func (p originPattern) match(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if p.scheme != "" && p.scheme != strings.ToLower(u.Scheme) {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	if suffix, ok := strings.CutPrefix(p.host, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == p.host
+}
+
+// sameOrigin reports whether the origins have the same scheme and host.
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil || ub.Host == "" {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
@@ -0,0 +1,81 @@
+package ethauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		match   bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"app.example.com", "http://app.example.com", true},
+		{"app.example.com", "https://app.example.com:8443", false},
+		{"app.example.com:8443", "https://app.example.com:8443", true},
+		{"*.example.com", "https://a.b.example.com", true},
+		{"*.example.com", "https://example.com", false},
+		{"*.example.com", "https://badexample.com", false},
+		{"https://*.example.com", "http://app.example.com", false},
+		{"example.com", "", false},
+		{"example.com", "example.com", false},
+	}
+	for _, tt := range tests {
+		p, err := parseOriginPattern(tt.pattern)
+		require.NoError(t, err)
+		require.Equal(t, tt.match, p.match(tt.origin), "%s ~ %s", tt.pattern, tt.origin)
+	}
+
+	for _, pattern := range []string{"", "https://", "example.com/path", "app.*.example.com"} {
+		_, err := parseOriginPattern(pattern)
+		require.Error(t, err, pattern)
+	}
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", Origin: "https://app.example.com", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	require.NoError(t, ethAuth.ConfigAllowedOrigins("https://*.example.com"))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	require.NoError(t, ethAuth.ConfigAllowedOrigins("https://other.example.com"))
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrInvalidOrigin)
+	require.NoError(t, ethAuth.ConfigAllowedOrigins())
+
+	// the Origin header must match the proof origin
+	handler := Middleware(ethAuth, MiddlewareOptions{VerifyOrigin: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for origin, code := range map[string]int{
+		"":                         http.StatusOK,
+		"https://app.example.com":  http.StatusOK,
+		"https://evil.example.com": http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, code, rec.Code, origin)
+	}
+}
@@ -0,0 +1,171 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func testProofString(t testing.TB) string {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithExpiresIn(time.Hour))
+	require.NoError(t, err)
+	return proofString
+}
+
+// withClaims replaces the base64 encoded claims of a proof string.
+func withClaims(proofString string, claims string) string {
+	parts := strings.Split(proofString, ".")
+	parts[2] = claims
+	return strings.Join(parts, ".")
+}
+
+func TestParseLimits(t *testing.T) {
+	proofString := testProofString(t)
+	_, err := Parse(proofString)
+	require.NoError(t, err)
+
+	parts := strings.Split(proofString, ".")
+	claimsJSON, err := Base64UrlDecode(parts[2])
+	require.NoError(t, err)
+
+	// proof strings and claims over the limits
+	_, err = Parse(proofString + "." + strings.Repeat("0", MaxProofLength))
+	require.ErrorContains(t, err, "exceeds")
+	_, err = Parse(withClaims(proofString, Base64UrlEncode([]byte(`{"app":"`+strings.Repeat("a", MaxClaimsLength)+`"}`))))
+	require.ErrorContains(t, err, "exceed")
+
+	// padding, line breaks and the standard base64 alphabet are rejected
+	for _, claims := range []string{
+		Base64UrlEncode(claimsJSON) + "==",
+		Base64UrlEncode(claimsJSON)[:8] + "\n" + Base64UrlEncode(claimsJSON)[8:],
+		"+" + Base64UrlEncode(claimsJSON)[1:],
+	} {
+		_, err = Parse(withClaims(proofString, claims))
+		require.Error(t, err, claims)
+	}
+}
+
+func TestParseShortSignature(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+	parts := strings.Split(testProofString(t), ".")
+	for _, sig := range []string{"", "0", "x"} {
+		parts[3] = sig
+		_, _, err := ethAuth.DecodeProof(strings.Join(parts, "."))
+		require.Error(t, err, sig)
+	}
+
+	proof := NewProof()
+	proof.Address = "0x1111111111111111111111111111111111111111"
+	proof.Claims = Claims{App: "ETHAuthTest", IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	proof.Signature = "0"
+	_, err = proof.Encode()
+	require.ErrorContains(t, err, "signature")
+}
+
+func TestDecodeProofUnknownClaims(t *testing.T) {
+	proofString := testProofString(t)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	ok, _, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(proof.claimsJSON, &claims))
+	claims["role"] = "admin"
+	claimsJSON, err := json.Marshal(claims)
+	require.NoError(t, err)
+	tampered := withClaims(proofString, Base64UrlEncode(claimsJSON))
+
+	// unknown claims are rejected, and so are claims unknown to the custom claims
+	_, _, err = ethAuth.DecodeProof(tampered)
+	require.ErrorContains(t, err, `unknown claim "role"`)
+
+	claims["admin"] = true
+	claimsJSON, err = json.Marshal(claims)
+	require.NoError(t, err)
+	ethAuth.ConfigCustomClaims(func() ClaimsProvider { return &testCustomClaims{} })
+	_, _, err = ethAuth.DecodeProof(withClaims(proofString, Base64UrlEncode(claimsJSON)))
+	require.ErrorContains(t, err, "cannot unmarshal custom claims")
+}
+
+func TestParseClaimsMode(t *testing.T) {
+	proofString := withClaims(testProofString(t), Base64UrlEncode([]byte(`{"app":"ETHAuthTest","iat":1,"exp":2,"v":"1","future":{"x":1}}`)))
+
+	// lenient parsing preserves the unknown claims, which are encoded again with the claims
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+	require.Equal(t, "ETHAuthTest", proof.Claims.App)
+	require.Equal(t, map[string]json.RawMessage{"future": json.RawMessage(`{"x":1}`)}, proof.Claims.Unknown)
+	claimsJSON, err := json.Marshal(proof.Claims)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"app":"ETHAuthTest","iat":1,"exp":2,"v":"1","future":{"x":1}}`, string(claimsJSON))
+
+	proof, err = ParseReader(strings.NewReader(proofString))
+	require.NoError(t, err)
+	require.Contains(t, proof.Claims.Unknown, "future")
+
+	// strict parsing rejects them
+	_, err = Parse(proofString, ParseLimits{ClaimsMode: ClaimsStrict})
+	require.ErrorContains(t, err, `unknown claim "future"`)
+	_, err = ParseReader(strings.NewReader(proofString), ParseLimits{ClaimsMode: ClaimsStrict})
+	require.ErrorContains(t, err, `unknown claim "future"`)
+
+	claims, err := UnmarshalClaims([]byte(`{"app":"ETHAuthTest"}`), ClaimsStrict)
+	require.NoError(t, err)
+	require.Nil(t, claims.Unknown)
+	_, err = UnmarshalClaims([]byte(`{"app":"ETHAuthTest","b":1,"a":2}`), ClaimsStrict)
+	require.ErrorContains(t, err, `unknown claim "a"`)
+
+	// unknown claims can't override known claims
+	claims.Unknown = map[string]json.RawMessage{"app": json.RawMessage(`"Other"`)}
+	_, err = json.Marshal(claims)
+	require.ErrorContains(t, err, "conflicts")
+}
+
+func FuzzParse(f *testing.F) {
+	proofString := testProofString(f)
+	f.Add(proofString)
+	f.Add(proofString + ".0x")
+	f.Add(withClaims(proofString, "e30"))
+	f.Add("eth.0x.e30.0x")
+	f.Add("...")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		proof, err := Parse(s)
+		if err != nil {
+			return
+		}
+		require.LessOrEqual(t, len(s), MaxProofLength)
+		require.LessOrEqual(t, len(proof.claimsJSON), MaxClaimsLength)
+		require.Equal(t, ETHAuthPrefix, proof.Prefix)
+
+		// the claims have a single encoding
+		require.Equal(t, strings.Split(s, ".")[2], Base64UrlEncode(proof.claimsJSON))
+	})
+}
+
+func FuzzDecodeProof(f *testing.F) {
+	ethAuth, err := New()
+	require.NoError(f, err)
+	f.Add(testProofString(f))
+	f.Add(withClaims(testProofString(f), Base64UrlEncode([]byte(`{"app":"ETHAuthTest","iat":1,"v":"1","x":null}`))))
+
+	f.Fuzz(func(t *testing.T, s string) {
+		ok, proof, err := ethAuth.DecodeProof(s)
+		if err == nil {
+			require.True(t, ok)
+			require.NotNil(t, proof)
+		}
+	})
+}
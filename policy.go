@@ -0,0 +1,56 @@
+package ethauth
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// AppPolicy restricts the lifetime and scopes of the proofs of an app, see
+// ETHAuth.ConfigAppPolicies.
+type AppPolicy struct {
+	// MaxLifetime is the maximum lifetime of the proofs of the app, from their `iat` to their
+	// `exp` claim, which are then both required. 0 doesn't restrict the lifetime.
+	MaxLifetime time.Duration
+
+	// Scopes are the scopes which proofs of the app may be granted. Empty allows any scope.
+	Scopes []string
+}
+
+// Check returns an error if the claims violate the policy, which issuers may call to reject
+// claims before signing them.
+func (p AppPolicy) Check(claims Claims) error {
+	if p.MaxLifetime > 0 {
+		if claims.IssuedAt == 0 {
+			return ErrMissingIssuedAt
+		}
+		if claims.ExpiresAt == 0 || time.Duration(claims.ExpiresAt-claims.IssuedAt)*time.Second > p.MaxLifetime {
+			return fmt.Errorf("%w, app %q proofs are valid for at most %s", ErrLifetimeExceeded, claims.App, p.MaxLifetime)
+		}
+	}
+	if len(p.Scopes) > 0 {
+		for _, scope := range claims.Scope {
+			if !slices.Contains(p.Scopes, scope) {
+				return fmt.Errorf("%w, app %q proofs may not be granted scope %q", ErrScopeNotAllowed, claims.App, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// ConfigAppPolicies sets the policies of the proofs of each app, by app name. Policies are
+// enforced at issuance by EncodeProof, and at verification by DecodeProof, so ie. a
+// marketing site can't mint year-long proofs while an exchange app is held to 15 minutes.
+// Proofs of apps without a policy are not restricted, see ConfigAllowedApps to reject them.
+func (w *ETHAuth) ConfigAppPolicies(policies map[string]AppPolicy) error {
+	for app, policy := range policies {
+		if app == "" {
+			return fmt.Errorf("ethauth: app policy requires an app name")
+		}
+		if policy.MaxLifetime < 0 {
+			return fmt.Errorf("ethauth: app policy %q max lifetime must not be negative", app)
+		}
+	}
+	w.appPolicies = policies
+	return nil
+}
@@ -0,0 +1,56 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppPolicies(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.Error(t, ethAuth.ConfigAppPolicies(map[string]AppPolicy{"": {}}))
+	require.Error(t, ethAuth.ConfigAppPolicies(map[string]AppPolicy{"Exchange": {MaxLifetime: -time.Minute}}))
+	require.NoError(t, ethAuth.ConfigAppPolicies(map[string]AppPolicy{
+		"Exchange":  {MaxLifetime: 15 * time.Minute, Scopes: []string{"read:orders", "write:orders"}},
+		"Marketing": {MaxLifetime: 30 * 24 * time.Hour},
+	}))
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	newClaims := func(app string, ttl time.Duration, scopes ...string) Claims {
+		claims := Claims{App: app, ETHAuthVersion: ETHAuthVersion, Scope: scopes}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(ttl)
+		return claims
+	}
+
+	// policies are enforced at issuance
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, newClaims("Exchange", 15*time.Minute, "read:orders")))
+	require.NoError(t, err)
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, newClaims("Exchange", time.Hour)))
+	require.ErrorIs(t, err, ErrLifetimeExceeded)
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, newClaims("Exchange", 15*time.Minute, "admin")))
+	require.ErrorIs(t, err, ErrScopeNotAllowed)
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, newClaims("Marketing", 7*24*time.Hour, "admin")))
+	require.NoError(t, err)
+
+	// apps without a policy are not restricted
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, newClaims("Other", 90*24*time.Hour)))
+	require.NoError(t, err)
+
+	// and at verification, of proofs issued elsewhere
+	proofString, err := signTestProof(t, wallet, newClaims("Exchange", time.Hour)).Encode()
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrLifetimeExceeded)
+	require.Equal(t, "policy_violation", FailureReason(err))
+
+	claims := newClaims("Exchange", 15*time.Minute)
+	claims.IssuedAt = 0
+	_, err = ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.ErrorIs(t, err, ErrMissingIssuedAt)
+}
@@ -0,0 +1,177 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// HeaderPoP is the request header carrying the proof-of-possession signature of proofs bound
+// to a client key by their `cnf` claim.
+const HeaderPoP = "ETHAuth-PoP"
+
+// ConfirmationTLSPrefix prefixes the `cnf` claim of proofs bound to a client TLS certificate,
+// followed by the base64url SHA-256 thumbprint of the certificate, as the x5t#S256
+// confirmation method of RFC 8705.
+const ConfirmationTLSPrefix = "x5t#S256:"
+
+// PoPMaxAge is how far the timestamp of a proof-of-possession signature may be from the
+// time of verification.
+const PoPMaxAge = time.Minute
+
+// KeyConfirmation returns the `cnf` claim binding a proof to the client key of the address,
+// which must then sign each request, see SignPoP.
+func KeyConfirmation(address common.Address) string {
+	return address.Hex()
+}
+
+// TLSConfirmation returns the `cnf` claim binding a proof to the client TLS certificate, so
+// the proof is only accepted over mutual TLS connections authenticated by the certificate.
+func TLSConfirmation(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return ConfirmationTLSPrefix + base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// validConfirmation reports whether the `cnf` claim is a key address or a TLS thumbprint.
+func validConfirmation(cnf string) bool {
+	if thumbprint, ok := strings.CutPrefix(cnf, ConfirmationTLSPrefix); ok {
+		sum, err := base64.RawURLEncoding.DecodeString(thumbprint)
+		return err == nil && len(sum) == sha256.Size
+	}
+	return common.IsHexAddress(cnf) && strings.HasPrefix(cnf, "0x")
+}
+
+// popMessage is the message signed by proof-of-possession signatures, binding the request
+// method and url to the proof, by its signature, at the timestamp.
+func popMessage(proof *Proof, method, host, path string, timestamp int64) []byte {
+	return []byte(fmt.Sprintf("ethauth-pop\n%s\n%s%s\n%d\n%s", strings.ToUpper(method), strings.ToLower(host), path, timestamp, strings.ToLower(proof.Signature)))
+}
+
+// SignPoP returns the HeaderPoP value of a request of the method to the host and path,
+// signed now by the client key the proof is bound to. The signature is an EIP-191 personal
+// signature, so browser clients may sign it with a non-extractable key or wallet as well.
+func SignPoP(proof *Proof, key *ecdsa.PrivateKey, method, host, path string) (string, error) {
+	timestamp := time.Now().Unix()
+	sig, err := crypto.Sign(eip191MessageDigest(popMessage(proof, method, host, path, timestamp)), key)
+	if err != nil {
+		return "", fmt.Errorf("ethauth: failed to sign proof-of-possession - %w", err)
+	}
+	sig[64] += 27
+	return fmt.Sprintf("%d.%s", timestamp, ethcoder.HexEncode(sig)), nil
+}
+
+// AuthRequest is the request whose proof is verified by Authenticate.
+type AuthRequest struct {
+	// Context is the context of the request, which the proof is verified with, or
+	// context.Background() if nil
+	Context context.Context
+
+	// Authorization is the `Bearer <proof>` Authorization header
+	Authorization string
+
+	// Origin is the Origin header, checked with MiddlewareOptions.VerifyOrigin
+	Origin string
+
+	// Method, Host and Path, without the query, are the request target signed by the
+	// proof-of-possession of proofs bound to a client key
+	Method string
+	Host   string
+	Path   string
+
+	// Cookie is the Cookie header, for proofs read from the proof cookie of
+	// MiddlewareOptions.Cookie
+	Cookie string
+
+	// PoP is the HeaderPoP header
+	PoP string
+
+	// MerkleProof is the HeaderMerkleProof header, see MiddlewareOptions.Merkle
+	MerkleProof string
+
+	// TLS is the state of the connection, for proofs bound to a client TLS certificate
+	TLS *tls.ConnectionState
+
+	// RemoteAddr and UserAgent are the client IP address, with or without a port, and
+	// User-Agent header, for proofs bound to their client, see ConfigClientBinding
+	RemoteAddr string
+	UserAgent  string
+
+	// Body returns the request body, for request proofs signing it, see ProofTypeRequest
+	Body func() ([]byte, error)
+}
+
+// NewAuthRequest returns the AuthRequest of a net/http request. The body of the request is
+// only read to verify request proofs, up to MaxRequestBodyLength, and is then replaced by a
+// reader of the same body.
+func NewAuthRequest(r *http.Request) AuthRequest {
+	return AuthRequest{
+		Context:       r.Context(),
+		Authorization: r.Header.Get("Authorization"),
+		Origin:        r.Header.Get("Origin"),
+		Method:        r.Method,
+		Host:          r.Host,
+		Path:          r.URL.EscapedPath(),
+		Cookie:        r.Header.Get("Cookie"),
+		PoP:           r.Header.Get(HeaderPoP),
+		MerkleProof:   r.Header.Get(HeaderMerkleProof),
+		TLS:           r.TLS,
+		RemoteAddr:    r.RemoteAddr,
+		UserAgent:     r.UserAgent(),
+		Body: func() ([]byte, error) {
+			if r.Body == nil {
+				return nil, nil
+			}
+			return readRequestBody(r)
+		},
+	}
+}
+
+// VerifyPoP verifies that the request is made by the holder of the client key or TLS
+// certificate the proof is bound to by its `cnf` claim. Proofs without a `cnf` claim are bearer
+// proofs, which need no proof-of-possession.
+func (w *ETHAuth) VerifyPoP(proof *Proof, req AuthRequest) error {
+	cnf := proof.Claims.Confirmation
+	if cnf == "" {
+		return nil
+	}
+
+	if thumbprint, ok := strings.CutPrefix(cnf, ConfirmationTLSPrefix); ok {
+		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+			return fmt.Errorf("%w, proof is bound to a client certificate", ErrInvalidPoP)
+		}
+		if TLSConfirmation(req.TLS.PeerCertificates[0]) != ConfirmationTLSPrefix+thumbprint {
+			return fmt.Errorf("%w, client certificate does not match", ErrInvalidPoP)
+		}
+		return nil
+	}
+
+	timestampString, sig, ok := strings.Cut(req.PoP, ".")
+	if !ok {
+		return fmt.Errorf("%w, missing %s header", ErrInvalidPoP, HeaderPoP)
+	}
+	timestamp, err := strconv.ParseInt(timestampString, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w, invalid timestamp", ErrInvalidPoP)
+	}
+	if d := w.clock().Sub(time.Unix(timestamp, 0)); d > PoPMaxAge || d < -PoPMaxAge {
+		return fmt.Errorf("%w, signature has expired", ErrInvalidPoP)
+	}
+
+	valid, err := ValidateEOASignature(cnf, popMessage(proof, req.Method, req.Host, req.Path, timestamp), sig)
+	if err != nil || !valid {
+		return fmt.Errorf("%w, invalid signature", ErrInvalidPoP)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package ethauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofOfPossession(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	clientKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithConfirmation(KeyConfirmation(crypto.PubkeyToAddress(clientKey.PublicKey))))
+	require.NoError(t, err)
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+
+	handler := Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	doRequest := func(method, path, pop string) int {
+		req := httptest.NewRequest(method, "http://api.example.com"+path, nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		if pop != "" {
+			req.Header.Set(HeaderPoP, pop)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// the bound proof is useless without a proof-of-possession of the client key
+	require.Equal(t, http.StatusUnauthorized, doRequest("GET", "/orders", ""))
+
+	pop, err := SignPoP(proof, clientKey, "GET", "api.example.com", "/orders")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, doRequest("GET", "/orders?limit=10", pop))
+
+	// the signature is bound to the request method and path
+	require.Equal(t, http.StatusUnauthorized, doRequest("POST", "/orders", pop))
+	require.Equal(t, http.StatusUnauthorized, doRequest("GET", "/account", pop))
+
+	// and must be signed by the client key
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	pop, err = SignPoP(proof, otherKey, "GET", "api.example.com", "/orders")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, doRequest("GET", "/orders", pop))
+
+	// recently
+	ethAuth.ConfigClock(func() time.Time { return time.Now().Add(2 * PoPMaxAge) })
+	pop, err = SignPoP(proof, clientKey, "GET", "api.example.com", "/orders")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, doRequest("GET", "/orders", pop))
+
+	// the cnf claim is signed, so it can't be stripped from the proof
+	stripped := *proof
+	stripped.Claims.Confirmation = ""
+	strippedString, err := stripped.Encode()
+	require.NoError(t, err)
+	_, _, err = ethAuth.DecodeProof(strippedString)
+	require.Error(t, err)
+
+	// invalid confirmations are rejected at issuance
+	_, err = Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithConfirmation("key"))
+	require.ErrorIs(t, err, ErrInvalidConfirmation)
+}
+
+func TestTLSProofOfPossession(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	newCert := func() *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}
+	cert := newCert()
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithConfirmation(TLSConfirmation(cert)))
+	require.NoError(t, err)
+
+	authenticate := func(state *tls.ConnectionState) error {
+		_, err := Authenticate(ethAuth, AuthRequest{Authorization: "Bearer " + proofString, TLS: state}, MiddlewareOptions{})
+		return err
+	}
+	require.NoError(t, authenticate(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}))
+	require.ErrorIs(t, authenticate(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{newCert()}}), ErrInvalidPoP)
+	require.ErrorIs(t, authenticate(nil), ErrInvalidPoP)
+}
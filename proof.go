@@ -1,20 +1,32 @@
 package ethauth
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/crypto"
 )
 
 type Proof struct {
-	// "eth" prefix
+	// "eth" prefix, or "eth2" for proofs parsed from a compressed proof string
 	Prefix string
 
 	// Account addres (in hex)
 	Address string
 
+	// AccountChainID is the chain of the account, for proofs whose address is given as a
+	// CAIP-10 account id `eip155:<chain id>:<address>`, which must match the `cid` claim
+	AccountChainID uint64
+
 	// Claims object, aka, the message key of an EIP712 signature
 	Claims Claims
 
@@ -24,8 +36,27 @@ type Proof struct {
 	// Extra bytes in hex format used for signature validation
 	// ie. useful for counterfactual smart wallets
 	Extra string
+
+	// GuardianSignature is the countersignature of the proof by a server-side guardian
+	// key (in hex), see CountersignProof
+	GuardianSignature string
+
+	// ENSName is the ENS name of the account address, which is resolved by ETHAuth when
+	// an ENS resolver is configured and is not part of the proof string
+	ENSName string
+
+	// roleResolver is the role resolver of the ETHAuth instance which verified the proof,
+	// see HasRole
+	roleResolver RoleResolver
+
+	// claimsJSON is the raw claims JSON of a parsed proof
+	claimsJSON []byte
 }
 
+// ProofTypeEIP191 is the `typ` claim of proofs whose signature is an EIP-191 personal_sign
+// of the claims JSON, for wallets which are unable to sign EIP712 typed data.
+const ProofTypeEIP191 = "eip191"
+
 func NewProof() *Proof {
 	return &Proof{
 		Prefix: ETHAuthPrefix,
@@ -35,12 +66,301 @@ func NewProof() *Proof {
 	}
 }
 
+// Encode serializes the proof into the compact ETHAuth proof string format of
+// `eth.<address>.<claims>.<signature>[.<extra>][.<guardianSignature>]`, where the extra
+// part is left empty for countersigned proofs without extra data. Note, Encode does not validate the
+// proof signature or claims, see ETHAuth.EncodeProof for that.
+func (t *Proof) Encode() (string, error) {
+	return t.encode(false)
+}
+
+// encode serializes the proof into its proof string, or its compressed proof string, see
+// EncodeCompressed.
+func (t *Proof) encode(compressed bool) (string, error) {
+	if err := t.validateEncoding(); err != nil {
+		return "", err
+	}
+
+	claimsJSON, err := json.Marshal(t.Claims)
+	if err != nil {
+		return "", fmt.Errorf("ethauth: cannot marshal proof claims - %w", err)
+	}
+
+	// hex parts are encoded as is, or as base64 url-encoded bytes in compressed proof strings
+	signature, extra, guardianSignature := t.Signature, t.Extra, t.GuardianSignature
+	prefix := ETHAuthPrefix
+	if compressed {
+		prefix = ETHAuthCompressedPrefix
+		if claimsJSON, err = deflateClaims(claimsJSON); err != nil {
+			return "", err
+		}
+		if signature, err = compressHex(signature); err != nil {
+			return "", fmt.Errorf("ethauth: invalid signature encoding - %w", err)
+		}
+		if extra, err = compressHex(extra); err != nil {
+			return "", fmt.Errorf("ethauth: invalid extra encoding - %w", err)
+		}
+		if guardianSignature, err = compressHex(guardianSignature); err != nil {
+			return "", fmt.Errorf("ethauth: invalid guardian signature encoding - %w", err)
+		}
+	}
+
+	// Encode the proof string
+	var pb bytes.Buffer
+
+	// prefix
+	pb.WriteString(prefix)
+	pb.WriteString(".")
+
+	// address
+	if t.AccountChainID != 0 {
+		pb.WriteString(caip10NamespaceEIP155 + ":" + strconv.FormatUint(t.AccountChainID, 10) + ":")
+	}
+	pb.WriteString(t.addressKey())
+	pb.WriteString(".")
+
+	// message base64 encoded
+	pb.WriteString(Base64UrlEncode(claimsJSON))
+	pb.WriteString(".")
+
+	// signature
+	pb.WriteString(signature)
+
+	// extra
+	if t.Extra != "" || t.GuardianSignature != "" {
+		pb.WriteString(".")
+		pb.WriteString(extra)
+	}
+
+	// guardian countersignature
+	if t.GuardianSignature != "" {
+		pb.WriteString(".")
+		pb.WriteString(guardianSignature)
+	}
+
+	return pb.String(), nil
+}
+
+func (t *Proof) validateEncoding() error {
+	if err := t.validAddress(); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(t.Signature, "0x") {
+		return fmt.Errorf("ethauth: signature")
+	}
+	if t.Extra != "" && !strings.HasPrefix(t.Extra, "0x") {
+		return fmt.Errorf("ethauth: invalid extra encoding, expecting hex data")
+	}
+	if t.GuardianSignature != "" && !strings.HasPrefix(t.GuardianSignature, "0x") {
+		return fmt.Errorf("ethauth: invalid guardian signature encoding, expecting hex data")
+	}
+	return nil
+}
+
+// Account returns the account the proof authenticates, which is the `sub` claim of exchanged
+// proofs, as they are signed by the exchanger on behalf of the account, and the proof address
+// otherwise, in lower case unless it is the address of another scheme.
+func (t *Proof) Account() string {
+	if t.Claims.Type == ProofTypeExchanged {
+		return t.Claims.Subject
+	}
+	return t.addressKey()
+}
+
+// AddressBytes returns the proof address. Malformed and zero addresses are rejected with
+// ErrInvalidAddress, as are mixed-case addresses which are not a valid EIP-55 checksum.
+func (t *Proof) AddressBytes() (common.Address, error) {
+	if len(t.Address) != 42 || !common.IsHexAddress(t.Address) || t.Address[0:2] != "0x" {
+		return common.Address{}, ErrInvalidAddress
+	}
+	address := common.HexToAddress(t.Address)
+	if address == (common.Address{}) {
+		return common.Address{}, ErrInvalidAddress
+	}
+	hex := t.Address[2:]
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && t.Address != address.Hex() {
+		return common.Address{}, fmt.Errorf("%w, checksum mismatch", ErrInvalidAddress)
+	}
+	return address, nil
+}
+
+// Limits of the proof strings accepted by Parse, which is fed untrusted Authorization headers.
+const (
+	// MaxProofLength is the maximum length of a proof string
+	MaxProofLength = 16 * 1024
+
+	// MaxClaimsLength is the maximum length of the decoded claims JSON of a proof
+	MaxClaimsLength = 8 * 1024
+)
+
+// strictBase64UrlDecode decodes the unpadded base64 url-variant encoding of Base64UrlEncode.
+// Unlike Base64UrlDecode, padding, line breaks and non-canonical trailing bits are rejected,
+// so each claims message has a single encoding.
+func strictBase64UrlDecode(s string) ([]byte, error) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return nil, fmt.Errorf("ethauth: illegal base64 character at offset %d", i)
+		}
+	}
+	return base64.RawURLEncoding.Strict().DecodeString(s)
+}
+
+// Parse decodes an ETHAuth proof string into a Proof object. Proof strings over MaxProofLength,
+// claims over MaxClaimsLength and claims which are not strictly base64 url-encoded are
+// rejected, unless other limits are given. Unknown claims are preserved in Claims.Unknown,
+// or rejected in ClaimsStrict mode. Compressed proof strings, see EncodeCompressed, are
+// decoded all the same, and their claims are limited to MaxClaimsLength once inflated. Note,
+// Parse does not validate the proof signature or claims, see ETHAuth.DecodeProof for that.
+func Parse(proofString string, optLimits ...ParseLimits) (*Proof, error) {
+	limits := parseLimits(optLimits)
+	if int64(len(proofString)) > limits.MaxProofLength {
+		return nil, fmt.Errorf("ethauth: invalid proof string, exceeds %d bytes", limits.MaxProofLength)
+	}
+	parts := strings.Split(proofString, ".")
+	if len(parts) < 4 || len(parts) > 6 {
+		return nil, fmt.Errorf("ethauth: invalid proof string")
+	}
+
+	prefix := parts[0]
+	address := parts[1]
+	messageBase64 := parts[2]
+	signature := parts[3]
+	extra := ""
+	if len(parts) >= 5 {
+		extra = parts[4]
+	}
+	guardianSignature := ""
+	if len(parts) == 6 {
+		guardianSignature = parts[5]
+		if guardianSignature == "" {
+			return nil, fmt.Errorf("ethauth: invalid proof string")
+		}
+	}
+
+	// check prefix
+	if prefix != ETHAuthPrefix && prefix != ETHAuthCompressedPrefix {
+		return nil, fmt.Errorf("ethauth: not an ethauth proof")
+	}
+
+	var messageBytes []byte
+	var err error
+	if prefix == ETHAuthCompressedPrefix {
+		messageBytes, err = inflateClaims(messageBase64, limits.MaxClaimsLength)
+		if err != nil {
+			return nil, err
+		}
+		if signature, err = decompressHex(signature); err != nil {
+			return nil, fmt.Errorf("ethauth: decoding failed, invalid signature")
+		}
+		if extra, err = decompressHex(extra); err != nil {
+			return nil, fmt.Errorf("ethauth: decoding failed, invalid extra")
+		}
+		if guardianSignature, err = decompressHex(guardianSignature); err != nil {
+			return nil, fmt.Errorf("ethauth: decoding failed, invalid guardian signature")
+		}
+	} else {
+		// decode message base64
+		if int64(base64.RawURLEncoding.DecodedLen(len(messageBase64))) > limits.MaxClaimsLength {
+			return nil, fmt.Errorf("ethauth: decoding failed, claims exceed %d bytes", limits.MaxClaimsLength)
+		}
+		messageBytes, err = strictBase64UrlDecode(messageBase64)
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: decoding failed, invalid claims")
+		}
+	}
+
+	var claims Claims
+	err = json.Unmarshal(messageBytes, &claims)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: decoding failed, cannot unmarshal claims")
+	}
+	if limits.ClaimsMode == ClaimsStrict {
+		if err := claims.rejectUnknown(); err != nil {
+			return nil, err
+		}
+	}
+
+	// prepare proof
+	proof := NewProof()
+	proof.Prefix = prefix
+	proof.Address = address
+	if err := proof.parseAccountID(); err != nil {
+		return nil, err
+	}
+	proof.Claims = claims
+	proof.Signature = signature
+	proof.Extra = extra
+	proof.GuardianSignature = guardianSignature
+	proof.claimsJSON = messageBytes
+
+	return proof, nil
+}
+
+// Message returns the message signed by the proof signature. This is the EIP712 encoded
+// message of the claims, unless the `typ` claim selects one of the personal_sign proof types:
+// for SIWE proofs, the SIWE message derived from the claims, and for EIP-191 proofs,
+// the claims JSON. Proofs of a registered ProofScheme sign the message of their scheme.
 func (t *Proof) Message() ([]byte, error) {
-	return t.Claims.Message()
+	if ps, ok := t.scheme(); ok {
+		return ps.Message(t)
+	}
+	switch t.Claims.Type {
+	case ProofTypeSIWE:
+		siweMessage, err := SIWEMessageFromClaims(t.Address, t.Claims)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(siweMessage.String()), nil
+
+	case ProofTypeEIP191:
+		// sign the claims JSON as encoded in the proof string, if the proof was parsed
+		if t.claimsJSON != nil {
+			return t.claimsJSON, nil
+		}
+		return json.Marshal(t.Claims)
+
+	default:
+		return t.Claims.Message()
+	}
 }
 
+// MessageDigest returns the digest of the message signed by the proof signature. For
+// personal_sign proof types, this is the digest of the EIP-191 prefixed message, and for
+// proofs of a registered ProofScheme the keccak256 hash of their message.
 func (t *Proof) MessageDigest() ([]byte, error) {
-	return t.Claims.MessageDigest()
+	if ps, ok := t.scheme(); ok {
+		return schemeMessageDigest(ps, t)
+	}
+	switch t.Claims.Type {
+	case ProofTypeSIWE, ProofTypeEIP191:
+		message, err := t.Message()
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: failed to compute proof message digest - %w", err)
+		}
+		return eip191MessageDigest(message), nil
+
+	default:
+		return t.Claims.MessageDigest()
+	}
+}
+
+// messageDigest writes the digest of the message signed by the proof signature, computing
+// the digest of version 1 claims without allocating, see MessageDigest.
+func (t *Proof) messageDigest(digest *[32]byte) error {
+	if _, ok := t.scheme(); !ok && t.Claims.Type != ProofTypeSIWE && t.Claims.Type != ProofTypeEIP191 {
+		if ok, err := t.Claims.digestV1(digest); err != nil {
+			return fmt.Errorf("ethauth: failed to compute claims message digest - %w", err)
+		} else if ok {
+			return nil
+		}
+	}
+	d, err := t.MessageDigest()
+	if err != nil {
+		return err
+	}
+	copy(digest[:], d)
+	return nil
 }
 
 func (t *Proof) MessageTypedData() (*ethcoder.TypedData, error) {
@@ -54,7 +374,179 @@ type Claims struct {
 	Nonce          uint64 `json:"n,omitempty"`
 	Type           string `json:"typ,omitempty"`
 	Origin         string `json:"ogn,omitempty"`
+	ChainID        uint64 `json:"cid,omitempty"`
+	Audience       string `json:"aud,omitempty"`
+	Subject        string `json:"sub,omitempty"`
+	ID             string `json:"jti,omitempty"`
+	Scope          Scopes `json:"scope,omitempty"`
 	ETHAuthVersion string `json:"v,omitempty"`
+
+	// Confirmation binds the proof to a client key or TLS certificate, whose possession is
+	// verified on each request, see KeyConfirmation, TLSConfirmation and ETHAuth.VerifyPoP
+	Confirmation string `json:"cnf,omitempty"`
+
+	// RequestMethod, RequestPath and RequestBodyHash bind request proofs to the single HTTP
+	// request they sign, see ProofTypeRequest
+	RequestMethod   string `json:"htm,omitempty"`
+	RequestPath     string `json:"htp,omitempty"`
+	RequestBodyHash string `json:"bdh,omitempty"`
+
+	// ClientIP and UserAgent bind the proof to the network and user agent of its client, see
+	// IPBinding, UserAgentBinding and ETHAuth.ConfigClientBinding
+	ClientIP  string `json:"ip,omitempty"`
+	UserAgent string `json:"ua,omitempty"`
+
+	// Statement is a human-readable line shown by the wallet when signing the proof, see
+	// WithStatement
+	Statement string `json:"statement,omitempty"`
+
+	// Host binds the proof to the hosts it may be used on, see WithHost and
+	// MiddlewareOptions.VerifyHost
+	Host string `json:"dom,omitempty"`
+
+	// MerkleRoot is the root of the merkle tree the account of the proof is a member of, ie.
+	// of an allow-list, see WithMerkleRoot and MerkleVerifier
+	MerkleRoot string `json:"mrk,omitempty"`
+
+	// Custom application claims, signed as part of the claims message alongside the
+	// standard fields above
+	Custom ClaimsProvider `json:"-"`
+
+	// Unknown are the claims of a parsed proof which are neither standard nor custom claims,
+	// ie. the claims of a newer version, preserved in ClaimsLenient mode so they are encoded
+	// again with the claims. Unknown claims are not part of the EIP712 claims message,
+	// so they are only signed by EIP-191 proofs, see ClaimsMode
+	Unknown map[string]json.RawMessage `json:"-"`
+
+	// domain is the EIP712 domain the claims are signed under, or nil for the default domain
+	domain *DomainConfig
+}
+
+// ClaimsProvider is implemented by custom application claims which are embedded into the
+// proof claims. The keys returned by Map must match the names returned by TypedDataTypes,
+// and the JSON field names of the ClaimsProvider value, as the custom claims are encoded
+// alongside the standard claims in the same JSON object.
+type ClaimsProvider interface {
+	Map() map[string]interface{}
+	TypedDataTypes() []ethcoder.TypedDataArgument
+	Valid() error
+}
+
+// nonStandardClaims returns the top-level claims of the claims JSON which are not standard
+// claims, ie. the custom claims of the proof.
+func nonStandardClaims(claimsJSON []byte) (map[string]json.RawMessage, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(claimsJSON, &m); err != nil {
+		return nil, err
+	}
+	for k := range m {
+		if slices.Contains(standardClaimsKeys, k) {
+			delete(m, k)
+		}
+	}
+	return m, nil
+}
+
+// standardClaimsKeys lists the standard claims in their canonical order, which is the order
+// of the fields of the EIP712 Claims type.
+var standardClaimsKeys = []string{"app", "iat", "exp", "n", "typ", "ogn", "cid", "aud", "sub", "jti", "scope", "v", "cnf", "htm", "htp", "bdh", "ip", "ua", "statement", "dom", "mrk"}
+
+// ClaimsMode selects how the claims of a proof which are neither standard nor custom claims
+// are decoded, see ParseLimits.ClaimsMode.
+type ClaimsMode int
+
+const (
+	// ClaimsLenient preserves unknown claims in Claims.Unknown, for forward compatibility with
+	// the proofs of newer versions, ie. for clients and relays re-encoding the proofs they parse.
+	ClaimsLenient ClaimsMode = iota
+
+	// ClaimsStrict rejects claims with unknown fields, for servers which must not accept claims
+	// they don't understand, nor which may not be signed by the proof. ETHAuth always decodes
+	// proofs in strict mode, rejecting the claims not declared by ETHAuth.ConfigCustomClaims.
+	ClaimsStrict
+)
+
+// UnmarshalClaims decodes the claims JSON in the claims mode.
+func UnmarshalClaims(data []byte, mode ClaimsMode) (Claims, error) {
+	var c Claims
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Claims{}, err
+	}
+	if mode == ClaimsStrict {
+		if err := c.rejectUnknown(); err != nil {
+			return Claims{}, err
+		}
+	}
+	return c, nil
+}
+
+// rejectUnknown returns an error naming the first unknown claim, if any.
+func (c *Claims) rejectUnknown() error {
+	if len(c.Unknown) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(c.Unknown))
+	for k := range c.Unknown {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return fmt.Errorf("ethauth: decoding failed, unknown claim %q", keys[0])
+}
+
+func (c Claims) MarshalJSON() ([]byte, error) {
+	type claims Claims
+	data, err := json.Marshal(claims(c))
+	if err != nil || (c.Custom == nil && len(c.Unknown) == 0) {
+		return data, err
+	}
+
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if c.Custom != nil {
+		customData, err := json.Marshal(c.Custom)
+		if err != nil {
+			return nil, err
+		}
+		cm := map[string]json.RawMessage{}
+		if err := json.Unmarshal(customData, &cm); err != nil {
+			return nil, fmt.Errorf("ethauth: custom claims must encode to a JSON object - %w", err)
+		}
+		for k, v := range cm {
+			if slices.Contains(standardClaimsKeys, k) {
+				return nil, fmt.Errorf("ethauth: custom claim %q conflicts with a standard claim", k)
+			}
+			m[k] = v
+		}
+	}
+	for k, v := range c.Unknown {
+		if _, ok := m[k]; ok || slices.Contains(standardClaimsKeys, k) {
+			return nil, fmt.Errorf("ethauth: unknown claim %q conflicts with a known claim", k)
+		}
+		m[k] = v
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the claims JSON in ClaimsLenient mode, preserving the non-standard
+// claims in Unknown. See UnmarshalClaims to decode the claims in ClaimsStrict mode.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type claims Claims
+	v := claims(*c)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	unknown, err := nonStandardClaims(data)
+	if err != nil {
+		return err
+	}
+	if len(unknown) == 0 {
+		unknown = nil
+	}
+	*c = Claims(v)
+	c.Unknown = unknown
+	return nil
 }
 
 func (c *Claims) SetIssuedAtNow() {
@@ -65,22 +557,146 @@ func (c *Claims) SetExpiryIn(tm time.Duration) {
 	c.ExpiresAt = time.Now().UTC().Unix() + int64(tm.Seconds())
 }
 
+// Canonicalize returns the claims in canonical form, which issuers should sign so clients in
+// other languages derive the same message digest from the same claims: the scopes are sorted
+// and deduplicated, and the scheme and host of the origin are lowercased, without a trailing
+// slash. The EIP712 Claims type always lists the claims in canonical order, see TypedData.
+func (c Claims) Canonicalize() Claims {
+	if len(c.Scope) > 0 {
+		scope := slices.Clone(c.Scope)
+		slices.Sort(scope)
+		c.Scope = slices.Compact(scope)
+	}
+	if c.Origin != "" {
+		if u, err := url.Parse(c.Origin); err == nil && u.Host != "" {
+			u.Scheme = strings.ToLower(u.Scheme)
+			u.Host = strings.ToLower(u.Host)
+			if u.Path == "/" {
+				u.Path = ""
+			}
+			c.Origin = u.String()
+		}
+	}
+	return c
+}
+
+// ValidatorConfig configures the time-based validation of proof claims.
+type ValidatorConfig struct {
+	// Leeway is the allowed clock drift between the proof issuer and the validator, for both
+	// the `iat` and `exp` claims, unless IssuedAtLeeway or ExpirationLeeway are set
+	Leeway time.Duration
+
+	// IssuedAtLeeway is how far in the future proofs may have been issued, ie. by clients
+	// whose clock is ahead, which defaults to Leeway when zero
+	IssuedAtLeeway time.Duration
+
+	// ExpirationLeeway is how long after their `exp` claim proofs are still accepted, which
+	// defaults to Leeway when zero. Set Leeway to zero and IssuedAtLeeway instead to accept
+	// clock-skewed clients without accepting expired proofs.
+	ExpirationLeeway time.Duration
+
+	// MaxAge is the maximum lifetime of a proof, ie. how far in the future the proof
+	// may expire, and how far in the past the proof may have been issued
+	MaxAge time.Duration
+
+	// RequireExp rejects proofs without an `exp` claim
+	RequireExp bool
+
+	// RequireIat rejects proofs without an `iat` claim
+	RequireIat bool
+}
+
+// DefaultValidatorConfig allows 5 minutes of clock drift, proofs which are valid for
+// at most 1 year, and requires the `exp` claim to be set.
+var DefaultValidatorConfig = ValidatorConfig{
+	Leeway:     5 * time.Minute,
+	MaxAge:     365 * 24 * time.Hour,
+	RequireExp: true,
+}
+
 func (c Claims) Valid() error {
-	now := time.Now().Unix()
-	drift := int64(5 * 60)                                          // 5 minutes
-	max := int64(time.Duration(time.Hour*24*365).Seconds()) + drift // 1 year
+	return c.ValidAt(time.Now(), DefaultValidatorConfig)
+}
+
+// drifts returns the `iat` and `exp` leeways of the config, in seconds.
+func (cfg ValidatorConfig) drifts() (int64, int64) {
+	iatDrift, expDrift := int64(cfg.Leeway.Seconds()), int64(cfg.Leeway.Seconds())
+	if cfg.IssuedAtLeeway != 0 {
+		iatDrift = int64(cfg.IssuedAtLeeway.Seconds())
+	}
+	if cfg.ExpirationLeeway != 0 {
+		expDrift = int64(cfg.ExpirationLeeway.Seconds())
+	}
+	return iatDrift, expDrift
+}
+
+// acceptedUntil returns the last time the claims are accepted by ValidAt, ie. until which
+// their nonce must be remembered, or false if the claims have neither an `exp` nor an `iat`
+// claim and are accepted at any time.
+func (cfg ValidatorConfig) acceptedUntil(c Claims) (time.Time, bool) {
+	iatDrift, expDrift := cfg.drifts()
+	switch {
+	case c.ExpiresAt != 0:
+		return time.Unix(c.ExpiresAt+expDrift, 0), true
+	case c.IssuedAt != 0:
+		return time.Unix(c.IssuedAt+int64(cfg.MaxAge.Seconds())+iatDrift, 0), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// ValidAt validates the claims as of the time passed, using the validator config.
+func (c Claims) ValidAt(t time.Time, cfg ValidatorConfig) error {
+	now := t.Unix()
+	iatDrift, expDrift := cfg.drifts()
+	max := int64(cfg.MaxAge.Seconds()) + iatDrift
 
 	if c.ETHAuthVersion == "" {
-		return fmt.Errorf("claims: ethauth version is empty")
+		return ErrBadVersion
+	}
+	if _, ok := LookupClaimsVersion(c.ETHAuthVersion); !ok {
+		return ErrUnsupportedVersion
 	}
 	if c.App == "" {
-		return fmt.Errorf("claims: app is empty")
+		return ErrMissingApp
+	}
+	if c.Confirmation != "" && !validConfirmation(c.Confirmation) {
+		return ErrInvalidConfirmation
+	}
+	if err := c.validRequestBinding(); err != nil {
+		return err
+	}
+	if err := c.validClientBinding(); err != nil {
+		return err
 	}
-	if c.IssuedAt > now+drift {
-		return fmt.Errorf("claims: proof is issued from the future - check if device clock is synced.")
+	if err := c.validStatement(); err != nil {
+		return err
 	}
-	if c.ExpiresAt < now-drift || c.ExpiresAt > now+max || (c.IssuedAt != 0 && c.IssuedAt < now-max) {
-		return fmt.Errorf("claims: proof has expired")
+	if err := c.validHost(); err != nil {
+		return err
+	}
+	if err := c.validMerkleRoot(); err != nil {
+		return err
+	}
+	if c.Custom != nil {
+		if err := c.Custom.Valid(); err != nil {
+			return fmt.Errorf("claims: custom claims are invalid - %w", err)
+		}
+	}
+	if cfg.RequireIat && c.IssuedAt == 0 {
+		return ErrMissingIssuedAt
+	}
+	if c.IssuedAt > now+iatDrift {
+		return ErrIssuedInFuture
+	}
+	if c.IssuedAt != 0 && c.IssuedAt < now-max {
+		return ErrProofExpired
+	}
+	if c.ExpiresAt == 0 && !cfg.RequireExp {
+		return nil
+	}
+	if c.ExpiresAt < now-expDrift || c.ExpiresAt > now+max {
+		return ErrProofExpired
 	}
 
 	return nil
@@ -106,23 +722,91 @@ func (c Claims) Map() map[string]interface{} {
 	if c.Origin != "" {
 		m["ogn"] = c.Origin
 	}
+	if c.ChainID != 0 {
+		m["cid"] = c.ChainID
+	}
+	if c.Audience != "" {
+		m["aud"] = c.Audience
+	}
+	if c.Subject != "" {
+		m["sub"] = c.Subject
+	}
+	if c.ID != "" {
+		m["jti"] = c.ID
+	}
+	if len(c.Scope) > 0 {
+		m["scope"] = c.Scope.String()
+	}
 	if c.ETHAuthVersion != "" {
 		m["v"] = c.ETHAuthVersion
 	}
+	if c.Confirmation != "" {
+		m["cnf"] = c.Confirmation
+	}
+	if c.RequestMethod != "" {
+		m["htm"] = c.RequestMethod
+	}
+	if c.RequestPath != "" {
+		m["htp"] = c.RequestPath
+	}
+	if c.RequestBodyHash != "" {
+		m["bdh"] = c.RequestBodyHash
+	}
+	if c.ClientIP != "" {
+		m["ip"] = c.ClientIP
+	}
+	if c.UserAgent != "" {
+		m["ua"] = c.UserAgent
+	}
+	if c.Statement != "" {
+		m["statement"] = c.Statement
+	}
+	if c.Host != "" {
+		m["dom"] = c.Host
+	}
+	if c.MerkleRoot != "" {
+		m["mrk"] = c.MerkleRoot
+	}
+	if c.Custom != nil {
+		for k, v := range c.Custom.Map() {
+			m[k] = v
+		}
+	}
 	return m
 }
 
+// Domain returns the EIP712 domain the claims are signed under, which is DefaultDomainConfig
+// unless the claims were issued or verified with another DomainConfig. The domain includes
+// the chainId when the claims are bound to a chain, so a proof signed for one chain
+// cannot be replayed against another.
+func (c Claims) Domain() ethcoder.TypedDataDomain {
+	return c.domainConfig().key(c.ChainID).domain()
+}
+
+// TypedData returns the EIP712 typed data of the claims, as built by the claims schema of
+// their `v` claim version, see RegisterClaimsVersion.
 func (c Claims) TypedData() (*ethcoder.TypedData, error) {
+	version := c.ETHAuthVersion
+	if version == "" {
+		version = ETHAuthVersion
+	}
+	cv, ok := LookupClaimsVersion(version)
+	if !ok {
+		return nil, fmt.Errorf("ethauth: %w, version %q", ErrUnsupportedVersion, version)
+	}
+	return cv.TypedData(c)
+}
+
+// claimsTypedDataV1 builds the typed data of version 1 claims.
+func claimsTypedDataV1(c Claims) (*ethcoder.TypedData, error) {
+	domain := c.Domain()
 	td := &ethcoder.TypedData{
 		Types: ethcoder.TypedDataTypes{
-			"EIP712Domain": {
-				{Name: "name", Type: "string"},
-				{Name: "version", Type: "string"},
-			},
-			"Claims": {},
+			"EIP712Domain": domainType(domain),
+			"Claims":       {},
 		},
 		PrimaryType: "Claims",
-		Domain:      eip712Domain,
+		Domain:      domain,
 		Message:     c.Map(),
 	}
 
@@ -149,19 +833,79 @@ func (c Claims) TypedData() (*ethcoder.TypedData, error) {
 	if c.Origin != "" {
 		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "ogn", Type: "string"})
 	}
+	if c.ChainID != 0 {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "cid", Type: "uint64"})
+	}
+	if c.Audience != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "aud", Type: "string"})
+	}
+	if c.Subject != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "sub", Type: "string"})
+	}
+	if c.ID != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "jti", Type: "string"})
+	}
+	if len(c.Scope) > 0 {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "scope", Type: "string"})
+	}
 	if c.ETHAuthVersion != "" {
 		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "v", Type: "string"})
 	}
+	if c.Confirmation != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "cnf", Type: "string"})
+	}
+	if c.RequestMethod != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "htm", Type: "string"})
+	}
+	if c.RequestPath != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "htp", Type: "string"})
+	}
+	if c.RequestBodyHash != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "bdh", Type: "string"})
+	}
+	if c.ClientIP != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "ip", Type: "string"})
+	}
+	if c.UserAgent != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "ua", Type: "string"})
+	}
+	if c.Statement != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "statement", Type: "string"})
+	}
+	if c.Host != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "dom", Type: "string"})
+	}
+	if c.MerkleRoot != "" {
+		claimsType = append(claimsType, ethcoder.TypedDataArgument{Name: "mrk", Type: "string"})
+	}
+	if c.Custom != nil {
+		// custom claims follow the standard claims in name order, so the digest doesn't
+		// depend on the order the ClaimsProvider lists them in
+		customTypes := slices.Clone(c.Custom.TypedDataTypes())
+		slices.SortFunc(customTypes, func(a, b ethcoder.TypedDataArgument) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		for _, arg := range customTypes {
+			if slices.Contains(standardClaimsKeys, arg.Name) {
+				return nil, fmt.Errorf("ethauth: custom claim %q conflicts with a standard claim", arg.Name)
+			}
+			claimsType = append(claimsType, arg)
+		}
+	}
 	td.Types["Claims"] = claimsType
 
 	return td, nil
 }
 
+// Message returns the EIP712 encoded message of the claims. Note, Message does not validate
+// the claims, see Valid and ValidAt for that.
 func (c Claims) Message() ([]byte, error) {
-	if err := c.Valid(); err != nil {
-		return nil, fmt.Errorf("claims are invalid - %w", err)
+	var message [66]byte
+	if ok, err := c.encodeV1(&message); err != nil {
+		return nil, fmt.Errorf("ethauth: failed to compute claims typed data - %w", err)
+	} else if ok {
+		return message[:], nil
 	}
-
 	typedData, err := c.TypedData()
 	if err != nil {
 		return nil, fmt.Errorf("ethauth: failed to compute claims typed data - %w", err)
@@ -175,11 +919,21 @@ func (c Claims) Message() ([]byte, error) {
 	return encodedTypedData, nil
 }
 
+// eip191MessageDigest returns the digest of the EIP-191 personal_sign message.
+func eip191MessageDigest(message []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+}
+
 func (c Claims) MessageDigest() ([]byte, error) {
+	var digest [32]byte
+	if ok, err := c.digestV1(&digest); err != nil {
+		return nil, fmt.Errorf("ethauth: failed to compute claims message digest - %w", err)
+	} else if ok {
+		return digest[:], nil
+	}
 	encodedTypedData, err := c.Message()
 	if err != nil {
 		return nil, fmt.Errorf("ethauth: failed to compute claims message digest - %w", err)
 	}
-	digest := crypto.Keccak256(encodedTypedData)
-	return digest, nil
+	return crypto.Keccak256(encodedTypedData), nil
 }
@@ -0,0 +1,280 @@
+// Package qrlogin implements the "scan to sign in" flow, where a desktop browser displays a
+// login challenge as a QR code, and a mobile wallet scanning it signs an ETHAuth proof for the
+// challenge and posts it back to the callback URL to complete the login of the browser.
+//
+//	logins, err := qrlogin.NewManager(ethAuth, "https://api.example.com/qrlogin/callback")
+//	http.Handle("/qrlogin/callback", logins.CallbackHandler())
+//
+//	challenge, err := logins.NewChallenge("MyApp")
+//	// render challenge.URI() as a QR code, then wait for the wallet
+//	proof, err := logins.Wait(ctx, challenge.ID)
+package qrlogin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+)
+
+// DefaultScheme is the URI scheme of the login deep links, which wallets register to open them.
+const DefaultScheme = "ethauth"
+
+// DefaultTTL is how long a login challenge can be completed for.
+const DefaultTTL = 2 * time.Minute
+
+var (
+	ErrChallengeNotFound = errors.New("qrlogin: login challenge not found")
+	ErrChallengeExpired  = errors.New("qrlogin: login challenge has expired")
+	ErrChallengeMismatch = errors.New("qrlogin: proof does not match the login challenge")
+)
+
+// Challenge is a pending login, which completes once a wallet posts a proof signed for
+// its app and nonce to the callback URL.
+type Challenge struct {
+	ID        string
+	App       string
+	Nonce     uint64
+	Callback  string
+	ExpiresAt time.Time
+
+	scheme string
+	proof  *ethauth.Proof
+	done   chan struct{}
+}
+
+// URI returns the deep link of the challenge, the payload to render as a QR code, ie.
+// `ethauth://login?app=MyApp&n=...&callback=https%3A%2F%2F...&id=...&exp=...`.
+func (c *Challenge) URI() string {
+	q := url.Values{}
+	q.Set("id", c.ID)
+	q.Set("app", c.App)
+	q.Set("n", strconv.FormatUint(c.Nonce, 10))
+	q.Set("callback", c.Callback)
+	q.Set("exp", strconv.FormatInt(c.ExpiresAt.Unix(), 10))
+	return c.scheme + "://login?" + q.Encode()
+}
+
+// ParseURI parses the deep link of a challenge, for wallets to sign a proof for it.
+func ParseURI(uri string) (*Challenge, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host != "login" {
+		return nil, fmt.Errorf("qrlogin: invalid login URI")
+	}
+	q := u.Query()
+	nonce, err := strconv.ParseUint(q.Get("n"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("qrlogin: invalid login URI nonce")
+	}
+	exp, err := strconv.ParseInt(q.Get("exp"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("qrlogin: invalid login URI expiry")
+	}
+	c := &Challenge{
+		ID:        q.Get("id"),
+		App:       q.Get("app"),
+		Nonce:     nonce,
+		Callback:  q.Get("callback"),
+		ExpiresAt: time.Unix(exp, 0),
+		scheme:    u.Scheme,
+	}
+	if c.ID == "" || c.App == "" || c.Callback == "" {
+		return nil, fmt.Errorf("qrlogin: invalid login URI")
+	}
+	return c, nil
+}
+
+// Claims returns the claims for a wallet to sign to complete the challenge.
+func (c *Challenge) Claims() ethauth.Claims {
+	claims := ethauth.NewProof().Claims
+	claims.App = c.App
+	claims.Nonce = c.Nonce
+	claims.SetIssuedAtNow()
+	claims.ExpiresAt = c.ExpiresAt.Unix()
+	return claims
+}
+
+// Manager keeps the pending login challenges, and completes them with the proofs posted
+// to its CallbackHandler.
+type Manager struct {
+	// Scheme is the URI scheme of the login deep links, defaulting to DefaultScheme.
+	Scheme string
+
+	// TTL is how long a login challenge can be completed for, defaulting to DefaultTTL.
+	TTL time.Duration
+
+	// OnComplete, if set, is called with each completed challenge and its proof, ie. to
+	// start the session of the browser which displayed the challenge.
+	OnComplete func(ctx context.Context, challenge *Challenge, proof *ethauth.Proof) error
+
+	ethAuth    *ethauth.ETHAuth
+	callback   string
+	challenges map[string]*Challenge
+	mu         sync.Mutex
+}
+
+// NewManager returns a Manager validating the posted proofs with ethAuth, where callback is
+// the URL its CallbackHandler is served at.
+func NewManager(ethAuth *ethauth.ETHAuth, callback string) (*Manager, error) {
+	if ethAuth == nil {
+		return nil, fmt.Errorf("qrlogin: manager requires an ETHAuth instance")
+	}
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("qrlogin: invalid callback URL %q", callback)
+	}
+	return &Manager{ethAuth: ethAuth, callback: callback, challenges: map[string]*Challenge{}}, nil
+}
+
+// NewChallenge returns a new pending login challenge for the app.
+func (m *Manager) NewChallenge(app string) (*Challenge, error) {
+	if app == "" {
+		return nil, fmt.Errorf("qrlogin: app is empty")
+	}
+	var random [24]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, fmt.Errorf("qrlogin: unable to generate challenge - %w", err)
+	}
+
+	ttl := m.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	scheme := m.Scheme
+	if scheme == "" {
+		scheme = DefaultScheme
+	}
+
+	c := &Challenge{
+		ID:        hex.EncodeToString(random[:16]),
+		App:       app,
+		Nonce:     binary.BigEndian.Uint64(random[16:]) | 1,
+		Callback:  m.callback,
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second),
+		scheme:    scheme,
+		done:      make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, pending := range m.challenges {
+		if now.After(pending.ExpiresAt) {
+			delete(m.challenges, id)
+		}
+	}
+	m.challenges[c.ID] = c
+	return c, nil
+}
+
+// Complete validates the proof posted for the challenge, and completes the challenge.
+func (m *Manager) Complete(ctx context.Context, id string, proofString string) (*ethauth.Proof, error) {
+	m.mu.Lock()
+	c, ok := m.challenges[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrChallengeNotFound
+	}
+	if time.Now().After(c.ExpiresAt) {
+		return nil, ErrChallengeExpired
+	}
+
+	_, proof, err := m.ethAuth.DecodeProofContext(ctx, proofString)
+	if err != nil {
+		return nil, err
+	}
+	if proof.Claims.App != c.App || proof.Claims.Nonce != c.Nonce {
+		return nil, ErrChallengeMismatch
+	}
+
+	// each challenge completes once
+	m.mu.Lock()
+	if m.challenges[id] != c {
+		m.mu.Unlock()
+		return nil, ErrChallengeNotFound
+	}
+	delete(m.challenges, id)
+	m.mu.Unlock()
+
+	if m.OnComplete != nil {
+		if err := m.OnComplete(ctx, c, proof); err != nil {
+			return nil, err
+		}
+	}
+	c.proof = proof
+	close(c.done)
+	return proof, nil
+}
+
+// Wait waits for the challenge to be completed, and returns its proof.
+func (m *Manager) Wait(ctx context.Context, id string) (*ethauth.Proof, error) {
+	m.mu.Lock()
+	c, ok := m.challenges[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrChallengeNotFound
+	}
+
+	timer := time.NewTimer(time.Until(c.ExpiresAt))
+	defer timer.Stop()
+	select {
+	case <-c.done:
+		return c.proof, nil
+	case <-timer.C:
+		return nil, ErrChallengeExpired
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// CallbackHandler returns the handler receiving the proofs of the wallets, posted as the
+// `id` and `proof` form values, or as a JSON object with the same fields.
+func (m *Manager) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		var req struct {
+			ID    string `json:"id"`
+			Proof string `json:"proof"`
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
+				return
+			}
+		} else {
+			req.ID, req.Proof = r.PostFormValue("id"), r.PostFormValue("proof")
+		}
+
+		proof, err := m.Complete(r.Context(), req.ID, req.Proof)
+		switch {
+		case errors.Is(err, ErrChallengeNotFound), errors.Is(err, ErrChallengeExpired):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		case err != nil:
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		default:
+			writeJSON(w, http.StatusOK, map[string]string{"address": proof.Address})
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,101 @@
+package qrlogin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/go-ethauth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogin(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	logins, err := NewManager(ethAuth, "https://api.example.com/qrlogin/callback")
+	require.NoError(t, err)
+	var completed *Challenge
+	logins.OnComplete = func(ctx context.Context, challenge *Challenge, proof *ethauth.Proof) error {
+		completed = challenge
+		return nil
+	}
+
+	server := httptest.NewServer(logins.CallbackHandler())
+	defer server.Close()
+
+	challenge, err := logins.NewChallenge("ETHAuthTest")
+	require.NoError(t, err)
+
+	// the browser waits for the wallet
+	type result struct {
+		proof *ethauth.Proof
+		err   error
+	}
+	waited := make(chan result)
+	go func() {
+		proof, err := logins.Wait(context.Background(), challenge.ID)
+		waited <- result{proof, err}
+	}()
+
+	// the wallet scans the challenge, and signs a proof for it
+	scanned, err := ParseURI(challenge.URI())
+	require.NoError(t, err)
+	require.Equal(t, challenge.ID, scanned.ID)
+	require.Equal(t, challenge.Nonce, scanned.Nonce)
+	require.Equal(t, challenge.Callback, scanned.Callback)
+	require.Equal(t, challenge.ExpiresAt.Unix(), scanned.ExpiresAt.Unix())
+
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	post := func(claims ethauth.Claims) *http.Response {
+		proof := ethauth.NewProof()
+		proof.Claims = claims
+		require.NoError(t, ethauth.SignProof(proof, wallet.PrivateKey()))
+		proofString, err := proof.Encode()
+		require.NoError(t, err)
+		resp, err := http.PostForm(server.URL, url.Values{"id": {scanned.ID}, "proof": {proofString}})
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// proofs for another nonce are rejected
+	claims := scanned.Claims()
+	claims.Nonce++
+	require.Equal(t, http.StatusUnauthorized, post(claims).StatusCode)
+
+	require.Equal(t, http.StatusOK, post(scanned.Claims()).StatusCode)
+	r := <-waited
+	require.NoError(t, r.err)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), r.proof.Address)
+	require.Equal(t, challenge, completed)
+
+	// challenges complete once
+	require.Equal(t, http.StatusNotFound, post(scanned.Claims()).StatusCode)
+}
+
+func TestLoginExpired(t *testing.T) {
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+
+	logins, err := NewManager(ethAuth, "https://api.example.com/qrlogin/callback")
+	require.NoError(t, err)
+	logins.TTL = time.Second
+
+	challenge, err := logins.NewChallenge("ETHAuthTest")
+	require.NoError(t, err)
+	_, err = logins.Wait(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, ErrChallengeExpired)
+
+	_, err = logins.Wait(context.Background(), "unknown")
+	require.ErrorIs(t, err, ErrChallengeNotFound)
+
+	_, err = NewManager(ethAuth, "ethauth://callback")
+	require.Error(t, err)
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package ethauth
+
+// raceEnabled is set when the race detector is enabled, which randomly drops pooled values.
+const raceEnabled = true
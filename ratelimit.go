@@ -0,0 +1,136 @@
+package ethauth
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket limit, of Burst requests refilled at Rate requests per second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitStore keeps the token buckets of RateLimitMiddleware, so limits can be shared
+// by a cluster of API servers.
+type RateLimitStore interface {
+	// Allow takes a token from the bucket of the key, and returns false with how long until
+	// the bucket holds a token again if it is empty.
+	Allow(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// RateLimitOptions configures RateLimitMiddleware.
+type RateLimitOptions struct {
+	// Limit is the limit of each account address
+	Limit RateLimit
+
+	// Store keeps the token buckets, defaulting to a MemoryRateLimitStore.
+	Store RateLimitStore
+
+	// Limits, if set, returns the limit of an account, ie. to grant partners a higher limit,
+	// or false to use the default Limit.
+	Limits func(proof *Proof) (RateLimit, bool)
+}
+
+// RateLimitMiddleware returns a net/http middleware limiting the rate of requests of each
+// account, see Proof.Account, as verified by Middleware. Requests over the limit are rejected
+// with a 429 Too Many Requests status and a Retry-After header. Requests without a proof in their
+// context are passed through unlimited, so Middleware must be applied first.
+func RateLimitMiddleware(opts RateLimitOptions) func(next http.Handler) http.Handler {
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateLimitStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proof, ok := FromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := opts.Limit
+			if opts.Limits != nil {
+				if l, ok := opts.Limits(proof); ok {
+					limit = l
+				}
+			}
+
+			allowed, retryAfter, err := opts.Store.Allow(r.Context(), strings.ToLower(proof.Account()), limit)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MemoryRateLimitStore is an in-process RateLimitStore.
+type MemoryRateLimitStore struct {
+	buckets   map[string]*tokenBucket
+	lastPurge time.Time
+	mu        sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+var _ RateLimitStore = &MemoryRateLimitStore{}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   map[string]*tokenBucket{},
+		lastPurge: time.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) > time.Minute {
+		for k, b := range s.buckets {
+			if now.After(b.full) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		if limit.Rate <= 0 {
+			return false, time.Hour, nil
+		}
+		return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second)), nil
+	}
+	b.tokens--
+	if limit.Rate > 0 {
+		b.full = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+	} else {
+		b.full = now.Add(time.Hour)
+	}
+	return true, 0, nil
+}
@@ -0,0 +1,87 @@
+package ethauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	encode := func(wallet *ethwallet.Wallet) string {
+		claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(5 * time.Minute)
+		proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+		require.NoError(t, err)
+		return proofString
+	}
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	partner, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	proofString, partnerProofString := encode(wallet), encode(partner)
+
+	handler := Middleware(ethAuth, MiddlewareOptions{Optional: true})(RateLimitMiddleware(RateLimitOptions{
+		Limit: RateLimit{Rate: 1, Burst: 2},
+		Limits: func(proof *Proof) (RateLimit, bool) {
+			return RateLimit{Rate: 1, Burst: 5}, strings.EqualFold(proof.Address, partner.Address().Hex())
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	request := func(proofString string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		if proofString != "" {
+			req.Header.Set("Authorization", "Bearer "+proofString)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// each account has its own bucket
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusOK, request(proofString).Code)
+	}
+	rec := request(proofString)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, request(partnerProofString).Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, request(partnerProofString).Code)
+
+	// unauthenticated requests are not limited
+	for i := 0; i < 3; i++ {
+		require.Equal(t, http.StatusOK, request("").Code)
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	ctx := context.Background()
+	limit := RateLimit{Rate: 100, Burst: 1}
+
+	allowed, _, err := store.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, retryAfter, err := store.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.LessOrEqual(t, retryAfter, 10*time.Millisecond)
+
+	// buckets refill at the limit rate
+	time.Sleep(retryAfter + time.Millisecond)
+	allowed, _, err = store.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+}
@@ -0,0 +1,259 @@
+package ethauth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// SessionInfo is an active session of an account, ie. a proof decoded by an ETHAuth instance
+// with a SessionRegistry, which has not expired nor been logged out.
+type SessionInfo struct {
+	// ID identifies the session, which is the `jti` claim of the proof, or the hash of the
+	// proof signature for proofs without a `jti` claim
+	ID string
+
+	// Address is the account of the session, see Proof.addressKey
+	Address string
+
+	// App is the `app` claim of the proof
+	App string
+
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// LastSeen is the last time the proof was decoded
+	LastSeen time.Time
+}
+
+// SessionStore records the active sessions of each account, so users can list and log out
+// their sessions, ie. to "log out all devices". Sessions are recorded when their proof is
+// decoded by an ETHAuth instance configured with ETHAuth.ConfigSessionRegistry, which rejects
+// the proofs of logged out sessions with ErrProofRevoked.
+type SessionStore interface {
+	// Record records the session of a decoded proof, see NewSessionInfo, and returns
+	// ErrProofRevoked if the session has been logged out.
+	Record(ctx context.Context, session SessionInfo) error
+
+	// Sessions returns the active sessions of the account address, the most recent first.
+	Sessions(ctx context.Context, address string) ([]SessionInfo, error)
+
+	// Logout logs out the session of the account address, and reports whether it was active.
+	Logout(ctx context.Context, address, id string) (bool, error)
+
+	// LogoutAll logs out every session of the account address, including the sessions of
+	// proofs issued before now which have not been recorded yet, and returns the number of
+	// sessions logged out.
+	LogoutAll(ctx context.Context, address string) (int, error)
+}
+
+// SessionRegistry is an in-process SessionStore, whose expired sessions are removed by a
+// background sweeper, until the registry is closed.
+//
+// Clustered services should use a shared SessionStore, ie. of the store/postgres package, or
+// also revoke logged out sessions with a shared RevocationStore.
+type SessionRegistry struct {
+	sessions   map[string]map[string]*SessionInfo
+	loggedOut  map[sessionKey]time.Time
+	logoutTime map[string]time.Time
+	now        func() time.Time
+	mu         sync.RWMutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+var _ SessionStore = &SessionRegistry{}
+
+type sessionKey struct {
+	address string
+	id      string
+}
+
+// NewSessionRegistry returns a SessionRegistry whose sweeper removes expired sessions every
+// sweepInterval, which defaults to a minute. Call Close to stop the sweeper.
+func NewSessionRegistry(optSweepInterval ...time.Duration) *SessionRegistry {
+	interval := time.Minute
+	if len(optSweepInterval) > 0 && optSweepInterval[0] > 0 {
+		interval = optSweepInterval[0]
+	}
+	r := &SessionRegistry{
+		sessions:   map[string]map[string]*SessionInfo{},
+		loggedOut:  map[sessionKey]time.Time{},
+		logoutTime: map[string]time.Time{},
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+	go r.sweeper(interval)
+	return r
+}
+
+// NewSessionInfo returns the session of a decoded proof, last seen now.
+func NewSessionInfo(proof *Proof, now time.Time) SessionInfo {
+	return SessionInfo{
+		ID:        sessionID(proof),
+		Address:   proof.Account(),
+		App:       proof.Claims.App,
+		IssuedAt:  time.Unix(proof.Claims.IssuedAt, 0),
+		ExpiresAt: time.Unix(proof.Claims.ExpiresAt, 0),
+		LastSeen:  now,
+	}
+}
+
+func (r *SessionRegistry) Record(ctx context.Context, session SessionInfo) error {
+	address := NormalizeSessionAccount(session.Address)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.loggedOut[sessionKey{address, session.ID}]; ok {
+		return ErrProofRevoked
+	}
+	if t, ok := r.logoutTime[address]; ok && session.IssuedAt.Unix() < t.Unix() {
+		return ErrProofRevoked
+	}
+
+	sessions := r.sessions[address]
+	if sessions == nil {
+		sessions = map[string]*SessionInfo{}
+		r.sessions[address] = sessions
+	}
+	if s, ok := sessions[session.ID]; ok {
+		s.LastSeen = session.LastSeen
+		return nil
+	}
+	session.Address = address
+	sessions[session.ID] = &session
+	return nil
+}
+
+func (r *SessionRegistry) Sessions(ctx context.Context, address string) ([]SessionInfo, error) {
+	now := r.now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []SessionInfo
+	for _, s := range r.sessions[NormalizeSessionAccount(address)] {
+		if now.Before(s.ExpiresAt) {
+			sessions = append(sessions, *s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].IssuedAt.Equal(sessions[j].IssuedAt) {
+			return sessions[i].IssuedAt.After(sessions[j].IssuedAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+func (r *SessionRegistry) Logout(ctx context.Context, address, id string) (bool, error) {
+	address = NormalizeSessionAccount(address)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[address][id]
+	if !ok {
+		return false, nil
+	}
+	r.loggedOut[sessionKey{address, id}] = s.ExpiresAt
+	delete(r.sessions[address], id)
+	if len(r.sessions[address]) == 0 {
+		delete(r.sessions, address)
+	}
+	return true, nil
+}
+
+func (r *SessionRegistry) LogoutAll(ctx context.Context, address string) (int, error) {
+	address = NormalizeSessionAccount(address)
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := r.sessions[address]
+	for id, s := range sessions {
+		r.loggedOut[sessionKey{address, id}] = s.ExpiresAt
+	}
+	delete(r.sessions, address)
+	if t, ok := r.logoutTime[address]; !ok || now.After(t) {
+		r.logoutTime[address] = now
+	}
+	return len(sessions), nil
+}
+
+// Sweep removes the expired sessions, and the logged out sessions which have since expired,
+// and returns the number of expired sessions removed. Sweep is called by the sweeper.
+func (r *SessionRegistry) Sweep() int {
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for address, sessions := range r.sessions {
+		for id, s := range sessions {
+			if !now.Before(s.ExpiresAt) {
+				delete(sessions, id)
+				n++
+			}
+		}
+		if len(sessions) == 0 {
+			delete(r.sessions, address)
+		}
+	}
+	for key, exp := range r.loggedOut {
+		if !now.Before(exp) {
+			delete(r.loggedOut, key)
+		}
+	}
+	return n
+}
+
+// Close stops the sweeper.
+func (r *SessionRegistry) Close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+func (r *SessionRegistry) sweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Sweep()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// sessionID returns the `jti` claim of the proof, or the hash of its signature key.
+func sessionID(proof *Proof) string {
+	if proof.Claims.ID != "" {
+		return proof.Claims.ID
+	}
+	return ethcoder.HexEncode(crypto.Keccak256([]byte(signatureKey(proof.Signature))))
+}
+
+// NormalizeSessionAccount returns the lower case hex of Ethereum addresses, and the address as
+// is otherwise, ie. the `sub` claim of exchanged proofs, as the Address of SessionInfo.
+func NormalizeSessionAccount(address string) string {
+	if strings.HasPrefix(address, "0x") || strings.HasPrefix(address, "0X") {
+		return strings.ToLower(address)
+	}
+	return address
+}
+
+// ConfigSessionRegistry records the sessions of decoded proofs in the session store, ie. a
+// SessionRegistry, and rejects the proofs of logged out sessions.
+func (w *ETHAuth) ConfigSessionRegistry(store SessionStore) {
+	w.sessionRegistry = store
+}
@@ -0,0 +1,114 @@
+package ethauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRegistry(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	registry := NewSessionRegistry()
+	defer registry.Close()
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	ethAuth.ConfigSessionRegistry(registry)
+
+	laptop, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithID("laptop"), WithExpiresIn(time.Hour))
+	require.NoError(t, err)
+	phone, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithExpiresIn(time.Hour))
+	require.NoError(t, err)
+
+	for _, proofString := range []string{laptop, phone, laptop} {
+		_, _, err = ethAuth.DecodeProof(proofString)
+		require.NoError(t, err)
+	}
+
+	sessions, err := registry.Sessions(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), sessions[0].Address)
+	require.Equal(t, "ETHAuthTest", sessions[0].App)
+	require.Contains(t, []string{sessions[0].ID, sessions[1].ID}, "laptop")
+
+	// logging out a session rejects its proof
+	loggedOut, err := registry.Logout(ctx, wallet.Address().Hex(), "laptop")
+	require.NoError(t, err)
+	require.True(t, loggedOut)
+	loggedOut, err = registry.Logout(ctx, wallet.Address().Hex(), "laptop")
+	require.NoError(t, err)
+	require.False(t, loggedOut)
+	_, _, err = ethAuth.DecodeProof(laptop)
+	require.ErrorIs(t, err, ErrProofRevoked)
+	_, _, err = ethAuth.DecodeProof(phone)
+	require.NoError(t, err)
+	sessions, err = registry.Sessions(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	// logging out all devices rejects the proofs issued before, recorded or not
+	unseen, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), func(claims *Claims) {
+		claims.IssuedAt = time.Now().Add(-time.Minute).Unix()
+		claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	})
+	require.NoError(t, err)
+	n, err := registry.LogoutAll(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	for _, proofString := range []string{phone, unseen} {
+		_, _, err = ethAuth.DecodeProof(proofString)
+		require.ErrorIs(t, err, ErrProofRevoked)
+	}
+	sessions, err = registry.Sessions(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestSessionRegistrySweep(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	registry := NewSessionRegistry(time.Hour)
+	defer registry.Close()
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+
+	for _, ttl := range []time.Duration{time.Minute, time.Hour} {
+		proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithExpiresIn(ttl))
+		require.NoError(t, err)
+		proof, err := Parse(proofString)
+		require.NoError(t, err)
+		require.NoError(t, registry.Record(ctx, NewSessionInfo(proof, now)))
+	}
+	sessions, err := registry.Sessions(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	if sessions[0].ExpiresAt.Before(sessions[1].ExpiresAt) {
+		sessions[0], sessions[1] = sessions[1], sessions[0]
+	}
+	loggedOut, err := registry.Logout(ctx, wallet.Address().Hex(), sessions[0].ID)
+	require.NoError(t, err)
+	require.True(t, loggedOut)
+	require.Zero(t, registry.Sweep())
+
+	now = now.Add(2 * time.Minute)
+	require.Equal(t, 1, registry.Sweep())
+	sessions, err = registry.Sessions(ctx, wallet.Address().Hex())
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+	require.Empty(t, registry.sessions)
+	require.Len(t, registry.loggedOut, 1)
+
+	now = now.Add(time.Hour)
+	registry.Sweep()
+	require.Empty(t, registry.loggedOut)
+}
@@ -0,0 +1,107 @@
+package ethauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// NewRemoteSigner returns a Signer which forwards the proof typed data to an external wallet
+// JSON-RPC endpoint, such as a WalletConnect bridge or a signing sidecar, to be signed with
+// `eth_signTypedData_v4` by the account address. Personal_sign proof types are signed with
+// `personal_sign` instead. The http.DefaultClient is used unless a client is given.
+func NewRemoteSigner(rpcURL string, address common.Address, optClient ...*http.Client) Signer {
+	client := http.DefaultClient
+	if len(optClient) > 0 && optClient[0] != nil {
+		client = optClient[0]
+	}
+	return &remoteSigner{rpcURL: rpcURL, address: address, client: client}
+}
+
+type remoteSigner struct {
+	rpcURL  string
+	address common.Address
+	client  *http.Client
+	id      atomic.Uint64
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *remoteSigner) Sign(ctx context.Context, proof *Proof) ([]byte, error) {
+	var method string
+	var params []interface{}
+
+	switch proof.Claims.Type {
+	case ProofTypeSIWE, ProofTypeEIP191:
+		message, err := proof.Message()
+		if err != nil {
+			return nil, err
+		}
+		method, params = "personal_sign", []interface{}{ethcoder.HexEncode(message), s.address.Hex()}
+	default:
+		typedDataJSON, err := proof.TypedDataJSON()
+		if err != nil {
+			return nil, err
+		}
+		method, params = "eth_signTypedData_v4", []interface{}{s.address.Hex(), string(typedDataJSON)}
+	}
+
+	var sig string
+	if err := s.call(ctx, method, params, &sig); err != nil {
+		return nil, err
+	}
+	return ethcoder.HexDecode(sig)
+}
+
+func (s *remoteSigner) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      s.id.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ethauth: remote signer request failed - %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ethauth: remote signer request failed with status %d", resp.StatusCode)
+	}
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("ethauth: invalid remote signer response - %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("ethauth: remote signer %s failed - %s (%d)", method, rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("ethauth: invalid remote signer %s result - %w", method, err)
+	}
+	return nil
+}
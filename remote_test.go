@@ -0,0 +1,66 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	// wallet JSON-RPC endpoint
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64   `json:"id"`
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var sig []byte
+		switch req.Method {
+		case "eth_signTypedData_v4":
+			require.Equal(t, wallet.Address().Hex(), req.Params[0])
+			typedData := typedDataFromWalletJSON(t, req.Params[1])
+			digest, _, err := typedData.Encode()
+			require.NoError(t, err)
+			sig, err = crypto.Sign(digest, wallet.PrivateKey())
+			require.NoError(t, err)
+			sig[64] += 27
+		case "personal_sign":
+			require.Equal(t, wallet.Address().Hex(), req.Params[1])
+			sig, err = wallet.SignMessage(ethcoder.MustHexDecode(req.Params[0]))
+			require.NoError(t, err)
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32601, "message": "method not found"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": ethcoder.HexEncode(sig)})
+	}))
+	defer server.Close()
+
+	signer := NewRemoteSigner(server.URL, wallet.Address())
+	for _, typ := range []string{"", ProofTypeEIP191} {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", Type: typ, Nonce: 7, ChainID: 1, ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignProofWithSigner(context.Background(), proof, signer))
+
+		_, err = ethAuth.EncodeProof(proof)
+		require.NoError(t, err, typ)
+	}
+}
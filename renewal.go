@@ -0,0 +1,111 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// HeaderRenew is the response header carrying the RenewalChallenge of a proof about to expire,
+// see MiddlewareOptions.RenewWithin.
+const HeaderRenew = "X-EWT-Renew"
+
+// RenewalChallenge asks the client of a proof about to expire to sign a new proof of the
+// suggested claims, so the client can renew its proof before it expires, instead of failing
+// mid-action. It is sent as the base64url JSON of the HeaderRenew response header.
+type RenewalChallenge struct {
+	// Nonce is the `n` claim of the new proof, which answers an outstanding challenge of the
+	// ChallengeManager of the ETHAuth instance, if configured
+	Nonce uint64 `json:"nonce"`
+
+	// ExpiresAt is when the current proof expires
+	ExpiresAt int64 `json:"expiresAt"`
+
+	// Claims are the suggested claims of the new proof, which are the claims of the current
+	// proof, with the new nonce, issued now and valid for the lifetime of the current proof
+	Claims Claims `json:"claims"`
+}
+
+// Header returns the HeaderRenew value of the renewal challenge.
+func (c *RenewalChallenge) Header() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// ParseRenewalChallenge decodes the HeaderRenew value of a response, see RenewalChallenge.
+func ParseRenewalChallenge(header string) (*RenewalChallenge, error) {
+	data, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: invalid %s header - %w", HeaderRenew, err)
+	}
+	var c RenewalChallenge
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("ethauth: invalid %s header - %w", HeaderRenew, err)
+	}
+	return &c, nil
+}
+
+// RenewalChallenge returns the renewal challenge of a verified proof. When a ChallengeManager
+// is configured, its nonce is a challenge issued to the account and origin of the proof, so the
+// renewed proof is accepted. Session, request and exchanged proofs are not signed by the
+// account, so they can't be renewed by the client, see SessionManager.Renew.
+func (w *ETHAuth) RenewalChallenge(ctx context.Context, proof *Proof) (*RenewalChallenge, error) {
+	switch proof.Claims.Type {
+	case ProofTypeSession, ProofTypeRequest, ProofTypeExchanged:
+		return nil, fmt.Errorf("ethauth: %s proofs can't be renewed by the client", proof.Claims.Type)
+	}
+
+	var nonce uint64
+	var err error
+	if w.challenges != nil {
+		var challenge Challenge
+		challenge, err = w.challenges.Issue(ctx, proof.Address, proof.Claims.Origin)
+		nonce = challenge.Nonce
+	} else {
+		nonce, err = randomNonce()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.clock()
+	lifetime := time.Duration(proof.Claims.ExpiresAt-proof.Claims.IssuedAt) * time.Second
+	if proof.Claims.IssuedAt == 0 || lifetime <= 0 {
+		lifetime = time.Unix(proof.Claims.ExpiresAt, 0).Sub(now)
+	}
+
+	claims := proof.Claims
+	claims.Nonce = nonce
+	claims.ID = ""
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(lifetime).Unix()
+
+	return &RenewalChallenge{Nonce: nonce, ExpiresAt: proof.Claims.ExpiresAt, Claims: claims}, nil
+}
+
+// RenewalHeader returns the HeaderRenew value of the renewal challenge of a verified proof
+// which expires within opts.RenewWithin, or an empty string otherwise, as set by Middleware
+// and the router adapters of the middleware packages. Proofs which can't be renewed, or whose
+// challenge can't be issued, have no renewal header, as their request is served all the same.
+func RenewalHeader(ctx context.Context, ethAuth *ETHAuth, proof *Proof, opts MiddlewareOptions) string {
+	if opts.RenewWithin <= 0 || proof == nil {
+		return ""
+	}
+	if time.Unix(proof.Claims.ExpiresAt, 0).Sub(ethAuth.clock()) > opts.RenewWithin {
+		return ""
+	}
+	c, err := ethAuth.RenewalChallenge(ctx, proof)
+	if err != nil {
+		return ""
+	}
+	header, err := c.Header()
+	if err != nil {
+		return ""
+	}
+	return header
+}
@@ -0,0 +1,66 @@
+package ethauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewalHeader(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New(ValidateEOAProof)
+	require.NoError(t, err)
+	challenges := NewChallengeManager(time.Minute)
+	ethAuth.ConfigChallengeManager(challenges)
+
+	handler := Middleware(ethAuth, MiddlewareOptions{RenewWithin: 10 * time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(claims Claims) *httptest.ResponseRecorder {
+		challenge, err := challenges.Issue(context.Background(), wallet.Address().Hex(), "")
+		require.NoError(t, err)
+		claims.Nonce = challenge.Nonce
+		proofString, err := signTestProof(t, wallet, claims).Encode()
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	now := time.Now().Unix()
+	rec := request(Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion, IssuedAt: now - 3300, ExpiresAt: now + 300, Scope: Scopes{"read"}})
+	c, err := ParseRenewalChallenge(rec.Header().Get(HeaderRenew))
+	require.NoError(t, err)
+	require.Equal(t, now+300, c.ExpiresAt)
+	require.NotZero(t, c.Nonce)
+	require.Equal(t, c.Nonce, c.Claims.Nonce)
+	require.Equal(t, "ETHAuthTest", c.Claims.App)
+	require.Equal(t, Scopes{"read"}, c.Claims.Scope)
+	require.Equal(t, ETHAuthVersion, c.Claims.ETHAuthVersion)
+	require.Equal(t, int64(3600), c.Claims.ExpiresAt-c.Claims.IssuedAt)
+
+	// the proof of the suggested claims answers the challenge of the renewal
+	renewed, err := signTestProof(t, wallet, c.Claims).Encode()
+	require.NoError(t, err)
+	ok, _, err := ethAuth.DecodeProof(renewed)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// proofs which aren't about to expire are not renewed
+	rec = request(Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion, IssuedAt: now, ExpiresAt: now + 3600})
+	require.Empty(t, rec.Header().Get(HeaderRenew))
+
+	_, err = ParseRenewalChallenge("not a challenge")
+	require.Error(t, err)
+}
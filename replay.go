@@ -0,0 +1,123 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ReplayClient is a client using a proof, by its IP address and User-Agent header.
+type ReplayClient struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"ua"`
+}
+
+// ReplayEvent is the use of a proof by another client than the first client which used it,
+// reported by the Hooks.OnReplay hook, see ConfigReplayDetection.
+type ReplayEvent struct {
+	// First is the first client which used the proof, at FirstSeen
+	First     ReplayClient
+	FirstSeen time.Time
+
+	// Client is the client of the request reusing the proof
+	Client ReplayClient
+}
+
+// ReplayStore records the first client of each proof within its lifetime, so the reuse of
+// a proof by other clients can be detected, see ConfigReplayDetection.
+type ReplayStore interface {
+	// Observe records the client as the first client of the proof of the key until exp,
+	// unless the proof has been used already, and returns the first client of the proof and
+	// when it was first seen.
+	Observe(ctx context.Context, key string, client ReplayClient, exp time.Time) (ReplayClient, time.Time, error)
+}
+
+// ConfigReplayDetection enables the detection of proofs reused by different clients, ie. a
+// token captured and replayed from another network. Unlike the nonce store, which rejects
+// the reuse of a nonce, replays are only reported: Authenticate and Middleware accept the
+// request, then call the Hooks.OnReplay hook when the IP address or user agent of the client
+// differs from the first client which used the proof, so legitimate retries still pass while
+// the fraud pipeline is notified. Failures of the store are logged at the warn level.
+func (w *ETHAuth) ConfigReplayDetection(store ReplayStore) {
+	w.replayStore = store
+}
+
+// detectReplay records the client of the request using the proof, and calls the OnReplay
+// hook if the proof was first used by another client.
+func (w *ETHAuth) detectReplay(ctx context.Context, proof *Proof, req AuthRequest) {
+	if w.replayStore == nil {
+		return
+	}
+	key, err := verificationCacheKey(proof)
+	if err != nil {
+		return
+	}
+
+	client := ReplayClient{IP: req.RemoteAddr, UserAgent: req.UserAgent}
+	if addr, err := remoteIP(req.RemoteAddr); err == nil {
+		client.IP = addr.String()
+	}
+	exp, ok := w.validatorConfig.acceptedUntil(proof.Claims)
+	if !ok {
+		exp = w.clock().Add(w.validatorConfig.MaxAge)
+	}
+
+	first, seen, err := w.replayStore.Observe(ctx, hex.EncodeToString(key[:]), client, exp)
+	if err != nil {
+		w.log().Warn(ctx, "ethauth: replay store failed", "error", err)
+		return
+	}
+	if first != client && w.hooks.OnReplay != nil {
+		w.hooks.OnReplay(ctx, proof, ReplayEvent{First: first, FirstSeen: seen, Client: client})
+	}
+}
+
+// MemoryReplayStore is an in-process ReplayStore. Its proofs are sharded by key, and purged as
+// they expire by the timing wheel of their shard.
+type MemoryReplayStore struct {
+	shards [memoryShards]replayShard
+}
+
+type replayShard struct {
+	uses  map[string]replayUse
+	wheel expiryWheel[string]
+	mu    sync.Mutex
+}
+
+type replayUse struct {
+	client ReplayClient
+	seen   time.Time
+	exp    time.Time
+}
+
+var _ ReplayStore = &MemoryReplayStore{}
+
+func NewMemoryReplayStore() *MemoryReplayStore {
+	s := &MemoryReplayStore{}
+	for i := range s.shards {
+		s.shards[i].uses = map[string]replayUse{}
+	}
+	return s
+}
+
+func (s *MemoryReplayStore) Observe(ctx context.Context, key string, client ReplayClient, exp time.Time) (ReplayClient, time.Time, error) {
+	shard := &s.shards[shardIndex(key)]
+	now := time.Now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.wheel.advance(now, func(key string, exp int64) {
+		if u, ok := shard.uses[key]; ok && u.exp.Unix() == exp {
+			delete(shard.uses, key)
+		}
+	})
+
+	if u, ok := shard.uses[key]; ok && !now.After(u.exp) {
+		return u.client, u.seen, nil
+	}
+	shard.uses[key] = replayUse{client: client, seen: now, exp: exp}
+	shard.wheel.add(key, exp)
+	return client, now, nil
+}
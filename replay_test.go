@@ -0,0 +1,105 @@
+package ethauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayDetection(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	var events []ReplayEvent
+	ethAuth.ConfigHooks(Hooks{OnReplay: func(ctx context.Context, proof *Proof, event ReplayEvent) {
+		events = append(events, event)
+	}})
+	ethAuth.ConfigReplayDetection(NewMemoryReplayStore())
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	serve := func(proofString, remoteAddr, userAgent string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		rec := httptest.NewRecorder()
+		Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// retries of the first client are not reported, even from another port
+	require.Equal(t, http.StatusOK, serve(proofString, "203.0.113.7:1234", "wallet/1.0"))
+	require.Equal(t, http.StatusOK, serve(proofString, "203.0.113.7:5678", "wallet/1.0"))
+	require.Empty(t, events)
+
+	// reuses by other clients are accepted and reported
+	require.Equal(t, http.StatusOK, serve(proofString, "198.51.100.1:1234", "wallet/1.0"))
+	require.Equal(t, http.StatusOK, serve(proofString, "203.0.113.7:1234", "curl/8.0"))
+	require.Len(t, events, 2)
+	first := ReplayClient{IP: "203.0.113.7", UserAgent: "wallet/1.0"}
+	require.Equal(t, first, events[0].First)
+	require.Equal(t, ReplayClient{IP: "198.51.100.1", UserAgent: "wallet/1.0"}, events[0].Client)
+	require.Equal(t, first, events[1].First)
+	require.Equal(t, "curl/8.0", events[1].Client.UserAgent)
+	require.WithinDuration(t, time.Now(), events[0].FirstSeen, time.Minute)
+
+	// as are reuses of the proof re-encoded with the other form of the V of its signature
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+	sig, err := ethcoder.HexDecode(proof.Signature)
+	require.NoError(t, err)
+	sig[64] -= 27
+	proof.Signature = ethcoder.HexEncode(sig)
+	reencoded, err := proof.Encode()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, serve(reencoded, "192.0.2.1:1234", "wallet/1.0"))
+	require.Len(t, events, 3)
+	require.Equal(t, first, events[2].First)
+
+	// other proofs are tracked on their own
+	proofString, err = Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithNonce(1))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, serve(proofString, "198.51.100.1:1234", "wallet/1.0"))
+	require.Len(t, events, 3)
+
+	// failures of the store don't fail the request
+	ethAuth.ConfigReplayDetection(failingReplayStore{})
+	require.Equal(t, http.StatusOK, serve(proofString, "192.0.2.1:1234", "wallet/1.0"))
+	require.Len(t, events, 3)
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+	a, b := ReplayClient{IP: "192.0.2.1"}, ReplayClient{IP: "192.0.2.2"}
+
+	first, _, err := store.Observe(ctx, "key", a, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, a, first)
+	first, _, err = store.Observe(ctx, "key", b, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, a, first)
+
+	// expired uses are forgotten
+	_, _, err = store.Observe(ctx, "expired", a, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+	first, _, err = store.Observe(ctx, "expired", b, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, b, first)
+}
+
+type failingReplayStore struct{}
+
+func (failingReplayStore) Observe(ctx context.Context, key string, client ReplayClient, exp time.Time) (ReplayClient, time.Time, error) {
+	return ReplayClient{}, time.Time{}, errors.New("store is down")
+}
@@ -0,0 +1,125 @@
+package ethauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ProofTypeRequest is the `typ` claim of request proofs, which sign a single HTTP request by
+// its method, path and body hash in the `htm`, `htp` and `bdh` claims, instead of
+// authenticating a session. Request proofs give webhook-style integrity of the request and
+// authentication in one signature, and are verified by Middleware like any other proof.
+// Request proofs may be replayed within their lifetime, unless signed WithNonce for an
+// ETHAuth instance with a NonceStore.
+const ProofTypeRequest = "request"
+
+// DefaultRequestProofTTL is the lifetime of the request proofs signed by SignRequest.
+const DefaultRequestProofTTL = 5 * time.Minute
+
+// MaxRequestBodyLength is the maximum length of the request bodies signed by request proofs,
+// which are read into memory to be hashed. Request proofs of longer bodies are rejected.
+const MaxRequestBodyLength = 10 * 1024 * 1024
+
+// RequestBodyHash returns the `bdh` claim of a request body, its hex encoded keccak256 hash.
+func RequestBodyHash(body []byte) string {
+	return ethcoder.HexEncode(crypto.Keccak256(body))
+}
+
+// WithRequest sets the `typ`, `htm`, `htp` and `bdh` claims of a request proof, signing the
+// request of the method, to the path without its query, with the body.
+func WithRequest(method, path string, body []byte) IssueOption {
+	return func(claims *Claims) {
+		claims.Type = ProofTypeRequest
+		claims.RequestMethod = strings.ToUpper(method)
+		claims.RequestPath = path
+		claims.RequestBodyHash = RequestBodyHash(body)
+	}
+}
+
+// SignRequest signs a request proof of the request with the signer, and sets it as the
+// bearer proof of the Authorization header of the request. The request proof expires after
+// DefaultRequestProofTTL, unless WithExpiresIn is given.
+func SignRequest(ctx context.Context, r *http.Request, signer Signer, opts ...IssueOption) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = readRequestBody(r)
+		if err != nil {
+			return fmt.Errorf("ethauth: failed to read request body - %w", err)
+		}
+	}
+
+	opts = append([]IssueOption{WithExpiresIn(DefaultRequestProofTTL)}, opts...)
+	opts = append(opts, WithRequest(r.Method, r.URL.EscapedPath(), body))
+	proofString, err := IssueWithContext(ctx, signer, opts...)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+proofString)
+	return nil
+}
+
+// readRequestBody reads the body of the request, up to MaxRequestBodyLength, and replaces it
+// with a reader of the same body.
+func readRequestBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestBodyLength+1))
+	if err == nil && len(body) > MaxRequestBodyLength {
+		// the rest of the body is left unread, for the handler to reject as it sees fit
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, fmt.Errorf("body exceeds %d bytes", MaxRequestBodyLength)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// validRequestBinding validates that request proofs are bound to a request, and that only
+// request proofs are.
+func (c Claims) validRequestBinding() error {
+	bound := c.RequestMethod != "" || c.RequestPath != "" || c.RequestBodyHash != ""
+	if c.Type != ProofTypeRequest {
+		if bound {
+			return fmt.Errorf("claims: htm, htp and bdh claims require the %q typ", ProofTypeRequest)
+		}
+		return nil
+	}
+	if c.RequestMethod == "" || c.RequestPath == "" || c.RequestBodyHash == "" {
+		return fmt.Errorf("claims: request proofs require the htm, htp and bdh claims")
+	}
+	if c.IssuedAt == 0 {
+		return ErrMissingIssuedAt
+	}
+	return nil
+}
+
+// verifyRequestBinding verifies that a request proof signs the request.
+func verifyRequestBinding(proof *Proof, req AuthRequest) error {
+	if proof.Claims.Type != ProofTypeRequest {
+		return nil
+	}
+	if !strings.EqualFold(proof.Claims.RequestMethod, req.Method) || proof.Claims.RequestPath != req.Path {
+		return fmt.Errorf("%w, proof signs a %s %s request", ErrInvalidRequestBinding, proof.Claims.RequestMethod, proof.Claims.RequestPath)
+	}
+	if req.Body == nil {
+		return fmt.Errorf("%w, request body is unavailable", ErrInvalidRequestBinding)
+	}
+	body, err := req.Body()
+	if err != nil {
+		return fmt.Errorf("%w, unable to read request body - %v", ErrInvalidRequestBinding, err)
+	}
+	if !strings.EqualFold(RequestBodyHash(body), proof.Claims.RequestBodyHash) {
+		return fmt.Errorf("%w, request body does not match", ErrInvalidRequestBinding)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package ethauth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestProofs(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	signer := NewWalletSigner(wallet)
+
+	handler := Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the body is still readable by the handler
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		return rec
+	}
+
+	r := httptest.NewRequest("POST", "/webhooks/orders?retry=1", strings.NewReader(`{"order":1}`))
+	require.NoError(t, SignRequest(context.Background(), r, signer, WithApp("ETHAuthTest")))
+	authorization := r.Header.Get("Authorization")
+
+	rec := serve(r)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, `{"order":1}`, rec.Body.String())
+
+	proofString, _ := ProofFromRequest(r)
+	proof, err := Parse(proofString)
+	require.NoError(t, err)
+	require.Equal(t, ProofTypeRequest, proof.Claims.Type)
+	require.Equal(t, "POST", proof.Claims.RequestMethod)
+	require.Equal(t, "/webhooks/orders", proof.Claims.RequestPath)
+	require.Equal(t, RequestBodyHash([]byte(`{"order":1}`)), proof.Claims.RequestBodyHash)
+
+	// the proof is bound to the method, path and body of the request
+	for _, r := range []*http.Request{
+		httptest.NewRequest("PUT", "/webhooks/orders", strings.NewReader(`{"order":1}`)),
+		httptest.NewRequest("POST", "/webhooks/refunds", strings.NewReader(`{"order":1}`)),
+		httptest.NewRequest("POST", "/webhooks/orders", strings.NewReader(`{"order":2}`)),
+		httptest.NewRequest("GET", "/account", nil),
+	} {
+		r.Header.Set("Authorization", authorization)
+		require.Equal(t, http.StatusUnauthorized, serve(r).Code, r.Method+" "+r.URL.Path)
+	}
+
+	// requests without a body are signed with the hash of an empty body
+	r = httptest.NewRequest("GET", "/account", nil)
+	require.NoError(t, SignRequest(context.Background(), r, signer, WithApp("ETHAuthTest")))
+	require.Equal(t, http.StatusOK, serve(r).Code)
+
+	// bodies over MaxRequestBodyLength are neither signed nor read to be verified
+	large := strings.Repeat("a", MaxRequestBodyLength+1)
+	r = httptest.NewRequest("POST", "/webhooks/orders", strings.NewReader(large))
+	require.Error(t, SignRequest(context.Background(), r, signer, WithApp("ETHAuthTest")))
+	proofString, err = Issue(signer, WithApp("ETHAuthTest"), WithRequest("POST", "/webhooks/orders", []byte(large)))
+	require.NoError(t, err)
+	r = httptest.NewRequest("POST", "/webhooks/orders", strings.NewReader(large))
+	r.Header.Set("Authorization", "Bearer "+proofString)
+	_, err = Authenticate(ethAuth, NewAuthRequest(r), MiddlewareOptions{})
+	require.ErrorIs(t, err, ErrInvalidRequestBinding)
+	require.ErrorContains(t, err, "exceeds")
+
+	// request claims are rejected on other proof types
+	_, err = Issue(signer, WithApp("ETHAuthTest"), WithRequest("GET", "/", nil), func(claims *Claims) { claims.Type = "" })
+	require.Error(t, err)
+	_, err = Issue(signer, WithApp("ETHAuthTest"), func(claims *Claims) { claims.Type = ProofTypeRequest })
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package ethauth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RevocationStore records revoked proofs, so operators can revoke a specific proof by
+// its `jti` claim, all proofs of an account, see Proof.Account, or all proofs issued before a
+// point in time.
+type RevocationStore interface {
+	// RevokeID revokes the proof with the `jti` claim, until the proof expires at exp.
+	RevokeID(ctx context.Context, id string, exp time.Time) error
+
+	// RevokeAddress revokes all proofs of the account address.
+	RevokeAddress(ctx context.Context, address string) error
+
+	// RevokeIssuedBefore revokes all proofs of the account address issued before t. When
+	// address is empty, proofs of all accounts issued before t are revoked. Proofs without
+	// an `iat` claim are considered to be issued before t.
+	RevokeIssuedBefore(ctx context.Context, address string, t time.Time) error
+
+	// IsRevoked returns true if the proof has been revoked.
+	IsRevoked(ctx context.Context, proof *Proof) (bool, error)
+}
+
+// MemoryRevocationStore is an in-process RevocationStore. Its revoked proof ids and accounts
+// are sharded by id and address, so revocation checks of concurrent verifications rarely
+// contend on the same lock, and revoked proof ids are purged as they expire by the timing
+// wheel of their shard.
+type MemoryRevocationStore struct {
+	ids       [memoryShards]revokedIDShard
+	addresses [memoryShards]revokedAddressShard
+
+	// issuedBefore is the unix time before which the proofs of all accounts are revoked
+	issuedBefore atomic.Int64
+}
+
+type revokedIDShard struct {
+	ids   map[string]time.Time
+	wheel expiryWheel[string]
+	mu    sync.RWMutex
+}
+
+type revokedAddressShard struct {
+	revoked      map[string]struct{}
+	issuedBefore map[string]time.Time
+	mu           sync.RWMutex
+}
+
+var _ RevocationStore = &MemoryRevocationStore{}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	s := &MemoryRevocationStore{}
+	for i := range s.ids {
+		s.ids[i].ids = map[string]time.Time{}
+		s.addresses[i].revoked = map[string]struct{}{}
+		s.addresses[i].issuedBefore = map[string]time.Time{}
+	}
+	return s
+}
+
+func (s *MemoryRevocationStore) RevokeID(ctx context.Context, id string, exp time.Time) error {
+	shard := &s.ids[shardIndex(id)]
+	now := time.Now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.wheel.advance(now, func(id string, exp int64) {
+		if e, ok := shard.ids[id]; ok && e.Unix() == exp {
+			delete(shard.ids, id)
+		}
+	})
+	shard.ids[id] = exp
+	shard.wheel.add(id, exp)
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeAddress(ctx context.Context, address string) error {
+	address = strings.ToLower(address)
+	shard := &s.addresses[shardIndex(address)]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.revoked[address] = struct{}{}
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeIssuedBefore(ctx context.Context, address string, t time.Time) error {
+	if address == "" {
+		for {
+			before := s.issuedBefore.Load()
+			if before != 0 && t.Unix() <= before {
+				return nil
+			}
+			if s.issuedBefore.CompareAndSwap(before, t.Unix()) {
+				return nil
+			}
+		}
+	}
+
+	address = strings.ToLower(address)
+	shard := &s.addresses[shardIndex(address)]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if before, ok := shard.issuedBefore[address]; !ok || t.After(before) {
+		shard.issuedBefore[address] = t
+	}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, proof *Proof) (bool, error) {
+	if before := s.issuedBefore.Load(); before != 0 && proof.Claims.IssuedAt < before {
+		return true, nil
+	}
+
+	if proof.Claims.ID != "" {
+		shard := &s.ids[shardIndex(proof.Claims.ID)]
+		shard.mu.RLock()
+		exp, ok := shard.ids[proof.Claims.ID]
+		shard.mu.RUnlock()
+		if ok && !time.Now().After(exp) {
+			return true, nil
+		}
+	}
+
+	address := strings.ToLower(proof.Account())
+	shard := &s.addresses[shardIndex(address)]
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	if _, ok := shard.revoked[address]; ok {
+		return true, nil
+	}
+	if before, ok := shard.issuedBefore[address]; ok && proof.Claims.IssuedAt < before.Unix() {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,175 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// RoleResolver resolves the roles of the accounts of verified proofs, ie. from a database or
+// a contract, see ConfigRoleResolver and RequireRole. Unlike the `scope` claim, roles are not
+// signed by the proof, so they can be granted and revoked while the proof is valid.
+type RoleResolver interface {
+	// HasRole reports whether the account has the role.
+	HasRole(ctx context.Context, address common.Address, role string) (bool, error)
+}
+
+// ConfigRoleResolver sets the resolver of the roles of the accounts of the proofs verified by
+// the instance, see Proof.HasRole and RequireRole.
+func (w *ETHAuth) ConfigRoleResolver(resolver RoleResolver) {
+	w.roleResolver = resolver
+}
+
+// HasRole reports whether the account of the proof has the role, as resolved by the
+// RoleResolver of the ETHAuth instance which verified the proof.
+func (t *Proof) HasRole(ctx context.Context, role string) (bool, error) {
+	if t.roleResolver == nil {
+		return false, fmt.Errorf("ethauth: no role resolver configured")
+	}
+	address, err := t.AddressBytes()
+	if err != nil {
+		return false, err
+	}
+	return t.roleResolver.HasRole(ctx, address, role)
+}
+
+// RequireRole returns a net/http middleware which rejects requests whose proof, as passed in
+// the request context by Middleware, doesn't have all of the roles, see ConfigRoleResolver.
+// Requests without a proof are rejected with a 401 Unauthorized status, requests without the
+// roles with a 403 Forbidden status, and requests whose roles can't be resolved with a 503
+// Service Unavailable status.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proof, ok := FromContext(r.Context())
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			for _, role := range roles {
+				ok, err := proof.HasRole(r.Context(), role)
+				if err != nil {
+					http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+					return
+				}
+				if !ok {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaticRoles is a RoleResolver of the roles of each account.
+type StaticRoles map[common.Address][]string
+
+var _ RoleResolver = StaticRoles{}
+
+func (s StaticRoles) HasRole(ctx context.Context, address common.Address, role string) (bool, error) {
+	return slices.Contains(s[address], role), nil
+}
+
+// DefaultRoleTTL is how long the roles read by AccessControlResolver are cached, unless
+// configured otherwise.
+const DefaultRoleTTL = time.Minute
+
+// AccessControlRole returns the role id of a role name in an AccessControl contract of
+// OpenZeppelin: the zero id of DEFAULT_ADMIN_ROLE, the id of a hex role id, or else the
+// keccak256 of the name, as of `bytes32 public constant MINTER_ROLE = keccak256("MINTER_ROLE")`.
+func AccessControlRole(name string) common.Hash {
+	if name == "DEFAULT_ADMIN_ROLE" {
+		return common.Hash{}
+	}
+	if len(name) == 66 && strings.HasPrefix(name, "0x") {
+		if b, err := ethcoder.HexDecode(name); err == nil {
+			return common.BytesToHash(b)
+		}
+	}
+	return crypto.Keccak256Hash([]byte(name))
+}
+
+// AccessControlResolver is a RoleResolver reading the roles of accounts from the hasRole
+// method of an AccessControl contract of OpenZeppelin. Role names are mapped to role ids by
+// AccessControlRole, unless set by ConfigRole, and the roles of each account are cached for
+// the TTL of the resolver.
+type AccessControlResolver struct {
+	provider *ethrpc.Provider
+	contract common.Address
+	cache    *ttlCache[bool]
+
+	mu    sync.RWMutex
+	roles map[string]common.Hash
+}
+
+var _ RoleResolver = &AccessControlResolver{}
+
+// NewAccessControlResolver returns an AccessControlResolver of the contract, calling its
+// hasRole method over the provider and caching the roles for the optional TTL, which
+// defaults to DefaultRoleTTL.
+func NewAccessControlResolver(provider *ethrpc.Provider, contract common.Address, optTTL ...time.Duration) (*AccessControlResolver, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethauth: access control resolver provider is nil")
+	}
+	ttl := DefaultRoleTTL
+	if len(optTTL) > 0 && optTTL[0] > 0 {
+		ttl = optTTL[0]
+	}
+	return &AccessControlResolver{
+		provider: provider,
+		contract: contract,
+		cache:    newTTLCache[bool](ttl),
+		roles:    map[string]common.Hash{},
+	}, nil
+}
+
+// ConfigRole sets the role id of a role name, ie. so RequireRole("ADMIN") checks the
+// keccak256("ADMIN_ROLE") role of the contract.
+func (r *AccessControlResolver) ConfigRole(name string, role common.Hash) {
+	r.mu.Lock()
+	r.roles[name] = role
+	r.mu.Unlock()
+}
+
+func (r *AccessControlResolver) HasRole(ctx context.Context, address common.Address, name string) (bool, error) {
+	r.mu.RLock()
+	role, ok := r.roles[name]
+	r.mu.RUnlock()
+	if !ok {
+		role = AccessControlRole(name)
+	}
+
+	key := role.Hex() + ":" + strings.ToLower(address.Hex())
+	if hasRole, ok := r.cache.get(key); ok {
+		return hasRole, nil
+	}
+
+	input, err := ethcoder.ABIEncodeMethodCalldata("hasRole(bytes32,address)", []interface{}{role, address})
+	if err != nil {
+		return false, fmt.Errorf("ethauth: unable to encode hasRole call - %w", err)
+	}
+	rpcCtx, call := startRPCCall(ctx, "eth_call")
+	output, err := r.provider.CallContract(rpcCtx, ethereum.CallMsg{To: &r.contract, Data: input}, nil)
+	call.end(err)
+	if err != nil {
+		return false, fmt.Errorf("ethauth: hasRole call failed - %w", err)
+	}
+	if len(output) < 32 {
+		return false, fmt.Errorf("ethauth: hasRole call of %s returned no result", r.contract.Hex())
+	}
+
+	hasRole := output[31] == 1
+	r.cache.add(key, hasRole)
+	return hasRole, nil
+}
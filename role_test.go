@@ -0,0 +1,124 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// newAccessControlTestServer returns a JSON-RPC server answering the hasRole calls of the
+// contract, of the account holding the role.
+func newAccessControlTestServer(t *testing.T, contract common.Address, role common.Hash, account common.Address, calls *atomic.Int32) *httptest.Server {
+	expected, err := ethcoder.ABIEncodeMethodCalldata("hasRole(bytes32,address)", []interface{}{role, account})
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "eth_call", req.Method)
+		calls.Add(1)
+
+		var msg struct {
+			To    common.Address `json:"to"`
+			Input string         `json:"input"`
+			Data  string         `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(req.Params[0], &msg))
+		input := msg.Input
+		if input == "" {
+			input = msg.Data
+		}
+
+		result, err := ethcoder.ABIPackArguments([]string{"bool"}, []interface{}{msg.To == contract && input == ethcoder.HexEncode(expected)})
+		require.NoError(t, err)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": ethcoder.HexEncode(result)})
+	}))
+}
+
+func TestAccessControlResolver(t *testing.T) {
+	require.Equal(t, common.Hash{}, AccessControlRole("DEFAULT_ADMIN_ROLE"))
+	require.Equal(t, crypto.Keccak256Hash([]byte("MINTER_ROLE")), AccessControlRole("MINTER_ROLE"))
+	require.Equal(t, crypto.Keccak256Hash([]byte("x")), AccessControlRole(crypto.Keccak256Hash([]byte("x")).Hex()))
+
+	contract := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	account := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	var calls atomic.Int32
+	server := newAccessControlTestServer(t, contract, crypto.Keccak256Hash([]byte("ADMIN_ROLE")), account, &calls)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+	_, err = NewAccessControlResolver(nil, contract)
+	require.Error(t, err)
+	resolver, err := NewAccessControlResolver(provider, contract)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	ok, err := resolver.HasRole(ctx, account, "ADMIN_ROLE")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = resolver.HasRole(ctx, account, "ADMIN")
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = resolver.HasRole(ctx, common.HexToAddress("0x3333333333333333333333333333333333333333"), "ADMIN_ROLE")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, int32(3), calls.Load())
+
+	// role names map to the role ids of the contract, and roles are cached
+	resolver.ConfigRole("ADMIN", AccessControlRole("ADMIN_ROLE"))
+	ok, err = resolver.HasRole(ctx, account, "ADMIN")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestRequireRole(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	serve := func(roles ...string) int {
+		handler := Middleware(ethAuth)(RequireRole(roles...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+proofString)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// roles can't be resolved without a resolver
+	require.Equal(t, http.StatusServiceUnavailable, serve("ADMIN"))
+
+	ethAuth.ConfigRoleResolver(StaticRoles{wallet.Address(): {"ADMIN", "BILLING"}})
+	require.Equal(t, http.StatusOK, serve("ADMIN"))
+	require.Equal(t, http.StatusOK, serve("ADMIN", "BILLING"))
+	require.Equal(t, http.StatusForbidden, serve("ADMIN", "OWNER"))
+
+	rec := httptest.NewRecorder()
+	RequireRole("ADMIN")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, httptest.NewRequest("GET", "/admin", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	_, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	ok, err := proof.HasRole(context.Background(), "BILLING")
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,104 @@
+package ethauth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ProofScheme is the signature scheme of the proofs of a `typ` claim, for accounts which are not
+// Ethereum accounts, ie. the Solana accounts of ProofTypeSIWS proofs. The proofs have the same
+// claims as the proofs of Ethereum accounts, and only their address and signed message differ.
+// Proofs of a scheme are verified by a validator registered with ETHAuth.RegisterValidator.
+type ProofScheme struct {
+	// Type is the `typ` claim of the proofs of this scheme
+	Type string
+
+	// ValidAddress returns an error if the address isn't the address of an account of this
+	// scheme. Addresses are compared as is, so they must have a canonical encoding.
+	ValidAddress func(address string) error
+
+	// Message returns the message signed by the proof
+	Message func(proof *Proof) ([]byte, error)
+}
+
+var (
+	proofSchemesMu sync.RWMutex
+	proofSchemes   = map[string]ProofScheme{
+		ProofTypeSIWS: {Type: ProofTypeSIWS, ValidAddress: validSolanaAddress, Message: siwsMessage},
+	}
+)
+
+// RegisterProofScheme registers the signature scheme of the proofs of a `typ` claim. Schemes
+// can't be registered twice, nor for the `typ` claims of Ethereum proofs.
+func RegisterProofScheme(ps ProofScheme) error {
+	if ps.Type == "" {
+		return fmt.Errorf("ethauth: proof scheme type is empty")
+	}
+	if ps.ValidAddress == nil || ps.Message == nil {
+		return fmt.Errorf("ethauth: proof scheme %q requires an address validator and a message builder", ps.Type)
+	}
+	switch ps.Type {
+	case ProofTypeSIWE, ProofTypeEIP191, ProofTypeDelegated, ProofTypeRequest, ProofTypeSession, ProofTypeExchanged:
+		return fmt.Errorf("ethauth: proof scheme %q is an Ethereum proof type", ps.Type)
+	}
+
+	proofSchemesMu.Lock()
+	defer proofSchemesMu.Unlock()
+
+	if _, ok := proofSchemes[ps.Type]; ok {
+		return fmt.Errorf("ethauth: proof scheme %q is already registered", ps.Type)
+	}
+	proofSchemes[ps.Type] = ps
+	return nil
+}
+
+// LookupProofScheme returns the registered signature scheme of the proofs of a `typ` claim.
+func LookupProofScheme(typ string) (ProofScheme, bool) {
+	proofSchemesMu.RLock()
+	defer proofSchemesMu.RUnlock()
+	ps, ok := proofSchemes[typ]
+	return ps, ok
+}
+
+// scheme returns the signature scheme of the proof, if the proof isn't an Ethereum proof.
+func (t *Proof) scheme() (ProofScheme, bool) {
+	if t.Claims.Type == "" {
+		return ProofScheme{}, false
+	}
+	return LookupProofScheme(t.Claims.Type)
+}
+
+// validAddress returns an error if the proof address isn't an account address of the scheme
+// of the proof, see AddressBytes for Ethereum proofs.
+func (t *Proof) validAddress() error {
+	if ps, ok := t.scheme(); ok {
+		if err := ps.ValidAddress(t.Address); err != nil {
+			return fmt.Errorf("%w - %w", ErrInvalidAddress, err)
+		}
+		return nil
+	}
+	_, err := t.AddressBytes()
+	return err
+}
+
+// addressKey returns the proof address as a key of the account, which is the lower case hex
+// address of Ethereum accounts, and the address as is otherwise.
+func (t *Proof) addressKey() string {
+	if _, ok := t.scheme(); ok {
+		return t.Address
+	}
+	return strings.ToLower(t.Address)
+}
+
+// schemeMessageDigest returns the keccak256 hash of the message signed by a proof of a scheme,
+// which identifies the signed message, ie. in the verification cache.
+func schemeMessageDigest(ps ProofScheme, proof *Proof) ([]byte, error) {
+	message, err := ps.Message(proof)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(message), nil
+}
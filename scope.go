@@ -0,0 +1,54 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scopes is the `scope` claim, restricting what the proof may be used for. It is encoded as
+// a space-separated string, as with OAuth 2.0 scopes, and may also be decoded from an array.
+type Scopes []string
+
+// ParseScopes splits a space-separated scope string.
+func ParseScopes(scope string) Scopes {
+	return Scopes(strings.Fields(scope))
+}
+
+func (s Scopes) String() string {
+	return strings.Join(s, " ")
+}
+
+// Has reports whether the scopes include all of the given scopes.
+func (s Scopes) Has(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(s, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Scopes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Scopes) UnmarshalJSON(data []byte) error {
+	var scope string
+	if err := json.Unmarshal(data, &scope); err == nil {
+		*s = ParseScopes(scope)
+		return nil
+	}
+	var scopes []string
+	if err := json.Unmarshal(data, &scopes); err != nil {
+		return fmt.Errorf("claims: scope must be a string or an array of strings")
+	}
+	for _, scope := range scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n") {
+			return fmt.Errorf("claims: invalid scope %q", scope)
+		}
+	}
+	*s = scopes
+	return nil
+}
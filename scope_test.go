@@ -0,0 +1,71 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopes(t *testing.T) {
+	var claims Claims
+	require.NoError(t, json.Unmarshal([]byte(`{"app":"ETHAuthTest","scope":"read:orders write:orders"}`), &claims))
+	require.Equal(t, Scopes{"read:orders", "write:orders"}, claims.Scope)
+	require.NoError(t, json.Unmarshal([]byte(`{"app":"ETHAuthTest","scope":["read:orders","write:orders"]}`), &claims))
+	require.Equal(t, Scopes{"read:orders", "write:orders"}, claims.Scope)
+	require.Error(t, json.Unmarshal([]byte(`{"scope":["read:orders write:orders"]}`), &claims))
+
+	data, err := json.Marshal(claims)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"app":"ETHAuthTest","scope":"read:orders write:orders"}`, string(data))
+
+	require.True(t, claims.Scope.Has("write:orders"))
+	require.True(t, claims.Scope.Has())
+	require.False(t, claims.Scope.Has("write:orders", "admin"))
+}
+
+func TestRequiredScopes(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", Scope: Scopes{"read:orders"}, ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	// the scope claim is signed
+	_, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	proof.Claims.Scope = Scopes{"read:orders", "write:orders"}
+	require.False(t, ethAuth.ValidateProofSignature(proof))
+
+	ethAuth.ConfigRequiredScopes("read:orders")
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+
+	ethAuth.ConfigRequiredScopes("write:orders")
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.ErrorIs(t, err, ErrMissingScope)
+	ethAuth.ConfigRequiredScopes()
+
+	// per-handler scopes
+	handler := Middleware(ethAuth)(RequireScope("write:orders")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	req := httptest.NewRequest("POST", "/orders", nil)
+	req.Header.Set("Authorization", "Bearer "+proofString)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	handler = Middleware(ethAuth)(RequireScope("read:orders")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
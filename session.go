@@ -0,0 +1,191 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// ProofTypeSession is the `typ` claim of session proofs, which are signed by a server
+// session key on behalf of the wallet which signed the original proof.
+const ProofTypeSession = "session"
+
+// SessionPolicy configures the lifetime and renewal of session proofs.
+type SessionPolicy struct {
+	// TTL is the lifetime of each session proof, defaulting to 30 minutes.
+	TTL time.Duration
+
+	// MaxLifetime is how long after the wallet signed the original proof sessions
+	// may be renewed, defaulting to 7 days.
+	MaxLifetime time.Duration
+
+	// RenewWindow only allows renewing session proofs which expire within the window,
+	// so clients can't mint a new session proof on every request. 0 allows renewing at
+	// any time.
+	RenewWindow time.Duration
+}
+
+// DefaultSessionPolicy is the SessionPolicy used when none is given.
+var DefaultSessionPolicy = SessionPolicy{
+	TTL:         30 * time.Minute,
+	MaxLifetime: 7 * 24 * time.Hour,
+}
+
+// SessionManager exchanges wallet-signed proofs for short-lived session proofs signed by a
+// server session key, which can be renewed without prompting the wallet to sign again.
+//
+// Session proofs keep the `iat` claim of the wallet proof, so renewals are bounded by
+// the policy MaxLifetime, and by the MaxAge of the validator config. Each session proof has
+// a random `n` and `jti` claim of its own, as the nonce of the wallet proof is consumed as it
+// is exchanged, when a NonceStore is configured.
+type SessionManager struct {
+	ethAuth    *ETHAuth
+	sessionKey *ecdsa.PrivateKey
+	policy     SessionPolicy
+}
+
+// NewSessionManager returns a SessionManager signing session proofs with the sessionKey, and
+// registers its ValidateSessionProof validator with ethAuth.
+func NewSessionManager(ethAuth *ETHAuth, sessionKey *ecdsa.PrivateKey, optPolicy ...SessionPolicy) (*SessionManager, error) {
+	if ethAuth == nil {
+		return nil, fmt.Errorf("ethauth: session manager requires an ETHAuth instance")
+	}
+	if sessionKey == nil {
+		return nil, fmt.Errorf("ethauth: session manager requires a session key")
+	}
+
+	policy := DefaultSessionPolicy
+	if len(optPolicy) > 0 {
+		policy = optPolicy[0]
+		if policy.TTL <= 0 {
+			policy.TTL = DefaultSessionPolicy.TTL
+		}
+		if policy.MaxLifetime <= 0 {
+			policy.MaxLifetime = DefaultSessionPolicy.MaxLifetime
+		}
+	}
+
+	m := &SessionManager{ethAuth: ethAuth, sessionKey: sessionKey, policy: policy}
+	ethAuth.RegisterValidator(m.ValidateSessionProof)
+	return m, nil
+}
+
+// SessionAddress returns the address of the session key.
+func (m *SessionManager) SessionAddress() common.Address {
+	return crypto.PubkeyToAddress(m.sessionKey.PublicKey)
+}
+
+// Issue decodes and validates the wallet-signed proof string, and returns a session proof
+// on behalf of the wallet.
+func (m *SessionManager) Issue(proofString string) (string, *Proof, error) {
+	_, proof, err := m.ethAuth.DecodeProof(proofString)
+	if err != nil {
+		return "", nil, err
+	}
+	if proof.Claims.Type == ProofTypeSession {
+		return "", nil, fmt.Errorf("ethauth: session proofs must be renewed, not issued")
+	}
+	return m.newSession(proof)
+}
+
+// Renew decodes and validates the session proof string, and returns a new session proof
+// replacing it.
+func (m *SessionManager) Renew(sessionProofString string) (string, *Proof, error) {
+	_, proof, err := m.ethAuth.DecodeProof(sessionProofString)
+	if err != nil {
+		return "", nil, err
+	}
+	if proof.Claims.Type != ProofTypeSession {
+		return "", nil, fmt.Errorf("ethauth: proof is not a session proof")
+	}
+	if ok, _, err := m.ValidateSessionProof(context.Background(), nil, nil, proof); !ok {
+		return "", nil, err
+	}
+
+	now := m.ethAuth.clock()
+	if m.policy.RenewWindow > 0 && time.Unix(proof.Claims.ExpiresAt, 0).Sub(now) > m.policy.RenewWindow {
+		return "", nil, fmt.Errorf("ethauth: session proof can't be renewed until %s before it expires", m.policy.RenewWindow)
+	}
+	return m.newSession(proof)
+}
+
+func (m *SessionManager) newSession(proof *Proof) (string, *Proof, error) {
+	now := m.ethAuth.clock()
+
+	exp := now.Add(m.policy.TTL)
+	if maxExp := time.Unix(proof.Claims.IssuedAt, 0).Add(m.policy.MaxLifetime); exp.After(maxExp) {
+		exp = maxExp
+	}
+	if !exp.After(now) {
+		return "", nil, fmt.Errorf("ethauth: session has reached its maximum lifetime")
+	}
+
+	nonce, err := randomNonce()
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := randomProofID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	session := NewProof()
+	session.Address = proof.Address
+	session.Claims = proof.Claims
+	session.Claims.Type = ProofTypeSession
+	session.Claims.ExpiresAt = exp.Unix()
+	session.Claims.Nonce = nonce
+	session.Claims.ID = id
+
+	digest, err := session.MessageDigest()
+	if err != nil {
+		return "", nil, err
+	}
+	sig, err := crypto.Sign(digest, m.sessionKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("ethauth: failed to sign session proof - %w", err)
+	}
+	sig[64] += 27
+	session.Signature = ethcoder.HexEncode(sig)
+
+	sessionProofString, err := session.Encode()
+	if err != nil {
+		return "", nil, err
+	}
+	return sessionProofString, session, nil
+}
+
+// randomProofID returns a random `jti` claim.
+func randomProofID() (string, error) {
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", fmt.Errorf("ethauth: unable to generate proof id - %w", err)
+	}
+	return hex.EncodeToString(random[:]), nil
+}
+
+// ValidateSessionProof is a ValidatorFunc accepting session proofs signed by the session key.
+func (m *SessionManager) ValidateSessionProof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+	if proof.Claims.Type != ProofTypeSession {
+		return false, "", fmt.Errorf("ValidateSessionProof failed. proof is not a session proof")
+	}
+
+	message, err := proof.Message()
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateSessionProof failed. Unable to compute ethauth message digest, because %w", err)
+	}
+	isValid, err := ValidateEOASignature(m.SessionAddress().Hex(), message, proof.Signature)
+	if err != nil || !isValid {
+		return false, "", fmt.Errorf("ValidateSessionProof failed. invalid session key signature")
+	}
+	return true, proof.Address, nil
+}
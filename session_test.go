@@ -0,0 +1,108 @@
+package ethauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionManager(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	sessionKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sessions, err := NewSessionManager(ethAuth, sessionKey, SessionPolicy{TTL: 30 * time.Minute, MaxLifetime: time.Hour, RenewWindow: 5 * time.Minute})
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proofString, err := ethAuth.EncodeProof(signTestProof(t, wallet, claims))
+	require.NoError(t, err)
+
+	sessionString, session, err := sessions.Issue(proofString)
+	require.NoError(t, err)
+	require.Equal(t, ProofTypeSession, session.Claims.Type)
+	require.Equal(t, claims.IssuedAt, session.Claims.IssuedAt)
+
+	// session proofs are valid on behalf of the wallet
+	ok, decoded, err := ethAuth.DecodeProof(sessionString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, strings.ToLower(wallet.Address().Hex()), decoded.Address)
+
+	// session proofs can't be renewed until the renew window
+	_, _, err = sessions.Renew(sessionString)
+	require.Error(t, err)
+
+	now := time.Now()
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now.Add(28 * time.Minute) }))
+	renewedString, renewed, err := sessions.Renew(sessionString)
+	require.NoError(t, err)
+	require.Greater(t, renewed.Claims.ExpiresAt, session.Claims.ExpiresAt)
+
+	// renewals are capped to the max lifetime of the session
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now.Add(56 * time.Minute) }))
+	_, renewed, err = sessions.Renew(renewedString)
+	require.NoError(t, err)
+	require.Equal(t, claims.IssuedAt+int64(time.Hour.Seconds()), renewed.Claims.ExpiresAt)
+
+	// wallet proofs can't be renewed, and session proofs can't be forged by other keys
+	_, _, err = sessions.Renew(proofString)
+	require.Error(t, err)
+
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := NewSessionManager(ethAuth, otherKey)
+	require.NoError(t, err)
+	_, _, err = other.ValidateSessionProof(context.Background(), nil, nil, session)
+	require.Error(t, err)
+}
+
+func TestSessionManagerNonceStore(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(NewMemoryNonceStore())
+
+	sessionKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sessions, err := NewSessionManager(ethAuth, sessionKey, SessionPolicy{TTL: 30 * time.Minute, RenewWindow: 5 * time.Minute})
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithNonce(1), WithID("wallet"))
+	require.NoError(t, err)
+
+	// session proofs have a nonce and jti of their own, so they pass the nonce store
+	sessionString, session, err := sessions.Issue(proofString)
+	require.NoError(t, err)
+	require.NotZero(t, session.Claims.Nonce)
+	require.NotEqual(t, uint64(1), session.Claims.Nonce)
+	require.NotEmpty(t, session.Claims.ID)
+	require.NotEqual(t, "wallet", session.Claims.ID)
+	_, _, err = sessions.Issue(proofString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+
+	now := time.Now()
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return now.Add(28 * time.Minute) }))
+	renewedString, renewed, err := sessions.Renew(sessionString)
+	require.NoError(t, err)
+	require.NotEqual(t, session.Claims.Nonce, renewed.Claims.Nonce)
+	require.NotEqual(t, session.Claims.ID, renewed.Claims.ID)
+
+	// each session proof is renewed once
+	_, _, err = sessions.Renew(sessionString)
+	require.ErrorIs(t, err, ErrNonceUsed)
+	_, _, err = ethAuth.DecodeProof(renewedString)
+	require.NoError(t, err)
+}
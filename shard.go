@@ -0,0 +1,77 @@
+package ethauth
+
+import (
+	"hash/maphash"
+	"time"
+)
+
+// memoryShards is the number of shards of the maps of the in-process stores, so concurrent
+// verifications of different accounts rarely contend on the same lock.
+const memoryShards = 64
+
+var shardSeed = maphash.MakeSeed()
+
+// shardIndex returns the shard of the key.
+func shardIndex(key string) int {
+	return int(maphash.String(shardSeed, key) % memoryShards)
+}
+
+// wheelSlots is the number of one second slots of expiryWheel, so its span covers the lifetime
+// of most proofs, while the entries expiring later are carried over each rotation.
+const wheelSlots = 512
+
+// expiryWheel is a timing wheel of the expirations of the keys of a shard, so expired keys are
+// purged in time proportional to the keys expiring, rather than to the keys of the shard. It
+// is guarded by the lock of its shard.
+type expiryWheel[K comparable] struct {
+	slots [wheelSlots][]wheelEntry[K]
+
+	// next is the unix time of the next slot to expire
+	next int64
+}
+
+type wheelEntry[K comparable] struct {
+	key K
+
+	// due is the first second the key has expired at, ie. the second after its expiry
+	due int64
+}
+
+// add schedules the expiration of the key at exp.
+func (w *expiryWheel[K]) add(key K, exp time.Time) {
+	due := exp.Unix() + 1
+	if w.next == 0 {
+		w.next = time.Now().Unix()
+	}
+	slot := max(due, w.next)
+	w.slots[slot%wheelSlots] = append(w.slots[slot%wheelSlots], wheelEntry[K]{key: key, due: due})
+}
+
+// advance expires the keys of the slots up to now, calling expire with each key and the unix
+// time it was scheduled to expire at, so the shard can check the key hasn't been rescheduled
+// since.
+func (w *expiryWheel[K]) advance(now time.Time, expire func(key K, exp int64)) {
+	t := now.Unix()
+	if w.next == 0 || w.next > t {
+		return
+	}
+	end := t
+	if end-w.next >= wheelSlots {
+		// every slot is due after a long idle period
+		end = w.next + wheelSlots - 1
+	}
+	for tick := w.next; tick <= end; tick++ {
+		slot := &w.slots[tick%wheelSlots]
+		entries := *slot
+		*slot = nil
+		for _, e := range entries {
+			if e.due <= t {
+				expire(e.key, e.due-1)
+			} else {
+				// entries expiring beyond the span of the wheel are carried over
+				w.slots[e.due%wheelSlots] = append(w.slots[e.due%wheelSlots], e)
+			}
+		}
+	}
+	w.next = t + 1
+}
@@ -0,0 +1,119 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryWheel(t *testing.T) {
+	var wheel expiryWheel[string]
+	now := time.Unix(1700000000, 0)
+	wheel.next = now.Unix()
+
+	wheel.add("soon", now.Add(2*time.Second))
+	wheel.add("later", now.Add(time.Hour))
+	wheel.add("past", now.Add(-time.Minute))
+
+	var expired []string
+	expire := func(key string, exp int64) { expired = append(expired, key) }
+
+	// keys expire the second after their expiry
+	wheel.advance(now, expire)
+	require.Equal(t, []string{"past"}, expired)
+	wheel.advance(now.Add(2*time.Second), expire)
+	require.Equal(t, []string{"past"}, expired)
+	wheel.advance(now.Add(3*time.Second), expire)
+	require.Equal(t, []string{"past", "soon"}, expired)
+
+	// keys expiring beyond the span of the wheel are carried over each rotation
+	wheel.advance(now.Add(30*time.Minute), expire)
+	require.Equal(t, []string{"past", "soon"}, expired)
+	wheel.advance(now.Add(time.Hour+time.Second), expire)
+	require.Equal(t, []string{"past", "soon", "later"}, expired)
+}
+
+func TestMemoryNonceStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryNonceStore()
+	address := "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0"
+
+	require.NoError(t, store.Consume(ctx, address, 1, time.Now().Add(-time.Second)))
+	require.NoError(t, store.Consume(ctx, address, 2, time.Now().Add(time.Hour)))
+	require.ErrorIs(t, store.Consume(ctx, address, 2, time.Now().Add(time.Hour)), ErrNonceUsed)
+
+	// the expired nonce is purged by the following consumes of its shard, and can be consumed again
+	shard := &store.shards[shardIndex("0xe0c9828dee3411a28ccb4bb82a18d0aad24489e0")]
+	require.NoError(t, store.Consume(ctx, address, 3, time.Now().Add(time.Hour)))
+	require.Len(t, shard.nonces, 2)
+	require.NoError(t, store.Consume(ctx, address, 1, time.Now().Add(time.Hour)))
+}
+
+// BenchmarkMemoryNonceStore consumes nonces from concurrent goroutines, of a single hot
+// account, whose nonces share the lock of a shard, and of many accounts spread across shards.
+func BenchmarkMemoryNonceStore(b *testing.B) {
+	for _, accounts := range []int{1, 10000} {
+		b.Run(fmt.Sprintf("accounts=%d", accounts), func(b *testing.B) {
+			store := NewMemoryNonceStore()
+			addresses := benchmarkAddresses(accounts)
+			ctx := context.Background()
+			exp := time.Now().Add(time.Hour)
+			var nonce atomic.Uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := nonce.Add(1)
+					if err := store.Consume(ctx, addresses[n%uint64(len(addresses))], n, exp); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMemoryRevocationStore checks the revocation of proofs from concurrent goroutines,
+// while revoking proof ids each 100 checks.
+func BenchmarkMemoryRevocationStore(b *testing.B) {
+	for _, accounts := range []int{1, 10000} {
+		b.Run(fmt.Sprintf("accounts=%d", accounts), func(b *testing.B) {
+			store := NewMemoryRevocationStore()
+			addresses := benchmarkAddresses(accounts)
+			ctx := context.Background()
+			exp := time.Now().Add(time.Hour)
+			var i atomic.Uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				proof := NewProof()
+				proof.Claims.IssuedAt = time.Now().Unix()
+				for pb.Next() {
+					n := i.Add(1)
+					proof.Address = addresses[n%uint64(len(addresses))]
+					proof.Claims.ID = fmt.Sprintf("%d", n%1000)
+					if n%100 == 0 {
+						if err := store.RevokeID(ctx, fmt.Sprintf("revoked-%d", n), exp); err != nil {
+							b.Fatal(err)
+						}
+					}
+					if revoked, err := store.IsRevoked(ctx, proof); revoked || err != nil {
+						b.Fatal(revoked, err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func benchmarkAddresses(n int) []string {
+	addresses := make([]string, n)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("0x%040x", i+1)
+	}
+	return addresses
+}
@@ -0,0 +1,102 @@
+package ethauth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// secp256k1HalfNBytes is the big-endian encoding of half the order of the secp256k1 curve, the
+// largest s value of a low-S signature.
+var secp256k1HalfNBytes = [32]byte(secp256k1HalfN.FillBytes(make([]byte, 32)))
+
+// NormalizeSignature returns a copy of the 65-byte EOA signature with its V recovery id
+// normalized to 0 or 1. Wallets sign with V of 0 or 1, or of 27 or 28 as of eth_sign, which
+// are both accepted.
+//
+// Signatures whose s value is in the upper half of the curve order are rejected with
+// ErrMalleableSignature: (r, N-s) is a valid signature of the same digest as (r, s), so
+// accepting both would let anyone derive a second signature, and so a distinct proof string
+// and session id, from a proof. Signers of go-ethereum and ethers only emit low-S signatures.
+func NormalizeSignature(signature []byte) ([]byte, error) {
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("signature is not of proper length")
+	}
+	sig := make([]byte, 65)
+	copy(sig, signature)
+	if err := normalizeSignature(sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// normalizeSignature normalizes the V recovery id of the 65-byte signature in place, and
+// rejects high-S signatures, see NormalizeSignature.
+func normalizeSignature(sig []byte) error {
+	switch sig[64] {
+	case 0, 1:
+	case 27, 28:
+		sig[64] -= 27
+	default:
+		return fmt.Errorf("signature recovery id %d is not 0, 1, 27 or 28", sig[64])
+	}
+	// s is a fixed-width big-endian integer, so it compares as bytes
+	for i, b := range sig[32:64] {
+		if b != secp256k1HalfNBytes[i] {
+			if b > secp256k1HalfNBytes[i] {
+				return ErrMalleableSignature
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// recoverSignerAddress recovers the address of the signer of the digest from the 65-byte EOA
+// signature, once normalized, without modifying the signature.
+func recoverSignerAddress(digest, signature []byte) (common.Address, error) {
+	if len(digest) != 32 {
+		return common.Address{}, fmt.Errorf("digest is not of proper length (=32)")
+	}
+	sig, err := NormalizeSignature(signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pubkey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// isValidEOASignature reports whether the 65-byte signature is a signature of the digest by the
+// address, as ethwallet.IsValidEOASignature does for normalized signatures.
+func isValidEOASignature(address common.Address, digest, signature []byte) (bool, error) {
+	if len(digest) == 0 || len(signature) == 0 {
+		return false, fmt.Errorf("digest and signature must not be empty")
+	}
+	signer, err := recoverSignerAddress(digest, signature)
+	if err != nil {
+		return false, err
+	}
+	return signer == address, nil
+}
+
+// signatureKey returns the lower case hex of the signature of the proof with the V recovery id
+// of 65-byte signatures as 27 or 28, which wallets sign with, for the keys of the verification
+// cache, replay detection and sessions. The V of 0 or 1 is accepted alike, so a proof
+// re-encoded with the other V is keyed as the same proof, rather than dodging OnReplay and
+// the logout of its session.
+func signatureKey(signature string) string {
+	sig, err := ethcoder.HexDecode(signature)
+	if err != nil {
+		return strings.ToLower(signature)
+	}
+	if len(sig) == 65 && (sig[64] == 0 || sig[64] == 1) {
+		sig[64] += 27
+	}
+	return ethcoder.HexEncode(sig)
+}
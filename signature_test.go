@@ -0,0 +1,89 @@
+package ethauth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureNormalization(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	proof := signTestProof(t, wallet, claims)
+	sig, err := ethcoder.HexDecode(proof.Signature)
+	require.NoError(t, err)
+	recoveryID := sig[64] % 27
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	withSignature := func(sig []byte) *Proof {
+		p := *proof
+		p.Signature = ethcoder.HexEncode(sig)
+		return &p
+	}
+	withV := func(v byte) []byte {
+		return append(append([]byte{}, sig[:64]...), v)
+	}
+
+	// V is accepted as a recovery id, or offset by 27 as of eth_sign
+	for _, v := range []byte{recoveryID, recoveryID + 27} {
+		ok, err := ethAuth.ValidateProof(withSignature(withV(v)))
+		require.NoError(t, err, "v=%d", v)
+		require.True(t, ok)
+	}
+	// and both forms are keyed as the same proof, by the verification cache, replay detection
+	// and sessions
+	key, err := verificationCacheKey(withSignature(withV(recoveryID + 27)))
+	require.NoError(t, err)
+	otherKey, err := verificationCacheKey(withSignature(withV(recoveryID)))
+	require.NoError(t, err)
+	require.Equal(t, key, otherKey)
+	require.Equal(t, sessionID(withSignature(withV(recoveryID+27))), sessionID(withSignature(withV(recoveryID))))
+	for _, v := range []byte{2, 26, 29, 255} {
+		_, err := ethAuth.ValidateProof(withSignature(withV(v)))
+		require.ErrorIs(t, err, ErrInvalidSignature, "v=%d", v)
+	}
+
+	// the high-S form of the signature recovers the same signer, but is rejected
+	s := new(big.Int).SetBytes(sig[32:64])
+	malleable := withV((recoveryID ^ 1) + 27)
+	new(big.Int).Sub(secp256k1N, s).FillBytes(malleable[32:64])
+	_, err = ethAuth.ValidateProof(withSignature(malleable))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = ethAuth.VerifyProofSignature(context.Background(), withSignature(malleable))
+	require.ErrorIs(t, err, ErrMalleableSignature)
+	_, err = recoverSignerAddress(make([]byte, 32), malleable)
+	require.ErrorIs(t, err, ErrMalleableSignature)
+
+	normalized, err := NormalizeSignature(withV(recoveryID + 27))
+	require.NoError(t, err)
+	require.Equal(t, withV(recoveryID), normalized)
+	require.Equal(t, recoveryID+27, sig[64], "the signature isn't modified")
+	_, err = NormalizeSignature(sig[:64])
+	require.Error(t, err)
+
+	// personal_sign signatures of proofs-of-possession
+	message := []byte("ethauth")
+	personalSig, err := wallet.SignMessage(message)
+	require.NoError(t, err)
+	for _, v := range []byte{personalSig[64] % 27, personalSig[64]%27 + 27} {
+		personalSig[64] = v
+		ok, err := ValidateEOASignature(wallet.Address().Hex(), message, ethcoder.HexEncode(personalSig))
+		require.NoError(t, err, "v=%d", v)
+		require.True(t, ok)
+	}
+	s = new(big.Int).SetBytes(personalSig[32:64])
+	new(big.Int).Sub(secp256k1N, s).FillBytes(personalSig[32:64])
+	personalSig[64] = (personalSig[64] - 27) ^ 1 + 27
+	_, err = ValidateEOASignature(wallet.Address().Hex(), message, ethcoder.HexEncode(personalSig))
+	require.ErrorIs(t, err, ErrMalleableSignature)
+}
@@ -0,0 +1,95 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// Signer signs proof claims on behalf of an account address. Sign must return a 65-byte
+// [R || S || V] signature of the proof message digest.
+type Signer interface {
+	Address() common.Address
+	Sign(ctx context.Context, proof *Proof) ([]byte, error)
+}
+
+// SignProof signs the proof claims with the private key, and sets the proof address and signature.
+func SignProof(proof *Proof, privateKey *ecdsa.PrivateKey) error {
+	return SignProofWithSigner(context.Background(), proof, NewPrivateKeySigner(privateKey))
+}
+
+// SignProofWithSigner signs the proof claims with the signer, and sets the proof address and signature.
+func SignProofWithSigner(ctx context.Context, proof *Proof, signer Signer) error {
+	if proof == nil {
+		return fmt.Errorf("ethauth: proof is nil")
+	}
+	if signer == nil {
+		return fmt.Errorf("ethauth: signer is nil")
+	}
+
+	proof.Address = signer.Address().String()
+
+	sig, err := signer.Sign(ctx, proof)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to sign proof - %w", err)
+	}
+	if len(sig) != 65 {
+		return fmt.Errorf("ethauth: failed to sign proof, signature is not of proper length (=65)")
+	}
+
+	// normalize the recovery id to 27/28
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+
+	proof.Signature = ethcoder.HexEncode(sig)
+	return nil
+}
+
+// NewPrivateKeySigner returns a Signer for an ecdsa secp256k1 private key.
+func NewPrivateKeySigner(privateKey *ecdsa.PrivateKey) Signer {
+	return &privateKeySigner{privateKey: privateKey}
+}
+
+// NewWalletSigner returns a Signer for an ethkit wallet.
+func NewWalletSigner(wallet *ethwallet.Wallet) Signer {
+	return &privateKeySigner{privateKey: wallet.PrivateKey()}
+}
+
+// NewMnemonicSigner returns a Signer for the account of a BIP-39 mnemonic at the BIP-32
+// derivation path, or at the default path of m/44'/60'/0'/0/0 if none is given.
+func NewMnemonicSigner(mnemonic string, optPath ...string) (Signer, error) {
+	wallet, err := ethwallet.NewWalletFromMnemonic(mnemonic, optPath...)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: failed to derive wallet from mnemonic - %w", err)
+	}
+	return NewWalletSigner(wallet), nil
+}
+
+// NewMnemonicAccountSigner returns a Signer for the account of a BIP-39 mnemonic at the
+// derivation path m/44'/60'/0'/0/<accountIndex>, which is useful to sign proofs for
+// deterministic test accounts.
+func NewMnemonicAccountSigner(mnemonic string, accountIndex uint32) (Signer, error) {
+	return NewMnemonicSigner(mnemonic, fmt.Sprintf("m/44'/60'/0'/0/%d", accountIndex))
+}
+
+type privateKeySigner struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+func (s *privateKeySigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.privateKey.PublicKey)
+}
+
+func (s *privateKeySigner) Sign(ctx context.Context, proof *Proof) ([]byte, error) {
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(digest, s.privateKey)
+}
@@ -0,0 +1,299 @@
+package ethauth
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// ProofTypeSIWE is the `typ` claim of proofs whose signature is an EIP-191 personal_sign
+// of the Sign-In with Ethereum (EIP-4361) message derived from the proof claims.
+const ProofTypeSIWE = "siwe"
+
+const (
+	siweAppResourcePrefix   = "urn:ethauth:app:"
+	siweScopeResourcePrefix = "urn:ethauth:scope:"
+	siweCnfResourcePrefix   = "urn:ethauth:cnf:"
+	siweIPResourcePrefix    = "urn:ethauth:ip:"
+	siweUAResourcePrefix    = "urn:ethauth:ua:"
+	siweDomResourcePrefix   = "urn:ethauth:dom:"
+	siweMrkResourcePrefix   = "urn:ethauth:mrk:"
+	siweAudResourcePrefix   = "urn:ethauth:aud:"
+	siweSubResourcePrefix   = "urn:ethauth:sub:"
+	siweHtmResourcePrefix   = "urn:ethauth:htm:"
+	siweHtpResourcePrefix   = "urn:ethauth:htp:"
+	siweBdhResourcePrefix   = "urn:ethauth:bdh:"
+	siweMessageHeader       = " wants you to sign in with your Ethereum account:"
+)
+
+// SIWEMessage is a Sign-In with Ethereum (EIP-4361) message.
+type SIWEMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        uint64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	NotBefore      time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// SIWEMessageFromClaims maps the proof claims of the account address to a SIWE message.
+// The domain and URI are taken from the `ogn` claim, the app is carried as a resource
+// of the form `urn:ethauth:app:<app>`, as are the scopes and the other claims, ie.
+// `urn:ethauth:aud:<aud>`, and the `jti` claim as the request id. As the message is all the
+// proof signs, claims it can't carry, ie. custom claims, are rejected.
+func SIWEMessageFromClaims(address string, claims Claims) (*SIWEMessage, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("ethauth: invalid address")
+	}
+	m, err := signInMessageFromClaims(ProofTypeSIWE, common.HexToAddress(address).Hex(), claims)
+	if err != nil {
+		return nil, err
+	}
+	if m.ChainID == 0 {
+		m.ChainID = 1
+	}
+	return m, nil
+}
+
+// signInMessageFromClaims maps the proof claims of the account address to the sign-in message
+// of the proof type, see SIWEMessageFromClaims. The chain id is only set for claims bound to
+// a chain.
+func signInMessageFromClaims(typ, address string, claims Claims) (*SIWEMessage, error) {
+	if claims.Origin == "" {
+		return nil, fmt.Errorf("ethauth: %s proofs require the ogn claim", typ)
+	}
+	if claims.IssuedAt == 0 {
+		return nil, fmt.Errorf("ethauth: %s proofs require the iat claim", typ)
+	}
+	if claims.Custom != nil || len(claims.Unknown) > 0 {
+		return nil, fmt.Errorf("ethauth: %s proofs can't carry custom claims", typ)
+	}
+	if claims.ETHAuthVersion != "" && claims.ETHAuthVersion != ETHAuthVersion {
+		return nil, fmt.Errorf("ethauth: %s proofs require version %s claims", typ, ETHAuthVersion)
+	}
+	origin, err := url.Parse(claims.Origin)
+	if err != nil || origin.Host == "" {
+		return nil, fmt.Errorf("ethauth: %s proofs require the ogn claim to be an origin url", typ)
+	}
+
+	m := &SIWEMessage{
+		Domain:    origin.Host,
+		Address:   address,
+		Statement: claims.Statement,
+		URI:       claims.Origin,
+		Version:   "1",
+		ChainID:   claims.ChainID,
+		Nonce:     fmt.Sprintf("%08d", claims.Nonce),
+		IssuedAt:  time.Unix(claims.IssuedAt, 0).UTC(),
+		RequestID: claims.ID,
+	}
+	if claims.ExpiresAt != 0 {
+		m.ExpirationTime = time.Unix(claims.ExpiresAt, 0).UTC()
+	}
+	if claims.App != "" {
+		m.Resources = []string{siweAppResourcePrefix + claims.App}
+	}
+	for _, scope := range claims.Scope {
+		m.Resources = append(m.Resources, siweScopeResourcePrefix+scope)
+	}
+	for _, r := range []struct{ prefix, value string }{
+		{siweCnfResourcePrefix, claims.Confirmation},
+		{siweIPResourcePrefix, claims.ClientIP},
+		{siweUAResourcePrefix, claims.UserAgent},
+		{siweDomResourcePrefix, claims.Host},
+		{siweMrkResourcePrefix, claims.MerkleRoot},
+		{siweAudResourcePrefix, claims.Audience},
+		{siweSubResourcePrefix, claims.Subject},
+		{siweHtmResourcePrefix, claims.RequestMethod},
+		{siweHtpResourcePrefix, claims.RequestPath},
+		{siweBdhResourcePrefix, claims.RequestBodyHash},
+	} {
+		if r.value != "" {
+			m.Resources = append(m.Resources, r.prefix+r.value)
+		}
+	}
+	for _, resource := range m.Resources {
+		// each resource is a line of the message
+		if strings.ContainsAny(resource, "\r\n") {
+			return nil, fmt.Errorf("ethauth: %s proofs can't carry claims with line breaks", typ)
+		}
+	}
+	return m, nil
+}
+
+// Claims maps the SIWE message back to proof claims, see SIWEMessageFromClaims.
+func (m *SIWEMessage) Claims() (Claims, error) {
+	nonce, err := strconv.ParseUint(m.Nonce, 10, 64)
+	if err != nil {
+		return Claims{}, fmt.Errorf("ethauth: siwe nonce must be numeric")
+	}
+
+	claims := Claims{
+		Type:           ProofTypeSIWE,
+		Origin:         m.URI,
+		ChainID:        m.ChainID,
+		Nonce:          nonce,
+		IssuedAt:       m.IssuedAt.Unix(),
+		ID:             m.RequestID,
+		ETHAuthVersion: ETHAuthVersion,
+		Statement:      m.Statement,
+	}
+	if !m.ExpirationTime.IsZero() {
+		claims.ExpiresAt = m.ExpirationTime.Unix()
+	}
+	for _, resource := range m.Resources {
+		if strings.HasPrefix(resource, siweAppResourcePrefix) {
+			claims.App = strings.TrimPrefix(resource, siweAppResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweScopeResourcePrefix) {
+			claims.Scope = append(claims.Scope, strings.TrimPrefix(resource, siweScopeResourcePrefix))
+		}
+		if strings.HasPrefix(resource, siweCnfResourcePrefix) {
+			claims.Confirmation = strings.TrimPrefix(resource, siweCnfResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweIPResourcePrefix) {
+			claims.ClientIP = strings.TrimPrefix(resource, siweIPResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweUAResourcePrefix) {
+			claims.UserAgent = strings.TrimPrefix(resource, siweUAResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweDomResourcePrefix) {
+			claims.Host = strings.TrimPrefix(resource, siweDomResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweMrkResourcePrefix) {
+			claims.MerkleRoot = strings.TrimPrefix(resource, siweMrkResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweAudResourcePrefix) {
+			claims.Audience = strings.TrimPrefix(resource, siweAudResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweSubResourcePrefix) {
+			claims.Subject = strings.TrimPrefix(resource, siweSubResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweHtmResourcePrefix) {
+			claims.RequestMethod = strings.TrimPrefix(resource, siweHtmResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweHtpResourcePrefix) {
+			claims.RequestPath = strings.TrimPrefix(resource, siweHtpResourcePrefix)
+		}
+		if strings.HasPrefix(resource, siweBdhResourcePrefix) {
+			claims.RequestBodyHash = strings.TrimPrefix(resource, siweBdhResourcePrefix)
+		}
+	}
+	return claims, nil
+}
+
+// String returns the EIP-4361 text representation of the message, which is signed
+// with personal_sign.
+func (m *SIWEMessage) String() string {
+	return m.format(siweMessageHeader)
+}
+
+// format returns the text representation of the message with the header, where the chain id
+// is omitted if zero.
+func (m *SIWEMessage) format(header string) string {
+	var sb strings.Builder
+	sb.WriteString(m.Domain + header + "\n")
+	sb.WriteString(m.Address + "\n\n")
+	if m.Statement != "" {
+		sb.WriteString(m.Statement + "\n\n")
+	}
+	sb.WriteString("URI: " + m.URI + "\n")
+	sb.WriteString("Version: " + m.Version + "\n")
+	if m.ChainID != 0 {
+		sb.WriteString("Chain ID: " + strconv.FormatUint(m.ChainID, 10) + "\n")
+	}
+	sb.WriteString("Nonce: " + m.Nonce + "\n")
+	sb.WriteString("Issued At: " + m.IssuedAt.UTC().Format(time.RFC3339))
+	if !m.ExpirationTime.IsZero() {
+		sb.WriteString("\nExpiration Time: " + m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if !m.NotBefore.IsZero() {
+		sb.WriteString("\nNot Before: " + m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		sb.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		sb.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			sb.WriteString("\n- " + resource)
+		}
+	}
+	return sb.String()
+}
+
+// ParseSIWEMessage parses the EIP-4361 text representation of a SIWE message.
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 8 || !strings.HasSuffix(lines[0], siweMessageHeader) {
+		return nil, fmt.Errorf("ethauth: invalid siwe message")
+	}
+
+	m := &SIWEMessage{
+		Domain:  strings.TrimSuffix(lines[0], siweMessageHeader),
+		Address: lines[1],
+	}
+	if !common.IsHexAddress(m.Address) || lines[2] != "" {
+		return nil, fmt.Errorf("ethauth: invalid siwe message, bad address")
+	}
+
+	i := 3
+	if !strings.HasPrefix(lines[i], "URI: ") {
+		m.Statement = lines[i]
+		if i+1 >= len(lines) || lines[i+1] != "" {
+			return nil, fmt.Errorf("ethauth: invalid siwe message, bad statement")
+		}
+		i += 2
+	}
+
+	var err error
+	for ; i < len(lines); i++ {
+		key, value, _ := strings.Cut(lines[i], ": ")
+		switch key {
+		case "URI":
+			m.URI = value
+		case "Version":
+			m.Version = value
+		case "Chain ID":
+			m.ChainID, err = strconv.ParseUint(value, 10, 64)
+		case "Nonce":
+			m.Nonce = value
+		case "Issued At":
+			m.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			m.ExpirationTime, err = time.Parse(time.RFC3339, value)
+		case "Not Before":
+			m.NotBefore, err = time.Parse(time.RFC3339, value)
+		case "Request ID":
+			m.RequestID = value
+		case "Resources:":
+			for _, resource := range lines[i+1:] {
+				if !strings.HasPrefix(resource, "- ") {
+					return nil, fmt.Errorf("ethauth: invalid siwe message, bad resources")
+				}
+				m.Resources = append(m.Resources, strings.TrimPrefix(resource, "- "))
+			}
+			i = len(lines)
+		default:
+			return nil, fmt.Errorf("ethauth: invalid siwe message, unexpected line %q", lines[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ethauth: invalid siwe message, bad %s - %w", strings.ToLower(key), err)
+		}
+	}
+
+	if m.URI == "" || m.Version == "" || m.ChainID == 0 || m.Nonce == "" || m.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("ethauth: invalid siwe message, missing required fields")
+	}
+	return m, nil
+}
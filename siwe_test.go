@@ -0,0 +1,190 @@
+package ethauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSIWEProof(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	proof := NewProof()
+	proof.Claims = Claims{
+		App:            "ETHAuthTest",
+		Type:           ProofTypeSIWE,
+		Origin:         "https://app.example.com",
+		Nonce:          42,
+		ChainID:        1,
+		ID:             "proof-1",
+		ETHAuthVersion: ETHAuthVersion,
+	}
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+
+	// the siwe message is signed with personal_sign
+	proof.Address = wallet.Address().String()
+	message, err := proof.Message()
+	require.NoError(t, err)
+	sig, err := wallet.SignMessage(message)
+	require.NoError(t, err)
+
+	signed := NewProof()
+	signed.Claims = proof.Claims
+	require.NoError(t, SignProof(signed, wallet.PrivateKey()))
+	require.Equal(t, ethcoder.HexEncode(sig), signed.Signature)
+
+	proofString, err := ethAuth.EncodeProof(signed)
+	require.NoError(t, err)
+	ok, decoded, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// siwe message round trip
+	siweMessage, err := ParseSIWEMessage(string(message))
+	require.NoError(t, err)
+	require.Equal(t, "app.example.com", siweMessage.Domain)
+	require.Equal(t, wallet.Address().Hex(), siweMessage.Address)
+	require.Equal(t, string(message), siweMessage.String())
+
+	claims, err := siweMessage.Claims()
+	require.NoError(t, err)
+	require.Equal(t, decoded.Claims, claims)
+}
+
+func TestEIP191Proof(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	proof := NewProof()
+	proof.Claims.App = "ETHAuthTest"
+	proof.Claims.Type = ProofTypeEIP191
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(5 * time.Minute)
+
+	// the claims json is signed with personal_sign
+	claimsJSON, err := json.Marshal(proof.Claims)
+	require.NoError(t, err)
+	sig, err := wallet.SignMessage(claimsJSON)
+	require.NoError(t, err)
+	proof.Address = wallet.Address().String()
+	proof.Signature = ethcoder.HexEncode(sig)
+
+	proofString, err := ethAuth.EncodeProof(proof)
+	require.NoError(t, err)
+	ok, _, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// an EIP712 signature is rejected for the same claims
+	typedSig, err := wallet.SignData(mustClaimsMessage(t, proof.Claims))
+	require.NoError(t, err)
+	proof.Signature = ethcoder.HexEncode(typedSig)
+	_, err = ethAuth.EncodeProof(proof)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func mustClaimsMessage(t *testing.T, claims Claims) []byte {
+	message, err := claims.Message()
+	require.NoError(t, err)
+	return message
+}
+
+func TestSignInMessageClaims(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	_, solanaKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.RegisterValidator(ValidateSIWSProof)
+
+	claims := Claims{
+		App:             "ETHAuthTest",
+		Origin:          "https://app.example.com",
+		Nonce:           42,
+		ChainID:         1,
+		Audience:        "api.other.com",
+		Subject:         "alice",
+		RequestMethod:   "POST",
+		RequestPath:     "/orders",
+		RequestBodyHash: "0x" + strings.Repeat("ab", 32),
+		ClientIP:        "203.0.113.7",
+		Host:            "api.other.com",
+		ETHAuthVersion:  ETHAuthVersion,
+	}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+
+	tampers := map[string]func(c *Claims){
+		"aud": func(c *Claims) { c.Audience = "api.victim.com" },
+		"sub": func(c *Claims) { c.Subject = "admin" },
+		"htm": func(c *Claims) { c.RequestMethod = "DELETE" },
+		"htp": func(c *Claims) { c.RequestPath = "/admin" },
+		"bdh": func(c *Claims) { c.RequestBodyHash = "0x" + strings.Repeat("cd", 32) },
+		"ip":  func(c *Claims) { c.ClientIP = "198.51.100.1" },
+		"dom": func(c *Claims) { c.Host = "api.victim.com" },
+	}
+
+	for _, typ := range []string{ProofTypeSIWE, ProofTypeSIWS} {
+		sign := func(claims Claims) *Proof {
+			proof := NewProof()
+			proof.Claims = claims
+			if typ == ProofTypeSIWS {
+				require.NoError(t, SignSIWSProof(proof, solanaKey))
+			} else {
+				proof.Claims.Type = ProofTypeSIWE
+				require.NoError(t, SignProof(proof, wallet.PrivateKey()))
+			}
+			return proof
+		}
+
+		proof := sign(claims)
+		require.True(t, ethAuth.ValidateProofSignature(proof), typ)
+
+		// each claim is signed by the message
+		for name, tamper := range tampers {
+			tampered := *proof
+			tamper(&tampered.Claims)
+			require.False(t, ethAuth.ValidateProofSignature(&tampered), "%s %s", typ, name)
+		}
+
+		// claims the message can't carry are rejected
+		withCustom := *proof
+		withCustom.Claims.Unknown = map[string]json.RawMessage{"role": json.RawMessage(`"admin"`)}
+		require.False(t, ethAuth.ValidateProofSignature(&withCustom), typ)
+		_, err = withCustom.Message()
+		require.Error(t, err)
+
+		injected := claims
+		injected.Subject = "alice\n- urn:ethauth:scope:admin"
+		_, err = signInMessageFromClaims(typ, proof.Address, injected)
+		require.Error(t, err)
+	}
+
+	// the message maps back to the claims
+	siweClaims := claims
+	siweClaims.Type = ProofTypeSIWE
+	m, err := SIWEMessageFromClaims(wallet.Address().Hex(), siweClaims)
+	require.NoError(t, err)
+	parsed, err := ParseSIWEMessage(m.String())
+	require.NoError(t, err)
+	mapped, err := parsed.Claims()
+	require.NoError(t, err)
+	require.Equal(t, siweClaims, mapped)
+}
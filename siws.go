@@ -0,0 +1,140 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+)
+
+// ProofTypeSIWS is the `typ` claim of proofs of Solana accounts, whose address is the base58
+// ed25519 public key of the account, and whose signature is the ed25519 signature of the
+// Sign-In With Solana message derived from the proof claims, as for SIWE proofs.
+const ProofTypeSIWS = "siws"
+
+const siwsMessageHeader = " wants you to sign in with your Solana account:"
+
+// SIWSMessageFromClaims maps the proof claims of the Solana account address to a Sign-In With
+// Solana message, see SIWEMessageFromClaims. The chain id is only part of the message of
+// claims bound to a chain.
+func SIWSMessageFromClaims(address string, claims Claims) (*SIWEMessage, error) {
+	if err := validSolanaAddress(address); err != nil {
+		return nil, err
+	}
+	return signInMessageFromClaims(ProofTypeSIWS, address, claims)
+}
+
+// siwsMessage returns the Sign-In With Solana message signed by a SIWS proof.
+func siwsMessage(proof *Proof) ([]byte, error) {
+	m, err := SIWSMessageFromClaims(proof.Address, proof.Claims)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(m.format(siwsMessageHeader)), nil
+}
+
+// SignSIWSProof signs the proof claims with the ed25519 private key of a Solana account, and
+// sets the proof type, address and signature.
+func SignSIWSProof(proof *Proof, privateKey ed25519.PrivateKey) error {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return fmt.Errorf("ethauth: invalid ed25519 private key")
+	}
+	proof.Claims.Type = ProofTypeSIWS
+	proof.Address = base58Encode(privateKey.Public().(ed25519.PublicKey))
+
+	message, err := siwsMessage(proof)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to sign proof - %w", err)
+	}
+	proof.Signature = ethcoder.HexEncode(ed25519.Sign(privateKey, message))
+	return nil
+}
+
+// ValidateSIWSProof verifies the ed25519 signature of SIWS proofs of Solana accounts. It is not
+// one of the default validators, register it with ETHAuth.RegisterValidator to accept the
+// proofs of Solana accounts alongside those of Ethereum accounts.
+func ValidateSIWSProof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+	if proof.Claims.Type != ProofTypeSIWS {
+		return false, "", fmt.Errorf("ValidateSIWSProof failed. proof is not a %s proof", ProofTypeSIWS)
+	}
+	publicKey, err := base58Decode(proof.Address)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return false, "", fmt.Errorf("ValidateSIWSProof failed. address is not a valid Solana address")
+	}
+	signature, err := ethcoder.HexDecode(proof.Signature)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateSIWSProof failed. HexDecode of proof.signature failed - %w", err)
+	}
+	message, err := siwsMessage(proof)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateSIWSProof failed. %w", err)
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(publicKey, message, signature) {
+		return false, "", fmt.Errorf("ValidateSIWSProof failed. invalid signature")
+	}
+	return true, proof.Address, nil
+}
+
+// validSolanaAddress returns an error if the address isn't the canonical base58 encoding of
+// an ed25519 public key.
+func validSolanaAddress(address string) error {
+	publicKey, err := base58Decode(address)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize || base58Encode(publicKey) != address {
+		return fmt.Errorf("ethauth: address is not a valid Solana address")
+	}
+	return nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode returns the bitcoin alphabet base58 encoding of the data, as Solana addresses
+// are encoded.
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// base58Decode decodes a bitcoin alphabet base58 string, see base58Encode.
+func base58Decode(s string) ([]byte, error) {
+	if s == "" || len(s) > 64 {
+		return nil, fmt.Errorf("ethauth: invalid base58 string")
+	}
+	n, radix := new(big.Int), big.NewInt(58)
+	zeros := 0
+	for i := 0; i < len(s); i++ {
+		d := -1
+		for j := 0; j < len(base58Alphabet); j++ {
+			if base58Alphabet[j] == s[i] {
+				d = j
+				break
+			}
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("ethauth: invalid base58 string")
+		}
+		if d == 0 && n.Sign() == 0 {
+			zeros++
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
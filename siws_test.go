@@ -0,0 +1,117 @@
+package ethauth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSIWSProof(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	newProof := func() *Proof {
+		proof := NewProof()
+		proof.Claims = Claims{App: "ETHAuthTest", Origin: "https://app.example.com", Nonce: 42, ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		require.NoError(t, SignSIWSProof(proof, privateKey))
+		return proof
+	}
+	proof := newProof()
+	require.Equal(t, ProofTypeSIWS, proof.Claims.Type)
+
+	// the signed message is the sign-in message of the Solana account
+	message, err := proof.Message()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(message), "app.example.com wants you to sign in with your Solana account:\n"+proof.Address+"\n\nURI: https://app.example.com\nVersion: 1\nNonce: 00000042\n"))
+	signature, err := ethcoder.HexDecode(proof.Signature)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(privateKey.Public().(ed25519.PublicKey), message, signature))
+
+	// the default validators don't accept the proofs of Solana accounts
+	_, err = ethAuth.EncodeProof(newProof())
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	ethAuth.RegisterValidator(ValidateSIWSProof)
+	ethAuth.ConfigCache(NewVerificationCache(16, time.Minute))
+	proofString, err := ethAuth.EncodeProof(newProof())
+	require.NoError(t, err)
+	require.Contains(t, proofString, "."+proof.Address+".")
+
+	ok, decoded, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, proof.Address, decoded.Address)
+
+	// the address is case sensitive, also for cached verifications
+	_, _, err = ethAuth.DecodeProof(strings.Replace(proofString, proof.Address, flipCase(proof.Address), 1))
+	require.Error(t, err)
+	flipped := *decoded
+	flipped.Address = flipCase(decoded.Address)
+	require.NotEqual(t, decoded.addressKey(), flipped.addressKey())
+
+	// the signature is over the claims
+	tampered := newProof()
+	tampered.Claims.Scope = Scopes{"admin"}
+	_, err = ethAuth.EncodeProof(tampered)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// Solana proofs have no CBOR encoding or EIP712 digest
+	_, err = newProof().EncodeCBOR()
+	require.Error(t, err)
+
+	_, err = SIWSMessageFromClaims("0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", proof.Claims)
+	require.Error(t, err)
+}
+
+func TestBase58(t *testing.T) {
+	require.Equal(t, "11111111111111111111111111111111", base58Encode(make([]byte, 32)))
+	require.NoError(t, validSolanaAddress("11111111111111111111111111111111"))
+	require.NoError(t, validSolanaAddress("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"))
+
+	for i := 0; i < 100; i++ {
+		data := make([]byte, 32)
+		_, err := rand.Read(data[i%4:])
+		require.NoError(t, err)
+		decoded, err := base58Decode(base58Encode(data))
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(data, decoded))
+	}
+
+	for _, s := range []string{"", "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5D", "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA0", "111111111111111111111111111111111"} {
+		require.Error(t, validSolanaAddress(s), s)
+	}
+}
+
+func TestRegisterProofScheme(t *testing.T) {
+	require.Error(t, RegisterProofScheme(ProofScheme{}))
+	require.Error(t, RegisterProofScheme(ProofScheme{Type: ProofTypeSIWE, ValidAddress: validSolanaAddress, Message: siwsMessage}))
+	require.Error(t, RegisterProofScheme(ProofScheme{Type: ProofTypeSIWS, ValidAddress: validSolanaAddress, Message: siwsMessage}))
+	require.Error(t, RegisterProofScheme(ProofScheme{Type: "sui"}))
+
+	_, ok := LookupProofScheme(ProofTypeSIWS)
+	require.True(t, ok)
+	_, ok = LookupProofScheme(ProofTypeSIWE)
+	require.False(t, ok)
+}
+
+func flipCase(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+			b[i] ^= 0x20
+			return string(b)
+		}
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+package ethauth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+var (
+	// EntryPointV06 is the address of the ERC-4337 EntryPoint v0.6 contract.
+	EntryPointV06 = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+
+	// EntryPointV07 is the address of the ERC-4337 EntryPoint v0.7 contract.
+	EntryPointV07 = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+)
+
+// DefaultSmartAccountTTL is how long the deployments of smart accounts verified by
+// ConfigSmartAccounts are cached, unless configured otherwise.
+const DefaultSmartAccountTTL = 10 * time.Minute
+
+// accountDeployedTopic is the topic of the AccountDeployed event of the EntryPoint contracts,
+// emitted as the factory of the initCode of a user operation deploys its sender.
+var accountDeployedTopic = crypto.Keccak256Hash([]byte("AccountDeployed(bytes32,address,address,address)"))
+
+// erc1967ImplementationSlot is the storage slot of the implementation of ERC-1967 proxies.
+var erc1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+// eip1167Prefix and eip1167Suffix surround the implementation address in the code of EIP-1167
+// minimal proxies.
+var (
+	eip1167Prefix = ethcoder.MustHexDecode("0x363d3d373d3d3d363d73")
+	eip1167Suffix = ethcoder.MustHexDecode("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// SmartAccountPolicy restricts the accounts of proofs to the ERC-4337 smart accounts of known
+// factories and implementations, see ConfigSmartAccounts.
+type SmartAccountPolicy struct {
+	// Factories are the known account factories, or any factory when empty. Deployed accounts
+	// must have been deployed by one of them, per the AccountDeployed event of the EntryPoint,
+	// and counterfactual accounts must name one of them in their ERC-6492 signature.
+	Factories []common.Address
+
+	// Implementations are the known account implementations, or any implementation when empty,
+	// read from the ERC-1967 implementation slot or the EIP-1167 minimal proxy code of the
+	// account. Counterfactual accounts have no code yet, and are rejected unless Factories
+	// is set, as the known factories deploy known implementations.
+	Implementations []common.Address
+
+	// EntryPoints are the EntryPoint contracts whose AccountDeployed events are searched for
+	// the factory of an account, EntryPointV06 and EntryPointV07 when empty.
+	EntryPoints []common.Address
+
+	// FromBlock is the first block searched for AccountDeployed events, ie. the deployment
+	// block of the oldest factory, as providers may limit the block range of eth_getLogs.
+	FromBlock *big.Int
+
+	// AllowEOA accepts the proofs of EOA accounts as well.
+	AllowEOA bool
+
+	// TTL is how long the verified deployments are cached, DefaultSmartAccountTTL when zero.
+	TTL time.Duration
+}
+
+// ConfigSmartAccounts restricts the accounts of decoded proofs to the ERC-4337 smart accounts
+// of the policy, so only the users of our wallet authenticate. The signatures of smart
+// accounts are verified by the EIP-1271 isValidSignature method of the account, with the
+// ValidateContractAccountProof and ValidateERC6492Proof validators, then the factory and
+// implementation of the account are checked over the JSON-RPC provider of the instance.
+// Proofs of other accounts are rejected with ErrUnknownSmartAccount.
+func (w *ETHAuth) ConfigSmartAccounts(policy SmartAccountPolicy) {
+	if len(policy.EntryPoints) == 0 {
+		policy.EntryPoints = []common.Address{EntryPointV06, EntryPointV07}
+	}
+	if policy.TTL <= 0 {
+		policy.TTL = DefaultSmartAccountTTL
+	}
+	w.smartAccounts = &smartAccounts{policy: policy, known: newTTLCache[error](policy.TTL)}
+}
+
+type smartAccounts struct {
+	policy SmartAccountPolicy
+
+	// known caches the verdicts of deployed accounts
+	known *ttlCache[error]
+}
+
+// verifySmartAccount verifies that the account of the proof is a smart account of the policy.
+func (w *ETHAuth) verifySmartAccount(ctx context.Context, proof *Proof) error {
+	policy := w.smartAccounts.policy
+	address, err := proof.AddressBytes()
+	if err != nil {
+		return fmt.Errorf("%w, %v", ErrUnknownSmartAccount, err)
+	}
+	key := strings.ToLower(address.Hex())
+	if err, ok := w.smartAccounts.known.get(key); ok {
+		return err
+	}
+	if w.provider == nil {
+		return fmt.Errorf("ethauth: smart accounts require a JSON-RPC provider")
+	}
+	ctx = w.rpcContext(ctx)
+
+	rpcCtx, call := startRPCCall(ctx, "eth_getCode")
+	code, err := w.provider.CodeAt(rpcCtx, address, nil)
+	call.end(err)
+	if err != nil {
+		return fmt.Errorf("ethauth: unable to fetch account code - %w", err)
+	}
+
+	if len(code) == 0 {
+		// the account is an EOA, or a counterfactual account which is not deployed yet
+		signature, err := ethcoder.HexDecode(proof.Signature)
+		if err != nil || !IsERC6492Signature(signature) {
+			if policy.AllowEOA {
+				return nil
+			}
+			return fmt.Errorf("%w, account is not a smart account", ErrUnknownSmartAccount)
+		}
+		factory, _, _, err := DecodeERC6492Signature(signature)
+		if err != nil {
+			return fmt.Errorf("%w, %v", ErrUnknownSmartAccount, err)
+		}
+		if len(policy.Factories) == 0 && len(policy.Implementations) > 0 {
+			return fmt.Errorf("%w, implementation of counterfactual account can't be verified", ErrUnknownSmartAccount)
+		}
+		if len(policy.Factories) > 0 && !slices.Contains(policy.Factories, factory) {
+			return fmt.Errorf("%w, factory %s is not known", ErrUnknownSmartAccount, factory.Hex())
+		}
+		return nil
+	}
+
+	err = w.verifyDeployedSmartAccount(ctx, address, code)
+	if err != nil && !errors.Is(err, ErrUnknownSmartAccount) {
+		// failed calls are retried by the next verification
+		return err
+	}
+	w.smartAccounts.known.add(key, err)
+	return err
+}
+
+// verifyDeployedSmartAccount verifies the implementation and factory of a deployed account.
+func (w *ETHAuth) verifyDeployedSmartAccount(ctx context.Context, address common.Address, code []byte) error {
+	policy := w.smartAccounts.policy
+
+	if len(policy.Implementations) > 0 {
+		rpcCtx, call := startRPCCall(ctx, "eth_getStorageAt")
+		slot, err := w.provider.StorageAt(rpcCtx, address, erc1967ImplementationSlot, nil)
+		call.end(err)
+		if err != nil {
+			return fmt.Errorf("ethauth: unable to fetch account implementation - %w", err)
+		}
+		implementation := common.BytesToAddress(slot)
+		if implementation == (common.Address{}) && len(code) == len(eip1167Prefix)+20+len(eip1167Suffix) &&
+			bytes.HasPrefix(code, eip1167Prefix) && bytes.HasSuffix(code, eip1167Suffix) {
+			implementation = common.BytesToAddress(code[len(eip1167Prefix) : len(eip1167Prefix)+20])
+		}
+		if !slices.Contains(policy.Implementations, implementation) {
+			return fmt.Errorf("%w, implementation %s is not known", ErrUnknownSmartAccount, implementation.Hex())
+		}
+	}
+
+	if len(policy.Factories) > 0 {
+		sender := common.BytesToHash(address.Bytes())
+		rpcCtx, call := startRPCCall(ctx, "eth_getLogs")
+		logs, err := w.provider.FilterLogs(rpcCtx, ethereum.FilterQuery{
+			FromBlock: policy.FromBlock,
+			Addresses: policy.EntryPoints,
+			Topics:    [][]common.Hash{{accountDeployedTopic}, nil, {sender}},
+		})
+		call.end(err)
+		if err != nil {
+			return fmt.Errorf("ethauth: unable to fetch account deployment - %w", err)
+		}
+		for _, log := range logs {
+			if !slices.Contains(policy.EntryPoints, log.Address) || len(log.Topics) < 3 || log.Topics[0] != accountDeployedTopic || log.Topics[2] != sender {
+				continue
+			}
+			// the data of AccountDeployed events is the factory and paymaster of the deployment
+			if len(log.Data) >= 32 && slices.Contains(policy.Factories, common.BytesToAddress(log.Data[:32])) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w, account is not deployed by a known factory", ErrUnknownSmartAccount)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// newSmartAccountTestServer returns a JSON-RPC server of the deployed accounts, each of their
+// code, ERC-1967 implementation slot and AccountDeployed factory.
+func newSmartAccountTestServer(t *testing.T, accounts map[common.Address]testSmartAccount, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		calls.Add(1)
+
+		var result interface{}
+		switch req.Method {
+		case "eth_getCode", "eth_getStorageAt":
+			var address common.Address
+			require.NoError(t, json.Unmarshal(req.Params[0], &address))
+			account := accounts[address]
+			if req.Method == "eth_getCode" {
+				result = ethcoder.HexEncode(account.code)
+			} else {
+				result = common.BytesToHash(account.implementation.Bytes()).Hex()
+			}
+		case "eth_getLogs":
+			var filter struct {
+				Topics [][]common.Hash `json:"topics"`
+			}
+			require.NoError(t, json.Unmarshal(req.Params[0], &filter))
+			require.Equal(t, accountDeployedTopic, filter.Topics[0][0])
+			sender := filter.Topics[2][0]
+			logs := []map[string]interface{}{}
+			if account, ok := accounts[common.BytesToAddress(sender.Bytes())]; ok && account.factory != (common.Address{}) {
+				logs = append(logs, map[string]interface{}{
+					"address":          EntryPointV07,
+					"topics":           []common.Hash{accountDeployedTopic, common.HexToHash("0x01"), sender},
+					"data":             ethcoder.HexEncode(append(common.BytesToHash(account.factory.Bytes()).Bytes(), make([]byte, 32)...)),
+					"blockNumber":      "0x1",
+					"blockHash":        common.HexToHash("0x02"),
+					"transactionHash":  common.HexToHash("0x03"),
+					"transactionIndex": "0x0",
+					"logIndex":         "0x0",
+					"removed":          false,
+				})
+			}
+			result = logs
+		default:
+			t.Fatalf("unexpected method %s", req.Method)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
+type testSmartAccount struct {
+	code           []byte
+	implementation common.Address
+	factory        common.Address
+}
+
+func TestSmartAccounts(t *testing.T) {
+	factory := common.HexToAddress("0xfac0000000000000000000000000000000000001")
+	implementation := common.HexToAddress("0x1abc000000000000000000000000000000000001")
+	otherFactory := common.HexToAddress("0xfac0000000000000000000000000000000000002")
+	otherImplementation := common.HexToAddress("0x1abc000000000000000000000000000000000002")
+
+	proxy := common.HexToAddress("0xaaaa000000000000000000000000000000000001")
+	minimalProxy := common.HexToAddress("0xaaaa000000000000000000000000000000000002")
+	foreign := common.HexToAddress("0xaaaa000000000000000000000000000000000003")
+	upgraded := common.HexToAddress("0xaaaa000000000000000000000000000000000004")
+	counterfactual := common.HexToAddress("0xaaaa000000000000000000000000000000000005")
+	eoa := common.HexToAddress("0xaaaa000000000000000000000000000000000006")
+
+	minimalProxyCode := append(append(append([]byte{}, eip1167Prefix...), implementation.Bytes()...), eip1167Suffix...)
+	var calls atomic.Int32
+	server := newSmartAccountTestServer(t, map[common.Address]testSmartAccount{
+		proxy:        {code: []byte{0x60, 0x80}, implementation: implementation, factory: factory},
+		minimalProxy: {code: minimalProxyCode, factory: factory},
+		foreign:      {code: []byte{0x60, 0x80}, implementation: implementation, factory: otherFactory},
+		upgraded:     {code: []byte{0x60, 0x80}, implementation: otherImplementation, factory: factory},
+	}, &calls)
+	defer server.Close()
+
+	// the signatures are accepted, so only the accounts are verified
+	ethAuth, err := New(func(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+		return true, proof.Address, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(server.URL, 1))
+	ethAuth.ConfigSmartAccounts(SmartAccountPolicy{Factories: []common.Address{factory}, Implementations: []common.Address{implementation}})
+
+	decode := func(account common.Address, signature []byte) error {
+		proof := NewProof()
+		proof.Address = account.Hex()
+		proof.Claims = Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		proof.Signature = ethcoder.HexEncode(signature)
+		proofString, err := proof.Encode()
+		require.NoError(t, err)
+		_, _, err = ethAuth.DecodeProof(proofString)
+		return err
+	}
+	signature := make([]byte, 65)
+
+	// accounts of known factories and implementations pass, and are cached
+	require.NoError(t, decode(proxy, signature))
+	require.NoError(t, decode(minimalProxy, signature))
+	calls.Store(0)
+	require.NoError(t, decode(proxy, signature))
+	require.Zero(t, calls.Load())
+
+	// accounts of other factories, implementations or EOAs are rejected
+	err = decode(foreign, signature)
+	require.ErrorIs(t, err, ErrUnknownSmartAccount)
+	require.Equal(t, "unknown_smart_account", FailureReason(err))
+	require.ErrorIs(t, decode(upgraded, signature), ErrUnknownSmartAccount)
+	require.ErrorIs(t, decode(eoa, signature), ErrUnknownSmartAccount)
+
+	// counterfactual accounts must name a known factory in their ERC-6492 signature
+	erc6492Signature := func(factory common.Address) []byte {
+		wrapped, err := ethcoder.ABIPackArguments([]string{"address", "bytes", "bytes"}, []interface{}{factory, []byte{0x01}, signature})
+		require.NoError(t, err)
+		return append(wrapped, erc6492MagicSuffix...)
+	}
+	require.NoError(t, decode(counterfactual, erc6492Signature(factory)))
+	require.ErrorIs(t, decode(counterfactual, erc6492Signature(otherFactory)), ErrUnknownSmartAccount)
+
+	// EOAs may be allowed, and implementations or factories left unchecked
+	ethAuth.ConfigSmartAccounts(SmartAccountPolicy{Factories: []common.Address{factory, otherFactory}, AllowEOA: true})
+	require.NoError(t, decode(eoa, signature))
+	require.NoError(t, decode(foreign, signature))
+	require.NoError(t, decode(upgraded, signature))
+
+	ethAuth.ConfigSmartAccounts(SmartAccountPolicy{Implementations: []common.Address{implementation}})
+	require.NoError(t, decode(foreign, signature))
+	require.ErrorIs(t, decode(counterfactual, erc6492Signature(factory)), ErrUnknownSmartAccount)
+}
@@ -0,0 +1,34 @@
+package ethauth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxStatementLength is the maximum length in bytes of the `statement` claim.
+const MaxStatementLength = 256
+
+// WithStatement sets the `statement` claim, a human-readable line shown by the wallet when
+// signing the proof, ie. "Sign in to Example.app, this will not trigger a transaction". The
+// statement is part of the EIP712 Claims message, and the statement of the SIWE message of
+// `siwe` proofs.
+func WithStatement(statement string) IssueOption {
+	return func(claims *Claims) {
+		claims.Statement = statement
+	}
+}
+
+// validStatement validates that the `statement` claim is a single line of printable text, as
+// SIWE messages carry the statement on a line of its own.
+func (c Claims) validStatement() error {
+	if c.Statement == "" {
+		return nil
+	}
+	printable := utf8.ValidString(c.Statement) && !strings.ContainsFunc(c.Statement, func(r rune) bool { return !unicode.IsPrint(r) })
+	if len(c.Statement) > MaxStatementLength || !printable || strings.HasPrefix(c.Statement, "URI: ") {
+		return fmt.Errorf("claims: statement must be a line of at most %d bytes", MaxStatementLength)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package ethauth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatement(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	statement := "Sign in to Example.app, this will not trigger a transaction"
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithStatement(statement))
+	require.NoError(t, err)
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, statement, proof.Claims.Statement)
+
+	// the statement is part of the signed typed data
+	typedData, err := proof.MessageTypedData()
+	require.NoError(t, err)
+	require.Equal(t, statement, typedData.Message["statement"])
+	require.Contains(t, typedData.Types["Claims"], ethcoder.TypedDataArgument{Name: "statement", Type: "string"})
+	tampered := *proof
+	tampered.Claims.Statement = "Approve the transfer of all your tokens"
+	require.False(t, ethAuth.ValidateProofSignature(&tampered))
+	require.True(t, ethAuth.ValidateProofSignature(proof))
+
+	_, err = proof.Claims.VerifierClaims()
+	require.Error(t, err)
+
+	// the statement of siwe proofs is the statement of the siwe message
+	claims := Claims{App: "ETHAuthTest", Type: ProofTypeSIWE, Origin: "https://app.example.com", Nonce: 42, ETHAuthVersion: ETHAuthVersion, Statement: statement}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+	m, err := SIWEMessageFromClaims(wallet.Address().Hex(), claims)
+	require.NoError(t, err)
+	require.Contains(t, m.String(), "\n\n"+statement+"\n\n")
+	parsed, err := ParseSIWEMessage(m.String())
+	require.NoError(t, err)
+	parsedClaims, err := parsed.Claims()
+	require.NoError(t, err)
+	require.Equal(t, statement, parsedClaims.Statement)
+
+	for _, statement := range []string{
+		"Sign in\nURI: https://evil.example.com",
+		"URI: https://evil.example.com",
+		"Sign in\x00",
+		strings.Repeat("a", MaxStatementLength+1),
+	} {
+		claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion, Statement: statement}
+		claims.SetIssuedAtNow()
+		claims.SetExpiryIn(time.Hour)
+		require.Error(t, claims.Valid())
+		_, err := NewClaimsBuilder().App("ETHAuthTest").Statement(statement).Build()
+		require.Error(t, err)
+	}
+}
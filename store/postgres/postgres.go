@@ -0,0 +1,369 @@
+// Package postgres provides Postgres-backed implementations of the ethauth stores, so the auth
+// state of a cluster of API servers can be shared without running Redis. The stores use
+// database/sql, with the Postgres driver of your choice, ie. github.com/jackc/pgx/v5/stdlib or
+// github.com/lib/pq.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+)
+
+// DefaultTablePrefix is the prefix of the tables of the Postgres stores.
+const DefaultTablePrefix = "ethauth_"
+
+// Store implements ethauth.NonceStore, ethauth.RevocationStore and ethauth.SessionStore with
+// Postgres. Call Migrate to create or upgrade the schema of the store before using it, and
+// Sweep periodically to delete the expired nonces, revocations and sessions.
+type Store struct {
+	db     *sql.DB
+	prefix string
+	clock  func() time.Time
+}
+
+var (
+	_ ethauth.NonceStore      = &Store{}
+	_ ethauth.RevocationStore = &Store{}
+	_ ethauth.SessionStore    = &Store{}
+)
+
+var tablePrefixPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+func NewStore(db *sql.DB, optTablePrefix ...string) (*Store, error) {
+	prefix := DefaultTablePrefix
+	if len(optTablePrefix) > 0 {
+		prefix = optTablePrefix[0]
+	}
+	if !tablePrefixPattern.MatchString(prefix) {
+		return nil, fmt.Errorf("ethauth: invalid postgres table prefix %q", prefix)
+	}
+	return &Store{db: db, prefix: prefix, clock: time.Now}, nil
+}
+
+// ConfigClock sets the clock the nonces are consumed by, which
+// ETHAuth.ConfigNonceStore sets to the clock of the instance.
+func (s *Store) ConfigClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// migrations are the statements of the schema migrations of the store, in order, whose version
+// is their index plus one. Released migrations must never be changed, only appended to. Times
+// are unix seconds, and `{{prefix}}` is replaced by the table prefix.
+var migrations = [][]string{
+	{
+		`CREATE TABLE {{prefix}}nonces (
+			address text NOT NULL,
+			nonce text NOT NULL,
+			expires_at bigint NOT NULL,
+			PRIMARY KEY (address, nonce)
+		)`,
+		`CREATE INDEX {{prefix}}nonces_expires_at ON {{prefix}}nonces (expires_at)`,
+		`CREATE TABLE {{prefix}}revoked_ids (
+			id text PRIMARY KEY,
+			expires_at bigint NOT NULL
+		)`,
+		`CREATE INDEX {{prefix}}revoked_ids_expires_at ON {{prefix}}revoked_ids (expires_at)`,
+		`CREATE TABLE {{prefix}}revoked_addresses (
+			address text PRIMARY KEY
+		)`,
+		`CREATE TABLE {{prefix}}revoked_before (
+			address text PRIMARY KEY,
+			issued_before bigint NOT NULL
+		)`,
+		`CREATE TABLE {{prefix}}sessions (
+			address text NOT NULL,
+			id text NOT NULL,
+			app text NOT NULL,
+			issued_at bigint NOT NULL,
+			expires_at bigint NOT NULL,
+			last_seen bigint NOT NULL,
+			PRIMARY KEY (address, id)
+		)`,
+		`CREATE INDEX {{prefix}}sessions_expires_at ON {{prefix}}sessions (expires_at)`,
+		`CREATE TABLE {{prefix}}logged_out_sessions (
+			address text NOT NULL,
+			id text NOT NULL,
+			expires_at bigint NOT NULL,
+			PRIMARY KEY (address, id)
+		)`,
+		`CREATE INDEX {{prefix}}logged_out_sessions_expires_at ON {{prefix}}logged_out_sessions (expires_at)`,
+		`CREATE TABLE {{prefix}}session_logouts (
+			address text PRIMARY KEY,
+			logout_at bigint NOT NULL
+		)`,
+	},
+}
+
+// Migrate applies the schema migrations which have not been applied yet, recording the
+// applied versions in the schema_migrations table of the store. Concurrent migrations of the
+// same database, ie. by each server of a cluster on startup, are serialized by an advisory lock.
+func (s *Store) Migrate(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres migration failed - %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, s.table("schema_migrations")); err != nil {
+		return fmt.Errorf("ethauth: postgres migration failed - %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, s.query(`CREATE TABLE IF NOT EXISTS {{prefix}}schema_migrations (version integer PRIMARY KEY)`)); err != nil {
+		return fmt.Errorf("ethauth: postgres migration failed - %w", err)
+	}
+	var version int
+	err = tx.QueryRowContext(ctx, s.query(`SELECT COALESCE(MAX(version), 0) FROM {{prefix}}schema_migrations`)).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres migration failed - %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("ethauth: postgres schema version %d is newer than the store version %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		for _, statement := range migrations[i] {
+			if _, err := tx.ExecContext(ctx, s.query(statement)); err != nil {
+				return fmt.Errorf("ethauth: postgres migration %d failed - %w", i+1, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, s.query(`INSERT INTO {{prefix}}schema_migrations (version) VALUES ($1)`), i+1); err != nil {
+			return fmt.Errorf("ethauth: postgres migration %d failed - %w", i+1, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ethauth: postgres migration failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Consume(ctx context.Context, address string, nonce uint64, exp time.Time) error {
+	// exp is the last time the proof is accepted, so the nonces of proofs at the very end of
+	// their leeway are still recorded, as by the memory store, rather than rejected
+	now := s.clock()
+	if exp.Before(now.Add(time.Second)) {
+		exp = now.Add(time.Second)
+	}
+
+	// the nonce is consumed unless it has been consumed by a proof which is still accepted
+	res, err := s.db.ExecContext(ctx, s.query(`
+		INSERT INTO {{prefix}}nonces (address, nonce, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (address, nonce) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE {{prefix}}nonces.expires_at < $4`),
+		strings.ToLower(address), strconv.FormatUint(nonce, 10), exp.Unix(), now.Unix())
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres nonce store failed - %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres nonce store failed - %w", err)
+	}
+	if n == 0 {
+		return ethauth.ErrNonceUsed
+	}
+	return nil
+}
+
+func (s *Store) RevokeID(ctx context.Context, id string, exp time.Time) error {
+	if !exp.After(time.Now()) {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, s.query(`
+		INSERT INTO {{prefix}}revoked_ids (id, expires_at) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET expires_at = GREATEST({{prefix}}revoked_ids.expires_at, EXCLUDED.expires_at)`),
+		id, exp.Unix())
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres revocation store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RevokeAddress(ctx context.Context, address string) error {
+	_, err := s.db.ExecContext(ctx, s.query(`
+		INSERT INTO {{prefix}}revoked_addresses (address) VALUES ($1) ON CONFLICT (address) DO NOTHING`),
+		strings.ToLower(address))
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres revocation store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RevokeIssuedBefore(ctx context.Context, address string, t time.Time) error {
+	_, err := s.db.ExecContext(ctx, s.query(`
+		INSERT INTO {{prefix}}revoked_before (address, issued_before) VALUES ($1, $2)
+		ON CONFLICT (address) DO UPDATE SET issued_before = GREATEST({{prefix}}revoked_before.issued_before, EXCLUDED.issued_before)`),
+		issuedBeforeKey(address), t.Unix())
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres revocation store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) IsRevoked(ctx context.Context, proof *ethauth.Proof) (bool, error) {
+	address := strings.ToLower(proof.Account())
+
+	var revoked bool
+	err := s.db.QueryRowContext(ctx, s.query(`
+		SELECT
+			($1 <> '' AND EXISTS (SELECT 1 FROM {{prefix}}revoked_ids WHERE id = $1 AND expires_at >= $4))
+			OR EXISTS (SELECT 1 FROM {{prefix}}revoked_addresses WHERE address = $2)
+			OR EXISTS (SELECT 1 FROM {{prefix}}revoked_before WHERE address IN ($2, $3) AND $5 < issued_before)`),
+		proof.Claims.ID, address, issuedBeforeKey(""), time.Now().Unix(), proof.Claims.IssuedAt).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("ethauth: postgres revocation store failed - %w", err)
+	}
+	return revoked, nil
+}
+
+func (s *Store) Record(ctx context.Context, session ethauth.SessionInfo) error {
+	address := ethauth.NormalizeSessionAccount(session.Address)
+
+	// the session is recorded unless it has been logged out, in a single statement so a
+	// concurrent logout can't be undone
+	res, err := s.db.ExecContext(ctx, s.query(`
+		INSERT INTO {{prefix}}sessions (address, id, app, issued_at, expires_at, last_seen)
+		SELECT $1::text, $2::text, $3::text, $4::bigint, $5::bigint, $6::bigint
+		WHERE NOT EXISTS (SELECT 1 FROM {{prefix}}logged_out_sessions WHERE address = $1 AND id = $2)
+			AND NOT EXISTS (SELECT 1 FROM {{prefix}}session_logouts WHERE address = $1 AND $4 < logout_at)
+		ON CONFLICT (address, id) DO UPDATE SET last_seen = EXCLUDED.last_seen`),
+		address, session.ID, session.App, session.IssuedAt.Unix(), session.ExpiresAt.Unix(), session.LastSeen.Unix())
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	if n == 0 {
+		return ethauth.ErrProofRevoked
+	}
+	return nil
+}
+
+func (s *Store) Sessions(ctx context.Context, address string) ([]ethauth.SessionInfo, error) {
+	address = ethauth.NormalizeSessionAccount(address)
+
+	rows, err := s.db.QueryContext(ctx, s.query(`
+		SELECT id, app, issued_at, expires_at, last_seen FROM {{prefix}}sessions
+		WHERE address = $1 AND expires_at > $2
+		ORDER BY issued_at DESC, id`),
+		address, time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []ethauth.SessionInfo
+	for rows.Next() {
+		session := ethauth.SessionInfo{Address: address}
+		var issuedAt, expiresAt, lastSeen int64
+		if err := rows.Scan(&session.ID, &session.App, &issuedAt, &expiresAt, &lastSeen); err != nil {
+			return nil, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+		}
+		session.IssuedAt, session.ExpiresAt, session.LastSeen = time.Unix(issuedAt, 0), time.Unix(expiresAt, 0), time.Unix(lastSeen, 0)
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *Store) Logout(ctx context.Context, address, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.query(`
+		WITH deleted AS (
+			DELETE FROM {{prefix}}sessions WHERE address = $1 AND id = $2 RETURNING address, id, expires_at
+		)
+		INSERT INTO {{prefix}}logged_out_sessions (address, id, expires_at)
+		SELECT address, id, expires_at FROM deleted
+		ON CONFLICT (address, id) DO UPDATE SET expires_at = EXCLUDED.expires_at`),
+		ethauth.NormalizeSessionAccount(address), id)
+	if err != nil {
+		return false, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	return n > 0, nil
+}
+
+func (s *Store) LogoutAll(ctx context.Context, address string) (int, error) {
+	address = ethauth.NormalizeSessionAccount(address)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, s.query(`
+		WITH deleted AS (
+			DELETE FROM {{prefix}}sessions WHERE address = $1 RETURNING address, id, expires_at
+		)
+		INSERT INTO {{prefix}}logged_out_sessions (address, id, expires_at)
+		SELECT address, id, expires_at FROM deleted
+		ON CONFLICT (address, id) DO UPDATE SET expires_at = EXCLUDED.expires_at`),
+		address)
+	if err != nil {
+		return 0, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	_, err = tx.ExecContext(ctx, s.query(`
+		INSERT INTO {{prefix}}session_logouts (address, logout_at) VALUES ($1, $2)
+		ON CONFLICT (address) DO UPDATE SET logout_at = GREATEST({{prefix}}session_logouts.logout_at, EXCLUDED.logout_at)`),
+		address, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ethauth: postgres session store failed - %w", err)
+	}
+	return int(n), nil
+}
+
+// Sweep deletes the expired nonces, revoked proof ids, sessions and logged out sessions, and
+// returns the number of rows deleted. Call it periodically, ie. every few minutes.
+func (s *Store) Sweep(ctx context.Context) (int64, error) {
+	now := time.Now().Unix()
+	var deleted int64
+	var errs []error
+	for _, table := range []string{"nonces", "revoked_ids", "sessions", "logged_out_sessions"} {
+		res, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE expires_at < $1`, s.table(table)), now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			deleted += n
+		}
+	}
+	if len(errs) > 0 {
+		return deleted, fmt.Errorf("ethauth: postgres sweep failed - %w", errors.Join(errs...))
+	}
+	return deleted, nil
+}
+
+func (s *Store) table(name string) string {
+	return s.prefix + name
+}
+
+func (s *Store) query(q string) string {
+	return strings.ReplaceAll(q, "{{prefix}}", s.prefix)
+}
+
+func issuedBeforeKey(address string) string {
+	if address == "" {
+		return "*"
+	}
+	return strings.ToLower(address)
+}
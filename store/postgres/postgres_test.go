@@ -0,0 +1,327 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB is an in-memory database/sql driver interpreting the statements of the store, with
+// the semantics of their Postgres queries, so the store is tested without a Postgres server.
+type testDB struct {
+	mu             sync.Mutex
+	versions       []int64
+	nonces         map[[2]string]int64
+	revokedIDs     map[string]int64
+	revokedAddrs   map[string]bool
+	revokedBefore  map[string]int64
+	sessions       map[[2]string][]driver.Value
+	loggedOut      map[[2]string]int64
+	sessionLogouts map[string]int64
+}
+
+func newTestStore(t *testing.T) (*Store, *testDB) {
+	db := &testDB{
+		nonces:         map[[2]string]int64{},
+		revokedIDs:     map[string]int64{},
+		revokedAddrs:   map[string]bool{},
+		revokedBefore:  map[string]int64{},
+		sessions:       map[[2]string][]driver.Value{},
+		loggedOut:      map[[2]string]int64{},
+		sessionLogouts: map[string]int64{},
+	}
+	sqlDB := sql.OpenDB(db)
+	t.Cleanup(func() { sqlDB.Close() })
+	store, err := NewStore(sqlDB)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.Background()))
+	return store, db
+}
+
+func (db *testDB) Connect(context.Context) (driver.Conn, error) { return &testConn{db: db}, nil }
+func (db *testDB) Driver() driver.Driver                        { return nil }
+
+type testConn struct{ db *testDB }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepared statements are not supported")
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *testConn) Commit() error             { return nil }
+func (c *testConn) Rollback() error           { return nil }
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+func (c *testConn) ExecContext(ctx context.Context, query string, named []driver.NamedValue) (driver.Result, error) {
+	db := c.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query = whitespace.ReplaceAllString(query, " ")
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	key := func(i, j int) [2]string { return [2]string{args[i].(string), args[j].(string)} }
+
+	var n int64
+	switch {
+	case strings.Contains(query, "pg_advisory_xact_lock"), strings.HasPrefix(strings.TrimSpace(query), "CREATE"):
+	case strings.Contains(query, "INSERT INTO ethauth_schema_migrations"):
+		db.versions = append(db.versions, args[0].(int64))
+		n = 1
+	case strings.Contains(query, "INSERT INTO ethauth_nonces"):
+		if exp, ok := db.nonces[key(0, 1)]; !ok || exp < args[3].(int64) {
+			db.nonces[key(0, 1)] = args[2].(int64)
+			n = 1
+		}
+	case strings.Contains(query, "INSERT INTO ethauth_revoked_ids"):
+		db.revokedIDs[args[0].(string)] = max(db.revokedIDs[args[0].(string)], args[1].(int64))
+		n = 1
+	case strings.Contains(query, "INSERT INTO ethauth_revoked_addresses"):
+		db.revokedAddrs[args[0].(string)] = true
+		n = 1
+	case strings.Contains(query, "INSERT INTO ethauth_revoked_before"):
+		db.revokedBefore[args[0].(string)] = max(db.revokedBefore[args[0].(string)], args[1].(int64))
+		n = 1
+	case strings.Contains(query, "INSERT INTO ethauth_sessions"):
+		_, loggedOut := db.loggedOut[key(0, 1)]
+		if logoutAt, ok := db.sessionLogouts[args[0].(string)]; !loggedOut && (!ok || args[3].(int64) >= logoutAt) {
+			if session, ok := db.sessions[key(0, 1)]; ok {
+				session[5] = args[5]
+			} else {
+				db.sessions[key(0, 1)] = args
+			}
+			n = 1
+		}
+	case strings.Contains(query, "DELETE FROM ethauth_sessions WHERE address = $1 AND id = $2"):
+		if session, ok := db.sessions[key(0, 1)]; ok {
+			delete(db.sessions, key(0, 1))
+			db.loggedOut[key(0, 1)] = session[4].(int64)
+			n = 1
+		}
+	case strings.Contains(query, "DELETE FROM ethauth_sessions WHERE address = $1"):
+		for k, session := range db.sessions {
+			if k[0] == args[0].(string) {
+				delete(db.sessions, k)
+				db.loggedOut[k] = session[4].(int64)
+				n++
+			}
+		}
+	case strings.Contains(query, "INSERT INTO ethauth_session_logouts"):
+		db.sessionLogouts[args[0].(string)] = max(db.sessionLogouts[args[0].(string)], args[1].(int64))
+		n = 1
+	case strings.Contains(query, "DELETE FROM ethauth_nonces WHERE expires_at < $1"):
+		for k, exp := range db.nonces {
+			if exp < args[0].(int64) {
+				delete(db.nonces, k)
+				n++
+			}
+		}
+	case strings.Contains(query, "DELETE FROM ethauth_") && strings.Contains(query, "WHERE expires_at < $1"):
+	default:
+		return nil, fmt.Errorf("unexpected statement %q", query)
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (c *testConn) QueryContext(ctx context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	db := c.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+
+	switch {
+	case strings.Contains(query, "COALESCE(MAX(version), 0)"):
+		version := int64(0)
+		for _, v := range db.versions {
+			version = max(version, v)
+		}
+		return &testRows{columns: []string{"version"}, rows: [][]driver.Value{{version}}}, nil
+	case strings.Contains(query, "revoked_ids WHERE id = $1"):
+		id, address, all, now, iat := args[0].(string), args[1].(string), args[2].(string), args[3].(int64), args[4].(int64)
+		exp, ok := db.revokedIDs[id]
+		revoked := id != "" && ok && exp >= now
+		revoked = revoked || db.revokedAddrs[address]
+		for _, key := range []string{address, all} {
+			if before, ok := db.revokedBefore[key]; ok && iat < before {
+				revoked = true
+			}
+		}
+		return &testRows{columns: []string{"revoked"}, rows: [][]driver.Value{{revoked}}}, nil
+	case strings.Contains(query, "SELECT id, app, issued_at, expires_at, last_seen"):
+		rows := &testRows{columns: []string{"id", "app", "issued_at", "expires_at", "last_seen"}}
+		for k, session := range db.sessions {
+			if k[0] == args[0].(string) && session[4].(int64) > args[1].(int64) {
+				rows.rows = append(rows.rows, session[1:])
+			}
+		}
+		sort.Slice(rows.rows, func(i, j int) bool { return rows.rows[i][2].(int64) > rows.rows[j][2].(int64) })
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+}
+
+type testRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore(nil, "ethauth; DROP TABLE users; --")
+	require.Error(t, err)
+
+	store, db := newTestStore(t)
+	require.Equal(t, []int64{1}, db.versions)
+
+	// migrations already applied are skipped
+	require.NoError(t, store.Migrate(context.Background()))
+	require.Equal(t, []int64{1}, db.versions)
+}
+
+func TestNonceStore(t *testing.T) {
+	store, db := newTestStore(t)
+	ctx := context.Background()
+	address := ethauthtest.Alice.Address().Hex()
+
+	require.NoError(t, store.Consume(ctx, address, 1, time.Now().Add(time.Hour)))
+	require.ErrorIs(t, store.Consume(ctx, address, 1, time.Now().Add(time.Hour)), ethauth.ErrNonceUsed)
+	require.ErrorIs(t, store.Consume(ctx, strings.ToLower(address), 1, time.Now().Add(time.Hour)), ethauth.ErrNonceUsed)
+
+	// nonces at the end of the accepted lifetime of their proof are still recorded, as by the
+	// memory store
+	require.NoError(t, store.Consume(ctx, address, 2, time.Now().Add(-time.Second)))
+	require.ErrorIs(t, store.Consume(ctx, address, 2, time.Now().Add(-time.Second)), ethauth.ErrNonceUsed)
+
+	// nonces are consumed by the clock of the instance
+	now := time.Now().Add(-time.Hour)
+	store.ConfigClock(func() time.Time { return now })
+	require.NoError(t, store.Consume(ctx, address, 3, now.Add(time.Minute)))
+	require.Equal(t, now.Add(time.Minute).Unix(), db.nonces[[2]string{strings.ToLower(address), "3"}])
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, store.Consume(ctx, address, 3, now.Add(time.Minute)))
+}
+
+func TestNonceStoreLeeway(t *testing.T) {
+	store, _ := newTestStore(t)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(store)
+
+	decode := func(proofString string) error {
+		_, _, err := ethAuth.DecodeProof(proofString)
+		return err
+	}
+
+	// proofs expired within the leeway are accepted once, as by the memory store
+	claims := ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Nonce = 1
+	claims.IssuedAt = time.Now().Add(-time.Minute).Unix()
+	claims.ExpiresAt = time.Now().Add(-10 * time.Second).Unix()
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, claims)
+	require.NoError(t, decode(proofString))
+	require.ErrorIs(t, decode(proofString), ethauth.ErrNonceUsed)
+
+	// as are proofs without exp
+	ethAuth.ConfigValidatorConfig(ethauth.ValidatorConfig{Leeway: time.Minute, MaxAge: time.Hour})
+	claims = ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Nonce = 2
+	claims.ExpiresAt = 0
+	proofString = ethauthtest.Mint(t, ethauthtest.Alice, claims)
+	require.NoError(t, decode(proofString))
+	require.ErrorIs(t, decode(proofString), ethauth.ErrNonceUsed)
+}
+
+func TestRevocationStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	isRevoked := func(proof *ethauth.Proof) bool {
+		revoked, err := store.IsRevoked(ctx, proof)
+		require.NoError(t, err)
+		return revoked
+	}
+
+	claims := ethauthtest.ValidClaims("ETHAuthTest")
+	claims.ID = "proof-1"
+	proof := ethauthtest.Sign(t, ethauthtest.Alice, claims)
+	require.False(t, isRevoked(proof))
+	require.NoError(t, store.RevokeID(ctx, "proof-1", time.Now().Add(time.Hour)))
+	require.True(t, isRevoked(proof))
+
+	other := ethauthtest.Sign(t, ethauthtest.Bob, ethauthtest.ValidClaims("ETHAuthTest"))
+	require.False(t, isRevoked(other))
+	require.NoError(t, store.RevokeIssuedBefore(ctx, ethauthtest.Bob.Address().Hex(), time.Now().Add(time.Minute)))
+	require.True(t, isRevoked(other))
+
+	third := ethauthtest.Sign(t, ethauthtest.Carol, ethauthtest.ValidClaims("ETHAuthTest"))
+	require.False(t, isRevoked(third))
+	require.NoError(t, store.RevokeAddress(ctx, ethauthtest.Carol.Address().Hex()))
+	require.True(t, isRevoked(third))
+}
+
+func TestSessionStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	address := ethauthtest.Alice.Address().Hex()
+	now := time.Now().Truncate(time.Second)
+
+	session := func(id string, issuedAt time.Time) ethauth.SessionInfo {
+		return ethauth.SessionInfo{Address: address, ID: id, App: "ETHAuthTest", IssuedAt: issuedAt, ExpiresAt: now.Add(time.Hour), LastSeen: now}
+	}
+	require.NoError(t, store.Record(ctx, session("a", now.Add(-time.Minute))))
+	require.NoError(t, store.Record(ctx, session("b", now)))
+	sessions, err := store.Sessions(ctx, address)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, "b", sessions[0].ID)
+	require.Equal(t, now.Add(time.Hour), sessions[0].ExpiresAt)
+
+	// logged out sessions can't be recorded again
+	ok, err := store.Logout(ctx, address, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = store.Logout(ctx, address, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorIs(t, store.Record(ctx, session("a", now.Add(-time.Minute))), ethauth.ErrProofRevoked)
+
+	// nor can sessions issued before logging out of all of them
+	n, err := store.LogoutAll(ctx, address)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.ErrorIs(t, store.Record(ctx, session("c", now.Add(-time.Minute))), ethauth.ErrProofRevoked)
+	require.NoError(t, store.Record(ctx, session("d", now.Add(time.Minute))))
+	sessions, err = store.Sessions(ctx, address)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+}
@@ -0,0 +1,263 @@
+// Package redis provides Redis-backed implementations of the ethauth stores, so replay
+// protection state can be shared by a cluster of API servers.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultKeyPrefix is the prefix of the keys written by the Redis stores.
+const DefaultKeyPrefix = "ethauth:"
+
+// Store implements ethauth.NonceStore, ethauth.RevocationStore, ethauth.ChallengeStore,
+// ethauth.RateLimitStore and ethauth.ReplayStore with Redis. Nonce, challenge, replay and
+// revoked proof id keys expire along with the proofs and challenges they were recorded for, and rate limit buckets once they
+// are full again, so no additional cleanup is required. The revocation and challenge stores
+// require Redis 6.2 or later.
+type Store struct {
+	client    goredis.UniversalClient
+	keyPrefix string
+
+	// clock is the clock of the ETHAuth instance the nonce store is configured with
+	clock func() time.Time
+}
+
+var (
+	_ ethauth.NonceStore      = &Store{}
+	_ ethauth.RevocationStore = &Store{}
+	_ ethauth.ChallengeStore  = &Store{}
+	_ ethauth.RateLimitStore  = &Store{}
+	_ ethauth.ReplayStore     = &Store{}
+)
+
+func NewStore(client goredis.UniversalClient, optKeyPrefix ...string) *Store {
+	keyPrefix := DefaultKeyPrefix
+	if len(optKeyPrefix) > 0 {
+		keyPrefix = optKeyPrefix[0]
+	}
+	return &Store{client: client, keyPrefix: keyPrefix, clock: time.Now}
+}
+
+// ConfigClock sets the clock the TTLs of nonces are computed by, which
+// ETHAuth.ConfigNonceStore sets to the clock of the instance.
+func (s *Store) ConfigClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+func (s *Store) Consume(ctx context.Context, address string, nonce uint64, exp time.Time) error {
+	// exp is the last time the proof is accepted, so the nonces of proofs at the very end of
+	// their leeway are still recorded, as by the memory store, rather than rejected
+	ttl := exp.Sub(s.clock())
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	key := fmt.Sprintf("%snonce:%s:%d", s.keyPrefix, strings.ToLower(address), nonce)
+	ok, err := s.client.SetNX(ctx, key, 1, ttl).Result()
+	if err != nil {
+		return fmt.Errorf("ethauth: redis nonce store failed - %w", err)
+	}
+	if !ok {
+		return ethauth.ErrNonceUsed
+	}
+	return nil
+}
+
+func (s *Store) RevokeID(ctx context.Context, id string, exp time.Time) error {
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return nil
+	}
+	err := s.client.Set(ctx, s.keyPrefix+"revoked:id:"+id, 1, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("ethauth: redis revocation store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RevokeAddress(ctx context.Context, address string) error {
+	err := s.client.Set(ctx, s.keyPrefix+"revoked:address:"+strings.ToLower(address), 1, 0).Err()
+	if err != nil {
+		return fmt.Errorf("ethauth: redis revocation store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) RevokeIssuedBefore(ctx context.Context, address string, t time.Time) error {
+	err := s.client.ZAddGT(ctx, s.keyPrefix+"revoked:before", goredis.Z{
+		Score:  float64(t.Unix()),
+		Member: issuedBeforeMember(address),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("ethauth: redis revocation store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) IsRevoked(ctx context.Context, proof *ethauth.Proof) (bool, error) {
+	address := strings.ToLower(proof.Account())
+
+	pipe := s.client.Pipeline()
+	var idCmd *goredis.IntCmd
+	if proof.Claims.ID != "" {
+		idCmd = pipe.Exists(ctx, s.keyPrefix+"revoked:id:"+proof.Claims.ID)
+	}
+	addressCmd := pipe.Exists(ctx, s.keyPrefix+"revoked:address:"+address)
+	beforeCmd := pipe.ZMScore(ctx, s.keyPrefix+"revoked:before", issuedBeforeMember(""), issuedBeforeMember(address))
+	_, err := pipe.Exec(ctx)
+	if err != nil && err != goredis.Nil {
+		return false, fmt.Errorf("ethauth: redis revocation store failed - %w", err)
+	}
+
+	if idCmd != nil && idCmd.Val() > 0 {
+		return true, nil
+	}
+	if addressCmd.Val() > 0 {
+		return true, nil
+	}
+	for _, before := range beforeCmd.Val() {
+		if before != 0 && float64(proof.Claims.IssuedAt) < before {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) Put(ctx context.Context, challenge ethauth.Challenge) error {
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("ethauth: redis challenge store failed - %w", err)
+	}
+	err = s.client.Set(ctx, fmt.Sprintf("%schallenge:%d", s.keyPrefix, challenge.Nonce), data, ttl).Err()
+	if err != nil {
+		return fmt.Errorf("ethauth: redis challenge store failed - %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, nonce uint64) (ethauth.Challenge, error) {
+	data, err := s.client.Get(ctx, fmt.Sprintf("%schallenge:%d", s.keyPrefix, nonce)).Bytes()
+	if err == goredis.Nil {
+		return ethauth.Challenge{}, ethauth.ErrInvalidChallenge
+	}
+	if err != nil {
+		return ethauth.Challenge{}, fmt.Errorf("ethauth: redis challenge store failed - %w", err)
+	}
+	var challenge ethauth.Challenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return ethauth.Challenge{}, fmt.Errorf("ethauth: redis challenge store failed - %w", err)
+	}
+	return challenge, nil
+}
+
+func (s *Store) Take(ctx context.Context, nonce uint64) (ethauth.Challenge, error) {
+	data, err := s.client.GetDel(ctx, fmt.Sprintf("%schallenge:%d", s.keyPrefix, nonce)).Bytes()
+	if err == goredis.Nil {
+		return ethauth.Challenge{}, ethauth.ErrInvalidChallenge
+	}
+	if err != nil {
+		return ethauth.Challenge{}, fmt.Errorf("ethauth: redis challenge store failed - %w", err)
+	}
+	var challenge ethauth.Challenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return ethauth.Challenge{}, fmt.Errorf("ethauth: redis challenge store failed - %w", err)
+	}
+	return challenge, nil
+}
+
+// replayUse is the first use of a proof recorded by Observe.
+type replayUse struct {
+	ethauth.ReplayClient
+	Seen int64 `json:"seen"`
+}
+
+func (s *Store) Observe(ctx context.Context, key string, client ethauth.ReplayClient, exp time.Time) (ethauth.ReplayClient, time.Time, error) {
+	now := time.Now()
+	ttl := time.Until(exp)
+	if ttl <= 0 {
+		return client, now, nil
+	}
+	data, err := json.Marshal(replayUse{ReplayClient: client, Seen: now.Unix()})
+	if err != nil {
+		return ethauth.ReplayClient{}, time.Time{}, fmt.Errorf("ethauth: redis replay store failed - %w", err)
+	}
+
+	redisKey := s.keyPrefix + "replay:" + key
+	ok, err := s.client.SetNX(ctx, redisKey, data, ttl).Result()
+	if err != nil {
+		return ethauth.ReplayClient{}, time.Time{}, fmt.Errorf("ethauth: redis replay store failed - %w", err)
+	}
+	if ok {
+		return client, now, nil
+	}
+	data, err = s.client.Get(ctx, redisKey).Bytes()
+	if err == goredis.Nil {
+		// the first use expired since
+		return client, now, nil
+	}
+	if err != nil {
+		return ethauth.ReplayClient{}, time.Time{}, fmt.Errorf("ethauth: redis replay store failed - %w", err)
+	}
+	var first replayUse
+	if err := json.Unmarshal(data, &first); err != nil {
+		return ethauth.ReplayClient{}, time.Time{}, fmt.Errorf("ethauth: redis replay store failed - %w", err)
+	}
+	return first.ReplayClient, time.Unix(first.Seen, 0), nil
+}
+
+// takeTokenScript takes a token from the bucket hash of KEYS[1], holding its tokens and
+// the time they were last refilled at, given the rate, burst and current time in ms.
+var takeTokenScript = goredis.NewScript(`
+local rate, burst, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens, last = tonumber(bucket[1]) or burst, tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - last) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", now)
+local ttl = 3600000
+if rate > 0 then
+	ttl = math.ceil((burst - tokens) * 1000 / rate) + 1000
+end
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+func (s *Store) Allow(ctx context.Context, key string, limit ethauth.RateLimit) (bool, time.Duration, error) {
+	result, err := takeTokenScript.Run(ctx, s.client, []string{s.keyPrefix + "ratelimit:" + key},
+		limit.Rate, limit.Burst, time.Now().UnixMilli()).Slice()
+	if err != nil || len(result) != 2 {
+		return false, 0, fmt.Errorf("ethauth: redis rate limit store failed - %w", err)
+	}
+	if allowed, _ := result[0].(int64); allowed == 1 {
+		return true, 0, nil
+	}
+	if limit.Rate <= 0 {
+		return false, time.Hour, nil
+	}
+	tokensStr, _ := result[1].(string)
+	tokens, _ := strconv.ParseFloat(tokensStr, 64)
+	return false, time.Duration((1 - tokens) / limit.Rate * float64(time.Second)), nil
+}
+
+func issuedBeforeMember(address string) string {
+	if address == "" {
+		return "*"
+	}
+	return strings.ToLower(address)
+}
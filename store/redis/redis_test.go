@@ -0,0 +1,317 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/go-ethauth"
+	"github.com/0xsequence/go-ethauth/ethauthtest"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is an in-process server of the subset of the Redis protocol used by Store, so
+// the store is tested without a Redis server.
+type testServer struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+	zsets   map[string]map[string]float64
+	ttls    map[string]time.Duration
+}
+
+func newTestStore(t *testing.T) (*Store, *testServer) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &testServer{
+		listener: listener,
+		values:   map[string]string{},
+		expires:  map[string]time.Time{},
+		zsets:    map[string]map[string]float64{},
+		ttls:     map[string]time.Duration{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+
+	client := goredis.NewClient(&goredis.Options{Addr: listener.Addr().String(), Protocol: 2, DisableIndentity: true})
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+	return NewStore(client), srv
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func bulk(value string, ok bool) string {
+	if !ok {
+		return "$-1\r\n"
+	}
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func (s *testServer) get(key string) (string, bool) {
+	if exp, ok := s.expires[key]; ok && !time.Now().Before(exp) {
+		delete(s.values, key)
+		delete(s.expires, key)
+	}
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *testServer) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		key, value := args[1], args[2]
+		var nx bool
+		var ttl time.Duration
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX", "EX":
+				n, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(n) * time.Millisecond
+				if strings.ToUpper(args[i]) == "EX" {
+					ttl = time.Duration(n) * time.Second
+				}
+				i++
+			}
+		}
+		if _, ok := s.get(key); ok && nx {
+			return "$-1\r\n"
+		}
+		s.values[key] = value
+		delete(s.expires, key)
+		if ttl > 0 {
+			s.expires[key] = time.Now().Add(ttl)
+		}
+		s.ttls[key] = ttl
+		return "+OK\r\n"
+	case "GET":
+		return bulk(s.get(args[1]))
+	case "GETDEL":
+		value, ok := s.get(args[1])
+		delete(s.values, args[1])
+		return bulk(value, ok)
+	case "EXISTS":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := s.get(key); ok {
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "ZADD":
+		zset := s.zsets[args[1]]
+		if zset == nil {
+			zset = map[string]float64{}
+			s.zsets[args[1]] = zset
+		}
+		i := 2
+		gt := strings.ToUpper(args[i]) == "GT"
+		if gt {
+			i++
+		}
+		score, _ := strconv.ParseFloat(args[i], 64)
+		if prev, ok := zset[args[i+1]]; !ok || !gt || score > prev {
+			zset[args[i+1]] = score
+		}
+		return ":1\r\n"
+	case "ZMSCORE":
+		reply := fmt.Sprintf("*%d\r\n", len(args)-2)
+		for _, member := range args[2:] {
+			score, ok := s.zsets[args[1]][member]
+			reply += bulk(strconv.FormatFloat(score, 'f', -1, 64), ok)
+		}
+		return reply
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func TestNonceStore(t *testing.T) {
+	store, srv := newTestStore(t)
+	ctx := context.Background()
+	address := ethauthtest.Alice.Address().Hex()
+
+	require.NoError(t, store.Consume(ctx, address, 1, time.Now().Add(time.Hour)))
+	require.ErrorIs(t, store.Consume(ctx, address, 1, time.Now().Add(time.Hour)), ethauth.ErrNonceUsed)
+	require.ErrorIs(t, store.Consume(ctx, strings.ToLower(address), 1, time.Now().Add(time.Hour)), ethauth.ErrNonceUsed)
+
+	// nonces at the end of the accepted lifetime of their proof are still recorded, as by the
+	// memory store
+	require.NoError(t, store.Consume(ctx, address, 2, time.Now().Add(-time.Second)))
+	require.ErrorIs(t, store.Consume(ctx, address, 2, time.Now().Add(-time.Second)), ethauth.ErrNonceUsed)
+
+	// the TTL is computed by the clock of the instance
+	now := time.Now().Add(-time.Hour)
+	store.ConfigClock(func() time.Time { return now })
+	require.NoError(t, store.Consume(ctx, address, 3, now.Add(time.Minute)))
+	require.Equal(t, time.Minute, srv.ttls[fmt.Sprintf("%snonce:%s:%d", DefaultKeyPrefix, strings.ToLower(address), 3)])
+}
+
+func TestNonceStoreLeeway(t *testing.T) {
+	store, _ := newTestStore(t)
+	ethAuth, err := ethauth.New()
+	require.NoError(t, err)
+	ethAuth.ConfigNonceStore(store)
+
+	decode := func(proofString string) error {
+		_, _, err := ethAuth.DecodeProof(proofString)
+		return err
+	}
+
+	// proofs expired within the leeway are accepted once, as by the memory store
+	claims := ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Nonce = 1
+	claims.IssuedAt = time.Now().Add(-time.Minute).Unix()
+	claims.ExpiresAt = time.Now().Add(-10 * time.Second).Unix()
+	proofString := ethauthtest.Mint(t, ethauthtest.Alice, claims)
+	require.NoError(t, decode(proofString))
+	require.ErrorIs(t, decode(proofString), ethauth.ErrNonceUsed)
+
+	// as are proofs without exp
+	ethAuth.ConfigValidatorConfig(ethauth.ValidatorConfig{Leeway: time.Minute, MaxAge: time.Hour})
+	claims = ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Nonce = 2
+	claims.ExpiresAt = 0
+	proofString = ethauthtest.Mint(t, ethauthtest.Alice, claims)
+	require.NoError(t, decode(proofString))
+	require.ErrorIs(t, decode(proofString), ethauth.ErrNonceUsed)
+}
+
+func TestRevocationStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	claims := ethauthtest.ValidClaims("ETHAuthTest")
+	claims.ID = "proof-1"
+	proof := ethauthtest.Sign(t, ethauthtest.Alice, claims)
+	revoked, err := store.IsRevoked(ctx, proof)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, store.RevokeID(ctx, "proof-1", time.Now().Add(time.Hour)))
+	revoked, err = store.IsRevoked(ctx, proof)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	other := ethauthtest.Sign(t, ethauthtest.Bob, ethauthtest.ValidClaims("ETHAuthTest"))
+	revoked, err = store.IsRevoked(ctx, other)
+	require.NoError(t, err)
+	require.False(t, revoked)
+	require.NoError(t, store.RevokeIssuedBefore(ctx, ethauthtest.Bob.Address().Hex(), time.Now().Add(time.Minute)))
+	revoked, err = store.IsRevoked(ctx, other)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	third := ethauthtest.Sign(t, ethauthtest.Carol, ethauthtest.ValidClaims("ETHAuthTest"))
+	require.NoError(t, store.RevokeAddress(ctx, ethauthtest.Carol.Address().Hex()))
+	revoked, err = store.IsRevoked(ctx, third)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// exchanged proofs are revoked with the account of their `sub` claim
+	claims = ethauthtest.ValidClaims("ETHAuthTest")
+	claims.Type = ethauth.ProofTypeExchanged
+	claims.Subject = strings.ToLower(ethauthtest.Carol.Address().Hex())
+	exchanged := ethauthtest.Sign(t, ethauthtest.Alice, claims)
+	revoked, err = store.IsRevoked(ctx, exchanged)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestChallengeStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	challenge := ethauth.Challenge{Nonce: 42, ExpiresAt: time.Now().Add(time.Minute)}
+	require.NoError(t, store.Put(ctx, challenge))
+	got, err := store.Get(ctx, 42)
+	require.NoError(t, err)
+	require.Equal(t, challenge.Nonce, got.Nonce)
+	taken, err := store.Take(ctx, 42)
+	require.NoError(t, err)
+	require.Equal(t, challenge.Nonce, taken.Nonce)
+	_, err = store.Take(ctx, 42)
+	require.ErrorIs(t, err, ethauth.ErrInvalidChallenge)
+}
+
+func TestReplayStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	a, b := ethauth.ReplayClient{IP: "192.0.2.1"}, ethauth.ReplayClient{IP: "192.0.2.2"}
+
+	first, _, err := store.Observe(ctx, "key", a, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, a, first)
+	first, _, err = store.Observe(ctx, "key", b, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, a, first)
+}
@@ -0,0 +1,249 @@
+package ethauth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseLimits configures the parsing of proof strings by Parse and ParseReader: it bounds the
+// proof strings decoded, ie. to accept proofs carrying large custom claims, and selects the
+// ClaimsMode of their claims. Zero limits default to MaxProofLength and MaxClaimsLength.
+type ParseLimits struct {
+	// MaxProofLength is the maximum length of the proof string
+	MaxProofLength int64
+
+	// MaxClaimsLength is the maximum length of the decoded claims JSON
+	MaxClaimsLength int64
+
+	// ClaimsMode is how unknown claims are decoded, which defaults to ClaimsLenient
+	ClaimsMode ClaimsMode
+}
+
+func parseLimits(optLimits []ParseLimits) ParseLimits {
+	var limits ParseLimits
+	if len(optLimits) > 0 {
+		limits = optLimits[0]
+	}
+	if limits.MaxProofLength <= 0 {
+		limits.MaxProofLength = MaxProofLength
+	}
+	if limits.MaxClaimsLength <= 0 {
+		limits.MaxClaimsLength = MaxClaimsLength
+	}
+	return limits
+}
+
+// ParseReader decodes an ETHAuth proof string read from r, ie. a request body, into a Proof
+// object. Unlike Parse, the proof string is not read into memory as a whole: the claims are
+// base64 and JSON decoded as they are read, so memory is bounded by the limits whatever the
+// size of the input. Note, ParseReader does not validate the proof signature or claims, see
+// ETHAuth.DecodeProofReader for that.
+func ParseReader(r io.Reader, optLimits ...ParseLimits) (*Proof, error) {
+	limits := parseLimits(optLimits)
+	lr := &io.LimitedReader{R: r, N: limits.MaxProofLength + 1}
+	pr := &proofReader{r: bufio.NewReader(lr), more: true}
+
+	prefix, err := pr.readPart(len(ETHAuthPrefix))
+	if err != nil || prefix != ETHAuthPrefix || !pr.more {
+		return nil, fmt.Errorf("ethauth: not an ethauth proof")
+	}
+	address, err := pr.readPart(-1)
+	if err != nil || !pr.more {
+		return nil, fmt.Errorf("ethauth: invalid proof string")
+	}
+
+	claimsJSON, claims, err := pr.readClaims(limits.MaxClaimsLength)
+	if err != nil {
+		return nil, err
+	}
+	if limits.ClaimsMode == ClaimsStrict {
+		if err := claims.rejectUnknown(); err != nil {
+			return nil, err
+		}
+	}
+	if !pr.more {
+		return nil, fmt.Errorf("ethauth: invalid proof string")
+	}
+
+	var parts []string
+	for pr.more {
+		part, err := pr.readPart(-1)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("ethauth: invalid proof string")
+		}
+	}
+	if lr.N <= 0 {
+		return nil, fmt.Errorf("ethauth: invalid proof string, exceeds %d bytes", limits.MaxProofLength)
+	}
+
+	proof := NewProof()
+	proof.Prefix = prefix
+	proof.Address = address
+	if err := proof.parseAccountID(); err != nil {
+		return nil, err
+	}
+	proof.Claims = claims
+	proof.Signature = parts[0]
+	if len(parts) >= 2 {
+		proof.Extra = parts[1]
+	}
+	if len(parts) == 3 {
+		proof.GuardianSignature = parts[2]
+		if proof.GuardianSignature == "" {
+			return nil, fmt.Errorf("ethauth: invalid proof string")
+		}
+	}
+	proof.claimsJSON = claimsJSON
+	return proof, nil
+}
+
+// proofReader reads the dot separated parts of a proof string.
+type proofReader struct {
+	r *bufio.Reader
+
+	// more is false once the last part has been read
+	more bool
+}
+
+// readPart reads the next part of the proof string, of at most max bytes unless max is negative.
+func (pr *proofReader) readPart(max int) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := pr.readByte()
+		if err != nil {
+			return "", err
+		}
+		if c == '.' || !pr.more {
+			return sb.String(), nil
+		}
+		if max >= 0 && sb.Len() >= max {
+			return "", fmt.Errorf("ethauth: invalid proof string")
+		}
+		sb.WriteByte(c)
+	}
+}
+
+// readByte reads the next byte of the proof string, clearing more at the end of the input.
+func (pr *proofReader) readByte() (byte, error) {
+	if !pr.more {
+		return 0, io.ErrUnexpectedEOF
+	}
+	c, err := pr.r.ReadByte()
+	if err == io.EOF {
+		pr.more = false
+		return 0, nil
+	}
+	return c, err
+}
+
+// readClaims decodes the claims part of the proof string, returning the claims JSON which is
+// at most maxLength bytes.
+func (pr *proofReader) readClaims(maxLength int64) ([]byte, Claims, error) {
+	var claims Claims
+	var claimsJSON bytes.Buffer
+	part := &claimsPartReader{pr: pr}
+	decoded := io.TeeReader(&io.LimitedReader{R: base64.NewDecoder(base64.RawURLEncoding.Strict(), part), N: maxLength + 1}, &claimsJSON)
+
+	dec := json.NewDecoder(decoded)
+	err := dec.Decode(&claims)
+	if part.err != nil {
+		return nil, Claims{}, part.err
+	}
+	if err == nil {
+		// drain the claims part, which must only have whitespace after the claims object
+		_, err = io.Copy(io.Discard, decoded)
+	}
+	if part.err != nil {
+		return nil, Claims{}, part.err
+	}
+	if int64(claimsJSON.Len()) > maxLength {
+		return nil, Claims{}, fmt.Errorf("ethauth: decoding failed, claims exceed %d bytes", maxLength)
+	}
+	if err != nil || len(bytes.Trim(claimsJSON.Bytes()[dec.InputOffset():], " \t\r\n")) > 0 {
+		return nil, Claims{}, fmt.Errorf("ethauth: decoding failed, cannot unmarshal claims")
+	}
+	return claimsJSON.Bytes(), claims, nil
+}
+
+// claimsPartReader reads the base64 encoded claims part of the proof string, up to the next dot.
+type claimsPartReader struct {
+	pr   *proofReader
+	done bool
+	err  error
+}
+
+func (r *claimsPartReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && !r.done {
+		c, err := r.pr.readByte()
+		if err != nil {
+			r.err = err
+			return n, err
+		}
+		if c == '.' || !r.pr.more {
+			r.done = true
+			break
+		}
+		if !('A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			r.err = fmt.Errorf("ethauth: decoding failed, invalid claims")
+			return n, r.err
+		}
+		p[n] = c
+		n++
+	}
+	if n == 0 && r.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// DecodeProofReader will decode an ETHAuth proof string read from r, validate it, and return a
+// Proof object, see ParseReader. Encrypted proofs are read into memory, within the limits, to
+// be decrypted.
+func (w *ETHAuth) DecodeProofReader(r io.Reader, optLimits ...ParseLimits) (bool, *Proof, error) {
+	return w.DecodeProofReaderContext(context.Background(), r, optLimits...)
+}
+
+// DecodeProofReaderContext is DecodeProofReader with the context of the request of the proof.
+func (w *ETHAuth) DecodeProofReaderContext(ctx context.Context, r io.Reader, optLimits ...ParseLimits) (bool, *Proof, error) {
+	ctx, start := w.startVerification(ctx)
+	limits := parseLimits(optLimits)
+
+	br := bufio.NewReader(r)
+	if prefix, _ := br.Peek(len(EncryptedPrefix) + 1); string(prefix) == EncryptedPrefix+"." {
+		data, err := io.ReadAll(io.LimitReader(br, limits.MaxProofLength+1))
+		if err == nil && int64(len(data)) > limits.MaxProofLength {
+			err = fmt.Errorf("ethauth: invalid proof string, exceeds %d bytes", limits.MaxProofLength)
+		}
+		var proofString string
+		if err == nil {
+			proofString, err = DecryptProof(string(data), w.decryptionKey, limits)
+		}
+		if err != nil {
+			w.observeVerification(ctx, start, nil, err)
+			return false, nil, err
+		}
+		br = bufio.NewReader(strings.NewReader(proofString))
+	}
+
+	_, span := startSpan(ctx, SpanParse)
+	proof, err := ParseReader(br, limits)
+	span.End(err)
+	if err != nil {
+		w.observeVerification(ctx, start, nil, err)
+		return false, nil, err
+	}
+	ok, proof, err := w.verifyParsedProof(ctx, proof, nil)
+	w.observeVerification(ctx, start, proof, err)
+	return ok, proof, err
+}
@@ -0,0 +1,147 @@
+package ethauth
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+type testMerkleClaims struct {
+	Root  string   `json:"root"`
+	Proof []string `json:"proof"`
+}
+
+func (c *testMerkleClaims) Map() map[string]interface{} {
+	proof := make([]interface{}, len(c.Proof))
+	for i, p := range c.Proof {
+		proof[i] = p
+	}
+	return map[string]interface{}{"root": c.Root, "proof": proof}
+}
+
+func (c *testMerkleClaims) TypedDataTypes() []ethcoder.TypedDataArgument {
+	return []ethcoder.TypedDataArgument{{Name: "root", Type: "string"}, {Name: "proof", Type: "string[]"}}
+}
+
+func (c *testMerkleClaims) Valid() error {
+	if len(c.Proof) == 0 {
+		return fmt.Errorf("proof is empty")
+	}
+	return nil
+}
+
+// endlessReader is an input repeating a pattern forever, ie. a malicious request body.
+type endlessReader string
+
+func (r endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r[i%len(r)]
+	}
+	return len(p), nil
+}
+
+func TestParseReader(t *testing.T) {
+	proofString := testProofString(t)
+	expected, err := Parse(proofString)
+	require.NoError(t, err)
+
+	for _, s := range []string{proofString, proofString + ".", proofString + ".0x1234", proofString + "..0xabcd"} {
+		proof, err := ParseReader(strings.NewReader(s))
+		require.NoError(t, err, s)
+		expected, err := Parse(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, proof)
+	}
+
+	// invalid proof strings
+	for _, s := range []string{
+		"", "eth", "eth.", "jwt." + proofString[4:],
+		withClaims(proofString, "e30=="),
+		withClaims(proofString, Base64UrlEncode(expected.claimsJSON)+"\n"),
+		withClaims(proofString, Base64UrlEncode([]byte(`{"app":"ETHAuthTest"} {}`))),
+		strings.Join(strings.Split(proofString, ".")[:3], "."),
+		proofString + "..",
+		proofString + ".0x.0x.0x",
+	} {
+		_, err := ParseReader(strings.NewReader(s))
+		require.Error(t, err, s)
+	}
+}
+
+func TestParseReaderLimits(t *testing.T) {
+	// endless inputs are rejected without reading them as a whole
+	endlessClaims := func() io.Reader {
+		prefix := "eth.0xe0c9828dee3411a28ccb4bb82a18d0aad24489e0." + Base64UrlEncode([]byte(`{"app":"a`))
+		return io.MultiReader(strings.NewReader(prefix), endlessReader(Base64UrlEncode([]byte("aaa"))))
+	}
+	for _, r := range []io.Reader{
+		endlessClaims(),
+		io.MultiReader(strings.NewReader(testProofString(t)), endlessReader("0")),
+		endlessReader("e"),
+		endlessReader("eth."),
+	} {
+		_, err := ParseReader(r)
+		require.Error(t, err)
+	}
+	_, err := ParseReader(endlessClaims(), ParseLimits{MaxClaimsLength: 64})
+	require.ErrorContains(t, err, "claims exceed 64 bytes")
+
+	_, err = ParseReader(io.MultiReader(strings.NewReader(testProofString(t)), endlessReader("0")))
+	require.ErrorContains(t, err, "exceeds")
+}
+
+func TestDecodeProofReaderLargeClaims(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	merkle := &testMerkleClaims{Root: ethcoder.HexEncode(make([]byte, 32))}
+	for i := 0; i < 200; i++ {
+		merkle.Proof = append(merkle.Proof, ethcoder.HexEncode(make([]byte, 32)))
+	}
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithCustomClaims(merkle))
+	require.NoError(t, err)
+	require.Greater(t, len(proofString), MaxProofLength)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigCustomClaims(func() ClaimsProvider { return &testMerkleClaims{} })
+
+	// the proof is over the default limits
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.Error(t, err)
+	_, _, err = ethAuth.DecodeProofReader(strings.NewReader(proofString))
+	require.Error(t, err)
+
+	limits := ParseLimits{MaxProofLength: 64 * 1024, MaxClaimsLength: 32 * 1024}
+	ok, proof, err := ethAuth.DecodeProofReader(strings.NewReader(proofString), limits)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, merkle, proof.Claims.Custom)
+	require.WithinDuration(t, time.Now(), time.Unix(proof.Claims.IssuedAt, 0), time.Minute)
+}
+
+func FuzzParseReader(f *testing.F) {
+	proofString := testProofString(f)
+	f.Add(proofString)
+	f.Add(proofString + "..0x")
+	f.Add(withClaims(proofString, "e30"))
+	f.Add("eth.0x.e30.0x")
+
+	// ParseReader accepts the same proof strings as Parse
+	f.Fuzz(func(t *testing.T, s string) {
+		expected, expectedErr := Parse(s)
+		proof, err := ParseReader(strings.NewReader(s))
+		if expectedErr != nil {
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, expected, proof)
+	})
+}
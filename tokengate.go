@@ -0,0 +1,137 @@
+package ethauth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// DefaultTokenBalanceTTL is how long the token balances of ConfigTokenGates are cached, unless
+// configured otherwise.
+const DefaultTokenBalanceTTL = time.Minute
+
+// TokenGate requires the account of a proof to hold a minimum balance of the tokens of a
+// contract, see RequireTokenOwnership.
+type TokenGate struct {
+	Contract   common.Address
+	MinBalance *big.Int
+
+	// TokenID is the id of the tokens of an ERC-1155 contract, or nil for the balance of all of
+	// the tokens of an ERC-721 contract, or of an ERC-20 contract
+	TokenID *big.Int
+}
+
+// RequireTokenOwnership returns the TokenGate of the accounts holding at least minBalance
+// tokens of the ERC-721 contract, or of the ERC-1155 token of the optional token id.
+func RequireTokenOwnership(contract common.Address, minBalance *big.Int, optTokenID ...*big.Int) TokenGate {
+	gate := TokenGate{Contract: contract, MinBalance: minBalance}
+	if len(optTokenID) > 0 {
+		gate.TokenID = optTokenID[0]
+	}
+	return gate
+}
+
+// ConfigTokenGates requires the accounts of decoded proofs to pass each of the token gates, ie.
+// for token-gated APIs. Once the proof signature is verified, the balances of the account are
+// checked on-chain with the balanceOf method of the contracts, over the JSON-RPC provider of
+// the instance, and cached for ttl, DefaultTokenBalanceTTL when zero. Proofs of accounts
+// without sufficient balances are rejected with ErrTokenOwnership.
+func (w *ETHAuth) ConfigTokenGates(ttl time.Duration, gates ...TokenGate) error {
+	for _, gate := range gates {
+		if gate.Contract == (common.Address{}) || gate.MinBalance == nil || gate.MinBalance.Sign() < 0 {
+			return fmt.Errorf("ethauth: invalid token gate of contract %s", gate.Contract.Hex())
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultTokenBalanceTTL
+	}
+	if len(gates) == 0 {
+		w.tokenGates = nil
+		return nil
+	}
+	w.tokenGates = &tokenGates{gates: gates, balances: newTTLCache[*big.Int](ttl)}
+	return nil
+}
+
+type tokenGates struct {
+	gates    []TokenGate
+	balances *ttlCache[*big.Int]
+}
+
+// verifyTokenGates verifies that the account of the proof passes the token gates.
+func (w *ETHAuth) verifyTokenGates(ctx context.Context, proof *Proof) error {
+	address, err := proof.AddressBytes()
+	if err != nil {
+		return fmt.Errorf("%w, %v", ErrTokenOwnership, err)
+	}
+	ctx = w.rpcContext(ctx)
+
+	for _, gate := range w.tokenGates.gates {
+		balance, err := w.tokenBalance(ctx, gate, address)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(gate.MinBalance) < 0 {
+			return fmt.Errorf("%w, account holds %s tokens of %s", ErrTokenOwnership, balance.String(), gate.Contract.Hex())
+		}
+	}
+	return nil
+}
+
+// rpcContext returns a copy of the context carrying the hooks and logger of the instance, so
+// the RPC calls made once the proof signature is verified are reported as those of the
+// validators.
+func (w *ETHAuth) rpcContext(ctx context.Context) context.Context {
+	if w.hooks.OnRPCCall != nil {
+		ctx = context.WithValue(ctx, hooksCtxKey, &w.hooks)
+	}
+	if w.logger != nil {
+		ctx = context.WithValue(ctx, loggerCtxKey, w.logger)
+	}
+	return ctx
+}
+
+// tokenBalance returns the balance of the account of the tokens of the gate, from the cache or
+// the balanceOf method of the contract.
+func (w *ETHAuth) tokenBalance(ctx context.Context, gate TokenGate, account common.Address) (*big.Int, error) {
+	key := gate.Contract.Hex() + ":" + strings.ToLower(account.Hex())
+	if gate.TokenID != nil {
+		key += ":" + gate.TokenID.String()
+	}
+	if balance, ok := w.tokenGates.balances.get(key); ok {
+		return balance, nil
+	}
+	if w.provider == nil {
+		return nil, fmt.Errorf("ethauth: token gates require a JSON-RPC provider")
+	}
+
+	var input []byte
+	var err error
+	if gate.TokenID != nil {
+		input, err = ethcoder.ABIEncodeMethodCalldata("balanceOf(address,uint256)", []interface{}{account, gate.TokenID})
+	} else {
+		input, err = ethcoder.ABIEncodeMethodCalldata("balanceOf(address)", []interface{}{account})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: unable to encode balanceOf call - %w", err)
+	}
+
+	rpcCtx, call := startRPCCall(ctx, "eth_call")
+	output, err := w.provider.CallContract(rpcCtx, ethereum.CallMsg{To: &gate.Contract, Data: input}, nil)
+	call.end(err)
+	if err != nil {
+		return nil, fmt.Errorf("ethauth: token balanceOf call failed - %w", err)
+	}
+	if len(output) < 32 {
+		return nil, fmt.Errorf("ethauth: token balanceOf call of %s returned no balance", gate.Contract.Hex())
+	}
+	balance := new(big.Int).SetBytes(output[:32])
+	w.tokenGates.balances.add(key, balance)
+	return balance, nil
+}
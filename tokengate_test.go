@@ -0,0 +1,122 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenTestServer returns a JSON-RPC server answering the balanceOf calls of the account,
+// of its balance of the ERC-721 contract and of token 7 of the ERC-1155 contract.
+func newTokenTestServer(t *testing.T, account, erc721, erc1155 common.Address, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req.Method == "eth_getCode" {
+			// the account is not a contract wallet
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0x"})
+			return
+		}
+		require.Equal(t, "eth_call", req.Method)
+		calls.Add(1)
+
+		var msg struct {
+			To    common.Address `json:"to"`
+			Input string         `json:"input"`
+			Data  string         `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(req.Params[0], &msg))
+		input := msg.Input
+		if input == "" {
+			input = msg.Data
+		}
+
+		balance := big.NewInt(0)
+		switch msg.To {
+		case erc721:
+			expected, err := ethcoder.ABIEncodeMethodCalldata("balanceOf(address)", []interface{}{account})
+			require.NoError(t, err)
+			if input == ethcoder.HexEncode(expected) {
+				balance = big.NewInt(2)
+			}
+		case erc1155:
+			expected, err := ethcoder.ABIEncodeMethodCalldata("balanceOf(address,uint256)", []interface{}{account, big.NewInt(7)})
+			require.NoError(t, err)
+			if input == ethcoder.HexEncode(expected) {
+				balance = big.NewInt(1)
+			}
+		}
+		result, err := ethcoder.ABIPackArguments([]string{"uint256"}, []interface{}{balance})
+		require.NoError(t, err)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": ethcoder.HexEncode(result)})
+	}))
+}
+
+func TestTokenGates(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	erc721 := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	erc1155 := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	var calls atomic.Int32
+	server := newTokenTestServer(t, wallet.Address(), erc721, erc1155, &calls)
+	defer server.Close()
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(server.URL, 1))
+	require.Error(t, ethAuth.ConfigTokenGates(0, RequireTokenOwnership(common.Address{}, big.NewInt(1))))
+	require.Error(t, ethAuth.ConfigTokenGates(0, RequireTokenOwnership(erc721, nil)))
+
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	decode := func(proofString string) error {
+		_, _, err := ethAuth.DecodeProof(proofString)
+		return err
+	}
+
+	// accounts holding the tokens pass, and their balances are cached
+	require.NoError(t, ethAuth.ConfigTokenGates(time.Hour,
+		RequireTokenOwnership(erc721, big.NewInt(1)),
+		RequireTokenOwnership(erc1155, big.NewInt(1), big.NewInt(7)),
+	))
+	require.NoError(t, decode(proofString))
+	require.NoError(t, decode(proofString))
+	require.Equal(t, int32(2), calls.Load())
+
+	// accounts without enough tokens are rejected
+	require.NoError(t, ethAuth.ConfigTokenGates(time.Hour, RequireTokenOwnership(erc721, big.NewInt(3))))
+	err = decode(proofString)
+	require.ErrorIs(t, err, ErrTokenOwnership)
+	require.Equal(t, "insufficient_tokens", FailureReason(err))
+	require.NoError(t, ethAuth.ConfigTokenGates(time.Hour, RequireTokenOwnership(erc1155, big.NewInt(1), big.NewInt(8))))
+	require.ErrorIs(t, decode(proofString), ErrTokenOwnership)
+
+	other, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	otherProofString, err := Issue(NewWalletSigner(other), WithApp("ETHAuthTest"))
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigTokenGates(time.Hour, RequireTokenOwnership(erc721, big.NewInt(1))))
+	require.ErrorIs(t, decode(otherProofString), ErrTokenOwnership)
+
+	// gates are checked once the signature is verified
+	calls.Store(0)
+	_, _, err = ethAuth.DecodeProof(proofString[:len(proofString)-4] + "0000")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrTokenOwnership)
+	require.Zero(t, calls.Load())
+}
@@ -0,0 +1,183 @@
+package ethauth
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Tracer starts the spans of the steps of proof verifications, so auth latency shows up in
+// distributed traces. See the ethauthotel package for an OpenTelemetry tracer.
+type Tracer interface {
+	// Start starts a span of the name as a child of the span of the context, returning the
+	// context of the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets an attribute of the span, whose value is a string, bool or int.
+	SetAttribute(key string, value any)
+
+	// End ends the span, recording the error of the step, if any.
+	End(err error)
+}
+
+// Names of the spans of proof verifications.
+const (
+	// SpanVerify spans the decoding and verification of a proof
+	SpanVerify = "ethauth.verify"
+
+	// SpanParse spans the parsing of a proof string or of its CBOR or CACAO encoding
+	SpanParse = "ethauth.parse"
+
+	// SpanSignature spans the verification of the proof signature by the validators
+	SpanSignature = "ethauth.signature"
+
+	// SpanDigest spans the computation of the digest of the message signed by an EOA proof
+	SpanDigest = "ethauth.digest"
+
+	// SpanRecover spans the recovery of the signer of an EOA proof
+	SpanRecover = "ethauth.recover"
+
+	// SpanRPC spans a JSON-RPC call, ie. the EIP-1271 isValidSignature call of contract accounts
+	SpanRPC = "ethauth.rpc"
+
+	// SpanStore spans a lookup of a revocation, session, challenge or nonce store
+	SpanStore = "ethauth.store"
+)
+
+// Attributes of the spans of proof verifications.
+const (
+	// AttributeApp is the `app` claim of the proof, of SpanVerify
+	AttributeApp = "ethauth.app"
+
+	// AttributeFailureReason is the FailureReason of a failed verification, of SpanVerify
+	AttributeFailureReason = "ethauth.failure_reason"
+
+	// AttributeCacheHit reports whether the signature was found in the verification cache,
+	// of SpanSignature
+	AttributeCacheHit = "ethauth.cache_hit"
+
+	// AttributeValidator is the name of the validator which verified the signature, ie.
+	// "ValidateEOAProof", of SpanSignature
+	AttributeValidator = "ethauth.validator"
+
+	// AttributeRPCMethod is the JSON-RPC method, ie. "eth_call", of SpanRPC
+	AttributeRPCMethod = "rpc.method"
+
+	// AttributeStore is the store looked up, "revocation", "session", "challenge" or
+	// "nonce", of SpanStore
+	AttributeStore = "ethauth.store"
+)
+
+// ConfigTracer enables the tracing of proof verifications with the tracer.
+func (w *ETHAuth) ConfigTracer(tracer Tracer) {
+	w.tracer = tracer
+}
+
+var tracerCtxKey = &contextKey{"tracer"}
+
+// startSpan starts a span with the tracer of the ETHAuth instance verifying the proof, which
+// passes its tracer in the context, or a no-op span if tracing isn't configured.
+func startSpan(ctx context.Context, name string) (context.Context, Span) {
+	tracer, ok := ctx.Value(tracerCtxKey).(Tracer)
+	if !ok {
+		return ctx, noopSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value any) {}
+
+func (noopSpan) End(err error) {}
+
+var verifySpanCtxKey = &contextKey{"verify-span"}
+
+// startVerification starts the SpanVerify span of the decoding of a proof, which is ended by
+// observeVerification, and passes the tracer of the instance to the steps of the verification.
+func (w *ETHAuth) startVerification(ctx context.Context) (context.Context, time.Time) {
+	start := time.Now()
+	if w.tracer == nil {
+		return ctx, start
+	}
+	ctx = context.WithValue(ctx, tracerCtxKey, w.tracer)
+	ctx, span := w.tracer.Start(ctx, SpanVerify)
+	return context.WithValue(ctx, verifySpanCtxKey, span), start
+}
+
+// endVerification ends the SpanVerify span of startVerification.
+func endVerification(ctx context.Context, proof *Proof, err error) {
+	span, ok := ctx.Value(verifySpanCtxKey).(Span)
+	if !ok {
+		return
+	}
+	if proof != nil {
+		span.SetAttribute(AttributeApp, proof.Claims.App)
+	}
+	if err != nil {
+		span.SetAttribute(AttributeFailureReason, FailureReason(err))
+	}
+	span.End(err)
+}
+
+// startStoreSpan starts the SpanStore span of a lookup of the store.
+func startStoreSpan(ctx context.Context, store string) (context.Context, Span) {
+	ctx, span := startSpan(ctx, SpanStore)
+	span.SetAttribute(AttributeStore, store)
+	return ctx, span
+}
+
+// rpcCall is a JSON-RPC call made to verify a proof, observed by the OnRPCCall hook and
+// traced by a SpanRPC span.
+type rpcCall struct {
+	ctx    context.Context
+	method string
+	start  time.Time
+	span   Span
+}
+
+// startRPCCall starts a JSON-RPC call of the method, returning the context to make the call
+// with.
+func startRPCCall(ctx context.Context, method string) (context.Context, rpcCall) {
+	call := rpcCall{ctx: ctx, method: method, start: time.Now()}
+	ctx, call.span = startSpan(ctx, SpanRPC)
+	call.span.SetAttribute(AttributeRPCMethod, method)
+	return ctx, call
+}
+
+// end ends the JSON-RPC call with its error.
+func (c rpcCall) end(err error) {
+	observeRPCCall(c.ctx, c.method, c.start, err)
+	if err != nil {
+		logFromContext(c.ctx).Debug(c.ctx, "ethauth: json-rpc call failed", "method", c.method, "error", err)
+	}
+	c.span.End(err)
+}
+
+// validatorName returns the name of the function of the validator, ie. "ValidateEOAProof",
+// or of the constructor of validator closures, ie. "MultisigValidator".
+func validatorName(v ValidatorFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(v).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	name = name[strings.LastIndexByte(name, '/')+1:]
+	if _, after, ok := strings.Cut(name, "."); ok {
+		name = after
+	}
+	// method values, ie. "(*X).Validate-fm", and closures, ie. "MultisigValidator.func1"
+	name = strings.TrimSuffix(name, "-fm")
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 || strings.Trim(name[i+len(".func"):], "0123456789") != "" {
+			return name
+		}
+		name = name[:i]
+	}
+}
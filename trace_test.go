@@ -0,0 +1,86 @@
+package ethauth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &testSpan{name: name, attrs: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (s *testSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+
+func (s *testSpan) End(err error) { s.err, s.ended = err, true }
+
+func (t *testTracer) span(name string) *testSpan {
+	for i := len(t.spans) - 1; i >= 0; i-- {
+		if t.spans[i].name == name {
+			return t.spans[i]
+		}
+	}
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+	proofString, err := Issue(NewWalletSigner(wallet), WithApp("ETHAuthTest"), WithExpiresIn(time.Hour))
+	require.NoError(t, err)
+
+	tracer := &testTracer{}
+	ethAuth, err := New()
+	require.NoError(t, err)
+	ethAuth.ConfigTracer(tracer)
+	ethAuth.ConfigCache(NewVerificationCache(10, time.Minute))
+
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	for _, span := range tracer.spans {
+		require.True(t, span.ended, span.name)
+	}
+	require.Equal(t, "ETHAuthTest", tracer.span(SpanVerify).attrs[AttributeApp])
+	require.Equal(t, false, tracer.span(SpanSignature).attrs[AttributeCacheHit])
+	require.Equal(t, "ValidateEOAProof", tracer.span(SpanSignature).attrs[AttributeValidator])
+	require.NotNil(t, tracer.span(SpanDigest))
+	require.NotNil(t, tracer.span(SpanRecover))
+
+	// the validators aren't called for cached signatures
+	tracer.spans = nil
+	_, _, err = ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.Equal(t, true, tracer.span(SpanSignature).attrs[AttributeCacheHit])
+	require.Nil(t, tracer.span(SpanRecover))
+
+	tracer.spans = nil
+	_, _, err = ethAuth.DecodeProof("eth.invalid")
+	require.Error(t, err)
+	require.Error(t, tracer.span(SpanParse).err)
+	require.Equal(t, "invalid_proof", tracer.span(SpanVerify).attrs[AttributeFailureReason])
+}
+
+func TestValidatorName(t *testing.T) {
+	require.Equal(t, "ValidateEOAProof", validatorName(ValidateEOAProof))
+	require.Equal(t, "NewSignatureValidatorFunc", validatorName(NewSignatureValidatorFunc(EOASignatureValidator{})))
+}
@@ -0,0 +1,92 @@
+package ethauth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Transport is an http.RoundTripper which signs a proof with its Signer and attaches it
+// to outgoing requests as a bearer authorization header. The proof is cached and
+// re-signed automatically shortly before it expires.
+type Transport struct {
+	// Base is the underlying RoundTripper, defaulting to http.DefaultTransport.
+	Base http.RoundTripper
+
+	// Signer signs the proofs.
+	Signer Signer
+
+	// Claims is the template of the proof claims. IssuedAt and ExpiresAt are set
+	// each time a proof is signed.
+	Claims Claims
+
+	// TTL is the lifetime of each proof, defaulting to 1 hour.
+	TTL time.Duration
+
+	// RefreshBefore is how long before expiry the proof is re-signed, defaulting
+	// to 1/10th of the TTL.
+	RefreshBefore time.Duration
+
+	mu        sync.Mutex
+	proof     string
+	expiresAt time.Time
+}
+
+// NewTransport returns a Transport signing proofs of the claims template with the signer.
+func NewTransport(signer Signer, claims Claims, ttl time.Duration) *Transport {
+	return &Transport{Signer: signer, Claims: claims, TTL: ttl}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proof, err := t.Proof(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// per RoundTripper contract, the original request must not be modified
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+proof)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// Proof returns the cached encoded proof, signing a new one if it is missing or about to expire.
+func (t *Transport) Proof(req *http.Request) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ttl := t.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	refreshBefore := t.RefreshBefore
+	if refreshBefore <= 0 {
+		refreshBefore = ttl / 10
+	}
+
+	if t.proof != "" && time.Now().Add(refreshBefore).Before(t.expiresAt) {
+		return t.proof, nil
+	}
+
+	proof := NewProof()
+	proof.Claims = t.Claims
+	proof.Claims.SetIssuedAtNow()
+	proof.Claims.SetExpiryIn(ttl)
+
+	if err := SignProofWithSigner(req.Context(), proof, t.Signer); err != nil {
+		return "", err
+	}
+	encoded, err := proof.Encode()
+	if err != nil {
+		return "", fmt.Errorf("ethauth: failed to encode proof - %w", err)
+	}
+
+	t.proof = encoded
+	t.expiresAt = time.Unix(proof.Claims.ExpiresAt, 0)
+	return t.proof, nil
+}
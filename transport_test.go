@@ -0,0 +1,47 @@
+package ethauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	var proofs []string
+	server := httptest.NewServer(Middleware(ethAuth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proof, _ := ProofFromRequest(r)
+		proofs = append(proofs, proof)
+	})))
+	defer server.Close()
+
+	transport := NewTransport(NewWalletSigner(wallet), Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}, 5*time.Minute)
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	require.Len(t, proofs, 2)
+	require.Equal(t, proofs[0], proofs[1])
+
+	// re-signs once within the refresh window
+	transport.RefreshBefore = 10 * time.Minute
+	time.Sleep(1 * time.Second)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEqual(t, proofs[0], proofs[2])
+}
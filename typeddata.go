@@ -0,0 +1,116 @@
+package ethauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// maxSafeJSONInteger is the largest integer JavaScript numbers represent exactly.
+const maxSafeJSONInteger = 1<<53 - 1
+
+// TypedDataJSON returns the EIP712 typed data of the proof claims as the JSON payload browser
+// wallets expect for `eth_signTypedData_v4`, so frontends don't have to reconstruct it. The
+// JSON is deterministic: the EIP712Domain type comes first, followed by the primary Claims
+// type and any other types in name order, the domain and message fields follow the order of
+// their type, and the domain chainId is encoded as a decimal string. Message integers are
+// encoded as JSON numbers, or as decimal strings beyond the JavaScript safe integer range.
+func (t *Proof) TypedDataJSON() ([]byte, error) {
+	typedData, err := t.MessageTypedData()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	var encodeErr error
+	write := func(b *bytes.Buffer, v interface{}) {
+		data, err := json.Marshal(v)
+		if err != nil && encodeErr == nil {
+			encodeErr = fmt.Errorf("ethauth: failed to encode typed data - %w", err)
+		}
+		b.Write(data)
+	}
+
+	b.WriteString(`{"types":{`)
+
+	typeNames := make([]string, 0, len(typedData.Types))
+	for name := range typedData.Types {
+		if name != "EIP712Domain" && name != typedData.PrimaryType {
+			typeNames = append(typeNames, name)
+		}
+	}
+	sort.Strings(typeNames)
+	typeNames = append([]string{"EIP712Domain", typedData.PrimaryType}, typeNames...)
+
+	for i, name := range typeNames {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		write(&b, name)
+		b.WriteByte(':')
+		b.WriteByte('[')
+		for j, arg := range typedData.Types[name] {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(`{"name":`)
+			write(&b, arg.Name)
+			b.WriteString(`,"type":`)
+			write(&b, arg.Type)
+			b.WriteByte('}')
+		}
+		b.WriteByte(']')
+	}
+
+	b.WriteString(`},"primaryType":`)
+	write(&b, typedData.PrimaryType)
+
+	b.WriteString(`,"domain":{`)
+	domain := typedData.Domain.Map()
+	for i, arg := range typedData.Types["EIP712Domain"] {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		write(&b, arg.Name)
+		b.WriteByte(':')
+		if v, ok := domain[arg.Name].(*big.Int); ok {
+			write(&b, v.String())
+		} else {
+			write(&b, domain[arg.Name])
+		}
+	}
+
+	b.WriteString(`},"message":{`)
+	for i, arg := range typedData.Types[typedData.PrimaryType] {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		write(&b, arg.Name)
+		b.WriteByte(':')
+		write(&b, typedDataJSONValue(typedData.Message[arg.Name]))
+	}
+	b.WriteString(`}}`)
+
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	return b.Bytes(), nil
+}
+
+func typedDataJSONValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int64:
+		if n > maxSafeJSONInteger || n < -maxSafeJSONInteger {
+			return fmt.Sprintf("%d", n)
+		}
+	case uint64:
+		if n > maxSafeJSONInteger {
+			return fmt.Sprintf("%d", n)
+		}
+	case *big.Int:
+		return n.String()
+	}
+	return v
+}
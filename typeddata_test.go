@@ -0,0 +1,47 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedDataJSON(t *testing.T) {
+	proof := NewProof()
+	proof.Address = "0xe0c9828dee3411a28ccb4bb82a18d0aad24489e0"
+	proof.Claims = Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 1 << 60, ChainID: 137, ETHAuthVersion: ETHAuthVersion}
+
+	typedDataJSON, err := proof.TypedDataJSON()
+	require.NoError(t, err)
+	require.Equal(t, `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"}],`+
+		`"Claims":[{"name":"app","type":"string"},{"name":"iat","type":"int64"},{"name":"exp","type":"int64"},{"name":"n","type":"uint64"},{"name":"cid","type":"uint64"},{"name":"v","type":"string"}]},`+
+		`"primaryType":"Claims","domain":{"name":"ETHAuth","version":"1","chainId":"137"},`+
+		`"message":{"app":"ETHAuthTest","iat":1700000000,"exp":1700000300,"n":"1152921504606846976","cid":137,"v":"1"}}`, string(typedDataJSON))
+
+	// the JSON payload has the same digest as the proof
+	typedData := typedDataFromWalletJSON(t, string(typedDataJSON))
+	digest, _, err := typedData.Encode()
+	require.NoError(t, err)
+	expected, err := proof.MessageDigest()
+	require.NoError(t, err)
+	require.Equal(t, expected, digest)
+}
+
+// typedDataFromWalletJSON decodes an eth_signTypedData_v4 payload as a wallet would, accepting
+// the decimal string chainId which ethcoder doesn't.
+func typedDataFromWalletJSON(t *testing.T, typedDataJSON string) *ethcoder.TypedData {
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(typedDataJSON), &payload))
+	domain := payload["domain"].(map[string]interface{})
+	if chainID, ok := domain["chainId"].(string); ok {
+		domain["chainId"] = json.Number(chainID)
+	}
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	typedData, err := ethcoder.TypedDataFromJSON(string(data))
+	require.NoError(t, err)
+	return typedData
+}
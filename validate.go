@@ -1,6 +1,7 @@
 package ethauth
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
@@ -15,18 +16,92 @@ import (
 
 type ValidatorFunc func(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error)
 
+// SignatureValidator validates that a message digest has been signed by the account address.
+// Use NewSignatureValidatorFunc to register a SignatureValidator with ETHAuth.
+type SignatureValidator interface {
+	IsValidSignature(ctx context.Context, address common.Address, digest, signature []byte) (bool, error)
+}
+
+// NewSignatureValidatorFunc returns a ValidatorFunc which computes the proof message digest
+// and passes it to the SignatureValidator.
+func NewSignatureValidatorFunc(validator SignatureValidator) ValidatorFunc {
+	return func(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+		messageDigest, err := proof.MessageDigest()
+		if err != nil {
+			return false, "", fmt.Errorf("SignatureValidator failed. Unable to compute ethauth message digest, because %w", err)
+		}
+		address, err := proof.AddressBytes()
+		if err != nil {
+			return false, "", fmt.Errorf("SignatureValidator failed. address is not a valid Ethereum address")
+		}
+		signature, err := ethcoder.HexDecode(proof.Signature)
+		if err != nil {
+			return false, "", fmt.Errorf("SignatureValidator failed. HexDecode of proof.signature failed - %w", err)
+		}
+
+		isValid, err := validator.IsValidSignature(ctx, address, messageDigest, signature)
+		if err != nil {
+			return false, "", err
+		}
+		if !isValid {
+			return false, "", fmt.Errorf("SignatureValidator failed. invalid signature")
+		}
+		return true, proof.Address, nil
+	}
+}
+
+// EOASignatureValidator is a SignatureValidator for EOA (externally owned account) signatures.
+type EOASignatureValidator struct{}
+
+func (EOASignatureValidator) IsValidSignature(ctx context.Context, address common.Address, digest, signature []byte) (bool, error) {
+	return isValidEOASignature(address, digest, signature)
+}
+
+// ContractSignatureValidator is a SignatureValidator for deployed smart-contract based accounts,
+// calling the EIP-1271 isValidSignature method of the account contract.
+type ContractSignatureValidator struct {
+	Provider *ethrpc.Provider
+}
+
+func (v ContractSignatureValidator) IsValidSignature(ctx context.Context, address common.Address, digest, signature []byte) (bool, error) {
+	if v.Provider == nil {
+		return false, fmt.Errorf("ContractSignatureValidator failed. provider is nil")
+	}
+
+	input, err := ethcoder.ABIEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []interface{}{
+		ethcoder.BytesToBytes32(digest),
+		signature,
+	})
+	if err != nil {
+		return false, fmt.Errorf("ContractSignatureValidator failed. EncodeMethodCalldata error")
+	}
+
+	rpcCtx, call := startRPCCall(ctx, "eth_call")
+	output, err := v.Provider.CallContract(rpcCtx, ethereum.CallMsg{To: &address, Data: input}, nil)
+	call.end(err)
+	if err != nil {
+		return false, fmt.Errorf("ContractSignatureValidator failed. Provider CallContract failed - %w", err)
+	}
+	return len(output) >= 4 && IsValidSignatureBytes32MagicValue == ethcoder.HexEncode(output[:4]), nil
+}
+
 // ValidateEOAProof verifies the account proof, testing if the proof claims have been signed with an
 // EOA (externally owned account) and will return success/failture, the account address as a string, and any errors.
 func ValidateEOAProof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
-	// Compute eip712 encoded message from the proof claims
-	message, err := proof.Message()
+	// Compute eip712 message digest from the proof claims
+	var digest [32]byte
+	_, span := startSpan(ctx, SpanDigest)
+	err := proof.messageDigest(&digest)
+	span.End(err)
 	if err != nil {
 		return false, "", fmt.Errorf("ValidateEOAProof failed. Unable to compute ethauth message digest, because %w", err)
 	}
 
-	isValid, err := ValidateEOASignature(proof.Address, message, proof.Signature)
+	_, span = startSpan(ctx, SpanRecover)
+	isValid, err := recoverEOADigest(proof.Address, &digest, proof.Signature)
+	span.End(err)
 	if err != nil {
-		return false, "", err
+		return false, "", fmt.Errorf("ValidateEOASignature, %w", err)
 	}
 	if !isValid {
 		return false, "", fmt.Errorf("ValidateEOAProof failed. invalid EOA signature")
@@ -39,14 +114,26 @@ func ValidateEOAProof(ctx context.Context, provider *ethrpc.Provider, chainID *b
 // method of the remote contract. This method will return success/failure, the
 // account address as a string, and any errors. The wallet contract must be deployed in
 // order for this call to be successful. In order test an undeployed smart-wallet, you
-// will have to implement your own custom validator method.
+// will have to implement your own custom validator method. Proofs embedding a wallet witness
+// of a verifier of ETHAuth.ConfigWitnessVerifiers are verified offline instead.
 func ValidateContractAccountProof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+	// Verify the signature offline with the wallet witness of the proof, if any
+	if ok, err := verifyWitness(ctx, chainID, proof); ok {
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateContractAccountProof failed. %w", err)
+		}
+		return true, proof.Address, nil
+	}
+
 	if provider == nil {
 		return false, "", fmt.Errorf("ValidateContractAccountProof failed. provider is nil")
 	}
 	if chainID == nil {
 		return false, "", fmt.Errorf("ValidateContractAccountProof failed. chainID is nil")
 	}
+	if _, err := proof.AddressBytes(); err != nil {
+		return false, "", fmt.Errorf("ValidateContractAccountProof failed. address is not a valid Ethereum address")
+	}
 
 	// Compute eip712 message digest from the proof claims
 	messageDigest, err := proof.MessageDigest()
@@ -55,7 +142,9 @@ func ValidateContractAccountProof(ctx context.Context, provider *ethrpc.Provider
 	}
 
 	// Early check to ensure the contract wallet has been deployed
-	walletCode, err := provider.CodeAt(ctx, common.HexToAddress(proof.Address), nil)
+	rpcCtx, call := startRPCCall(ctx, "eth_getCode")
+	walletCode, err := provider.CodeAt(rpcCtx, common.HexToAddress(proof.Address), nil)
+	call.end(err)
 	if err != nil {
 		return false, "", fmt.Errorf("ValidateContractAccountProof failed. unable to fetch wallet contract code - %w", err)
 	}
@@ -78,13 +167,21 @@ func ValidateContractAccountProof(ctx context.Context, provider *ethrpc.Provider
 		return false, "", fmt.Errorf("ValidateContractAccountProof failed. EncodeMethodCalldata error")
 	}
 
+	// Verify the signature at the block of the `iat` claim, if configured
+	blockNumber, err := signatureBlockNumber(ctx, provider, proof)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateContractAccountProof failed. %w", err)
+	}
+
 	toAddress := common.HexToAddress(proof.Address)
 	txMsg := ethereum.CallMsg{
 		To:   &toAddress,
 		Data: input,
 	}
 
-	output, err := provider.CallContract(context.Background(), txMsg, nil)
+	rpcCtx, call = startRPCCall(ctx, "eth_call")
+	output, err := provider.CallContract(rpcCtx, txMsg, blockNumber)
+	call.end(err)
 	if err != nil {
 		return false, "", fmt.Errorf("ValidateContractAccountProof failed. Provider CallContract failed - %w", err)
 	}
@@ -96,9 +193,127 @@ func ValidateContractAccountProof(ctx context.Context, provider *ethrpc.Provider
 	return true, proof.Address, nil
 }
 
+// ValidateERC6492Proof verifies the account proof for counterfactual smart-contract based accounts,
+// where the proof signature has been wrapped as per ERC-6492 with the factory and calldata needed
+// to deploy the wallet. The deployment and the EIP-1271 isValidSignature call are simulated in a
+// single eth_call, so the wallet contract does not need to be deployed. This method will return
+// success/failure, the account address as a string, and any errors. Proofs embedding a wallet
+// witness of a verifier of ETHAuth.ConfigWitnessVerifiers are verified offline instead.
+func ValidateERC6492Proof(ctx context.Context, provider *ethrpc.Provider, chainID *big.Int, proof *Proof) (bool, string, error) {
+	// Verify the signature offline with the wallet witness of the proof, if any
+	if ok, err := verifyWitness(ctx, chainID, proof); ok {
+		if err != nil {
+			return false, "", fmt.Errorf("ValidateERC6492Proof failed. %w", err)
+		}
+		return true, proof.Address, nil
+	}
+
+	if provider == nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. provider is nil")
+	}
+	if chainID == nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. chainID is nil")
+	}
+	if _, err := proof.AddressBytes(); err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. address is not a valid Ethereum address")
+	}
+
+	signature, err := ethcoder.HexDecode(proof.Signature)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. HexDecode of proof.signature failed - %w", err)
+	}
+	factory, factoryCalldata, innerSignature, err := DecodeERC6492Signature(signature)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. %w", err)
+	}
+
+	// Compute eip712 message digest from the proof claims
+	messageDigest, err := proof.MessageDigest()
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. Unable to compute ethauth message digest, because %w", err)
+	}
+
+	isValidSignatureCalldata, err := ethcoder.ABIEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []interface{}{
+		ethcoder.BytesToBytes32(messageDigest),
+		innerSignature,
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. EncodeMethodCalldata error")
+	}
+
+	// The validator contract is deployless, its constructor arguments are laid out as
+	// signer | factory | len(factoryCalldata) | len(isValidSignatureCalldata) | factoryCalldata | isValidSignatureCalldata
+	input := append([]byte{}, erc6492ValidatorBytecode...)
+	input = append(input, common.LeftPadBytes(common.HexToAddress(proof.Address).Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(factory.Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(big.NewInt(int64(len(factoryCalldata))).Bytes(), 32)...)
+	input = append(input, common.LeftPadBytes(big.NewInt(int64(len(isValidSignatureCalldata))).Bytes(), 32)...)
+	input = append(input, factoryCalldata...)
+	input = append(input, isValidSignatureCalldata...)
+
+	// Verify the signature at the block of the `iat` claim, if configured
+	blockNumber, err := signatureBlockNumber(ctx, provider, proof)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. %w", err)
+	}
+
+	rpcCtx, call := startRPCCall(ctx, "eth_call")
+	output, err := provider.CallContract(rpcCtx, ethereum.CallMsg{Data: input}, blockNumber)
+	call.end(err)
+	if err != nil {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. Provider CallContract failed - %w", err)
+	}
+
+	isValid := len(output) == 32 && output[31] == 1
+	if !isValid {
+		return false, "", fmt.Errorf("ValidateERC6492Proof failed. invalid signature")
+	}
+	return true, proof.Address, nil
+}
+
+// DecodeERC6492Signature unwraps an ERC-6492 signature, returning the wallet factory address,
+// the factory calldata used to deploy the wallet, and the inner signature to be validated
+// with EIP-1271 once the wallet is deployed.
+func DecodeERC6492Signature(signature []byte) (common.Address, []byte, []byte, error) {
+	if !IsERC6492Signature(signature) {
+		return common.Address{}, nil, nil, fmt.Errorf("signature is not an ERC-6492 signature")
+	}
+
+	values, err := ethcoder.ABIUnpackArguments([]string{"address", "bytes", "bytes"}, signature[:len(signature)-32])
+	if err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("unable to decode ERC-6492 signature - %w", err)
+	}
+
+	factory, ok1 := values[0].(common.Address)
+	factoryCalldata, ok2 := values[1].([]byte)
+	innerSignature, ok3 := values[2].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return common.Address{}, nil, nil, fmt.Errorf("unable to decode ERC-6492 signature")
+	}
+	return factory, factoryCalldata, innerSignature, nil
+}
+
+// IsERC6492Signature returns true if the signature ends with the ERC-6492 magic suffix.
+func IsERC6492Signature(signature []byte) bool {
+	return len(signature) > 32 && bytes.Equal(signature[len(signature)-32:], erc6492MagicSuffix)
+}
+
 const (
-	// IsValidSignatureBytes32 is the EIP-1271 magic value we test
+	// IsValidSignatureBytes32MagicValue is the EIP-1271 magic value we test
 	IsValidSignatureBytes32MagicValue = "0x1626ba7e"
+
+	// ERC6492MagicSuffix is the 32-byte suffix appended to ERC-6492 wrapped signatures
+	ERC6492MagicSuffix = "0x6492649264926492649264926492649264926492649264926492649264926492"
+)
+
+var erc6492MagicSuffix = ethcoder.MustHexDecode(ERC6492MagicSuffix)
+
+// erc6492ValidatorBytecode is the creation code of a deployless validator contract. Its constructor
+// deploys the wallet through the factory if the signer has no code, calls isValidSignature on the
+// signer, and returns a 32-byte word of 1 if the EIP-1271 magic value was returned, or 0 otherwise.
+var erc6492ValidatorBytecode = ethcoder.MustHexDecode(
+	"0x61004b380361004b6000396000513b60235760006000604051608060006020515af1505b" +
+		"602060006060516040516080016000515afa60005160e01c631626ba7e141660005260206000f3",
 )
 
 // Validate the public key address of an Ethereum signed message
@@ -114,14 +329,18 @@ func ValidateEOASignature(address string, message []byte, signatureHex string) (
 	if err != nil {
 		return false, fmt.Errorf("ValidateEOASignature, signature is an invalid hex string")
 	}
+	if len(sig) != 65 {
+		return false, fmt.Errorf("ValidateEOASignature, signature is not of proper length")
+	}
+	if err := normalizeSignature(sig); err != nil {
+		return false, fmt.Errorf("ValidateEOASignature, %w", err)
+	}
 
 	isValid, err := ethwallet.IsValid191Signature(common.HexToAddress(address), message, sig)
 	if err != nil {
-		fmt.Println("NO1")
 		return false, fmt.Errorf("ValidateEOASignature, invalid signature")
 	}
 	if !isValid {
-		fmt.Println("NO2")
 		return false, fmt.Errorf("ValidateEOASignature, invalid signature")
 	}
 	return true, nil
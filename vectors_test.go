@@ -0,0 +1,129 @@
+package ethauth
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+var updateVectors = flag.Bool("update", false, "update the testdata vectors")
+
+// testVector is a cross-language test vector of the message, digest and signature of claims
+// signed by the test mnemonic account.
+type testVector struct {
+	Name      string          `json:"name"`
+	Address   string          `json:"address"`
+	Claims    json.RawMessage `json:"claims"`
+	TypedData json.RawMessage `json:"typedData,omitempty"`
+	Message   string          `json:"message"`
+	Digest    string          `json:"digest"`
+	Signature string          `json:"signature"`
+	Proof     string          `json:"proof"`
+}
+
+var testVectorClaims = []struct {
+	name   string
+	claims Claims
+}{
+	{"minimal", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, ETHAuthVersion: ETHAuthVersion}},
+	{"nonce-origin", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 42, Origin: "https://app.example.com", ETHAuthVersion: ETHAuthVersion}},
+	{"chain-id", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, ChainID: 137, ETHAuthVersion: ETHAuthVersion}},
+	{"audience-subject-id", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Audience: "https://api.example.com", Subject: "user-1", ID: "5f0c9b2e", ETHAuthVersion: ETHAuthVersion}},
+	{"scope", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Scope: Scopes{"read:orders", "write:orders"}, ETHAuthVersion: ETHAuthVersion}},
+	{"large-nonce", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 1<<64 - 1, ETHAuthVersion: ETHAuthVersion}},
+	{"eip191", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Type: ProofTypeEIP191, ETHAuthVersion: ETHAuthVersion}},
+	{"siwe", Claims{App: "ETHAuthTest", IssuedAt: 1700000000, ExpiresAt: 1700000300, Nonce: 42, Type: ProofTypeSIWE, Origin: "https://app.example.com", ChainID: 1, ETHAuthVersion: ETHAuthVersion}},
+}
+
+func newTestVector(t *testing.T, wallet *ethwallet.Wallet, name string, claims Claims) testVector {
+	proof := signTestProof(t, wallet, claims)
+	if claims.Type != "" {
+		// personal_sign proof types
+		require.NoError(t, SignProof(proof, wallet.PrivateKey()))
+	}
+
+	claimsJSON, err := json.Marshal(proof.Claims)
+	require.NoError(t, err)
+	message, err := proof.Message()
+	require.NoError(t, err)
+	digest, err := proof.MessageDigest()
+	require.NoError(t, err)
+	proofString, err := proof.Encode()
+	require.NoError(t, err)
+
+	v := testVector{
+		Name:      name,
+		Address:   proof.Address,
+		Claims:    claimsJSON,
+		Message:   ethcoder.HexEncode(message),
+		Digest:    ethcoder.HexEncode(digest),
+		Signature: proof.Signature,
+		Proof:     proofString,
+	}
+	if claims.Type == "" {
+		v.TypedData, err = proof.TypedDataJSON()
+		require.NoError(t, err)
+	}
+	return v
+}
+
+func TestVectors(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	var vectors []testVector
+	for _, tc := range testVectorClaims {
+		vectors = append(vectors, newTestVector(t, wallet, tc.name, tc.claims))
+	}
+
+	if *updateVectors {
+		data, err := json.MarshalIndent(vectors, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile("ethauthtest/testdata/vectors.json", append(data, '\n'), 0644))
+	}
+
+	data, err := os.ReadFile("ethauthtest/testdata/vectors.json")
+	require.NoError(t, err)
+	var expected []testVector
+	require.NoError(t, json.Unmarshal(data, &expected))
+	require.Len(t, vectors, len(expected))
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigClock(func() time.Time { return time.Unix(1700000100, 0) }))
+
+	for i, v := range expected {
+		actual := vectors[i]
+		require.JSONEq(t, string(v.Claims), string(actual.Claims), v.Name)
+		if v.TypedData != nil || actual.TypedData != nil {
+			require.JSONEq(t, string(v.TypedData), string(actual.TypedData), v.Name)
+		}
+		v.Claims, v.TypedData = actual.Claims, actual.TypedData
+		require.Equal(t, v, actual, v.Name)
+
+		// the vector proofs verify
+		_, _, err := ethAuth.DecodeProof(v.Proof)
+		require.NoError(t, err, v.Name)
+	}
+}
+
+func TestClaimsCanonicalize(t *testing.T) {
+	claims := Claims{App: "ETHAuthTest", Origin: "HTTPS://App.Example.com/", Scope: Scopes{"write", "read", "write"}}
+	canonical := claims.Canonicalize()
+	require.Equal(t, "https://app.example.com", canonical.Origin)
+	require.Equal(t, Scopes{"read", "write"}, canonical.Scope)
+	require.Equal(t, Scopes{"write", "read", "write"}, claims.Scope)
+	require.Equal(t, canonical, canonical.Canonicalize())
+
+	// custom claims are typed in name order
+	claims = Claims{App: "ETHAuthTest", Custom: &testCustomClaims{Role: "admin", Tenant: 7}}
+	typedData, err := claims.TypedData()
+	require.NoError(t, err)
+	require.Equal(t, []ethcoder.TypedDataArgument{{Name: "app", Type: "string"}, {Name: "role", Type: "string"}, {Name: "tenant", Type: "uint64"}}, typedData.Types["Claims"])
+}
@@ -0,0 +1,70 @@
+package ethauth
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+)
+
+// ClaimsVersion is the claims schema of a `v` claim version, which defines the EIP712 typed
+// data signed for the claims. Registering the schemas of each version lets the claims
+// format evolve, so validators keep accepting proofs issued by deployed clients of an older
+// version while new clients issue proofs of the current one.
+type ClaimsVersion struct {
+	// Version is the `v` claim of the claims of this schema
+	Version string
+
+	// TypedData builds the EIP712 typed data of the claims
+	TypedData func(c Claims) (*ethcoder.TypedData, error)
+}
+
+var (
+	claimsVersionsMu sync.RWMutex
+	claimsVersions   = map[string]ClaimsVersion{
+		ETHAuthVersion: {Version: ETHAuthVersion, TypedData: claimsTypedDataV1},
+	}
+)
+
+// RegisterClaimsVersion registers the claims schema of a new `v` claim version. Versions
+// can't be registered twice, so the schema of a version never changes once proofs have
+// been signed with it. Use ETHAuth.ConfigDeprecatedVersion to phase out the proofs of
+// an older version.
+func RegisterClaimsVersion(cv ClaimsVersion) error {
+	if cv.Version == "" {
+		return fmt.Errorf("ethauth: claims version is empty")
+	}
+	if cv.TypedData == nil {
+		return fmt.Errorf("ethauth: claims version %q typed data builder is nil", cv.Version)
+	}
+
+	claimsVersionsMu.Lock()
+	defer claimsVersionsMu.Unlock()
+
+	if _, ok := claimsVersions[cv.Version]; ok {
+		return fmt.Errorf("ethauth: claims version %q is already registered", cv.Version)
+	}
+	claimsVersions[cv.Version] = cv
+	return nil
+}
+
+// LookupClaimsVersion returns the registered claims schema of a `v` claim version.
+func LookupClaimsVersion(version string) (ClaimsVersion, bool) {
+	claimsVersionsMu.RLock()
+	defer claimsVersionsMu.RUnlock()
+	cv, ok := claimsVersions[version]
+	return cv, ok
+}
+
+// ClaimsVersions returns the registered claims versions, in order.
+func ClaimsVersions() []string {
+	claimsVersionsMu.RLock()
+	defer claimsVersionsMu.RUnlock()
+	versions := make([]string, 0, len(claimsVersions))
+	for v := range claimsVersions {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	return versions
+}
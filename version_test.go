@@ -0,0 +1,72 @@
+package ethauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/require"
+)
+
+// claimsTypedDataV2 is a test claims schema which signs the claims under the version 2 domain.
+func claimsTypedDataV2(c Claims) (*ethcoder.TypedData, error) {
+	td, err := claimsTypedDataV1(c)
+	if err != nil {
+		return nil, err
+	}
+	td.Domain.Version = "2"
+	return td, nil
+}
+
+func TestClaimsVersions(t *testing.T) {
+	if _, ok := LookupClaimsVersion("2"); !ok {
+		require.NoError(t, RegisterClaimsVersion(ClaimsVersion{Version: "2", TypedData: claimsTypedDataV2}))
+	}
+	require.Error(t, RegisterClaimsVersion(ClaimsVersion{Version: "2", TypedData: claimsTypedDataV2}))
+	require.Error(t, RegisterClaimsVersion(ClaimsVersion{Version: "3"}))
+	require.Error(t, RegisterClaimsVersion(ClaimsVersion{TypedData: claimsTypedDataV2}))
+	require.Equal(t, []string{"1", "2"}, ClaimsVersions())
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+
+	claims := Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+	claims.SetIssuedAtNow()
+	claims.SetExpiryIn(5 * time.Minute)
+
+	// the versions sign different messages
+	v1 := signTestProof(t, wallet, claims)
+	claims.ETHAuthVersion = "2"
+	v2 := signTestProof(t, wallet, claims)
+	require.NotEqual(t, v1.Signature, v2.Signature)
+
+	// proofs of both versions are accepted
+	for _, proof := range []*Proof{v1, v2} {
+		proofString, err := ethAuth.EncodeProof(proof)
+		require.NoError(t, err)
+		_, _, err = ethAuth.DecodeProof(proofString)
+		require.NoError(t, err)
+	}
+
+	// proofs of unregistered versions are rejected
+	claims.ETHAuthVersion = "3"
+	_, err = claims.TypedData()
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+	require.ErrorIs(t, claims.Valid(), ErrUnsupportedVersion)
+	require.ErrorIs(t, ethAuth.ConfigDeprecatedVersion("3", time.Time{}), ErrUnsupportedVersion)
+
+	// deprecated versions are accepted until their sunset
+	require.NoError(t, ethAuth.ConfigDeprecatedVersion("1", time.Now().Add(time.Hour)))
+	_, err = ethAuth.ValidateProof(v1)
+	require.NoError(t, err)
+
+	require.NoError(t, ethAuth.ConfigDeprecatedVersion("1", time.Now().Add(-time.Hour)))
+	_, err = ethAuth.ValidateProof(v1)
+	require.ErrorIs(t, err, ErrDeprecatedVersion)
+	_, err = ethAuth.ValidateProof(v2)
+	require.NoError(t, err)
+}
@@ -0,0 +1,245 @@
+package ethauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// WalletWitness is the signature validation witness of a contract wallet, which a proof may
+// embed in its Extra so the proof signature is verified offline, without any JSON-RPC call,
+// by the WitnessVerifier of its Type, see ETHAuth.ConfigWitnessVerifiers.
+type WalletWitness struct {
+	// Type selects the WitnessVerifier of the witness, ie. "sequence"
+	Type string `json:"type"`
+
+	// Data is the witness of the verifier, ie. a SequenceWitness
+	Data json.RawMessage `json:"data"`
+}
+
+// WitnessVerifier verifies that the witness of a contract wallet authorizes the wallet
+// address to sign the digest on the chain, so its EIP-1271 isValidSignature method would
+// accept the signature. The witness must be self-authenticating, ie. its configuration must
+// derive the wallet address, as it is provided by the client.
+type WitnessVerifier interface {
+	VerifyWitness(ctx context.Context, chainID *big.Int, address common.Address, digest []byte, witness json.RawMessage) (bool, error)
+}
+
+var witnessCtxKey = &contextKey{"witness"}
+
+// ConfigWitnessVerifiers sets the verifiers of the wallet witnesses of proofs, by witness type.
+// ValidateContractAccountProof and ValidateERC6492Proof verify the proofs embedding a witness
+// of a configured type offline, and only call the wallet contract when the witness is absent.
+// A proof whose witness is invalid is rejected, rather than verified on the chain.
+func (w *ETHAuth) ConfigWitnessVerifiers(verifiers map[string]WitnessVerifier) error {
+	for typ, verifier := range verifiers {
+		if typ == "" || verifier == nil {
+			return fmt.Errorf("ethauth: witness verifier requires a type and a verifier")
+		}
+	}
+	w.witnessVerifiers = verifiers
+	return nil
+}
+
+// SetWitness embeds the wallet witness in the proof Extra.
+func (t *Proof) SetWitness(witness WalletWitness) error {
+	if witness.Type == "" {
+		return fmt.Errorf("ethauth: wallet witness type is empty")
+	}
+	data, err := json.Marshal(witness)
+	if err != nil {
+		return fmt.Errorf("ethauth: failed to encode wallet witness - %w", err)
+	}
+	t.Extra = ethcoder.HexEncode(data)
+	return nil
+}
+
+// Witness returns the wallet witness embedded in the proof Extra, or nil if the proof has no
+// witness, ie. its Extra is empty, or carries other data such as the delegations of delegated
+// proofs.
+func (t *Proof) Witness() (*WalletWitness, error) {
+	if t.Extra == "" || t.Claims.Type == ProofTypeDelegated {
+		return nil, nil
+	}
+	data, err := ethcoder.HexDecode(t.Extra)
+	if err != nil || len(data) == 0 || data[0] != '{' {
+		return nil, nil
+	}
+	var witness WalletWitness
+	if err := json.Unmarshal(data, &witness); err != nil {
+		return nil, fmt.Errorf("ethauth: invalid wallet witness encoding - %w", err)
+	}
+	if witness.Type == "" {
+		return nil, fmt.Errorf("ethauth: wallet witness type is empty")
+	}
+	return &witness, nil
+}
+
+// verifyWitness verifies the proof signature with the witness embedded in the proof, and
+// reports whether the proof has a witness of a verifier passed in the context. The chain of
+// the witness is the `cid` claim of the proof, or the chain of the provider.
+func verifyWitness(ctx context.Context, chainID *big.Int, proof *Proof) (bool, error) {
+	verifiers, ok := ctx.Value(witnessCtxKey).(map[string]WitnessVerifier)
+	if !ok {
+		return false, nil
+	}
+	witness, err := proof.Witness()
+	if err != nil {
+		return true, err
+	}
+	if witness == nil {
+		return false, nil
+	}
+	verifier, ok := verifiers[witness.Type]
+	if !ok {
+		return false, nil
+	}
+
+	if proof.Claims.ChainID != 0 {
+		chainID = new(big.Int).SetUint64(proof.Claims.ChainID)
+	}
+	if chainID == nil {
+		return true, fmt.Errorf("wallet witness requires a chainId")
+	}
+	address, err := proof.AddressBytes()
+	if err != nil {
+		return true, fmt.Errorf("address is not a valid Ethereum address")
+	}
+	digest, err := proof.MessageDigest()
+	if err != nil {
+		return true, fmt.Errorf("unable to compute ethauth message digest, because %w", err)
+	}
+
+	isValid, err := verifier.VerifyWitness(ctx, chainID, address, digest, witness.Data)
+	if err != nil {
+		return true, fmt.Errorf("invalid %s wallet witness - %w", witness.Type, err)
+	}
+	if !isValid {
+		return true, fmt.Errorf("invalid %s wallet witness", witness.Type)
+	}
+	return true, nil
+}
+
+// WitnessTypeSequence is the WalletWitness type of Sequence wallets, see SequenceWallet.
+const WitnessTypeSequence = "sequence"
+
+// SequenceWitness is the witness of a Sequence wallet, which is the wallet configuration of
+// weighted owners and a threshold, and the signatures of the owners of the proof.
+type SequenceWitness struct {
+	Threshold uint16           `json:"threshold"`
+	Signers   []SequenceSigner `json:"signers"`
+}
+
+// SequenceSigner is a weighted owner of a Sequence wallet configuration.
+type SequenceSigner struct {
+	Weight  uint8  `json:"weight"`
+	Address string `json:"address"`
+
+	// Signature is the 65-byte signature of the Sequence subdigest of the proof by the owner
+	// (in hex), or empty for owners which have not signed the proof
+	Signature string `json:"signature,omitempty"`
+}
+
+// SequenceWallet is a WitnessVerifier of the witnesses of Sequence wallets deployed by the
+// Factory with the MainModule implementation, whose address is derived from the image hash
+// of their initial configuration.
+//
+// Note, the witness proves the signature under the initial configuration of the wallet, so it
+// is still accepted once the wallet has updated its configuration on the chain, ie. to remove
+// a compromised owner. Deployments verifying witnesses should bound the age of proofs with
+// MaxAge, or verify the proofs of wallets known to have updated their configuration on the
+// chain.
+type SequenceWallet struct {
+	Factory    common.Address
+	MainModule common.Address
+}
+
+// SequenceWalletV1 is the wallet context of version 1 Sequence wallets.
+var SequenceWalletV1 = SequenceWallet{
+	Factory:    common.HexToAddress("0xf9D09D634Fb818b05149329C1dcCFAeA53639d96"),
+	MainModule: common.HexToAddress("0xd01F11855bCcb95f88D7A48492F66410d4637313"),
+}
+
+// sequenceWalletCreationCode is the creation code of the proxy of Sequence wallets, followed by
+// the address of the main module.
+var sequenceWalletCreationCode = ethcoder.MustHexDecode("0x603a600e3d39601a805130553df3363d3d373d3d3d363d30545af43d82803e903d91601857fd5bf3")
+
+// ImageHash returns the image hash of the wallet configuration of the witness.
+func (SequenceWallet) ImageHash(witness SequenceWitness) (common.Hash, error) {
+	imageHash := crypto.Keccak256(common.LeftPadBytes(big.NewInt(int64(witness.Threshold)).Bytes(), 32))
+	for _, signer := range witness.Signers {
+		if !common.IsHexAddress(signer.Address) {
+			return common.Hash{}, fmt.Errorf("signer %q is not a valid Ethereum address", signer.Address)
+		}
+		imageHash = crypto.Keccak256(
+			imageHash,
+			common.LeftPadBytes([]byte{signer.Weight}, 32),
+			common.LeftPadBytes(common.HexToAddress(signer.Address).Bytes(), 32),
+		)
+	}
+	return common.BytesToHash(imageHash), nil
+}
+
+// Address returns the counterfactual address of the wallet of the initial configuration of
+// the witness.
+func (v SequenceWallet) Address(witness SequenceWitness) (common.Address, error) {
+	imageHash, err := v.ImageHash(witness)
+	if err != nil {
+		return common.Address{}, err
+	}
+	code := append(append([]byte{}, sequenceWalletCreationCode...), common.LeftPadBytes(v.MainModule.Bytes(), 32)...)
+	return crypto.CreateAddress2(v.Factory, imageHash, crypto.Keccak256(code)), nil
+}
+
+// Subdigest returns the digest the owners of the wallet sign on the chain, which binds the
+// digest to the wallet and the chain.
+func (SequenceWallet) Subdigest(chainID *big.Int, address common.Address, digest []byte) []byte {
+	return crypto.Keccak256([]byte("\x19\x01"), common.LeftPadBytes(chainID.Bytes(), 32), address.Bytes(), digest)
+}
+
+func (v SequenceWallet) VerifyWitness(ctx context.Context, chainID *big.Int, address common.Address, digest []byte, data json.RawMessage) (bool, error) {
+	var witness SequenceWitness
+	if err := json.Unmarshal(data, &witness); err != nil {
+		return false, fmt.Errorf("invalid encoding - %w", err)
+	}
+	if witness.Threshold == 0 {
+		return false, fmt.Errorf("threshold is zero")
+	}
+	wallet, err := v.Address(witness)
+	if err != nil {
+		return false, err
+	}
+	if wallet != address {
+		return false, fmt.Errorf("configuration is not of wallet %s", address.Hex())
+	}
+
+	subdigest := v.Subdigest(chainID, address, digest)
+	var weight int
+	var signers []common.Address
+	for i, signer := range witness.Signers {
+		if signer.Signature == "" {
+			continue
+		}
+		owner := common.HexToAddress(signer.Address)
+		sig, err := ethcoder.HexDecode(signer.Signature)
+		if err != nil || len(sig) != 65 {
+			return false, fmt.Errorf("signature of signer %d is not a 65-byte signature", i)
+		}
+		recovered, err := recoverSignerAddress(subdigest, sig)
+		if err != nil || recovered != owner {
+			return false, fmt.Errorf("invalid signature of signer %d", i)
+		}
+		// owners listed more than once in the configuration count once
+		if !slices.Contains(signers, owner) {
+			signers = append(signers, owner)
+			weight += int(signer.Weight)
+		}
+	}
+	return weight >= int(witness.Threshold), nil
+}
@@ -0,0 +1,138 @@
+package ethauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceWitness(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	config := SequenceWitness{Threshold: 2}
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		keys = append(keys, key)
+		config.Signers = append(config.Signers, SequenceSigner{Weight: 1, Address: crypto.PubkeyToAddress(key.PublicKey).Hex()})
+	}
+	wallet, err := SequenceWalletV1.Address(config)
+	require.NoError(t, err)
+
+	// the wallet contract is never called for proofs with a witness
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected json-rpc call")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ethAuth, err := New()
+	require.NoError(t, err)
+	require.NoError(t, ethAuth.ConfigJsonRpcProvider(server.URL, 137))
+	require.NoError(t, ethAuth.ConfigWitnessVerifiers(map[string]WitnessVerifier{WitnessTypeSequence: SequenceWalletV1}))
+
+	newProof := func(signers ...int) *Proof {
+		proof := NewProof()
+		proof.Address = wallet.Hex()
+		proof.Claims = Claims{App: "ETHAuthTest", ETHAuthVersion: ETHAuthVersion}
+		proof.Claims.SetIssuedAtNow()
+		proof.Claims.SetExpiryIn(5 * time.Minute)
+		proof.Signature = "0x00"
+
+		digest, err := proof.MessageDigest()
+		require.NoError(t, err)
+		subdigest := SequenceWalletV1.Subdigest(big.NewInt(137), wallet, digest)
+		witness := SequenceWitness{Threshold: config.Threshold, Signers: append([]SequenceSigner{}, config.Signers...)}
+		for _, i := range signers {
+			sig, err := crypto.Sign(subdigest, keys[i])
+			require.NoError(t, err)
+			witness.Signers[i].Signature = ethcoder.HexEncode(sig)
+		}
+		data, err := json.Marshal(witness)
+		require.NoError(t, err)
+		require.NoError(t, proof.SetWitness(WalletWitness{Type: WitnessTypeSequence, Data: data}))
+		return proof
+	}
+
+	proofString, err := ethAuth.EncodeProof(newProof(0, 2))
+	require.NoError(t, err)
+	ok, proof, err := ethAuth.DecodeProof(proofString)
+	require.NoError(t, err)
+	require.True(t, ok)
+	witness, err := proof.Witness()
+	require.NoError(t, err)
+	require.Equal(t, WitnessTypeSequence, witness.Type)
+
+	// below the threshold of the wallet configuration
+	_, err = ethAuth.VerifyProofSignature(context.Background(), newProof(1))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+	require.ErrorContains(t, err, "invalid sequence wallet witness")
+
+	// the configuration of the witness must derive the proof address
+	proof = newProof(0, 1)
+	proof.Address = crypto.PubkeyToAddress(keys[0].PublicKey).Hex()
+	_, err = ethAuth.ValidateProof(proof)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// signatures of the owners are bound to the chain
+	proof = newProof(0, 1)
+	proof.Claims.ChainID = 1
+	_, err = ethAuth.VerifyProofSignature(context.Background(), proof)
+	require.ErrorContains(t, err, "invalid signature of signer 0")
+
+	// a tampered signature of an owner is rejected
+	proof = newProof(0, 1)
+	proof.Claims.App = "Other"
+	_, err = ethAuth.ValidateProof(proof)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// the batched contract account validator verifies witnesses offline too
+	batchAuth, err := New(NewBatchRemoteValidator().ValidateContractAccountProof)
+	require.NoError(t, err)
+	require.NoError(t, batchAuth.ConfigJsonRpcProvider(server.URL, 137))
+	require.NoError(t, batchAuth.ConfigWitnessVerifiers(map[string]WitnessVerifier{WitnessTypeSequence: SequenceWalletV1}))
+	ok, err = batchAuth.ValidateProof(newProof(0, 2))
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = batchAuth.ValidateProof(newProof(1))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	require.Error(t, ethAuth.ConfigWitnessVerifiers(map[string]WitnessVerifier{"": SequenceWalletV1}))
+}
+
+func TestProofWitness(t *testing.T) {
+	proof := NewProof()
+	witness, err := proof.Witness()
+	require.NoError(t, err)
+	require.Nil(t, witness)
+
+	// extra data which isn't a witness, ie. of counterfactual wallets
+	proof.Extra = "0x1234"
+	witness, err = proof.Witness()
+	require.NoError(t, err)
+	require.Nil(t, witness)
+
+	proof.Extra = ethcoder.HexEncode([]byte(`{"data":{}}`))
+	_, err = proof.Witness()
+	require.Error(t, err)
+	require.Error(t, proof.SetWitness(WalletWitness{}))
+
+	require.NoError(t, proof.SetWitness(WalletWitness{Type: "custom", Data: json.RawMessage(`{"a":1}`)}))
+	witness, err = proof.Witness()
+	require.NoError(t, err)
+	require.Equal(t, &WalletWitness{Type: "custom", Data: json.RawMessage(`{"a":1}`)}, witness)
+
+	// the extra data of delegated proofs are their delegations
+	proof.Claims.Type = ProofTypeDelegated
+	witness, err = proof.Witness()
+	require.NoError(t, err)
+	require.Nil(t, witness)
+}